@@ -0,0 +1,238 @@
+package fake_proxmox
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// liveConfigKeys are changed on a running vm right away, like proxmox every other option is queued as pending until
+// the vm is restarted unless its hotplug option allows it
+var liveConfigKeys = []string{
+	"balloon", "cicustom", "cipassword", "citype", "ciupgrade", "ciuser", "cpulimit", "cpuunits", "description",
+	"hookscript", "hotplug", "lock", "name", "nameserver", "onboot", "protection", "searchdomain", "shares", "sshkeys",
+	"startup", "tags", "vmstatestorage",
+}
+
+var (
+	ipConfigKeyRegex = regexp.MustCompile(`^ipconfig\d+$`)
+	usbKeyRegex      = regexp.MustCompile(`^usb\d+$`)
+)
+
+// defaultHotplug is what proxmox hot plugs when the hotplug option of a vm is not set
+const defaultHotplug = "network,disk,usb"
+
+// isLiveChange reports whether proxmox applies a change of the option to the running vm. Drives are always changed
+// live, the provider stops the vm for drive changes that can't be hot plugged.
+func isLiveChange(vm *qemuVm, key string) bool {
+	if slices.Contains(liveConfigKeys, key) || ipConfigKeyRegex.MatchString(key) || driveKeyRegex.MatchString(key) || unusedKeyRegex.MatchString(key) {
+		return true
+	}
+
+	hotplug, hasHotplug := vm.config["hotplug"]
+	if !hasHotplug || hotplug == "1" {
+		hotplug = defaultHotplug
+	}
+	hotplugOptions := strings.Split(hotplug, ",")
+
+	switch {
+	case netKeyRegex.MatchString(key):
+		return slices.Contains(hotplugOptions, "network")
+	case usbKeyRegex.MatchString(key) || key == "tablet":
+		return slices.Contains(hotplugOptions, "usb")
+	case key == "memory":
+		return slices.Contains(hotplugOptions, "memory")
+	case key == "vcpus":
+		return slices.Contains(hotplugOptions, "cpu")
+	}
+	return false
+}
+
+// splitPendingChanges returns the changes of an update that apply to the vm right away and queues the others as
+// pending while the vm runs. Values equal to the current config clear a pending change of the option. Updating a
+// stopped vm applies the changes that are still pending first.
+func (server *Server) splitPendingChanges(vm *qemuVm, values map[string]string) map[string]string {
+	if vm.status != "running" {
+		server.applyPendingChanges(vm)
+		return values
+	}
+
+	live := map[string]string{}
+	var liveDeletions []string
+	queue := func(key string, value string, isDeletion bool) {
+		if vm.pending == nil {
+			vm.pending = map[string]string{}
+			vm.pendingDeletions = map[string]bool{}
+		}
+		delete(vm.pending, key)
+		delete(vm.pendingDeletions, key)
+
+		currentValue, isSet := vm.config[key]
+		if isDeletion && isSet {
+			vm.pendingDeletions[key] = true
+		} else if !isDeletion && currentValue != value {
+			vm.pending[key] = value
+		}
+	}
+
+	if deletions, hasDeletions := values["delete"]; hasDeletions {
+		for _, key := range strings.FieldsFunc(deletions, func(r rune) bool { return r == ',' || r == ';' || r == ' ' }) {
+			if isLiveChange(vm, key) {
+				liveDeletions = append(liveDeletions, key)
+			} else {
+				queue(key, "", true)
+			}
+		}
+	}
+
+	for key, value := range values {
+		if slices.Contains(qemuRequestOnlyKeys, key) {
+			continue
+		}
+		if isLiveChange(vm, key) {
+			live[key] = value
+		} else {
+			queue(key, value, value == "")
+		}
+	}
+
+	if len(liveDeletions) > 0 {
+		live["delete"] = strings.Join(liveDeletions, ",")
+	}
+	return live
+}
+
+// applyPendingChanges makes the pending changes part of the config, proxmox does this whenever the vm starts
+func (server *Server) applyPendingChanges(vm *qemuVm) {
+	values := map[string]string{}
+	for key, value := range vm.pending {
+		if !server.pendingKeptOnReboot[key] {
+			values[key] = value
+			delete(vm.pending, key)
+		}
+	}
+	var deletions []string
+	for key := range vm.pendingDeletions {
+		if !server.pendingKeptOnReboot[key] {
+			deletions = append(deletions, key)
+			delete(vm.pendingDeletions, key)
+		}
+	}
+	if len(deletions) > 0 {
+		values["delete"] = strings.Join(deletions, ",")
+	}
+
+	if len(values) > 0 {
+		server.applyVmConfig(vm, values)
+	}
+}
+
+// configWithPendingChanges returns the config the vm has after its next restart
+func configWithPendingChanges(vm *qemuVm) map[string]string {
+	config := map[string]string{}
+	for key, value := range vm.config {
+		if !vm.pendingDeletions[key] {
+			config[key] = value
+		}
+	}
+	for key, value := range vm.pending {
+		config[key] = value
+	}
+	return config
+}
+
+// KeepPendingOnReboot makes reboots leave changes to the given options pending, the way a reboot inside the guest
+// does that never restarts the qemu process. Calling it without options lets reboots apply everything again.
+func (server *Server) KeepPendingOnReboot(keys ...string) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	server.pendingKeptOnReboot = map[string]bool{}
+	for _, key := range keys {
+		server.pendingKeptOnReboot[key] = true
+	}
+}
+
+// VmPending returns the pending value of every option that changes once the vm restarts, removed options map to an
+// empty string
+func (server *Server) VmPending(vmId int) map[string]string {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	pending := map[string]string{}
+	vm, found := server.vms[vmId]
+	if !found {
+		return pending
+	}
+	for key, value := range vm.pending {
+		pending[key] = value
+	}
+	for key := range vm.pendingDeletions {
+		pending[key] = ""
+	}
+	return pending
+}
+
+func (server *Server) getVmPending(writer http.ResponseWriter, request *http.Request) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	vm, found := server.requireVm(writer, request)
+	if !found {
+		return
+	}
+
+	keys := sortedKeys(configWithPendingChanges(vm))
+	for key := range vm.pendingDeletions {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	options := []map[string]interface{}{}
+	for _, key := range slices.Compact(keys) {
+		option := map[string]interface{}{"key": key}
+		if value, isSet := vm.config[key]; isSet {
+			option["value"] = configValue(key, value)
+		}
+		if value, isPending := vm.pending[key]; isPending {
+			option["pending"] = configValue(key, value)
+		}
+		if vm.pendingDeletions[key] {
+			option["delete"] = 1
+		}
+		options = append(options, option)
+	}
+	writeData(writer, options)
+}
+
+func (server *Server) rebootVm(writer http.ResponseWriter, request *http.Request) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	vm, found := server.requireVm(writer, request)
+	if !found {
+		return
+	}
+	if vm.status != "running" {
+		writeData(writer, server.failTask(vm.node, "qmreboot", strconv.Itoa(vm.id), fmt.Sprintf("VM %d not running", vm.id)))
+		return
+	}
+
+	upid, succeeded := server.runTask(vm.node, "qmreboot", strconv.Itoa(vm.id))
+	if succeeded {
+		server.applyPendingChanges(vm)
+	}
+	writeData(writer, upid)
+}
+
+// configValue returns the option the way proxmox encodes it in json
+func configValue(key string, value string) interface{} {
+	if slices.Contains(integerConfigKeys, key) {
+		number, _ := strconv.Atoi(value)
+		return number
+	}
+	return value
+}
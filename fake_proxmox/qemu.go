@@ -0,0 +1,1040 @@
+package fake_proxmox
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type qemuVm struct {
+	id     int
+	node   string
+	status string
+	config map[string]string
+	// pending and pendingDeletions hold the changes made while the vm runs that only apply once it restarts
+	pending          map[string]string
+	pendingDeletions map[string]bool
+}
+
+var (
+	driveKeyRegex   = regexp.MustCompile(`^(ide|sata|scsi|virtio|efidisk|tpmstate)(\d+)$`)
+	unusedKeyRegex  = regexp.MustCompile(`^unused(\d+)$`)
+	netKeyRegex     = regexp.MustCompile(`^net(\d+)$`)
+	indexedKeyRegex = regexp.MustCompile(`^(hostpci|ipconfig|net|numa|parallel|serial|unused|usb|virtiofs)\d+$`)
+	sizeRegex       = regexp.MustCompile(`^(\+)?(\d+(?:\.\d+)?)([KMGT]?)$`)
+)
+
+// maxDriveIndex is the highest index proxmox accepts for every bus
+var maxDriveIndex = map[string]int{"ide": 3, "sata": 5, "scsi": 30, "virtio": 15, "efidisk": 0, "tpmstate": 0}
+
+// qemuConfigKeys are the options of a vm config that proxmox accepts without an index
+var qemuConfigKeys = []string{
+	"acpi", "affinity", "agent", "amd-sev", "arch", "args", "audio0", "autostart", "balloon", "bios", "boot", "bootdisk",
+	"cdrom", "cicustom", "cipassword", "citype", "ciupgrade", "ciuser", "cores", "cpu", "cpulimit", "cpuunits",
+	"description", "freeze", "hookscript", "hotplug", "hugepages", "ivshmem", "keephugepages", "keyboard", "kvm",
+	"localtime", "lock", "machine", "memory", "meta", "migrate_downtime", "migrate_speed", "name", "nameserver", "numa",
+	"onboot", "ostype", "protection", "reboot", "rng0", "scsihw", "searchdomain", "shares", "smbios1", "smp", "sockets",
+	"spice_enhancements", "sshkeys", "startdate", "startup", "tablet", "tags", "tdf", "template", "vcpus", "vga",
+	"vmgenid", "vmstatestorage", "watchdog",
+}
+
+// cloudInitTypes are the formats proxmox generates the cloud-init data in
+var cloudInitTypes = []string{"configdrive2", "nocloud", "opennebula"}
+
+// qemuRequestOnlyKeys are parameters of the create and update calls that are not stored in the config
+var qemuRequestOnlyKeys = []string{"vmid", "node", "digest", "skiplock", "background_delay", "start", "storage", "pool", "unique", "force", "delete", "revert"}
+
+// integerConfigKeys proxmox returns these options as json numbers, every other option as a string
+var integerConfigKeys = []string{"acpi", "autostart", "balloon", "ciupgrade", "cores", "cpuunits", "kvm", "numa", "onboot", "protection", "reboot", "shares", "sockets", "tablet", "template", "vcpus"}
+
+// Vm returns a copy of the config of a vm and the node it is on, found is false when no vm with the id exists
+func (server *Server) Vm(vmId int) (config map[string]string, nodeName string, found bool) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	vm, found := server.vms[vmId]
+	if !found {
+		return nil, "", false
+	}
+	config = map[string]string{}
+	for key, value := range vm.config {
+		config[key] = value
+	}
+	return config, vm.node, true
+}
+
+// VmStatus returns running or stopped, or an empty string when no vm with the id exists
+func (server *Server) VmStatus(vmId int) string {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	vm, found := server.vms[vmId]
+	if !found {
+		return ""
+	}
+	return vm.status
+}
+
+// requireVm looks up the vm of the request path on the node of the request path, like proxmox a vm on another node
+// is reported as a missing config file
+func (server *Server) requireVm(writer http.ResponseWriter, request *http.Request) (*qemuVm, bool) {
+	if _, found := server.requireNode(writer, request); !found {
+		return nil, false
+	}
+
+	vmId, parseError := strconv.Atoi(request.PathValue("vmid"))
+	if parseError != nil {
+		writeError(writer, http.StatusBadRequest, "Parameter verification failed.", map[string]string{"vmid": fmt.Sprintf("type check ('integer') failed - got '%s'", request.PathValue("vmid"))})
+		return nil, false
+	}
+
+	vm, found := server.vms[vmId]
+	if !found || vm.node != request.PathValue("node") {
+		writeError(writer, http.StatusInternalServerError, fmt.Sprintf("Configuration file 'nodes/%s/qemu-server/%d.conf' does not exist", request.PathValue("node"), vmId), nil)
+		return nil, false
+	}
+	return vm, true
+}
+
+func (server *Server) listVms(writer http.ResponseWriter, request *http.Request) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	if _, found := server.requireNode(writer, request); !found {
+		return
+	}
+
+	vms := []map[string]interface{}{}
+	for _, vm := range server.vms {
+		if vm.node != request.PathValue("node") {
+			continue
+		}
+		memory, _ := strconv.ParseInt(vm.config["memory"], 10, 64)
+		vms = append(vms, map[string]interface{}{
+			"vmid":   vm.id,
+			"name":   vm.config["name"],
+			"status": vm.status,
+			"maxmem": memory * 1024 * 1024,
+			"tags":   vm.config["tags"],
+		})
+	}
+	writeData(writer, vms)
+}
+
+func (server *Server) createVm(writer http.ResponseWriter, request *http.Request) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	if _, found := server.requireNode(writer, request); !found {
+		return
+	}
+	nodeName := request.PathValue("node")
+	values := formValues(request)
+
+	vmIdValue, hasVmId := values["vmid"]
+	if !hasVmId {
+		writeError(writer, http.StatusBadRequest, "Parameter verification failed.", map[string]string{"vmid": "property is missing and it is not optional"})
+		return
+	}
+	vmId, parseError := strconv.Atoi(vmIdValue)
+	if parseError != nil {
+		writeError(writer, http.StatusBadRequest, "Parameter verification failed.", map[string]string{"vmid": fmt.Sprintf("type check ('integer') failed - got '%s'", vmIdValue)})
+		return
+	}
+	if vmId < 100 {
+		writeError(writer, http.StatusBadRequest, "Parameter verification failed.", map[string]string{"vmid": "value must have a minimum value of 100"})
+		return
+	}
+	if existingVm, exists := server.vms[vmId]; exists {
+		writeError(writer, http.StatusInternalServerError, fmt.Sprintf("unable to create VM %d - VM %d already exists on node '%s'", vmId, vmId, existingVm.node), nil)
+		return
+	}
+
+	vm := qemuVm{id: vmId, node: nodeName, status: "stopped", config: map[string]string{}}
+	if !server.validateVmConfig(writer, &vm, values) {
+		return
+	}
+
+	upid, succeeded := server.runTask(nodeName, "qmcreate", vmIdValue)
+	if succeeded {
+		vm.config["meta"] = fmt.Sprintf("creation-qemu=9.0.2,ctime=%d", time.Now().Unix())
+		vm.config["smbios1"] = fmt.Sprintf("uuid=%s", fakeUuid(vmId, "smbios"))
+		vm.config["vmgenid"] = fakeUuid(vmId, "vmgenid")
+		server.applyVmConfig(&vm, values)
+		server.vms[vmId] = &vm
+	}
+	writeData(writer, upid)
+}
+
+func (server *Server) getVmConfig(writer http.ResponseWriter, request *http.Request) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	vm, found := server.requireVm(writer, request)
+	if !found {
+		return
+	}
+
+	// like proxmox the config already shows the pending changes unless the current config is asked for
+	vmConfig := configWithPendingChanges(vm)
+	if formValues(request)["current"] == "1" {
+		vmConfig = vm.config
+	}
+
+	config := map[string]interface{}{"digest": configDigest(vm.config)}
+	for key, value := range vmConfig {
+		config[key] = configValue(key, value)
+	}
+	// proxmox never hands out the cloud-init password
+	if _, hasPassword := config["cipassword"]; hasPassword {
+		config["cipassword"] = "**********"
+	}
+	writeData(writer, config)
+}
+
+// updateVmConfig the post variant runs as a task and returns its upid, the put variant answers once the change is done
+func (server *Server) updateVmConfig(writer http.ResponseWriter, request *http.Request) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	vm, found := server.requireVm(writer, request)
+	if !found {
+		return
+	}
+	values := formValues(request)
+
+	if digest, hasDigest := values["digest"]; hasDigest && digest != configDigest(vm.config) {
+		writeError(writer, http.StatusInternalServerError, "detected modified configuration - file changed by other user? Try again.", nil)
+		return
+	}
+
+	if !server.validateVmConfig(writer, vm, values) {
+		return
+	}
+
+	if request.Method == http.MethodPut {
+		server.applyVmConfig(vm, server.splitPendingChanges(vm, values))
+		writeData(writer, nil)
+		return
+	}
+
+	upid, succeeded := server.runTask(vm.node, "qmconfig", strconv.Itoa(vm.id), unsetDeletionWarnings(vm, values)...)
+	if succeeded {
+		server.applyVmConfig(vm, server.splitPendingChanges(vm, values))
+	}
+	writeData(writer, upid)
+}
+
+// unsetDeletionWarnings returns the warnings proxmox logs for options it is asked to delete that the vm doesn't have
+func unsetDeletionWarnings(vm *qemuVm, values map[string]string) []string {
+	var warnings []string
+	for _, key := range strings.FieldsFunc(values["delete"], func(r rune) bool { return r == ',' || r == ';' || r == ' ' }) {
+		_, isSet := vm.config[key]
+		_, isPending := vm.pending[key]
+		if !isSet && !isPending {
+			warnings = append(warnings, fmt.Sprintf("WARN: cannot delete '%s' - not set in current configuration!", key))
+		}
+	}
+	return warnings
+}
+
+// validateVmConfig rejects the request the way proxmox does before anything is changed, it answers the request
+// itself and returns false when a parameter is not valid
+func (server *Server) validateVmConfig(writer http.ResponseWriter, vm *qemuVm, values map[string]string) bool {
+	parameterErrors := map[string]string{}
+
+	for key, value := range values {
+		if slices.Contains(qemuRequestOnlyKeys, key) || slices.Contains(qemuConfigKeys, key) || indexedKeyRegex.MatchString(key) {
+			if slices.Contains(integerConfigKeys, key) || key == "memory" {
+				if _, parseError := strconv.Atoi(value); parseError != nil && value != "" {
+					parameterErrors[key] = fmt.Sprintf("type check ('integer') failed - got '%s'", value)
+				}
+			}
+			if cloudInitError := server.validateCloudInitOption(vm, key, value); cloudInitError != "" {
+				parameterErrors[key] = cloudInitError
+			}
+			continue
+		}
+
+		driveMatch := driveKeyRegex.FindStringSubmatch(key)
+		if driveMatch == nil {
+			parameterErrors[key] = "property is not defined in schema and the schema does not allow additional properties"
+			continue
+		}
+		index, _ := strconv.Atoi(driveMatch[2])
+		if index > maxDriveIndex[driveMatch[1]] {
+			parameterErrors[key] = "property is not defined in schema and the schema does not allow additional properties"
+			continue
+		}
+		if driveError := server.validateDrive(vm, value); driveError != "" {
+			parameterErrors[key] = driveError
+		}
+	}
+
+	if len(parameterErrors) > 0 {
+		writeError(writer, http.StatusBadRequest, "Parameter verification failed.", parameterErrors)
+		return false
+	}
+	return true
+}
+
+// validateCloudInitOption checks the format of the cloud-init data and that custom snippets are on storages that hold
+// snippets
+func (server *Server) validateCloudInitOption(vm *qemuVm, key string, value string) string {
+	if value == "" {
+		return ""
+	}
+	switch key {
+	case "citype":
+		if !slices.Contains(cloudInitTypes, value) {
+			return fmt.Sprintf("value '%s' does not have a value in the enumeration '%s'", value, strings.Join(cloudInitTypes, ", "))
+		}
+	case "cicustom":
+		for _, snippet := range strings.Split(value, ",") {
+			kind, volid, isSnippet := strings.Cut(snippet, "=")
+			if !isSnippet || !slices.Contains([]string{"meta", "network", "user", "vendor"}, kind) {
+				return fmt.Sprintf("invalid format - format error cicustom.%s: property is not defined in schema", kind)
+			}
+			storageName, _, _ := strings.Cut(volid, ":")
+			snippetStorage, found := server.findStorage(vm.node, storageName)
+			if !found {
+				return fmt.Sprintf("storage '%s' does not exist", storageName)
+			}
+			if !slices.Contains(strings.Split(snippetStorage.content, ","), "snippets") {
+				return fmt.Sprintf("storage '%s' does not support snippets", storageName)
+			}
+		}
+	}
+	return ""
+}
+
+func (server *Server) validateDrive(vm *qemuVm, value string) string {
+	if value == "" {
+		return ""
+	}
+	parts := strings.Split(value, ",")
+	storageName, volumeName, isVolume := strings.Cut(parts[0], ":")
+	if !isVolume {
+		if parts[0] == "none" || parts[0] == "cdrom" {
+			return ""
+		}
+		return fmt.Sprintf("invalid format - unable to parse drive options '%s'", value)
+	}
+
+	if _, found := server.findStorage(vm.node, storageName); !found {
+		return fmt.Sprintf("storage '%s' does not exist", storageName)
+	}
+
+	options := driveOptions(parts[1:])
+	if importFrom, hasImport := options["import-from"]; hasImport {
+		if _, found := server.findVolume(vm.node, importFrom); !found {
+			return fmt.Sprintf("volume '%s' does not exist", importFrom)
+		}
+		return ""
+	}
+
+	if volumeName == "cloudinit" {
+		return ""
+	}
+	if _, sizeError := strconv.ParseFloat(volumeName, 64); sizeError == nil {
+		return ""
+	}
+	if _, found := server.findVolume(vm.node, parts[0]); !found {
+		return fmt.Sprintf("volume '%s' does not exist", parts[0])
+	}
+	return ""
+}
+
+// applyVmConfig stores the validated parameters. Disks given as storage:size are allocated, a disk that is replaced
+// or removed from the config is kept as an unused disk, removing an unused disk destroys it.
+func (server *Server) applyVmConfig(vm *qemuVm, values map[string]string) {
+	if deletions, hasDeletions := values["delete"]; hasDeletions {
+		for _, key := range strings.FieldsFunc(deletions, func(r rune) bool { return r == ',' || r == ';' || r == ' ' }) {
+			server.removeVmOption(vm, key)
+		}
+	}
+
+	for _, key := range sortedKeys(values) {
+		value := values[key]
+		if slices.Contains(qemuRequestOnlyKeys, key) {
+			continue
+		}
+		if value == "" {
+			server.removeVmOption(vm, key)
+			continue
+		}
+
+		switch {
+		case driveKeyRegex.MatchString(key):
+			value = server.allocateDrive(vm, key, value)
+		case netKeyRegex.MatchString(key):
+			value = normalizeNetworkDevice(vm, key, value)
+		case key == "tags":
+			value = strings.Join(strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ';' || r == ' ' }), ";")
+		}
+		vm.config[key] = value
+	}
+}
+
+func (server *Server) removeVmOption(vm *qemuVm, key string) {
+	value, found := vm.config[key]
+	if !found {
+		return
+	}
+	delete(vm.config, key)
+
+	volid := strings.Split(value, ",")[0]
+	if _, isVolume := server.findVolume(vm.node, volid); !isVolume {
+		return
+	}
+	if unusedKeyRegex.MatchString(key) || strings.Contains(value, "media=cdrom") {
+		server.destroyVolume(vm.node, volid)
+		return
+	}
+	if driveKeyRegex.MatchString(key) {
+		vm.config[nextUnusedKey(vm)] = volid
+	}
+}
+
+// allocateDrive turns the storage:size and import-from forms of a drive into the volume that was created for it
+func (server *Server) allocateDrive(vm *qemuVm, key string, value string) string {
+	parts := strings.Split(value, ",")
+	storageName, volumeName, isVolume := strings.Cut(parts[0], ":")
+	if !isVolume {
+		return value
+	}
+	options := driveOptions(parts[1:])
+
+	var allocated *volume
+	switch {
+	case volumeName == "cloudinit":
+		allocated = server.allocateVolume(vm, storageName, fmt.Sprintf("vm-%d-cloudinit", vm.id), 4*1024*1024)
+		options["media"] = "cdrom"
+	case options["import-from"] != "":
+		source, _ := server.findVolume(vm.node, options["import-from"])
+		allocated = server.allocateVolume(vm, storageName, nextDiskName(server, vm, storageName), source.size)
+		delete(options, "import-from")
+	default:
+		sizeInGibibytes, sizeError := strconv.ParseFloat(volumeName, 64)
+		if sizeError != nil {
+			existing, _ := server.findVolume(vm.node, parts[0])
+			options["size"] = formatSize(existing.size)
+			return formatDrive(parts[0], options)
+		}
+		allocated = server.allocateVolume(vm, storageName, nextDiskName(server, vm, storageName), int64(sizeInGibibytes*float64(gibibyte)))
+	}
+
+	if _, hasReplaced := vm.config[key]; hasReplaced {
+		server.removeVmOption(vm, key)
+	}
+
+	if options["media"] != "cdrom" {
+		options["size"] = formatSize(allocated.size)
+	} else {
+		delete(options, "size")
+	}
+	return formatDrive(allocated.volid, options)
+}
+
+func (server *Server) allocateVolume(vm *qemuVm, storageName string, volumeName string, size int64) *volume {
+	targetStorage, _ := server.findStorage(vm.node, storageName)
+	format := "raw"
+	if targetStorage.storageType == "dir" {
+		format = "qcow2"
+		volumeName = fmt.Sprintf("%d/%s.qcow2", vm.id, volumeName)
+	}
+	allocated := volume{
+		volid:   fmt.Sprintf("%s:%s", storageName, volumeName),
+		format:  format,
+		content: "images",
+		size:    size,
+		ctime:   time.Now().Unix(),
+	}
+	targetStorage.volumes[allocated.volid] = &allocated
+	return &allocated
+}
+
+func (server *Server) destroyVolume(nodeName string, volid string) {
+	storageName, _, _ := strings.Cut(volid, ":")
+	if targetStorage, found := server.findStorage(nodeName, storageName); found {
+		delete(targetStorage.volumes, volid)
+	}
+}
+
+func nextDiskName(server *Server, vm *qemuVm, storageName string) string {
+	targetStorage, _ := server.findStorage(vm.node, storageName)
+	for index := 0; ; index++ {
+		name := fmt.Sprintf("vm-%d-disk-%d", vm.id, index)
+		// like proxmox the base image of a template takes up the number of the disk it was converted from
+		baseName := fmt.Sprintf("base-%d-disk-%d", vm.id, index)
+		taken := false
+		for volid := range targetStorage.volumes {
+			if strings.HasSuffix(volid, ":"+name) || strings.HasSuffix(volid, "/"+name+".qcow2") || strings.HasSuffix(volid, ":"+baseName) || strings.HasSuffix(volid, "/"+baseName+".qcow2") {
+				taken = true
+				break
+			}
+		}
+		if !taken {
+			return name
+		}
+	}
+}
+
+func nextUnusedKey(vm *qemuVm) string {
+	for index := 0; ; index++ {
+		key := fmt.Sprintf("unused%d", index)
+		if _, taken := vm.config[key]; !taken {
+			return key
+		}
+	}
+}
+
+// normalizeNetworkDevice generates a mac address when none was given and puts the model first, like proxmox does
+func normalizeNetworkDevice(vm *qemuVm, key string, value string) string {
+	parts := strings.Split(value, ",")
+	model, macAddress, _ := strings.Cut(parts[0], "=")
+	if macAddress == "" {
+		index, _ := strconv.Atoi(strings.TrimPrefix(key, "net"))
+		macAddress = fmt.Sprintf("BC:24:11:%02X:%02X:%02X", (vm.id>>8)&0xff, vm.id&0xff, index)
+	}
+
+	options := driveOptions(parts[1:])
+	formatted := fmt.Sprintf("%s=%s", model, macAddress)
+	for _, option := range sortedKeys(options) {
+		formatted += fmt.Sprintf(",%s=%s", option, options[option])
+	}
+	return formatted
+}
+
+func driveOptions(parts []string) map[string]string {
+	options := map[string]string{}
+	for _, part := range parts {
+		key, value, _ := strings.Cut(part, "=")
+		if key != "" {
+			options[key] = value
+		}
+	}
+	return options
+}
+
+// formatDrive proxmox writes the volume first and the options sorted by name after it
+func formatDrive(volid string, options map[string]string) string {
+	formatted := volid
+	for _, option := range sortedKeys(options) {
+		formatted += fmt.Sprintf(",%s=%s", option, options[option])
+	}
+	return formatted
+}
+
+// formatSize uses the largest unit the size is a whole multiple of, e.g. 32G or 2252M
+func formatSize(sizeInBytes int64) string {
+	units := []string{"T", "G", "M", "K"}
+	for index, unit := range units {
+		unitSize := int64(1) << (10 * (len(units) - index))
+		if sizeInBytes%unitSize == 0 {
+			return fmt.Sprintf("%d%s", sizeInBytes/unitSize, unit)
+		}
+	}
+	return strconv.FormatInt(sizeInBytes, 10)
+}
+
+func parseSize(size string) (sizeInBytes int64, relative bool, valid bool) {
+	match := sizeRegex.FindStringSubmatch(size)
+	if match == nil {
+		return 0, false, false
+	}
+	number, _ := strconv.ParseFloat(match[2], 64)
+	multiplier := map[string]float64{"": 1, "K": 1 << 10, "M": 1 << 20, "G": 1 << 30, "T": 1 << 40}[match[3]]
+	return int64(number * multiplier), match[1] == "+", true
+}
+
+func configDigest(config map[string]string) string {
+	var lines []string
+	for _, key := range sortedKeys(config) {
+		lines = append(lines, fmt.Sprintf("%s: %s", key, config[key]))
+	}
+	return fmt.Sprintf("%x", sha1.Sum([]byte(strings.Join(lines, "\n"))))
+}
+
+func fakeUuid(vmId int, purpose string) string {
+	sum := fmt.Sprintf("%x", sha1.Sum([]byte(fmt.Sprintf("%s-%d", purpose, vmId))))
+	return fmt.Sprintf("%s-%s-%s-%s-%s", sum[0:8], sum[8:12], sum[12:16], sum[16:20], sum[20:32])
+}
+
+func (server *Server) deleteVm(writer http.ResponseWriter, request *http.Request) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	vm, found := server.requireVm(writer, request)
+	if !found {
+		return
+	}
+	if vm.config["protection"] == "1" {
+		writeError(writer, http.StatusInternalServerError, fmt.Sprintf("can't remove VM %d - protection mode enabled", vm.id), nil)
+		return
+	}
+	if vm.status == "running" {
+		writeError(writer, http.StatusInternalServerError, fmt.Sprintf("VM %d is running - destroy failed", vm.id), nil)
+		return
+	}
+
+	upid, succeeded := server.runTask(vm.node, "qmdestroy", strconv.Itoa(vm.id))
+	if succeeded {
+		for _, value := range vm.config {
+			server.destroyVolume(vm.node, strings.Split(value, ",")[0])
+		}
+		delete(server.vms, vm.id)
+	}
+	writeData(writer, upid)
+}
+
+func (server *Server) resizeVmDisk(writer http.ResponseWriter, request *http.Request) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	vm, found := server.requireVm(writer, request)
+	if !found {
+		return
+	}
+	values := formValues(request)
+	diskKey := values["disk"]
+
+	drive, hasDrive := vm.config[diskKey]
+	if !driveKeyRegex.MatchString(diskKey) || !hasDrive {
+		writeError(writer, http.StatusInternalServerError, fmt.Sprintf("disk '%s' does not exist", diskKey), nil)
+		return
+	}
+	if strings.Contains(drive, "media=cdrom") {
+		writeError(writer, http.StatusInternalServerError, "you can't resize a cdrom", nil)
+		return
+	}
+	newSize, relative, validSize := parseSize(values["size"])
+	if !validSize {
+		writeError(writer, http.StatusBadRequest, "Parameter verification failed.", map[string]string{"size": fmt.Sprintf("value '%s' does not match the regex pattern", values["size"])})
+		return
+	}
+
+	parts := strings.Split(drive, ",")
+	resized, _ := server.findVolume(vm.node, parts[0])
+	if relative {
+		newSize += resized.size
+	}
+	if newSize < resized.size {
+		writeError(writer, http.StatusInternalServerError, "shrinking disks is not supported", nil)
+		return
+	}
+
+	upid, succeeded := server.runTask(vm.node, "resize", strconv.Itoa(vm.id))
+	if succeeded {
+		resized.size = newSize
+		options := driveOptions(parts[1:])
+		options["size"] = formatSize(newSize)
+		vm.config[diskKey] = formatDrive(parts[0], options)
+	}
+	writeData(writer, upid)
+}
+
+func (server *Server) getVmStatus(writer http.ResponseWriter, request *http.Request) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	vm, found := server.requireVm(writer, request)
+	if !found {
+		return
+	}
+
+	agent, _ := strconv.Atoi(strings.Split(vm.config["agent"], ",")[0])
+	writeData(writer, map[string]interface{}{
+		"vmid":      vm.id,
+		"name":      vm.config["name"],
+		"status":    vm.status,
+		"qmpstatus": vm.status,
+		"agent":     agent,
+	})
+}
+
+func (server *Server) startVm(writer http.ResponseWriter, request *http.Request) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	vm, found := server.requireVm(writer, request)
+	if !found {
+		return
+	}
+	if vm.status == "running" {
+		writeData(writer, server.failTask(vm.node, "qmstart", strconv.Itoa(vm.id), fmt.Sprintf("VM %d already running", vm.id)))
+		return
+	}
+
+	startConfig := configWithPendingChanges(vm)
+	if slices.Contains(strings.Split(startConfig["hotplug"], ","), "memory") && startConfig["numa"] != "1" {
+		writeData(writer, server.failTask(vm.node, "qmstart", strconv.Itoa(vm.id), "NUMA needs to be enabled for memory hotplug"))
+		return
+	}
+
+	upid, succeeded := server.runTask(vm.node, "qmstart", strconv.Itoa(vm.id))
+	if succeeded {
+		server.applyPendingChanges(vm)
+		vm.status = "running"
+	}
+	writeData(writer, upid)
+}
+
+// stopVm handles both shutdown and stop, stopping a vm that is not running completes without doing anything
+func (server *Server) stopVm(writer http.ResponseWriter, request *http.Request) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	vm, found := server.requireVm(writer, request)
+	if !found {
+		return
+	}
+
+	taskType := "qmshutdown"
+	if strings.HasSuffix(request.URL.Path, "/stop") {
+		taskType = "qmstop"
+	}
+	upid, succeeded := server.runTask(vm.node, taskType, strconv.Itoa(vm.id))
+	if succeeded {
+		vm.status = "stopped"
+	}
+	writeData(writer, upid)
+}
+
+func (server *Server) moveVmDisk(writer http.ResponseWriter, request *http.Request) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	vm, found := server.requireVm(writer, request)
+	if !found {
+		return
+	}
+	values := formValues(request)
+	diskKey := values["disk"]
+
+	drive, hasDrive := vm.config[diskKey]
+	if !driveKeyRegex.MatchString(diskKey) || !hasDrive {
+		writeError(writer, http.StatusInternalServerError, fmt.Sprintf("disk '%s' does not exist", diskKey), nil)
+		return
+	}
+	targetStorageName := values["storage"]
+	if _, storageExists := server.findStorage(vm.node, targetStorageName); !storageExists {
+		writeError(writer, http.StatusBadRequest, "Parameter verification failed.", map[string]string{"storage": fmt.Sprintf("storage '%s' does not exist", targetStorageName)})
+		return
+	}
+	parts := strings.Split(drive, ",")
+	currentStorageName, _, _ := strings.Cut(parts[0], ":")
+	if currentStorageName == targetStorageName {
+		writeError(writer, http.StatusInternalServerError, "you can't move to the same storage with same format", nil)
+		return
+	}
+
+	upid, succeeded := server.runTask(vm.node, "qmmove", strconv.Itoa(vm.id))
+	if succeeded {
+		moved, _ := server.findVolume(vm.node, parts[0])
+		allocated := server.allocateVolume(vm, targetStorageName, nextDiskName(server, vm, targetStorageName), moved.size)
+		if values["delete"] == "1" {
+			server.destroyVolume(vm.node, moved.volid)
+		} else {
+			vm.config[nextUnusedKey(vm)] = moved.volid
+		}
+		vm.config[diskKey] = formatDrive(allocated.volid, driveOptions(parts[1:]))
+	}
+	writeData(writer, upid)
+}
+
+func (server *Server) migrateVm(writer http.ResponseWriter, request *http.Request) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	vm, found := server.requireVm(writer, request)
+	if !found {
+		return
+	}
+	values := formValues(request)
+	targetNode := values["target"]
+
+	if targetNode == vm.node {
+		writeError(writer, http.StatusBadRequest, "Parameter verification failed.", map[string]string{"target": "target is local node."})
+		return
+	}
+	if _, nodeExists := server.nodes[targetNode]; !nodeExists {
+		writeError(writer, http.StatusBadRequest, "Parameter verification failed.", map[string]string{"target": fmt.Sprintf("no such cluster node '%s'", targetNode)})
+		return
+	}
+	if vm.status == "running" && values["online"] != "1" {
+		writeError(writer, http.StatusInternalServerError, "can't migrate running VM without --online", nil)
+		return
+	}
+
+	upid, succeeded := server.runTask(vm.node, "qmigrate", strconv.Itoa(vm.id))
+	if succeeded {
+		for _, value := range vm.config {
+			volid := strings.Split(value, ",")[0]
+			migrated, isVolume := server.findVolume(vm.node, volid)
+			if !isVolume {
+				continue
+			}
+			storageName, _, _ := strings.Cut(volid, ":")
+			server.destroyVolume(vm.node, volid)
+			targetStorage, _ := server.findStorage(targetNode, storageName)
+			targetStorage.volumes[volid] = migrated
+		}
+		vm.node = targetNode
+	}
+	writeData(writer, upid)
+}
+
+// AddVm creates a stopped vm on a node as if it had been created through the api, e.g. a template to clone from.
+// Drives given as storage:size are allocated the same way.
+func (server *Server) AddVm(nodeName string, vmId int, config map[string]string) error {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	if _, nodeExists := server.nodes[nodeName]; !nodeExists {
+		return fmt.Errorf("node %s does not exist", nodeName)
+	}
+	if _, exists := server.vms[vmId]; exists {
+		return fmt.Errorf("vm %d already exists", vmId)
+	}
+
+	vm := qemuVm{id: vmId, node: nodeName, status: "stopped", config: map[string]string{}}
+	for key, value := range config {
+		if driveKeyRegex.MatchString(key) {
+			if driveError := server.validateDrive(&vm, value); driveError != "" {
+				return fmt.Errorf("%s: %s", key, driveError)
+			}
+		}
+	}
+	vm.config["smbios1"] = fmt.Sprintf("uuid=%s", fakeUuid(vmId, "smbios"))
+	vm.config["vmgenid"] = fakeUuid(vmId, "vmgenid")
+	server.applyVmConfig(&vm, config)
+	server.vms[vmId] = &vm
+	return nil
+}
+
+// listClusterResources only knows about vms, like proxmox the type filter also returns containers which the fake has none of
+func (server *Server) listClusterResources(writer http.ResponseWriter, request *http.Request) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	resourceType := request.URL.Query().Get("type")
+	if resourceType != "" && resourceType != "vm" {
+		writeData(writer, []interface{}{})
+		return
+	}
+
+	resources := []map[string]interface{}{}
+	for _, vmId := range sortedVmIds(server.vms) {
+		vm := server.vms[vmId]
+		template, _ := strconv.Atoi(vm.config["template"])
+		resources = append(resources, map[string]interface{}{
+			"id":       fmt.Sprintf("qemu/%d", vm.id),
+			"type":     "qemu",
+			"node":     vm.node,
+			"vmid":     vm.id,
+			"name":     vm.config["name"],
+			"status":   vm.status,
+			"template": template,
+		})
+	}
+	writeData(writer, resources)
+}
+
+// cloneVm copies the config of a vm or template to a new vm. Every disk is copied for a full clone, a linked clone
+// shares the disks of its template which the fake models as new volumes as well. Like proxmox the clone gets new mac
+// addresses and a new vmgenid.
+func (server *Server) cloneVm(writer http.ResponseWriter, request *http.Request) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	source, found := server.requireVm(writer, request)
+	if !found {
+		return
+	}
+	values := formValues(request)
+
+	newId, parseError := strconv.Atoi(values["newid"])
+	if parseError != nil || newId < 100 {
+		writeError(writer, http.StatusBadRequest, "Parameter verification failed.", map[string]string{"newid": fmt.Sprintf("type check ('integer') failed - got '%s'", values["newid"])})
+		return
+	}
+	if _, exists := server.vms[newId]; exists {
+		writeError(writer, http.StatusInternalServerError, fmt.Sprintf("unable to create VM %d: config file already exists", newId), nil)
+		return
+	}
+
+	isTemplate := source.config["template"] == "1"
+	fullClone := values["full"] == "1" || (!isTemplate && values["full"] == "")
+	if !fullClone && !isTemplate {
+		writeError(writer, http.StatusInternalServerError, "Linked clone feature is not supported for running or non-template VMs", nil)
+		return
+	}
+	if !fullClone && values["storage"] != "" {
+		writeError(writer, http.StatusBadRequest, "Parameter verification failed.", map[string]string{"storage": "option is only allowed for full clones"})
+		return
+	}
+	if values["snapname"] != "" {
+		writeError(writer, http.StatusInternalServerError, fmt.Sprintf("snapshot '%s' does not exist", values["snapname"]), nil)
+		return
+	}
+
+	targetNode := source.node
+	if values["target"] != "" {
+		if _, nodeExists := server.nodes[values["target"]]; !nodeExists {
+			writeError(writer, http.StatusBadRequest, "Parameter verification failed.", map[string]string{"target": fmt.Sprintf("no such cluster node '%s'", values["target"])})
+			return
+		}
+		targetNode = values["target"]
+	}
+	if values["storage"] != "" {
+		if _, storageExists := server.findStorage(targetNode, values["storage"]); !storageExists {
+			writeError(writer, http.StatusBadRequest, "Parameter verification failed.", map[string]string{"storage": fmt.Sprintf("storage '%s' does not exist", values["storage"])})
+			return
+		}
+	}
+
+	upid, succeeded := server.runTask(source.node, "qmclone", strconv.Itoa(source.id))
+	if !succeeded {
+		writeData(writer, upid)
+		return
+	}
+
+	clone := qemuVm{id: newId, node: targetNode, status: "stopped", config: map[string]string{}}
+	for _, key := range sortedKeys(source.config) {
+		value := source.config[key]
+		switch {
+		case key == "template" || key == "vmgenid" || key == "smbios1" || unusedKeyRegex.MatchString(key):
+			continue
+		case driveKeyRegex.MatchString(key):
+			value = server.cloneDrive(source, &clone, value, values["storage"])
+		case netKeyRegex.MatchString(key):
+			model, _, _ := strings.Cut(value, "=")
+			_, options, _ := strings.Cut(value, ",")
+			value = normalizeNetworkDevice(&clone, key, strings.TrimSuffix(model+","+options, ","))
+		}
+		clone.config[key] = value
+	}
+	clone.config["name"] = values["name"]
+	if clone.config["name"] == "" {
+		clone.config["name"] = fmt.Sprintf("Copy-of-VM-%s", source.config["name"])
+	}
+	clone.config["smbios1"] = fmt.Sprintf("uuid=%s", fakeUuid(newId, "smbios"))
+	clone.config["vmgenid"] = fakeUuid(newId, "vmgenid")
+	server.vms[newId] = &clone
+	writeData(writer, upid)
+}
+
+// cloneDrive copies the volume of a drive to the storage of the clone, cdroms holding an iso are kept as they are
+func (server *Server) cloneDrive(source *qemuVm, clone *qemuVm, value string, targetStorageName string) string {
+	parts := strings.Split(value, ",")
+	sourceVolume, isVolume := server.findVolume(source.node, parts[0])
+	if !isVolume || (strings.Contains(value, "media=cdrom") && !strings.Contains(parts[0], "cloudinit")) {
+		return value
+	}
+
+	storageName, _, _ := strings.Cut(parts[0], ":")
+	if targetStorageName != "" {
+		storageName = targetStorageName
+	}
+	volumeName := nextDiskName(server, clone, storageName)
+	if strings.Contains(parts[0], "cloudinit") {
+		volumeName = fmt.Sprintf("vm-%d-cloudinit", clone.id)
+	}
+	cloned := server.allocateVolume(clone, storageName, volumeName, sourceVolume.size)
+	return formatDrive(cloned.volid, driveOptions(parts[1:]))
+}
+
+// convertVmToTemplate marks a stopped vm as a template and renames its disks to base images like proxmox does on
+// storages that support them, cdroms and the cloudinit drive are left as they are. When a disk is given only that disk
+// is converted, the vm becomes a template either way.
+func (server *Server) convertVmToTemplate(writer http.ResponseWriter, request *http.Request) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	vm, found := server.requireVm(writer, request)
+	if !found {
+		return
+	}
+	values := formValues(request)
+	diskKey := values["disk"]
+
+	if diskKey != "" {
+		if _, hasDrive := vm.config[diskKey]; !driveKeyRegex.MatchString(diskKey) || !hasDrive {
+			writeError(writer, http.StatusBadRequest, "Parameter verification failed.", map[string]string{"disk": fmt.Sprintf("disk '%s' does not exist", diskKey)})
+			return
+		}
+	}
+	if vm.config["template"] == "1" && diskKey == "" {
+		writeError(writer, http.StatusInternalServerError, "you can't convert a template to a template", nil)
+		return
+	}
+	if vm.status == "running" {
+		writeError(writer, http.StatusInternalServerError, "you can't convert a VM to template if VM is running", nil)
+		return
+	}
+
+	upid, succeeded := server.runTask(vm.node, "qmtemplate", strconv.Itoa(vm.id))
+	if succeeded {
+		for key, value := range vm.config {
+			if !driveKeyRegex.MatchString(key) || (diskKey != "" && key != diskKey) || strings.Contains(value, "media=cdrom") {
+				continue
+			}
+			parts := strings.Split(value, ",")
+			converted, isVolume := server.findVolume(vm.node, parts[0])
+			if !isVolume || !strings.Contains(parts[0], fmt.Sprintf("vm-%d-disk-", vm.id)) {
+				continue
+			}
+			server.destroyVolume(vm.node, converted.volid)
+			converted.volid = strings.Replace(converted.volid, fmt.Sprintf("vm-%d-disk-", vm.id), fmt.Sprintf("base-%d-disk-", vm.id), 1)
+			storageName, _, _ := strings.Cut(converted.volid, ":")
+			convertedStorage, _ := server.findStorage(vm.node, storageName)
+			convertedStorage.volumes[converted.volid] = converted
+			vm.config[key] = formatDrive(converted.volid, driveOptions(parts[1:]))
+		}
+		vm.config["template"] = "1"
+	}
+	writeData(writer, upid)
+}
+
+// getNextVmId returns the lowest free id from 100 on, or checks the id given as vmid. Like proxmox the id is returned
+// as a string.
+func (server *Server) getNextVmId(writer http.ResponseWriter, request *http.Request) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	vmIdValue := request.URL.Query().Get("vmid")
+	if vmIdValue == "" {
+		vmId := 100
+		for _, exists := server.vms[vmId]; exists; _, exists = server.vms[vmId] {
+			vmId++
+		}
+		writeData(writer, strconv.Itoa(vmId))
+		return
+	}
+
+	vmId, parseError := strconv.Atoi(vmIdValue)
+	if parseError != nil || vmId < 100 {
+		writeError(writer, http.StatusBadRequest, "Parameter verification failed.", map[string]string{"vmid": fmt.Sprintf("type check ('integer') failed - got '%s'", vmIdValue)})
+		return
+	}
+	if _, exists := server.vms[vmId]; exists {
+		writeError(writer, http.StatusBadRequest, "Parameter verification failed.", map[string]string{"vmid": fmt.Sprintf("VM %d already exists", vmId)})
+		return
+	}
+	writeData(writer, vmIdValue)
+}
+
+func sortedVmIds(vms map[int]*qemuVm) []int {
+	vmIds := make([]int, 0, len(vms))
+	for vmId := range vms {
+		vmIds = append(vmIds, vmId)
+	}
+	slices.Sort(vmIds)
+	return vmIds
+}
@@ -0,0 +1,208 @@
+package fake_proxmox
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+var sdnZoneIdRegex = regexp.MustCompile(`^[a-z][a-z0-9]*$`)
+
+// sdnZoneTypes are the zone types proxmox knows and the options each of them accepts next to nodes and ipam
+var sdnZoneTypes = map[string][]string{
+	"simple": {"dhcp"},
+	"vlan":   {"bridge"},
+	"qinq":   {"bridge", "tag", "vlan-protocol"},
+	"vxlan":  {"peers", "vxlan-port"},
+	"evpn":   {"controller", "vrf-vxlan", "mac", "exitnodes", "exitnodes-primary", "exitnodes-local-routing", "advertise-subnets", "disable-arp-nd-suppression", "rt-import"},
+}
+
+var sdnZoneCommonOptions = []string{"nodes", "ipam", "dns", "reversedns", "dnszone", "mtu"}
+
+// SdnZone returns a copy of the options of a zone, found is false when the zone does not exist
+func (server *Server) SdnZone(zone string) (options map[string]string, found bool) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	storedZone, found := server.sdnZones[zone]
+	if !found {
+		return nil, false
+	}
+	options = map[string]string{}
+	for key, value := range storedZone {
+		options[key] = value
+	}
+	return options, true
+}
+
+func (server *Server) listSdnZones(writer http.ResponseWriter, _ *http.Request) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	zones := []map[string]string{}
+	for _, zone := range sortedKeys(server.sdnZones) {
+		zones = append(zones, sdnZoneResponse(zone, server.sdnZones[zone]))
+	}
+	writeData(writer, zones)
+}
+
+func (server *Server) createSdnZone(writer http.ResponseWriter, request *http.Request) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	values := formValues(request)
+	zone := values["zone"]
+
+	if zone == "" {
+		writeError(writer, http.StatusBadRequest, "Parameter verification failed.", map[string]string{"zone": "property is missing and it is not optional"})
+		return
+	}
+	if !sdnZoneIdRegex.MatchString(zone) || len(zone) > 8 {
+		writeError(writer, http.StatusBadRequest, "Parameter verification failed.", map[string]string{"zone": fmt.Sprintf("invalid format - zone ID '%s' contains illegal characters or is longer than 8 characters", zone)})
+		return
+	}
+	if _, exists := server.sdnZones[zone]; exists {
+		writeError(writer, http.StatusInternalServerError, fmt.Sprintf("sdn zone object ID '%s' already defined", zone), nil)
+		return
+	}
+	zoneOptions, hasZoneType := sdnZoneTypes[values["type"]]
+	if !hasZoneType {
+		writeError(writer, http.StatusBadRequest, "Parameter verification failed.", map[string]string{"type": fmt.Sprintf("value '%s' does not have a value in the enumeration '%s'", values["type"], strings.Join(sortedKeys(sdnZoneTypes), ", "))})
+		return
+	}
+
+	storedZone := map[string]string{"type": values["type"]}
+	if !server.applySdnZoneOptions(writer, storedZone, zoneOptions, values) {
+		return
+	}
+	server.sdnZones[zone] = storedZone
+	writeData(writer, nil)
+}
+
+func (server *Server) getSdnZone(writer http.ResponseWriter, request *http.Request) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	zone := request.PathValue("zone")
+	storedZone, found := server.sdnZones[zone]
+	if !found {
+		writeError(writer, http.StatusInternalServerError, fmt.Sprintf("sdn '%s' does not exist", zone), nil)
+		return
+	}
+	writeData(writer, sdnZoneResponse(zone, storedZone))
+}
+
+func (server *Server) updateSdnZone(writer http.ResponseWriter, request *http.Request) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	zone := request.PathValue("zone")
+	storedZone, found := server.sdnZones[zone]
+	if !found {
+		writeError(writer, http.StatusInternalServerError, fmt.Sprintf("sdn '%s' does not exist", zone), nil)
+		return
+	}
+
+	values := formValues(request)
+	if _, changesType := values["type"]; changesType {
+		writeError(writer, http.StatusBadRequest, "Parameter verification failed.", map[string]string{"type": "can't change fixed property"})
+		return
+	}
+
+	updatedZone := map[string]string{}
+	for key, value := range storedZone {
+		updatedZone[key] = value
+	}
+	for _, key := range strings.Split(values["delete"], ",") {
+		delete(updatedZone, key)
+	}
+	if !server.applySdnZoneOptions(writer, updatedZone, sdnZoneTypes[storedZone["type"]], values) {
+		return
+	}
+	server.sdnZones[zone] = updatedZone
+	writeData(writer, nil)
+}
+
+func (server *Server) deleteSdnZone(writer http.ResponseWriter, request *http.Request) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	zone := request.PathValue("zone")
+	if _, found := server.sdnZones[zone]; !found {
+		writeError(writer, http.StatusInternalServerError, fmt.Sprintf("sdn '%s' does not exist", zone), nil)
+		return
+	}
+	delete(server.sdnZones, zone)
+	writeData(writer, nil)
+}
+
+// applySdn applying the pending sdn configuration is a task that reloads the network on every node
+func (server *Server) applySdn(writer http.ResponseWriter, _ *http.Request) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	upid, _ := server.runTask(server.nodeNames[0], "reloadnetworkall", "")
+	writeData(writer, upid)
+}
+
+// applySdnZoneOptions stores the options the zone type accepts, like proxmox the nodes are kept as a sorted set
+func (server *Server) applySdnZoneOptions(writer http.ResponseWriter, storedZone map[string]string, zoneOptions []string, values map[string]string) bool {
+	parameterErrors := map[string]string{}
+	for key, value := range values {
+		if key == "zone" || key == "type" || key == "delete" || key == "digest" {
+			continue
+		}
+		if !slices.Contains(sdnZoneCommonOptions, key) && !slices.Contains(zoneOptions, key) {
+			parameterErrors[key] = "property is not defined in schema and the schema does not allow additional properties"
+			continue
+		}
+		if key == "nodes" {
+			for _, nodeName := range strings.Split(value, ",") {
+				if _, nodeExists := server.nodes[nodeName]; !nodeExists && nodeName != "" {
+					parameterErrors[key] = fmt.Sprintf("invalid format - value '%s' does not look like a valid node name", nodeName)
+				}
+			}
+		}
+	}
+	if len(parameterErrors) > 0 {
+		writeError(writer, http.StatusBadRequest, "Parameter verification failed.", parameterErrors)
+		return false
+	}
+
+	for key, value := range values {
+		if key == "zone" || key == "type" || key == "delete" || key == "digest" {
+			continue
+		}
+		if key == "nodes" {
+			value = uniqueSorted(strings.Split(value, ","))
+		}
+		if value == "" {
+			delete(storedZone, key)
+			continue
+		}
+		storedZone[key] = value
+	}
+	return true
+}
+
+func uniqueSorted(values []string) string {
+	unique := []string{}
+	for _, value := range values {
+		if value != "" && !slices.Contains(unique, value) {
+			unique = append(unique, value)
+		}
+	}
+	sort.Strings(unique)
+	return strings.Join(unique, ",")
+}
+
+func sdnZoneResponse(zone string, storedZone map[string]string) map[string]string {
+	response := map[string]string{"zone": zone, "digest": configDigest(storedZone)}
+	for key, value := range storedZone {
+		response[key] = value
+	}
+	return response
+}
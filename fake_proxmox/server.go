@@ -0,0 +1,258 @@
+package fake_proxmox
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	// TokenId and TokenSecret are the only api token the fake server accepts
+	TokenId     = "root@pam!fake"
+	TokenSecret = "00000000-0000-0000-0000-000000000000"
+	// Username and Password can be used to log in with a ticket instead of a token
+	Username = "root@pam"
+	Password = "fake-password"
+
+	apiPrefix = "/api2/json"
+)
+
+// Server
+/**
+ * @description an in-memory stand-in for the proxmox ve api. It keeps nodes, storage content, qemu vms, tasks and sdn
+ * zones in memory and answers the endpoints the provider uses the way proxmox does, including the error messages the
+ * provider relies on. Every task completes immediately, unless a different outcome was set with SetTaskOutcome.
+ */
+type Server struct {
+	*httptest.Server
+
+	lock         sync.Mutex
+	nodes        map[string]*node
+	nodeNames    []string
+	vms          map[int]*qemuVm
+	tasks        map[string]*task
+	taskOutcomes map[string]taskOutcome
+	sdnZones     map[string]map[string]string
+	tickets      map[string]string
+	nextPid      int
+
+	snippetUploadsRejected bool
+	pendingKeptOnReboot    map[string]bool
+}
+
+// NewServer starts a fake cluster with the given nodes, a single node called pve when none are given. Close must be
+// called once the server is no longer needed.
+func NewServer(nodeNames ...string) *Server {
+	if len(nodeNames) == 0 {
+		nodeNames = []string{"pve"}
+	}
+
+	server := Server{
+		nodes:        map[string]*node{},
+		nodeNames:    nodeNames,
+		vms:          map[int]*qemuVm{},
+		tasks:        map[string]*task{},
+		taskOutcomes: map[string]taskOutcome{},
+		sdnZones:     map[string]map[string]string{},
+		tickets:      map[string]string{},
+		nextPid:      1000,
+	}
+
+	for _, nodeName := range nodeNames {
+		server.nodes[nodeName] = newNode(nodeName)
+	}
+
+	server.Server = httptest.NewTLSServer(server.routes())
+	return &server
+}
+
+// ProviderConfig returns a provider block that points the proxmox provider at the fake server, settings are added to
+// the block as they are
+func (server *Server) ProviderConfig(settings ...string) string {
+	return fmt.Sprintf(`
+provider "proxmox" {
+  host             = %q
+  api_token_id     = %q
+  api_token_secret = %q
+  verify_tls       = false
+%s
+}
+`, server.URL, TokenId, TokenSecret, strings.Join(settings, "\n"))
+}
+
+// Fingerprint returns the sha256 fingerprint of the server certificate in the format proxmox reports it
+func (server *Server) Fingerprint() string {
+	sum := sha256.Sum256(server.Certificate().Raw)
+	parts := make([]string, len(sum))
+	for i, b := range sum {
+		parts[i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(parts, ":")
+}
+
+func (server *Server) routes() http.Handler {
+	mux := http.NewServeMux()
+
+	handle := func(pattern string, handler http.HandlerFunc) {
+		method, path, _ := strings.Cut(pattern, " ")
+		mux.Handle(fmt.Sprintf("%s %s%s", method, apiPrefix, path), server.authenticated(handler))
+	}
+
+	mux.HandleFunc("POST "+apiPrefix+"/access/ticket", server.createTicket)
+
+	handle("GET /nodes", server.listNodes)
+	handle("GET /nodes/{node}/network", server.getNodeNetwork)
+	handle("GET /nodes/{node}/storage", server.listStorage)
+	handle("GET /nodes/{node}/storage/{$}", server.listStorage)
+	handle("GET /nodes/{node}/storage/{storage}/content", server.listStorageContent)
+	handle("POST /nodes/{node}/storage/{storage}/upload", server.uploadStorageFile)
+	handle("DELETE /nodes/{node}/storage/{storage}/content/{volume}", server.deleteStorageVolume)
+	handle("GET /storage/{storage}", server.getStorageConfig)
+	handle("GET /cluster/status", server.getClusterStatus)
+
+	handle("GET /nodes/{node}/tasks/{upid}/status", server.getTaskStatus)
+	handle("GET /nodes/{node}/tasks/{upid}/log", server.getTaskLog)
+
+	handle("GET /nodes/{node}/qemu", server.listVms)
+	handle("POST /nodes/{node}/qemu", server.createVm)
+	handle("GET /nodes/{node}/qemu/{vmid}/config", server.getVmConfig)
+	handle("POST /nodes/{node}/qemu/{vmid}/config", server.updateVmConfig)
+	handle("PUT /nodes/{node}/qemu/{vmid}/config", server.updateVmConfig)
+	handle("DELETE /nodes/{node}/qemu/{vmid}", server.deleteVm)
+	handle("PUT /nodes/{node}/qemu/{vmid}/resize", server.resizeVmDisk)
+	handle("GET /nodes/{node}/qemu/{vmid}/status/current", server.getVmStatus)
+	handle("POST /nodes/{node}/qemu/{vmid}/status/start", server.startVm)
+	handle("POST /nodes/{node}/qemu/{vmid}/status/shutdown", server.stopVm)
+	handle("POST /nodes/{node}/qemu/{vmid}/status/stop", server.stopVm)
+	handle("POST /nodes/{node}/qemu/{vmid}/status/reboot", server.rebootVm)
+	handle("GET /nodes/{node}/qemu/{vmid}/pending", server.getVmPending)
+	handle("POST /nodes/{node}/qemu/{vmid}/move_disk", server.moveVmDisk)
+	handle("POST /nodes/{node}/qemu/{vmid}/migrate", server.migrateVm)
+	handle("POST /nodes/{node}/qemu/{vmid}/clone", server.cloneVm)
+	handle("POST /nodes/{node}/qemu/{vmid}/template", server.convertVmToTemplate)
+	handle("GET /cluster/resources", server.listClusterResources)
+	handle("GET /cluster/nextid", server.getNextVmId)
+
+	handle("GET /cluster/sdn/zones", server.listSdnZones)
+	handle("POST /cluster/sdn/zones", server.createSdnZone)
+	handle("GET /cluster/sdn/zones/{zone}", server.getSdnZone)
+	handle("PUT /cluster/sdn/zones/{zone}", server.updateSdnZone)
+	handle("DELETE /cluster/sdn/zones/{zone}", server.deleteSdnZone)
+	handle("PUT /cluster/sdn", server.applySdn)
+
+	mux.HandleFunc("/", func(writer http.ResponseWriter, request *http.Request) {
+		writeError(writer, http.StatusNotImplemented, fmt.Sprintf("Method '%s %s' not implemented", request.Method, strings.TrimPrefix(request.URL.Path, apiPrefix)), nil)
+	})
+
+	return mux
+}
+
+// authenticated accepts the fake api token, or a ticket cookie together with the csrf token for anything but reads
+func (server *Server) authenticated(handler http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.Header.Get("Authorization") == fmt.Sprintf("PVEAPIToken=%s=%s", TokenId, TokenSecret) {
+			handler(writer, request)
+			return
+		}
+
+		cookie, cookieError := request.Cookie("PVEAuthCookie")
+		if cookieError != nil {
+			writeError(writer, http.StatusUnauthorized, "No ticket", nil)
+			return
+		}
+
+		server.lock.Lock()
+		csrfToken, validTicket := server.tickets[cookie.Value]
+		server.lock.Unlock()
+
+		if !validTicket {
+			writeError(writer, http.StatusUnauthorized, "authentication failure", nil)
+			return
+		}
+		if request.Method != http.MethodGet && request.Header.Get("CSRFPreventionToken") != csrfToken {
+			writeError(writer, http.StatusUnauthorized, "Permission denied - invalid csrf token", nil)
+			return
+		}
+		handler(writer, request)
+	})
+}
+
+func (server *Server) createTicket(writer http.ResponseWriter, request *http.Request) {
+	username := request.FormValue("username")
+	password := request.FormValue("password")
+
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	_, renewingTicket := server.tickets[password]
+	if username != Username || (password != Password && !renewingTicket) {
+		writeError(writer, http.StatusUnauthorized, "authentication failure", nil)
+		return
+	}
+
+	server.nextPid++
+	ticket := fmt.Sprintf("PVE:%s:%08X::fake-signature", username, server.nextPid)
+	csrfToken := fmt.Sprintf("%08X:fake-csrf", server.nextPid)
+	server.tickets[ticket] = csrfToken
+
+	writeData(writer, map[string]interface{}{
+		"username":            username,
+		"ticket":              ticket,
+		"CSRFPreventionToken": csrfToken,
+	})
+}
+
+// writeData wraps the response in the data envelope every proxmox response uses
+func writeData(writer http.ResponseWriter, data interface{}) {
+	writeEnvelope(writer, map[string]interface{}{"data": data})
+}
+
+func writeEnvelope(writer http.ResponseWriter, envelope map[string]interface{}) {
+	writer.Header().Set("Content-Type", "application/json;charset=UTF-8")
+	_ = json.NewEncoder(writer).Encode(envelope)
+}
+
+// writeError answers the way proxmox does, the reason in the body and parameter validation errors keyed by parameter
+func writeError(writer http.ResponseWriter, status int, message string, parameterErrors map[string]string) {
+	body := map[string]interface{}{"data": nil, "message": message + "\n"}
+	if len(parameterErrors) > 0 {
+		body["errors"] = parameterErrors
+	}
+	writer.Header().Set("Content-Type", "application/json;charset=UTF-8")
+	writer.WriteHeader(status)
+	_ = json.NewEncoder(writer).Encode(body)
+}
+
+// requireNode looks up the node of the request path and answers with the proxmox error when it does not exist
+func (server *Server) requireNode(writer http.ResponseWriter, request *http.Request) (*node, bool) {
+	foundNode, found := server.nodes[request.PathValue("node")]
+	if !found {
+		writeError(writer, http.StatusInternalServerError, fmt.Sprintf("no such cluster node '%s'", request.PathValue("node")), nil)
+		return nil, false
+	}
+	return foundNode, true
+}
+
+// formValues parses the form body of the request, proxmox also accepts parameters of a delete in the query string
+func formValues(request *http.Request) map[string]string {
+	_ = request.ParseForm()
+	values := map[string]string{}
+	for key, value := range request.Form {
+		values[key] = value[len(value)-1]
+	}
+	return values
+}
+
+func sortedKeys[V any](values map[string]V) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,265 @@
+package fake_proxmox
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"terraform-provider-proxmox/proxmox_client"
+	"terraform-provider-proxmox/services"
+	"terraform-provider-proxmox/services/vm"
+	proxmoxTypes "terraform-provider-proxmox/types"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func newTestClient(t *testing.T, server *Server, auth *proxmox_client.AuthStruct) proxmox_client.ProxmoxClient {
+	if auth == nil {
+		auth = &proxmox_client.AuthStruct{TokenId: TokenId, TokenSecret: TokenSecret}
+	}
+	client, clientError := proxmox_client.NewClient([]string{server.URL}, auth, &proxmox_client.TlsOptions{VerifyTls: false}, nil, nil, nil, nil)
+	if clientError != nil {
+		t.Fatal(clientError)
+	}
+	return client
+}
+
+func newTestVmServices(client proxmox_client.ProxmoxClient) (vm.VmService, vm.DiskService) {
+	proxmoxUtils := services.NewProxmoxUtilService()
+	taskService := services.NewTaskService(client)
+	diskService := vm.NewDiskService(context.Background(), client, proxmoxUtils, taskService)
+	return vm.NewVmService(context.Background(), client, diskService, proxmoxUtils, taskService), diskService
+}
+
+func newTestVmModel(vmId string, disks ...proxmoxTypes.VmDisk) *proxmoxTypes.VmModel {
+	bootOrder, _ := types.ListValueFrom(context.Background(), types.StringType, []string{"scsi0"})
+	return &proxmoxTypes.VmModel{
+		Acpi:             types.BoolValue(true),
+		Agent:            types.BoolValue(false),
+		Bios:             types.StringValue("seabios"),
+		BootOrder:        bootOrder,
+		CloudInitUpgrade: types.BoolValue(true),
+		Cores:            types.Int64Value(2),
+		Cpu:              types.StringValue("host"),
+		CpuLimit:         types.Int64Value(0),
+		Disks:            disks,
+		Kvm:              types.BoolValue(true),
+		Memory:           types.Int64Value(2048),
+		Name:             types.StringValue("fake-vm"),
+		Nameserver:       types.StringValue("1.1.1.1"),
+		NetworkInterfaces: []proxmoxTypes.VmNetworkInterface{{
+			Type:       types.StringValue("virtio"),
+			MacAddress: types.StringValue("BC:24:11:00:00:01"),
+			Bridge:     types.StringValue("vmbr0"),
+			Firewall:   types.BoolValue(true),
+			Order:      types.Int64Value(0),
+			Mtu:        types.Int64Null(),
+		}},
+		NodeName:   types.StringValue("pve"),
+		OsType:     types.StringValue("l26"),
+		ScsiHw:     types.StringValue("virtio-scsi-single"),
+		Sockets:    types.Int64Value(1),
+		SshKeys:    types.ListNull(types.StringType),
+		Tags:       types.ListNull(types.StringType),
+		VmId:       types.StringValue(vmId),
+		PowerState: types.StringValue("stopped"),
+	}
+}
+
+func newTestDisk(storage string, size string, order int64) proxmoxTypes.VmDisk {
+	return proxmoxTypes.VmDisk{
+		BusType:         types.StringValue("scsi"),
+		StorageLocation: types.StringValue(storage),
+		IoThread:        types.BoolValue(true),
+		Size:            types.StringValue(size),
+		Cache:           types.StringValue("default"),
+		AsyncIo:         types.StringValue("default"),
+		Replicate:       types.BoolValue(true),
+		Backup:          types.BoolValue(true),
+		Order:           types.Int64Value(order),
+		ImportFrom:      types.StringValue(""),
+		Path:            types.StringValue(""),
+	}
+}
+
+func TestVmLifecycle(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	vmService, _ := newTestVmServices(newTestClient(t, server, nil))
+	ctx := context.Background()
+	plan := newTestVmModel("100", newTestDisk("local-zfs", "8G", 0))
+
+	if createError := vmService.CreateVm(ctx, plan); createError != nil {
+		t.Fatalf("failed to create the vm: %v", createError)
+	}
+
+	config, nodeName, found := server.Vm(100)
+	if !found || nodeName != "pve" {
+		t.Fatalf("expected vm 100 on pve, found %v on %q", found, nodeName)
+	}
+	if config["scsi0"] != "local-zfs:vm-100-disk-0,iothread=1,size=8G" || config["scsi1"] != "local-zfs:vm-100-cloudinit,media=cdrom" {
+		t.Fatalf("unexpected drives %q and %q", config["scsi0"], config["scsi1"])
+	}
+
+	response, foundOnNode, getVmError := vmService.GetVm(ctx, nil, plan.VmId.ValueStringPointer())
+	if getVmError != nil || *foundOnNode != "pve" {
+		t.Fatalf("failed to find the vm: %v", getVmError)
+	}
+	state := *plan
+	vmService.UpdateVmModelFromResponse(&state, plan, response)
+	if len(state.Disks) != 1 || state.Disks[0].Size.ValueString() != "8G" || state.Memory.ValueInt64() != 2048 {
+		t.Fatalf("unexpected state read back from the config: %+v", state)
+	}
+	if len(state.NetworkInterfaces) != 1 || state.NetworkInterfaces[0].MacAddress.ValueString() != "BC:24:11:00:00:01" {
+		t.Fatalf("unexpected network interfaces %+v", state.NetworkInterfaces)
+	}
+
+	if startError := vmService.StartVm(ctx, plan.NodeName.ValueStringPointer(), plan.VmId.ValueStringPointer()); startError != nil {
+		t.Fatalf("failed to start the vm: %v", startError)
+	}
+	if deleteError := vmService.DeleteVm(ctx, plan.NodeName.ValueStringPointer(), plan.VmId.ValueStringPointer()); deleteError != nil {
+		t.Fatalf("failed to delete the vm: %v", deleteError)
+	}
+	if _, _, found = server.Vm(100); found {
+		t.Fatal("expected the vm to be gone")
+	}
+	if server.HasVolume("pve", "local-zfs:vm-100-disk-0") {
+		t.Fatal("expected the disk to be destroyed with the vm")
+	}
+}
+
+func TestParallelCreatesGetDistinctVmIds(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	if addVmError := server.AddVm("pve", 1000, map[string]string{"name": "taken"}); addVmError != nil {
+		t.Fatal(addVmError)
+	}
+	client, clientError := proxmox_client.NewClient([]string{server.URL}, &proxmox_client.AuthStruct{TokenId: TokenId, TokenSecret: TokenSecret}, &proxmox_client.TlsOptions{VerifyTls: false}, nil, nil, &proxmox_client.VmIdRange{First: 1000, Last: 1003}, nil)
+	if clientError != nil {
+		t.Fatal(clientError)
+	}
+	vmService, _ := newTestVmServices(client)
+
+	var wait sync.WaitGroup
+	plans := []*proxmoxTypes.VmModel{newTestVmModel(""), newTestVmModel(""), newTestVmModel("")}
+	createErrors := make([]error, len(plans))
+	for i, plan := range plans {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			createErrors[i] = vmService.CreateVm(context.Background(), plan)
+		}()
+	}
+	wait.Wait()
+
+	var vmIds []string
+	for i, plan := range plans {
+		if createErrors[i] != nil {
+			t.Fatalf("failed to create vm %d: %v", i, createErrors[i])
+		}
+		vmIds = append(vmIds, plan.VmId.ValueString())
+	}
+	sort.Strings(vmIds)
+	if strings.Join(vmIds, ",") != "1001,1002,1003" {
+		t.Fatalf("expected the free ids of the range, got %v", vmIds)
+	}
+
+	rangeExhaustedError := vmService.CreateVm(context.Background(), newTestVmModel(""))
+	if rangeExhaustedError == nil || !strings.Contains(rangeExhaustedError.Error(), "no free vm id left between 1000 and 1003") {
+		t.Fatalf("expected the range to be exhausted, got %v", rangeExhaustedError)
+	}
+}
+
+func TestMissingVmIsNotFound(t *testing.T) {
+	server := NewServer("pve", "pve2")
+	defer server.Close()
+	client := newTestClient(t, server, nil)
+	vmService, _ := newTestVmServices(client)
+	nodeName, vmId := "pve", "404"
+
+	_, getVmError := client.GetVmById(context.Background(), &nodeName, &vmId)
+	if !proxmox_client.IsNotFound(getVmError) {
+		t.Fatalf("expected a not found error, got %v", getVmError)
+	}
+
+	_, _, searchError := vmService.SearchVmById(context.Background(), &vmId)
+	if !errors.Is(searchError, vm.ErrVmNotFound) {
+		t.Fatalf("expected ErrVmNotFound, got %v", searchError)
+	}
+}
+
+func TestRemovedDiskIsKeptAsUnusedUntilDeleted(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	vmService, diskService := newTestVmServices(newTestClient(t, server, nil))
+	ctx := context.Background()
+	extraDisk := newTestDisk("local-zfs", "4G", 1)
+	plan := newTestVmModel("101", newTestDisk("local-zfs", "8G", 0), extraDisk)
+
+	if createError := vmService.CreateVm(ctx, plan); createError != nil {
+		t.Fatalf("failed to create the vm: %v", createError)
+	}
+	if deleteError := diskService.DeleteVmDisk(ctx, &extraDisk, plan.NodeName.ValueStringPointer(), plan.VmId.ValueStringPointer()); deleteError != nil {
+		t.Fatalf("failed to delete the disk: %v", deleteError)
+	}
+
+	config, _, _ := server.Vm(101)
+	if _, stillAttached := config["scsi1"]; stillAttached {
+		t.Fatal("expected scsi1 to be detached")
+	}
+	if _, stillUnused := config["unused0"]; stillUnused || server.HasVolume("pve", "local-zfs:vm-101-disk-1") {
+		t.Fatal("expected the detached disk to be destroyed")
+	}
+}
+
+func TestInvalidParametersAreReportedPerParameter(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	client := newTestClient(t, server, nil)
+
+	params := url.Values{}
+	params.Add("vmid", "102")
+	params.Add("scsi0", "missing-storage:8")
+	params.Add("cores", "two")
+	_, createError := client.CreateVm(context.Background(), params, "pve")
+
+	apiError, isApiError := proxmox_client.AsApiError(createError)
+	if !isApiError || apiError.Errors["scsi0"] != "storage 'missing-storage' does not exist" || apiError.Errors["cores"] == "" {
+		t.Fatalf("expected errors for scsi0 and cores, got %v", createError)
+	}
+}
+
+func TestTaskOutcomeCanBeChanged(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	vmService, _ := newTestVmServices(newTestClient(t, server, nil))
+	server.SetTaskOutcome("qmcreate", "unable to create image: out of space", "allocating disk")
+
+	createError := vmService.CreateVm(context.Background(), newTestVmModel("103", newTestDisk("local-zfs", "8G", 0)))
+
+	var taskFailedError *services.TaskFailedError
+	if !errors.As(createError, &taskFailedError) || taskFailedError.LogTail[0] != "allocating disk" {
+		t.Fatalf("expected the failed task with its log, got %v", createError)
+	}
+	if _, _, found := server.Vm(103); found {
+		t.Fatal("a failed create must not leave a vm behind")
+	}
+}
+
+func TestTicketLogin(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	client := newTestClient(t, server, &proxmox_client.AuthStruct{Username: Username, Password: Password})
+	vmService, _ := newTestVmServices(client)
+
+	nodes, listNodesError := client.ListNodes(context.Background())
+	if listNodesError != nil || len(nodes.Data) != 1 || nodes.Data[0].SslFingerprint != server.Fingerprint() {
+		t.Fatalf("failed to list nodes with a ticket: %v", listNodesError)
+	}
+	if createError := vmService.CreateVm(context.Background(), newTestVmModel("104", newTestDisk("local-zfs", "8G", 0))); createError != nil {
+		t.Fatalf("writes must pass the csrf token along with the ticket: %v", createError)
+	}
+}
@@ -0,0 +1,377 @@
+package fake_proxmox
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+const gibibyte = int64(1024 * 1024 * 1024)
+
+type node struct {
+	name     string
+	storages map[string]*storage
+}
+
+type storage struct {
+	name        string
+	storageType string
+	content     string
+	path        string
+	volumes     map[string]*volume
+}
+
+type volume struct {
+	volid   string
+	format  string
+	content string
+	size    int64
+	ctime   int64
+	data    []byte
+}
+
+// newNode creates a node with the storage layout of a default zfs installation, local for files and local-zfs for disks
+func newNode(name string) *node {
+	return &node{
+		name: name,
+		storages: map[string]*storage{
+			"local": {
+				name:        "local",
+				storageType: "dir",
+				content:     "iso,vztmpl,backup,import,snippets",
+				path:        "/var/lib/vz",
+				volumes:     map[string]*volume{},
+			},
+			"local-zfs": {
+				name:        "local-zfs",
+				storageType: "zfspool",
+				content:     "images,rootdir",
+				volumes:     map[string]*volume{},
+			},
+		},
+	}
+}
+
+// AddStorageContent adds a file such as a cloud image to the storage of a node, volid takes the form storage:path
+func (server *Server) AddStorageContent(nodeName string, volid string, format string, content string, sizeInBytes int64) error {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	storageName, _, _ := strings.Cut(volid, ":")
+	targetStorage, found := server.findStorage(nodeName, storageName)
+	if !found {
+		return fmt.Errorf("storage '%s' does not exist on node %s", storageName, nodeName)
+	}
+
+	targetStorage.volumes[volid] = &volume{volid: volid, format: format, content: content, size: sizeInBytes, ctime: time.Now().Unix()}
+	return nil
+}
+
+// HasVolume reports whether the volume still exists on the node, e.g. to check that a disk was removed with its vm
+func (server *Server) HasVolume(nodeName string, volid string) bool {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	_, found := server.findVolume(nodeName, volid)
+	return found
+}
+
+// StorageFile returns the content of a file uploaded to the storage of a node
+func (server *Server) StorageFile(nodeName string, volid string) ([]byte, bool) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	foundVolume, found := server.findVolume(nodeName, volid)
+	if !found {
+		return nil, false
+	}
+	return foundVolume.data, true
+}
+
+// RejectSnippetUploads makes the upload api refuse snippets the way proxmox up to version 8 does, which only takes
+// isos, container templates and images to import
+func (server *Server) RejectSnippetUploads(reject bool) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	server.snippetUploadsRejected = reject
+}
+
+func (server *Server) findStorage(nodeName string, storageName string) (*storage, bool) {
+	foundNode, found := server.nodes[nodeName]
+	if !found {
+		return nil, false
+	}
+	foundStorage, found := foundNode.storages[storageName]
+	return foundStorage, found
+}
+
+func (server *Server) findVolume(nodeName string, volid string) (*volume, bool) {
+	storageName, _, _ := strings.Cut(volid, ":")
+	foundStorage, found := server.findStorage(nodeName, storageName)
+	if !found {
+		return nil, false
+	}
+	foundVolume, found := foundStorage.volumes[volid]
+	return foundVolume, found
+}
+
+func (server *Server) listNodes(writer http.ResponseWriter, _ *http.Request) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	var nodes []map[string]interface{}
+	for _, nodeName := range server.nodeNames {
+		nodes = append(nodes, map[string]interface{}{
+			"node":            nodeName,
+			"id":              "node/" + nodeName,
+			"type":            "node",
+			"status":          "online",
+			"level":           "",
+			"maxcpu":          8,
+			"cpu":             0.02,
+			"maxmem":          32 * gibibyte,
+			"mem":             4 * gibibyte,
+			"maxdisk":         100 * gibibyte,
+			"disk":            10 * gibibyte,
+			"uptime":          86400,
+			"ssl_fingerprint": server.Fingerprint(),
+		})
+	}
+	writeData(writer, nodes)
+}
+
+func (server *Server) getNodeNetwork(writer http.ResponseWriter, request *http.Request) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	if _, found := server.requireNode(writer, request); !found {
+		return
+	}
+
+	writeData(writer, []map[string]interface{}{
+		{
+			"iface":        "vmbr0",
+			"type":         "bridge",
+			"method":       "static",
+			"method6":      "manual",
+			"families":     []string{"inet"},
+			"active":       1,
+			"autostart":    1,
+			"priority":     4,
+			"address":      "192.168.1.10",
+			"netmask":      "24",
+			"cidr":         "192.168.1.10/24",
+			"gateway":      "192.168.1.1",
+			"bridge_ports": "eno1",
+			"bridge_stp":   "off",
+			"bridge_fd":    "0",
+		},
+		{
+			"iface":    "eno1",
+			"type":     "eth",
+			"method":   "manual",
+			"method6":  "manual",
+			"families": []string{"inet"},
+			"active":   1,
+			"exists":   1,
+			"priority": 3,
+		},
+	})
+}
+
+func (server *Server) listStorage(writer http.ResponseWriter, request *http.Request) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	foundNode, found := server.requireNode(writer, request)
+	if !found {
+		return
+	}
+
+	var storages []map[string]interface{}
+	for _, storageName := range sortedKeys(foundNode.storages) {
+		nodeStorage := foundNode.storages[storageName]
+		var used int64
+		for _, storedVolume := range nodeStorage.volumes {
+			used += storedVolume.size
+		}
+		total := 100 * gibibyte
+		storages = append(storages, map[string]interface{}{
+			"storage":       nodeStorage.name,
+			"type":          nodeStorage.storageType,
+			"content":       nodeStorage.content,
+			"enabled":       1,
+			"active":        1,
+			"shared":        0,
+			"total":         total,
+			"used":          used,
+			"avail":         total - used,
+			"used_fraction": float64(used) / float64(total),
+		})
+	}
+	writeData(writer, storages)
+}
+
+func (server *Server) listStorageContent(writer http.ResponseWriter, request *http.Request) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	if _, found := server.requireNode(writer, request); !found {
+		return
+	}
+	nodeStorage, found := server.findStorage(request.PathValue("node"), request.PathValue("storage"))
+	if !found {
+		writeError(writer, http.StatusInternalServerError, fmt.Sprintf("storage '%s' does not exist", request.PathValue("storage")), nil)
+		return
+	}
+
+	contentFilter := request.URL.Query().Get("content")
+	content := []map[string]interface{}{}
+	for _, volid := range sortedKeys(nodeStorage.volumes) {
+		storedVolume := nodeStorage.volumes[volid]
+		if contentFilter != "" && storedVolume.content != contentFilter {
+			continue
+		}
+		content = append(content, map[string]interface{}{
+			"volid":   storedVolume.volid,
+			"format":  storedVolume.format,
+			"content": storedVolume.content,
+			"size":    storedVolume.size,
+			"ctime":   storedVolume.ctime,
+		})
+	}
+	writeData(writer, content)
+}
+
+func (server *Server) uploadStorageFile(writer http.ResponseWriter, request *http.Request) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	if _, found := server.requireNode(writer, request); !found {
+		return
+	}
+	nodeStorage, found := server.findStorage(request.PathValue("node"), request.PathValue("storage"))
+	if !found {
+		writeError(writer, http.StatusInternalServerError, fmt.Sprintf("storage '%s' does not exist", request.PathValue("storage")), nil)
+		return
+	}
+
+	parseError := request.ParseMultipartForm(32 << 20)
+	if parseError != nil {
+		writeError(writer, http.StatusBadRequest, "Parameter verification failed.", map[string]string{"filename": parseError.Error()})
+		return
+	}
+	content := request.FormValue("content")
+	uploadContentTypes := []string{"iso", "vztmpl", "import", "snippets"}
+	if server.snippetUploadsRejected {
+		uploadContentTypes = uploadContentTypes[:3]
+	}
+	if !slices.Contains(uploadContentTypes, content) {
+		writeError(writer, http.StatusBadRequest, "Parameter verification failed.", map[string]string{"content": fmt.Sprintf("value '%s' does not have a value in the enumeration '%s'", content, strings.Join(uploadContentTypes, ", "))})
+		return
+	}
+	if !slices.Contains(strings.Split(nodeStorage.content, ","), content) {
+		writeError(writer, http.StatusInternalServerError, fmt.Sprintf("storage '%s' does not support '%s' content", nodeStorage.name, content), nil)
+		return
+	}
+
+	file, fileHeader, fileError := request.FormFile("filename")
+	if fileError != nil {
+		writeError(writer, http.StatusBadRequest, "Parameter verification failed.", map[string]string{"filename": "property is missing and it is not optional"})
+		return
+	}
+	defer file.Close()
+	data, readError := io.ReadAll(file)
+	if readError != nil {
+		writeError(writer, http.StatusInternalServerError, readError.Error(), nil)
+		return
+	}
+
+	volid := fmt.Sprintf("%s:%s/%s", nodeStorage.name, content, fileHeader.Filename)
+	upid, succeeded := server.runTask(request.PathValue("node"), "imgcopy", "", fmt.Sprintf("target file: %s/%s/%s", nodeStorage.path, content, fileHeader.Filename))
+	if succeeded {
+		nodeStorage.volumes[volid] = &volume{volid: volid, format: "raw", content: content, size: int64(len(data)), ctime: time.Now().Unix(), data: data}
+	}
+	writeData(writer, upid)
+}
+
+func (server *Server) deleteStorageVolume(writer http.ResponseWriter, request *http.Request) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	if _, found := server.requireNode(writer, request); !found {
+		return
+	}
+	nodeStorage, found := server.findStorage(request.PathValue("node"), request.PathValue("storage"))
+	if !found {
+		writeError(writer, http.StatusInternalServerError, fmt.Sprintf("storage '%s' does not exist", request.PathValue("storage")), nil)
+		return
+	}
+
+	volid := request.PathValue("volume")
+	if !strings.Contains(volid, ":") {
+		volid = nodeStorage.name + ":" + volid
+	}
+	if _, found := nodeStorage.volumes[volid]; !found {
+		writeError(writer, http.StatusInternalServerError, fmt.Sprintf("volume '%s' does not exist", volid), nil)
+		return
+	}
+
+	upid, succeeded := server.runTask(request.PathValue("node"), "imgdel", "")
+	if succeeded {
+		delete(nodeStorage.volumes, volid)
+	}
+	writeData(writer, upid)
+}
+
+// getStorageConfig answers with the cluster wide definition of the storage, every node of the fake has the same storages
+func (server *Server) getStorageConfig(writer http.ResponseWriter, request *http.Request) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	nodeStorage, found := server.findStorage(server.nodeNames[0], request.PathValue("storage"))
+	if !found {
+		writeError(writer, http.StatusInternalServerError, fmt.Sprintf("storage '%s' does not exist", request.PathValue("storage")), nil)
+		return
+	}
+
+	config := map[string]interface{}{
+		"storage": nodeStorage.name,
+		"type":    nodeStorage.storageType,
+		"content": nodeStorage.content,
+		"digest":  "da39a3ee5e6b4b0d3255bfef95601890afd80709",
+	}
+	if nodeStorage.path != "" {
+		config["path"] = nodeStorage.path
+	}
+	writeData(writer, config)
+}
+
+func (server *Server) getClusterStatus(writer http.ResponseWriter, _ *http.Request) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	status := []map[string]interface{}{
+		{"type": "cluster", "id": "cluster", "name": "fake", "nodes": len(server.nodeNames), "quorate": 1, "version": len(server.nodeNames)},
+	}
+	for index, nodeName := range server.nodeNames {
+		local := 0
+		if index == 0 {
+			local = 1
+		}
+		status = append(status, map[string]interface{}{
+			"type":   "node",
+			"id":     "node/" + nodeName,
+			"name":   nodeName,
+			"ip":     "127.0.0.1",
+			"online": 1,
+			"local":  local,
+			"nodeid": index + 1,
+		})
+	}
+	writeData(writer, status)
+}
@@ -0,0 +1,163 @@
+package fake_proxmox
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type task struct {
+	upid       string
+	node       string
+	pid        int
+	startTime  int64
+	taskType   string
+	id         string
+	exitStatus string
+	log        []string
+}
+
+type taskOutcome struct {
+	exitStatus string
+	log        []string
+}
+
+// SetTaskOutcome makes every following task of the given type, e.g. qmcreate or qmstart, stop with the exit status and
+// log lines instead of succeeding. An exit status of "WARNINGS: <count>" makes the task complete with warnings.
+// An empty exit status restores the default outcome.
+func (server *Server) SetTaskOutcome(taskType string, exitStatus string, log ...string) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	if exitStatus == "" {
+		delete(server.taskOutcomes, taskType)
+		return
+	}
+	server.taskOutcomes[taskType] = taskOutcome{exitStatus: exitStatus, log: log}
+}
+
+// runTask records a task that already finished and returns its upid. The change the task stands for has to be
+// applied by the caller, unless the task failed, which is reported through the returned boolean. Like in proxmox a
+// task that logged lines starting with "WARN: " completes with warnings instead of OK.
+func (server *Server) runTask(nodeName string, taskType string, id string, log ...string) (string, bool) {
+	server.nextPid++
+	startTime := time.Now().Unix()
+	upid := fmt.Sprintf("UPID:%s:%08X:%08X:%08X:%s:%s:%s:", nodeName, server.nextPid, server.nextPid*100, startTime, taskType, id, Username)
+
+	finishedTask := task{
+		upid:       upid,
+		node:       nodeName,
+		pid:        server.nextPid,
+		startTime:  startTime,
+		taskType:   taskType,
+		id:         id,
+		exitStatus: "OK",
+		log:        append([]string{}, log...),
+	}
+
+	outcome, hasOutcome := server.taskOutcomes[taskType]
+	if hasOutcome {
+		finishedTask.exitStatus = outcome.exitStatus
+		finishedTask.log = append(finishedTask.log, outcome.log...)
+	}
+
+	if finishedTask.exitStatus == "OK" {
+		warnings := 0
+		for _, line := range finishedTask.log {
+			if strings.HasPrefix(line, "WARN: ") {
+				warnings++
+			}
+		}
+		if warnings > 0 {
+			finishedTask.exitStatus = fmt.Sprintf("WARNINGS: %d", warnings)
+		}
+	}
+
+	switch {
+	case finishedTask.exitStatus == "OK":
+		finishedTask.log = append(finishedTask.log, "TASK OK")
+	case strings.HasPrefix(finishedTask.exitStatus, "WARNINGS:"):
+		finishedTask.log = append(finishedTask.log, "TASK "+finishedTask.exitStatus)
+	default:
+		finishedTask.log = append(finishedTask.log, "TASK ERROR: "+finishedTask.exitStatus)
+	}
+
+	server.tasks[upid] = &finishedTask
+	failed := finishedTask.exitStatus != "OK" && !strings.HasPrefix(finishedTask.exitStatus, "WARNINGS:")
+	return upid, !failed
+}
+
+// failTask records a task that failed regardless of the outcome set for its type and returns its upid
+func (server *Server) failTask(nodeName string, taskType string, id string, exitStatus string) string {
+	outcome, hasOutcome := server.taskOutcomes[taskType]
+	server.taskOutcomes[taskType] = taskOutcome{exitStatus: exitStatus}
+	upid, _ := server.runTask(nodeName, taskType, id)
+	if hasOutcome {
+		server.taskOutcomes[taskType] = outcome
+	} else {
+		delete(server.taskOutcomes, taskType)
+	}
+	return upid
+}
+
+func (server *Server) requireTask(writer http.ResponseWriter, request *http.Request) (*task, bool) {
+	foundTask, found := server.tasks[request.PathValue("upid")]
+	if !found || foundTask.node != request.PathValue("node") {
+		writeError(writer, http.StatusInternalServerError, "no such task", nil)
+		return nil, false
+	}
+	return foundTask, true
+}
+
+func (server *Server) getTaskStatus(writer http.ResponseWriter, request *http.Request) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	foundTask, found := server.requireTask(writer, request)
+	if !found {
+		return
+	}
+
+	writeData(writer, map[string]interface{}{
+		"upid":       foundTask.upid,
+		"node":       foundTask.node,
+		"pid":        foundTask.pid,
+		"pstart":     foundTask.pid * 100,
+		"starttime":  foundTask.startTime,
+		"type":       foundTask.taskType,
+		"id":         foundTask.id,
+		"user":       Username,
+		"status":     "stopped",
+		"exitstatus": foundTask.exitStatus,
+	})
+}
+
+func (server *Server) getTaskLog(writer http.ResponseWriter, request *http.Request) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	foundTask, found := server.requireTask(writer, request)
+	if !found {
+		return
+	}
+
+	start, _ := strconv.Atoi(request.URL.Query().Get("start"))
+	limit, limitError := strconv.Atoi(request.URL.Query().Get("limit"))
+	if limitError != nil || limit <= 0 {
+		limit = 50
+	}
+
+	lines := []map[string]interface{}{}
+	for index := start; index < len(foundTask.log) && index < start+limit; index++ {
+		lines = append(lines, map[string]interface{}{"n": index + 1, "t": foundTask.log[index]})
+	}
+
+	writeTaskLog(writer, lines, len(foundTask.log))
+}
+
+// writeTaskLog the log is the only response that carries a total next to the data
+func writeTaskLog(writer http.ResponseWriter, lines []map[string]interface{}, total int) {
+	writeEnvelope(writer, map[string]interface{}{"data": lines, "total": total})
+}
@@ -1,24 +1,20 @@
 #!/bin/bash
-#External Mocks
-#mockgen github.com/diskfs/go-diskfs/filesystem FileSystem > services/filesystem_mock_test.go
-#sed -i 's/package mock_filesystem/package services/g' services/filesystem_mock_test.go
-#
-#mockgen os FileInfo > services/fileInfo_mock_test.go
-#sed -i 's/package mock_os/package services/g' services/fileInfo_mock_test.go
-#
-#mockgen github.com/diskfs/go-diskfs/filesystem File > services/filesystem_file_mock_test.go
-#sed -i 's/package mock_filesystem/package services/g' services/filesystem_file_mock_test.go
+set -e
+
+#mockgen is a tool dependency in go.mod, so everyone generates with the same version
+MOCKGEN="go tool mockgen"
+MODULE="terraform-provider-proxmox"
 
 #Internal Mocks
 
-mockgen -source=proxmox_client/client.go -destination=proxmox_client/client_mock.go
-sed -i 's/package mock_proxmox_client/package proxmox_client/g' proxmox_client/client_mock.go
-sed -i 's/proxmox_client\.//g' proxmox_client/client_mock.go
-sed -i 's~proxmox_client "terraform-provider-proxmox/proxmox_client"~~g' proxmox_client/client_mock.go
+$MOCKGEN -source=proxmox_client/client.go -destination=proxmox_client/client_mock.go \
+  -package=proxmox_client -self_package=$MODULE/proxmox_client
+
+$MOCKGEN -source=services/proxmox_utils.go -destination=services/proxmox_utils_mock.go \
+  -package=services -self_package=$MODULE/services
 
+$MOCKGEN -source=services/task_service.go -destination=services/task_service_mock.go \
+  -package=services -self_package=$MODULE/services
 
-mockgen -source=services/proxmox_utils.go -destination=services/proxmox_utils_mock.go
-sed -i 's/package mock_proxmox_client/package proxmox_client/g' services/proxmox_utils_mock.go
-sed -i 's/mock_services\.//g' services/proxmox_utils_mock.go
-sed -i 's~mock_services "terraform-provider-proxmox/proxmox_client"~~g' services/proxmox_utils_mock.go
-sed -i 's/mock_services/services/g' services/proxmox_utils_mock.go
\ No newline at end of file
+$MOCKGEN -source=services/vm/vm_disk.go -destination=services/vm/vm_disk_mock.go \
+  -package=vm -self_package=$MODULE/services/vm
@@ -1,6 +1,7 @@
 package healthcheck_client
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/http"
@@ -10,7 +11,7 @@ import (
 
 type HealthCheckClient interface {
 	SetTimeout(timeout int)
-	CheckHttpGet(address string, apiPath *string, useTls *bool, requestedPort *int64) (*string, error)
+	CheckHttpGet(ctx context.Context, address string, apiPath *string, useTls *bool, requestedPort *int64) (*string, error)
 }
 
 type healthCheckClientImpl struct {
@@ -34,7 +35,7 @@ func (healthCheckClient *healthCheckClientImpl) SetTimeout(timeout int) {
 	healthCheckClient.httpClient.Timeout = time.Duration(timeout) * time.Second
 }
 
-func (healthCheckClient *healthCheckClientImpl) CheckHttpGet(address string, apiPath *string, useTls *bool, requestedPort *int64) (*string, error) {
+func (healthCheckClient *healthCheckClientImpl) CheckHttpGet(ctx context.Context, address string, apiPath *string, useTls *bool, requestedPort *int64) (*string, error) {
 	var port, protocol, path string
 	if requestedPort == nil {
 		port = "80"
@@ -52,7 +53,11 @@ func (healthCheckClient *healthCheckClientImpl) CheckHttpGet(address string, api
 		path = *apiPath
 	}
 	path, _ = strings.CutPrefix(path, "/")
-	response, responseError := healthCheckClient.httpClient.Get(fmt.Sprintf("%s://%s:%s/%s", protocol, address, port, path))
+	request, requestCreationError := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s://%s:%s/%s", protocol, address, port, path), nil)
+	if requestCreationError != nil {
+		return nil, requestCreationError
+	}
+	response, responseError := healthCheckClient.httpClient.Do(request)
 	if responseError != nil {
 		return nil, responseError
 	}
@@ -0,0 +1,280 @@
+package proxmox
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"terraform-provider-proxmox/proxmox_client"
+	"terraform-provider-proxmox/services"
+	proxmoxTypes "terraform-provider-proxmox/types"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+var (
+	_ resource.Resource               = &cloudInitSnippetResource{}
+	_ resource.ResourceWithConfigure  = &cloudInitSnippetResource{}
+	_ resource.ResourceWithModifyPlan = &cloudInitSnippetResource{}
+)
+
+func NewCloudInitSnippetResource() resource.Resource {
+	return &cloudInitSnippetResource{}
+}
+
+// defaultCloudInitSnippetTimeout applies to every operation on a snippet unless a timeouts block says otherwise
+const defaultCloudInitSnippetTimeout = 5 * time.Minute
+
+// cloudInitSnippetResource writes a cloud-init file to a storage so vms can reference it with cloud_init_custom.
+// Proxmox has no api to read snippets back, changes made to the file outside of terraform are only detected when the
+// ssh block of the provider is set, the checksum of the file is then read on the node.
+type cloudInitSnippetResource struct {
+	snippetService services.SnippetService
+}
+
+type cloudInitSnippetResourceModel struct {
+	proxmoxTypes.CloudInitSnippet
+	Timeouts timeouts.Value `tfsdk:"timeouts"`
+}
+
+// Configure adds the provider configured client to the resource.
+func (r *cloudInitSnippetResource) Configure(_ context.Context, req resource.ConfigureRequest, _ *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	proxmoxClient := req.ProviderData.(proxmox_client.ProxmoxClient)
+	r.snippetService = services.NewSnippetService(proxmoxClient, services.NewTaskService(proxmoxClient))
+}
+
+// Metadata returns the resource type name.
+func (r *cloudInitSnippetResource) Metadata(_ context.Context, req resource.MetadataRequest, response *resource.MetadataResponse) {
+	response.TypeName = req.ProviderTypeName + "_cloud_init_snippet"
+}
+
+// Schema defines the schema for the resource.
+func (r *cloudInitSnippetResource) Schema(ctx context.Context, _ resource.SchemaRequest, response *resource.SchemaResponse) {
+	response.Schema = schema.Schema{
+		Description: "writes a cloud-init user, network, vendor or meta data file to a storage with the snippets content type, reference its volid in the cloud_init_custom block of proxmox_vm. The file is uploaded through the api where proxmox accepts snippets and written over ssh, see the ssh block of the provider, where it doesn't",
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Update: true,
+				Delete: true,
+			}),
+		},
+		Attributes: map[string]schema.Attribute{
+			"node_name": schema.StringAttribute{
+				Required:    true,
+				Description: "node the snippet is written to, any node that has the storage will do for shared storages",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"storage": schema.StringAttribute{
+				Required:    true,
+				Description: "storage the snippet is written to, the storage must allow the snippets content type",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"file_name": schema.StringAttribute{
+				Required:    true,
+				Description: "name of the file in the snippets directory of the storage, e.g. web-01-user.yaml",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+				Validators: []validator.String{
+					snippetFileNameValidator{},
+				},
+			},
+			"type": schema.StringAttribute{
+				Optional:    true,
+				Computed:    true,
+				Default:     stringdefault.StaticString("user"),
+				Description: "kind of cloud-init data, user and vendor data without a header get #cloud-config prepended",
+				Validators: []validator.String{
+					stringOneOfValidator{allowedValues: proxmoxTypes.CloudInitSnippetTypes},
+				},
+			},
+			"content": schema.StringAttribute{
+				Required:    true,
+				Description: "yaml content of the snippet, e.g. from yamlencode, or a script starting with #!",
+			},
+			"sha256": schema.StringAttribute{
+				Computed:    true,
+				Description: "sha256 checksum of the file written to the storage, changes whenever the snippet does. With the ssh block of the provider set the file on the node is checked as well, so changes made outside of terraform show up as a diff",
+			},
+			"volid": schema.StringAttribute{
+				Computed:    true,
+				Description: "volume id of the snippet as storage:snippets/file_name",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+		},
+	}
+}
+
+// ModifyPlan renders the snippet so the plan shows its checksum and invalid content is reported before anything is
+// written
+func (r *cloudInitSnippetResource) ModifyPlan(ctx context.Context, request resource.ModifyPlanRequest, response *resource.ModifyPlanResponse) {
+	if request.Plan.Raw.IsNull() {
+		return
+	}
+
+	var plan cloudInitSnippetResourceModel
+	response.Diagnostics.Append(request.Plan.Get(ctx, &plan)...)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	if !plan.Storage.IsUnknown() && !plan.FileName.IsUnknown() {
+		plan.Volid = types.StringValue(services.SnippetVolid(plan.Storage.ValueString(), plan.FileName.ValueString()))
+	}
+	if plan.Content.IsUnknown() || plan.Type.IsUnknown() {
+		plan.Sha256 = types.StringUnknown()
+	} else {
+		rendered, renderError := services.RenderSnippet(plan.Type.ValueString(), plan.Content.ValueString())
+		if renderError != nil {
+			response.Diagnostics.AddAttributeError(path.Root("content"), "Invalid snippet content", renderError.Error())
+			return
+		}
+		checksum := sha256.Sum256(rendered)
+		plan.Sha256 = types.StringValue(hex.EncodeToString(checksum[:]))
+	}
+
+	response.Diagnostics.Append(response.Plan.Set(ctx, &plan)...)
+}
+
+// Create creates the resource and sets the initial Terraform state.
+func (r *cloudInitSnippetResource) Create(ctx context.Context, request resource.CreateRequest, response *resource.CreateResponse) {
+	var plan cloudInitSnippetResourceModel
+	diags := request.Plan.Get(ctx, &plan)
+	response.Diagnostics.Append(diags...)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	createTimeout, diags := plan.Timeouts.Create(ctx, defaultCloudInitSnippetTimeout)
+	response.Diagnostics.Append(diags...)
+	if response.Diagnostics.HasError() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, createTimeout)
+	defer cancel()
+
+	uploadError := r.snippetService.UploadSnippet(ctx, &plan.CloudInitSnippet)
+
+	if uploadError != nil {
+		response.Diagnostics.AddError("Failed to write the cloud-init snippet", uploadError.Error())
+		return
+	}
+
+	diags = response.State.Set(ctx, plan)
+	response.Diagnostics.Append(diags...)
+}
+
+// Read removes the snippet from the state when the file is gone, its content can't be read back
+func (r *cloudInitSnippetResource) Read(ctx context.Context, request resource.ReadRequest, response *resource.ReadResponse) {
+	var state cloudInitSnippetResourceModel
+	diags := request.State.Get(ctx, &state)
+	response.Diagnostics.Append(diags...)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	readTimeout, diags := state.Timeouts.Read(ctx, defaultCloudInitSnippetTimeout)
+	response.Diagnostics.Append(diags...)
+	if response.Diagnostics.HasError() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, readTimeout)
+	defer cancel()
+
+	exists, existsError := r.snippetService.SnippetExists(ctx, &state.CloudInitSnippet)
+
+	if existsError != nil {
+		response.Diagnostics.AddError("Failed to read the cloud-init snippet", existsError.Error())
+		return
+	}
+	if !exists {
+		tflog.Warn(ctx, fmt.Sprintf("Snippet %s no longer exists on node %s, removing it from state", state.Volid.ValueString(), state.NodeName.ValueString()))
+		response.State.RemoveResource(ctx)
+		return
+	}
+
+	checksum, checksumError := r.snippetService.SnippetChecksum(ctx, &state.CloudInitSnippet)
+
+	if checksumError != nil {
+		response.Diagnostics.AddError("Failed to read the checksum of the cloud-init snippet", checksumError.Error())
+		return
+	}
+	if checksum != nil {
+		state.Sha256 = types.StringValue(*checksum)
+	}
+
+	response.Diagnostics.Append(response.State.Set(ctx, &state)...)
+}
+
+// Update writes the changed snippet over the existing file, vms referencing it keep the same volid
+func (r *cloudInitSnippetResource) Update(ctx context.Context, request resource.UpdateRequest, response *resource.UpdateResponse) {
+	var plan cloudInitSnippetResourceModel
+	diags := request.Plan.Get(ctx, &plan)
+	response.Diagnostics.Append(diags...)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	updateTimeout, diags := plan.Timeouts.Update(ctx, defaultCloudInitSnippetTimeout)
+	response.Diagnostics.Append(diags...)
+	if response.Diagnostics.HasError() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, updateTimeout)
+	defer cancel()
+
+	uploadError := r.snippetService.UploadSnippet(ctx, &plan.CloudInitSnippet)
+
+	if uploadError != nil {
+		response.Diagnostics.AddError("Failed to write the cloud-init snippet", uploadError.Error())
+		return
+	}
+
+	diags = response.State.Set(ctx, plan)
+	response.Diagnostics.Append(diags...)
+}
+
+// Delete deletes the resource and removes the Terraform state on success.
+func (r *cloudInitSnippetResource) Delete(ctx context.Context, request resource.DeleteRequest, response *resource.DeleteResponse) {
+	var state cloudInitSnippetResourceModel
+	diags := request.State.Get(ctx, &state)
+	response.Diagnostics.Append(diags...)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	deleteTimeout, diags := state.Timeouts.Delete(ctx, defaultCloudInitSnippetTimeout)
+	response.Diagnostics.Append(diags...)
+	if response.Diagnostics.HasError() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, deleteTimeout)
+	defer cancel()
+
+	deleteError := r.snippetService.DeleteSnippet(ctx, &state.CloudInitSnippet)
+
+	if deleteError != nil {
+		response.Diagnostics.AddError("Failed to delete the cloud-init snippet", deleteError.Error())
+	}
+}
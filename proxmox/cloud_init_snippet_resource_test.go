@@ -0,0 +1,77 @@
+package proxmox
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"terraform-provider-proxmox/fake_proxmox"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+)
+
+func testAccCloudInitSnippetConfig(server *fake_proxmox.Server, storage string, hostname string) string {
+	return server.ProviderConfig() + fmt.Sprintf(`
+resource "proxmox_cloud_init_snippet" "user" {
+  node_name = "pve"
+  storage   = %q
+  file_name = "web-user.yaml"
+  content   = yamlencode({
+    hostname = %q
+    packages = ["nginx"]
+  })
+}
+`, storage, hostname)
+}
+
+func checkSnippetFile(server *fake_proxmox.Server, expected string) resource.TestCheckFunc {
+	return func(_ *terraform.State) error {
+		data, found := server.StorageFile("pve", "local:snippets/web-user.yaml")
+		if !found || !strings.HasPrefix(string(data), "#cloud-config\n") || !strings.Contains(string(data), expected) {
+			return fmt.Errorf("expected a cloud-config snippet containing %q, got %q", expected, data)
+		}
+		return nil
+	}
+}
+
+func TestAccCloudInitSnippetResource(t *testing.T) {
+	server := fake_proxmox.NewServer()
+	defer server.Close()
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy: func(_ *terraform.State) error {
+			if server.HasVolume("pve", "local:snippets/web-user.yaml") {
+				return fmt.Errorf("snippet web-user.yaml still exists")
+			}
+			return nil
+		},
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCloudInitSnippetConfig(server, "local", "web-01"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("proxmox_cloud_init_snippet.user", "volid", "local:snippets/web-user.yaml"),
+					resource.TestCheckResourceAttr("proxmox_cloud_init_snippet.user", "type", "user"),
+					resource.TestMatchResourceAttr("proxmox_cloud_init_snippet.user", "sha256", regexp.MustCompile("^[0-9a-f]{64}$")),
+					checkSnippetFile(server, `"hostname": "web-01"`),
+				),
+			},
+			{
+				Config: testAccCloudInitSnippetConfig(server, "local", "web-02"),
+				Check:  checkSnippetFile(server, `"hostname": "web-02"`),
+			},
+			{
+				PreConfig: func() {
+					server.RejectSnippetUploads(true)
+				},
+				Config:      testAccCloudInitSnippetConfig(server, "local", "web-03"),
+				ExpectError: regexp.MustCompile(`ssh access to the nodes\s+is\s+not\s+configured`),
+			},
+			{
+				Config:      testAccCloudInitSnippetConfig(server, "local-zfs", "web-02"),
+				ExpectError: regexp.MustCompile(`storage local-zfs does not allow\s+snippets`),
+			},
+		},
+	})
+}
@@ -0,0 +1,149 @@
+package proxmox
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"terraform-provider-proxmox/proxmox_client"
+	"terraform-provider-proxmox/services"
+	proxmoxTypes "terraform-provider-proxmox/types"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+)
+
+// attributePathFunc maps a proxmox api parameter name to the terraform attribute it was generated from
+type attributePathFunc func(parameter string) (path.Path, bool)
+
+var vmParameterAttributes = map[string]string{
+	"acpi":         "acpi",
+	"agent":        "qemu_agent_enabled",
+	"bios":         "bios",
+	"boot":         "boot_order",
+	"cicustom":     "cloud_init_custom",
+	"cipassword":   "cloud_init_password",
+	"citype":       "cloud_init_type",
+	"ciupgrade":    "perform_cloud_init_upgrade",
+	"ciuser":       "default_user",
+	"cores":        "cores",
+	"cpu":          "cpu_type",
+	"cpulimit":     "cpu_limit",
+	"description":  "description",
+	"hotplug":      "hotplug",
+	"kvm":          "kvm",
+	"memory":       "memory",
+	"name":         "name",
+	"nameserver":   "nameserver",
+	"numa":         "numa_active",
+	"onboot":       "start_on_boot",
+	"ostype":       "os_type",
+	"protection":   "protection",
+	"scsihw":       "scsi_hw",
+	"searchdomain": "search_domain",
+	"sockets":      "sockets",
+	"sshkeys":      "ssh_keys",
+	"startup":      "host_startup_order",
+	"tags":         "tags",
+	"target":       "node_name",
+	"vmid":         "vm_id",
+}
+
+var indexedParameterRegex = regexp.MustCompile(`^(ide|sata|scsi|virtio|net|ipconfig)(\d+)$`)
+
+// addApiErrorDiagnostics
+/**
+ * @description reports an error returned by the proxmox client. Parameter validation errors are attached to the
+ * attribute that produced the rejected parameter whenever it can be determined, so terraform can point at the
+ * offending line in the configuration.
+ */
+func addApiErrorDiagnostics(diagnostics *diag.Diagnostics, summary string, err error, attributePath attributePathFunc) {
+	apiError, isApiError := proxmox_client.AsApiError(err)
+	if !isApiError {
+		diagnostics.AddError(summary, err.Error())
+		return
+	}
+
+	if proxmox_client.IsPermissionDenied(apiError) {
+		diagnostics.AddError(summary, fmt.Sprintf("%s\n\nThe configured user or api token is missing the privileges required for %s %s", err.Error(), apiError.Method, apiError.Path))
+		return
+	}
+
+	if len(apiError.Errors) == 0 || attributePath == nil {
+		diagnostics.AddError(summary, err.Error())
+		return
+	}
+
+	for _, parameter := range apiError.Parameters() {
+		detail := fmt.Sprintf("proxmox rejected %s: %s", parameter, strings.TrimSpace(apiError.Errors[parameter]))
+		if parameterPath, found := attributePath(parameter); found {
+			diagnostics.AddAttributeError(parameterPath, summary, detail)
+		} else {
+			diagnostics.AddError(summary, detail)
+		}
+	}
+}
+
+func vmAttributePath(plan *proxmoxTypes.VmModel) attributePathFunc {
+	return func(parameter string) (path.Path, bool) {
+		if attribute, exists := vmParameterAttributes[parameter]; exists {
+			return path.Root(attribute), true
+		}
+
+		matches := indexedParameterRegex.FindStringSubmatch(parameter)
+		if matches == nil || plan == nil {
+			return path.Empty(), false
+		}
+		index, _ := strconv.ParseInt(matches[2], 10, 64)
+
+		switch matches[1] {
+		case "net":
+			for i, nic := range plan.NetworkInterfaces {
+				if nic.Order.ValueInt64() == index {
+					return path.Root("network_interface").AtListIndex(i), true
+				}
+			}
+		case "ipconfig":
+			for i, ipConfig := range plan.IpConfigurations {
+				if ipConfig.Order.ValueInt64() == index {
+					return path.Root("ip_config").AtListIndex(i), true
+				}
+			}
+		default:
+			for i, disk := range plan.Disks {
+				if disk.BusType.ValueString() == matches[1] && disk.Order.ValueInt64() == index {
+					return path.Root("disk").AtListIndex(i), true
+				}
+			}
+		}
+		return path.Empty(), false
+	}
+}
+
+func sdnZoneAttributePath(parameter string) (path.Path, bool) {
+	switch parameter {
+	case "type", "zone", "ipam", "nodes", "peers":
+		return path.Root(parameter), true
+	}
+	return path.Empty(), false
+}
+
+func vmTemplateAttributePath(parameter string) (path.Path, bool) {
+	switch parameter {
+	case "disk":
+		return path.Root("disk"), true
+	case "vmid":
+		return path.Root("vm_id"), true
+	}
+	return path.Empty(), false
+}
+
+// addTaskWarningDiagnostics reports every task that completed with warnings during the operation as a warning diagnostic
+func addTaskWarningDiagnostics(diagnostics *diag.Diagnostics, collector *services.TaskWarningCollector) {
+	for _, warning := range collector.Warnings() {
+		diagnostics.AddWarning(
+			fmt.Sprintf("Proxmox %s task completed with warnings", warning.TaskType),
+			fmt.Sprintf("task %s on node %s finished with %s\n\n%s", warning.Upid, warning.NodeName, warning.ExitStatus, strings.Join(warning.LogLines, "\n")),
+		)
+	}
+}
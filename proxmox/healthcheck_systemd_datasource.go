@@ -39,7 +39,7 @@ func (d *healthCheckSystemdDatasource) Read(ctx context.Context, request datasou
 		return
 	}
 	for true {
-		metrics, getMetricsError := d.healthCheckClient.CheckHttpGet(plan.Address.ValueString(), plan.Path.ValueStringPointer(), plan.TlsEnabled.ValueBoolPointer(), plan.CustomPort.ValueInt64Pointer())
+		metrics, getMetricsError := d.healthCheckClient.CheckHttpGet(ctx, plan.Address.ValueString(), plan.Path.ValueStringPointer(), plan.TlsEnabled.ValueBoolPointer(), plan.CustomPort.ValueInt64Pointer())
 
 		if getMetricsError != nil {
 			response.Diagnostics.AddError("Failed to retrieve metrics for systemd health check", getMetricsError.Error())
@@ -70,7 +70,12 @@ func (d *healthCheckSystemdDatasource) Read(ctx context.Context, request datasou
 			}
 
 		}
-		time.Sleep(time.Duration(3) * time.Second)
+		select {
+		case <-ctx.Done():
+			response.Diagnostics.AddError(fmt.Sprintf("Stopped waiting for service %s", plan.ServiceName.ValueString()), ctx.Err().Error())
+			return
+		case <-time.After(time.Duration(3) * time.Second):
+		}
 	}
 
 }
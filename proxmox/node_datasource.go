@@ -53,7 +53,7 @@ func (d *nodeDataSource) Read(ctx context.Context, request datasource.ReadReques
 
 	tflog.Debug(ctx, fmt.Sprintf("Node name is %s", plan.Name.ValueString()))
 
-	nodes, listNodesError := d.client.ListNodes()
+	nodes, listNodesError := d.client.ListNodes(ctx)
 
 	if listNodesError != nil {
 		response.Diagnostics.AddError("Failed to list nodes", listNodesError.Error())
@@ -80,7 +80,7 @@ func (d *nodeDataSource) Read(ctx context.Context, request datasource.ReadReques
 		return
 	}
 
-	networkConfig, getNetworkConfigError := d.client.GetNodeNetworkConfig(plan.Name.ValueString())
+	networkConfig, getNetworkConfigError := d.client.GetNodeNetworkConfig(ctx, plan.Name.ValueString())
 
 	if getNetworkConfigError != nil {
 		response.Diagnostics.AddError("Failed to retrieve networkconfig for node", getNetworkConfigError.Error())
@@ -2,9 +2,13 @@ package proxmox
 
 import (
 	"context"
+	"os"
+	"strconv"
 	"terraform-provider-proxmox/proxmox_client"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 
 	"github.com/hashicorp/terraform-plugin-framework/path"
@@ -41,15 +45,55 @@ func (p *proxmoxProvider) Resources(ctx context.Context) []func() resource.Resou
 	return []func() resource.Resource{
 		NewVmResource,
 		NewSdnZoneResource,
+		NewVmTemplateResource,
+		NewCloudInitSnippetResource,
 	}
 }
 
 // proxmoxProviderModel maps provider schema data to a Go type.
 type proxmoxProviderModel struct {
-	Host      types.String `tfsdk:"host"`
-	Username  types.String `tfsdk:"username"`
-	Password  types.String `tfsdk:"password"`
-	VerifyTLS types.Bool   `tfsdk:"verify_tls"`
+	Host           types.String                   `tfsdk:"host"`
+	Hosts          []types.String                 `tfsdk:"hosts"`
+	DiscoverHosts  types.Bool                     `tfsdk:"discover_hosts"`
+	Username       types.String                   `tfsdk:"username"`
+	Password       types.String                   `tfsdk:"password"`
+	ApiTokenId     types.String                   `tfsdk:"api_token_id"`
+	ApiTokenSecret types.String                   `tfsdk:"api_token_secret"`
+	TotpSecret     types.String                   `tfsdk:"totp_secret"`
+	VerifyTLS      types.Bool                     `tfsdk:"verify_tls"`
+	CaCertPem      types.String                   `tfsdk:"ca_cert_pem"`
+	CaCertFile     types.String                   `tfsdk:"ca_cert_file"`
+	TlsFingerprint types.String                   `tfsdk:"tls_fingerprint_sha256"`
+	ConfigFile     types.String                   `tfsdk:"config_file"`
+	Profile        types.String                   `tfsdk:"profile"`
+	Retry          *proxmoxProviderRetryModel     `tfsdk:"retry"`
+	VmIdRange      *proxmoxProviderVmIdRangeModel `tfsdk:"vm_id_range"`
+	Ssh            *proxmoxProviderSshModel       `tfsdk:"ssh"`
+
+	MaxConcurrentRequests     types.Int64   `tfsdk:"max_concurrent_requests"`
+	RequestsPerSecond         types.Float64 `tfsdk:"requests_per_second"`
+	MaxConcurrentTasksPerNode types.Int64   `tfsdk:"max_concurrent_tasks_per_node"`
+}
+
+type proxmoxProviderRetryModel struct {
+	MaxAttempts    types.Int64   `tfsdk:"max_attempts"`
+	InitialBackoff types.String  `tfsdk:"initial_backoff"`
+	MaxBackoff     types.String  `tfsdk:"max_backoff"`
+	Jitter         types.Float64 `tfsdk:"jitter"`
+}
+
+type proxmoxProviderVmIdRangeModel struct {
+	First types.Int64 `tfsdk:"first"`
+	Last  types.Int64 `tfsdk:"last"`
+}
+
+type proxmoxProviderSshModel struct {
+	Username       types.String `tfsdk:"username"`
+	Password       types.String `tfsdk:"password"`
+	PrivateKey     types.String `tfsdk:"private_key"`
+	Port           types.Int64  `tfsdk:"port"`
+	HostKeySha256  types.String `tfsdk:"host_key_sha256"`
+	KnownHostsFile types.String `tfsdk:"known_hosts_file"`
 }
 
 // Metadata returns the provider type name.
@@ -60,19 +104,142 @@ func (p *proxmoxProvider) Metadata(_ context.Context, _ provider.MetadataRequest
 // Schema defines the provider-level schema for configuration data.
 func (p *proxmoxProvider) Schema(_ context.Context, _ provider.SchemaRequest, resp *provider.SchemaResponse) {
 	resp.Schema = schema.Schema{
+		Blocks: map[string]schema.Block{
+			"retry": schema.SingleNestedBlock{
+				Description: "controls how failed requests that are safe to send again are retried",
+				Attributes: map[string]schema.Attribute{
+					"max_attempts": schema.Int64Attribute{
+						Optional:    true,
+						Description: "total number of attempts per request including the first, defaults to 4. Set to 1 to disable retries",
+					},
+					"initial_backoff": schema.StringAttribute{
+						Optional:    true,
+						Description: "delay before the first retry as a duration, e.g. 500ms. Doubles with every attempt, defaults to 1s",
+					},
+					"max_backoff": schema.StringAttribute{
+						Optional:    true,
+						Description: "upper limit for the delay between attempts, defaults to 30s",
+					},
+					"jitter": schema.Float64Attribute{
+						Optional:    true,
+						Description: "fraction between 0 and 1 that each delay is randomly spread by, defaults to 0.2",
+					},
+				},
+			},
+			"vm_id_range": schema.SingleNestedBlock{
+				Description: "ids handed out to vms created without a vm_id, so teams sharing a cluster don't collide",
+				Attributes: map[string]schema.Attribute{
+					"first": schema.Int64Attribute{
+						Optional:    true,
+						Description: "lowest id to hand out, defaults to 100",
+					},
+					"last": schema.Int64Attribute{
+						Optional:    true,
+						Description: "highest id to hand out, defaults to 999999999",
+					},
+				},
+			},
+			"ssh": schema.SingleNestedBlock{
+				Description: "shell access to the nodes for what the api can't do, such as writing cloud-init snippets to storages that don't accept them as uploads",
+				Attributes: map[string]schema.Attribute{
+					"username": schema.StringAttribute{
+						Optional:    true,
+						Description: "user to log in as, defaults to root",
+					},
+					"password": schema.StringAttribute{
+						Optional:  true,
+						Sensitive: true,
+					},
+					"private_key": schema.StringAttribute{
+						Optional:    true,
+						Sensitive:   true,
+						Description: "pem encoded private key, either this or password is required",
+					},
+					"port": schema.Int64Attribute{
+						Optional:    true,
+						Description: "ssh port of the nodes, defaults to 22",
+					},
+					"host_key_sha256": schema.StringAttribute{
+						Optional:    true,
+						Description: "sha256 fingerprint of the host key to trust as printed by ssh-keygen -l, the known hosts file is used when not set",
+					},
+					"known_hosts_file": schema.StringAttribute{
+						Optional:    true,
+						Description: "known hosts file the host keys of the nodes are checked against, defaults to ~/.ssh/known_hosts",
+					},
+				},
+			},
+		},
 		Attributes: map[string]schema.Attribute{
 			"host": schema.StringAttribute{
 				Optional: true,
 			},
+			"hosts": schema.ListAttribute{
+				Optional:    true,
+				ElementType: types.StringType,
+				Description: "additional api endpoints of the same cluster. Requests stay on one endpoint and fail over to the next when it becomes unreachable, an unreachable endpoint is skipped for a minute and has to answer a probe before it is used again. Can also be set as a comma separated list with PROXMOX_HOSTS",
+			},
+			"discover_hosts": schema.BoolAttribute{
+				Optional:    true,
+				Description: "adds every online cluster node from /cluster/status as a failover endpoint. Can also be enabled with PROXMOX_DISCOVER_HOSTS=true",
+			},
 			"username": schema.StringAttribute{
-				Optional: true,
+				Optional:    true,
+				Description: "user to log in as, e.g. root@pam. A token id (user@realm!token) is also accepted for backwards compatibility",
 			},
 			"password": schema.StringAttribute{
+				Optional:    true,
+				Sensitive:   true,
+				Description: "password used to request an authentication ticket, or the token secret when username is a token id",
+			},
+			"api_token_id": schema.StringAttribute{
+				Optional:    true,
+				Description: "api token id in the form user@realm!token, takes precedence over username and password",
+			},
+			"api_token_secret": schema.StringAttribute{
 				Optional:  true,
 				Sensitive: true,
 			},
+			"totp_secret": schema.StringAttribute{
+				Optional:    true,
+				Sensitive:   true,
+				Description: "base32 totp secret used to answer the second factor challenge when logging in with a password",
+			},
 			"verify_tls": schema.BoolAttribute{
-				Optional: true,
+				Optional:    true,
+				Description: "defaults to false, can also be set with PROXMOX_INSECURE or verify_tls in the config_file profile",
+			},
+			"ca_cert_pem": schema.StringAttribute{
+				Optional:    true,
+				Description: "pem encoded ca certificate(s) to trust in addition to the system roots",
+			},
+			"ca_cert_file": schema.StringAttribute{
+				Optional:    true,
+				Description: "path to a pem encoded ca bundle to trust in addition to the system roots, can also be set with PROXMOX_CA_CERT_FILE",
+			},
+			"tls_fingerprint_sha256": schema.StringAttribute{
+				Optional:    true,
+				Description: "sha256 fingerprint of the node certificate to trust, as reported by the proxmox_node datasource. Can also be set with PROXMOX_TLS_FINGERPRINT_SHA256",
+			},
+			"config_file": schema.StringAttribute{
+				Optional:    true,
+				Description: "path to an ini or yaml file of named cluster profiles, can also be set with PROXMOX_CONFIG_FILE",
+			},
+			"profile": schema.StringAttribute{
+				Optional:    true,
+				Description: "name of the profile to read from config_file, can also be set with PROXMOX_PROFILE. Defaults to default",
+			},
+			"max_concurrent_requests": schema.Int64Attribute{
+				Optional:    true,
+				Description: "maximum number of api requests in flight at the same time, unlimited when not set",
+			},
+			"requests_per_second": schema.Float64Attribute{
+				Optional:    true,
+				Description: "maximum number of api requests sent per second, unlimited when not set",
+			},
+			"max_concurrent_tasks_per_node": schema.Int64Attribute{
+				Optional:    true,
+				Description: "maximum number of long-running tasks such as vm creation, disk moves and migrations started on a single node at the same time, unlimited when not set",
 			},
 		},
 	}
@@ -89,42 +256,101 @@ func (p *proxmoxProvider) Configure(ctx context.Context, req provider.ConfigureR
 
 	// Default values to environment variables, but override
 	// with Terraform configuration value if set.
+	// The profiles file is only consulted for values that are not set in either.
 
-	host := config.Host.ValueString()
-	username := config.Username.ValueString()
-	password := config.Password.ValueString()
-	verifyTls := config.VerifyTLS.ValueBool()
+	profile := providerProfile{}
+	configFile := firstNonEmpty(config.ConfigFile.ValueString(), os.Getenv("PROXMOX_CONFIG_FILE"))
+	if configFile != "" {
+		loadedProfile, loadProfileError := loadProviderProfile(configFile, firstNonEmpty(config.Profile.ValueString(), os.Getenv("PROXMOX_PROFILE")))
+		if loadProfileError != nil {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("config_file"),
+				"Failed to load proxmox profile",
+				loadProfileError.Error(),
+			)
+			return
+		}
+		profile = *loadedProfile
+	}
+
+	host := firstNonEmpty(config.Host.ValueString(), os.Getenv("PROXMOX_HOST"), profile.Host)
+	hosts := profile.Hosts
+	if len(config.Hosts) > 0 {
+		hosts = []string{}
+		for _, configuredHost := range config.Hosts {
+			hosts = append(hosts, configuredHost.ValueString())
+		}
+	} else if os.Getenv("PROXMOX_HOSTS") != "" {
+		hosts = splitHosts(os.Getenv("PROXMOX_HOSTS"))
+	}
+	if host != "" {
+		hosts = append([]string{host}, hosts...)
+	}
+	username := firstNonEmpty(config.Username.ValueString(), os.Getenv("PROXMOX_USERNAME"), profile.Username)
+	password := firstNonEmpty(config.Password.ValueString(), os.Getenv("PROXMOX_PASSWORD"), profile.Password)
+	apiTokenId := firstNonEmpty(config.ApiTokenId.ValueString(), os.Getenv("PROXMOX_API_TOKEN_ID"), profile.ApiTokenId)
+	apiTokenSecret := firstNonEmpty(config.ApiTokenSecret.ValueString(), os.Getenv("PROXMOX_API_TOKEN_SECRET"), profile.ApiTokenSecret)
+	totpSecret := firstNonEmpty(config.TotpSecret.ValueString(), os.Getenv("PROXMOX_TOTP_SECRET"), profile.TotpSecret)
+
+	// an unset verify_tls has always meant not verifying the certificates of the nodes
+	verifyTls := false
+	if !config.VerifyTLS.IsNull() {
+		verifyTls = config.VerifyTLS.ValueBool()
+	} else if insecure := os.Getenv("PROXMOX_INSECURE"); insecure != "" {
+		insecureValue, parseError := strconv.ParseBool(insecure)
+		if parseError != nil {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("verify_tls"),
+				"Invalid PROXMOX_INSECURE value",
+				"PROXMOX_INSECURE must be set to true or false",
+			)
+			return
+		}
+		verifyTls = !insecureValue
+	} else if profile.verifyTlsSetting() != nil {
+		verifyTls = *profile.verifyTlsSetting()
+	}
 
 	// If any of the expected configurations are missing, return
 	// errors with provider-specific guidance.
 
-	if host == "" {
+	if len(hosts) == 0 {
 		resp.Diagnostics.AddAttributeError(
 			path.Root("host"),
 			"Missing proxmox API Host",
 			"The provider cannot create the proxmox API client as there is a missing or empty value for the proxmox API host. "+
-				"Set the host value in the configuration or use the PROXMOX_HOST environment variable. "+
-				"If either is already set, ensure the value is not empty.",
+				"Set the host or hosts value in the configuration, use the PROXMOX_HOST or PROXMOX_HOSTS environment variable or set it in the config_file profile. "+
+				"If any of these is already set, ensure the value is not empty.",
 		)
 	}
 
-	if username == "" {
+	if apiTokenId != "" && apiTokenSecret == "" {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("api_token_secret"),
+			"Missing proxmox API Token Secret",
+			"The provider cannot create the proxmox API client as an api token id was provided without a secret. "+
+				"Set the api_token_secret value in the configuration, use the PROXMOX_API_TOKEN_SECRET environment variable or set it in the config_file profile. "+
+				"If any of these is already set, ensure the value is not empty.",
+		)
+	}
+
+	if apiTokenId == "" && username == "" {
 		resp.Diagnostics.AddAttributeError(
 			path.Root("username"),
 			"Missing proxmox API Username",
 			"The provider cannot create the proxmox API client as there is a missing or empty value for the proxmox API username. "+
-				"Set the username value in the configuration or use the PROXMOX_USERNAME environment variable. "+
-				"If either is already set, ensure the value is not empty.",
+				"Set the username value in the configuration, use the PROXMOX_USERNAME environment variable or set it in the config_file profile. "+
+				"If any of these is already set, ensure the value is not empty.",
 		)
 	}
 
-	if password == "" {
+	if apiTokenId == "" && password == "" {
 		resp.Diagnostics.AddAttributeError(
 			path.Root("password"),
 			"Missing proxmox API Password",
 			"The provider cannot create the proxmox API client as there is a missing or empty value for the proxmox API password. "+
-				"Set the password value in the configuration or use the PROXMOX_PASSWORD environment variable. "+
-				"If either is already set, ensure the value is not empty.",
+				"Set the password value in the configuration, use the PROXMOX_PASSWORD environment variable or set it in the config_file profile. "+
+				"If any of these is already set, ensure the value is not empty.",
 		)
 	}
 
@@ -132,11 +358,174 @@ func (p *proxmoxProvider) Configure(ctx context.Context, req provider.ConfigureR
 		return
 	}
 
+	tlsFingerprint := firstNonEmpty(config.TlsFingerprint.ValueString(), os.Getenv("PROXMOX_TLS_FINGERPRINT_SHA256"), profile.TlsFingerprint)
+	if tlsFingerprint != "" {
+		_, fingerprintError := proxmox_client.NormalizeFingerprint(tlsFingerprint)
+		if fingerprintError != nil {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("tls_fingerprint_sha256"),
+				"Invalid TLS fingerprint",
+				fingerprintError.Error(),
+			)
+			return
+		}
+	}
+
+	retryPolicy := proxmox_client.DefaultRetryPolicy()
+	if config.Retry != nil {
+		resp.Diagnostics.Append(config.Retry.applyTo(&retryPolicy)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	limitOptions := proxmox_client.LimitOptions{
+		MaxConcurrentRequests:     int(config.MaxConcurrentRequests.ValueInt64()),
+		RequestsPerSecond:         config.RequestsPerSecond.ValueFloat64(),
+		MaxConcurrentTasksPerNode: int(config.MaxConcurrentTasksPerNode.ValueInt64()),
+	}
+	if limitOptions.MaxConcurrentRequests < 0 {
+		resp.Diagnostics.AddAttributeError(path.Root("max_concurrent_requests"), "Invalid max_concurrent_requests", "max_concurrent_requests must not be negative")
+	}
+	if limitOptions.RequestsPerSecond < 0 {
+		resp.Diagnostics.AddAttributeError(path.Root("requests_per_second"), "Invalid requests_per_second", "requests_per_second must not be negative")
+	}
+	if limitOptions.MaxConcurrentTasksPerNode < 0 {
+		resp.Diagnostics.AddAttributeError(path.Root("max_concurrent_tasks_per_node"), "Invalid max_concurrent_tasks_per_node", "max_concurrent_tasks_per_node must not be negative")
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	vmIdRange := proxmox_client.VmIdRange{}
+	if config.VmIdRange != nil {
+		resp.Diagnostics.Append(config.VmIdRange.applyTo(&vmIdRange)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	var sshOptions *proxmox_client.SshOptions
+	if config.Ssh != nil {
+		sshOptions = &proxmox_client.SshOptions{}
+		resp.Diagnostics.Append(config.Ssh.applyTo(sshOptions)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
 	// Create a new proxmox client using the configuration values
-	client := proxmox_client.NewClient(&host, &username, &password, &verifyTls, ctx)
+	auth := proxmox_client.AuthStruct{
+		Username:    username,
+		Password:    password,
+		TokenId:     apiTokenId,
+		TokenSecret: apiTokenSecret,
+		TotpSecret:  totpSecret,
+	}
+	tlsOptions := proxmox_client.TlsOptions{
+		VerifyTls:         verifyTls,
+		CaCertPem:         config.CaCertPem.ValueString(),
+		CaCertFile:        firstNonEmpty(config.CaCertFile.ValueString(), os.Getenv("PROXMOX_CA_CERT_FILE"), profile.CaCertFile),
+		FingerprintSha256: tlsFingerprint,
+	}
+	client, newClientError := proxmox_client.NewClient(hosts, &auth, &tlsOptions, &retryPolicy, &limitOptions, &vmIdRange, sshOptions)
+	if newClientError != nil {
+		resp.Diagnostics.AddError("Failed to create proxmox API client", newClientError.Error())
+		return
+	}
+
+	discoverHosts := config.DiscoverHosts.ValueBool()
+	if config.DiscoverHosts.IsNull() {
+		discoverHosts, _ = strconv.ParseBool(os.Getenv("PROXMOX_DISCOVER_HOSTS"))
+	}
+	if discoverHosts {
+		// the configured hosts keep working without discovery, so a failure here should not stop the run
+		discoveryError := client.DiscoverClusterEndpoints(ctx)
+		if discoveryError != nil {
+			resp.Diagnostics.AddWarning("Failed to discover proxmox cluster nodes", discoveryError.Error())
+		}
+	}
 
 	// Make the proxmox client available during DataSource and Resource
 	// type Configure methods.
 	resp.DataSourceData = client
 	resp.ResourceData = client
 }
+
+func (retryModel *proxmoxProviderRetryModel) applyTo(retryPolicy *proxmox_client.RetryPolicy) diag.Diagnostics {
+	var diags diag.Diagnostics
+	retryPath := path.Root("retry")
+
+	if !retryModel.MaxAttempts.IsNull() {
+		if retryModel.MaxAttempts.ValueInt64() < 1 {
+			diags.AddAttributeError(retryPath.AtName("max_attempts"), "Invalid retry max_attempts", "max_attempts must be at least 1")
+		}
+		retryPolicy.MaxAttempts = int(retryModel.MaxAttempts.ValueInt64())
+	}
+
+	if !retryModel.InitialBackoff.IsNull() {
+		initialBackoff, parseError := time.ParseDuration(retryModel.InitialBackoff.ValueString())
+		if parseError != nil {
+			diags.AddAttributeError(retryPath.AtName("initial_backoff"), "Invalid retry initial_backoff", parseError.Error())
+		}
+		retryPolicy.InitialBackoff = initialBackoff
+	}
+
+	if !retryModel.MaxBackoff.IsNull() {
+		maxBackoff, parseError := time.ParseDuration(retryModel.MaxBackoff.ValueString())
+		if parseError != nil {
+			diags.AddAttributeError(retryPath.AtName("max_backoff"), "Invalid retry max_backoff", parseError.Error())
+		}
+		retryPolicy.MaxBackoff = maxBackoff
+	}
+
+	if !retryModel.Jitter.IsNull() {
+		if retryModel.Jitter.ValueFloat64() < 0 || retryModel.Jitter.ValueFloat64() > 1 {
+			diags.AddAttributeError(retryPath.AtName("jitter"), "Invalid retry jitter", "jitter must be between 0 and 1")
+		}
+		retryPolicy.Jitter = retryModel.Jitter.ValueFloat64()
+	}
+
+	return diags
+}
+
+func (vmIdRangeModel *proxmoxProviderVmIdRangeModel) applyTo(vmIdRange *proxmox_client.VmIdRange) diag.Diagnostics {
+	var diags diag.Diagnostics
+	rangePath := path.Root("vm_id_range")
+
+	vmIdRange.First = int(vmIdRangeModel.First.ValueInt64())
+	vmIdRange.Last = int(vmIdRangeModel.Last.ValueInt64())
+
+	if !vmIdRangeModel.First.IsNull() && vmIdRange.First < 100 {
+		diags.AddAttributeError(rangePath.AtName("first"), "Invalid vm_id_range first", "proxmox only accepts vm ids from 100")
+	}
+	if !vmIdRangeModel.Last.IsNull() && vmIdRange.Last > 999999999 {
+		diags.AddAttributeError(rangePath.AtName("last"), "Invalid vm_id_range last", "proxmox only accepts vm ids up to 999999999")
+	}
+	if !vmIdRangeModel.First.IsNull() && !vmIdRangeModel.Last.IsNull() && vmIdRange.First > vmIdRange.Last {
+		diags.AddAttributeError(rangePath, "Invalid vm_id_range", "first must not be greater than last")
+	}
+
+	return diags
+}
+
+func (sshModel *proxmoxProviderSshModel) applyTo(sshOptions *proxmox_client.SshOptions) diag.Diagnostics {
+	var diags diag.Diagnostics
+	sshPath := path.Root("ssh")
+
+	sshOptions.Username = sshModel.Username.ValueString()
+	sshOptions.Password = sshModel.Password.ValueString()
+	sshOptions.PrivateKey = sshModel.PrivateKey.ValueString()
+	sshOptions.Port = int(sshModel.Port.ValueInt64())
+	sshOptions.HostKeySha256 = sshModel.HostKeySha256.ValueString()
+	sshOptions.KnownHostsFile = sshModel.KnownHostsFile.ValueString()
+
+	if sshOptions.Password == "" && sshOptions.PrivateKey == "" {
+		diags.AddAttributeError(sshPath, "Invalid ssh", "either password or private_key has to be set")
+	}
+	if !sshModel.Port.IsNull() && (sshOptions.Port < 1 || sshOptions.Port > 65535) {
+		diags.AddAttributeError(sshPath.AtName("port"), "Invalid ssh port", "port must be between 1 and 65535")
+	}
+
+	return diags
+}
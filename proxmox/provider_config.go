@@ -0,0 +1,185 @@
+package proxmox
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const defaultProfileName = "default"
+
+// providerProfile holds the connection settings for a single named cluster within a profiles file
+type providerProfile struct {
+	Host           string   `yaml:"host"`
+	Hosts          []string `yaml:"hosts"`
+	Username       string   `yaml:"username"`
+	Password       string   `yaml:"password"`
+	ApiTokenId     string   `yaml:"api_token_id"`
+	ApiTokenSecret string   `yaml:"api_token_secret"`
+	TotpSecret     string   `yaml:"totp_secret"`
+	VerifyTls      *bool    `yaml:"verify_tls"`
+	Insecure       *bool    `yaml:"insecure"`
+	CaCertFile     string   `yaml:"ca_cert_file"`
+	TlsFingerprint string   `yaml:"tls_fingerprint_sha256"`
+}
+
+// loadProviderProfile
+/**
+ * @description reads the named profile from a profiles file. Files ending in .yaml or .yml are read as a yaml map of
+ * profile names to settings, everything else is read as an ini file with one section per profile.
+ * @param configFile: path to the profiles file, a leading ~ is expanded to the current user's home directory
+ * @param profileName: the profile to load, the default profile is used when empty
+ */
+func loadProviderProfile(configFile string, profileName string) (*providerProfile, error) {
+	if profileName == "" {
+		profileName = defaultProfileName
+	}
+
+	if strings.HasPrefix(configFile, "~/") {
+		homeDir, homeDirError := os.UserHomeDir()
+		if homeDirError != nil {
+			return nil, homeDirError
+		}
+		configFile = filepath.Join(homeDir, configFile[2:])
+	}
+
+	content, readError := os.ReadFile(configFile)
+	if readError != nil {
+		return nil, readError
+	}
+
+	var profiles map[string]providerProfile
+	var parseError error
+	switch strings.ToLower(filepath.Ext(configFile)) {
+	case ".yaml", ".yml":
+		profiles, parseError = parseYamlProfiles(content)
+	default:
+		profiles, parseError = parseIniProfiles(content)
+	}
+
+	if parseError != nil {
+		return nil, errors.New(fmt.Sprintf("failed to parse %s: %s", configFile, parseError.Error()))
+	}
+
+	profile, exists := profiles[profileName]
+	if !exists {
+		return nil, errors.New(fmt.Sprintf("profile %s does not exist in %s", profileName, configFile))
+	}
+
+	return &profile, nil
+}
+
+func parseYamlProfiles(content []byte) (map[string]providerProfile, error) {
+	profiles := make(map[string]providerProfile)
+	unmarshallingError := yaml.Unmarshal(content, &profiles)
+	if unmarshallingError != nil {
+		return nil, unmarshallingError
+	}
+	return profiles, nil
+}
+
+func parseIniProfiles(content []byte) (map[string]providerProfile, error) {
+	profiles := make(map[string]providerProfile)
+	currentSection := ""
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			currentSection = strings.TrimSpace(line[1 : len(line)-1])
+			profiles[currentSection] = providerProfile{}
+			continue
+		}
+
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			return nil, errors.New(fmt.Sprintf("line %d is not a key = value pair", lineNumber))
+		}
+		if currentSection == "" {
+			return nil, errors.New(fmt.Sprintf("line %d is not within a profile section", lineNumber))
+		}
+
+		key = strings.TrimSpace(key)
+		value = strings.Trim(strings.TrimSpace(value), "\"")
+		profile := profiles[currentSection]
+		switch key {
+		case "host":
+			profile.Host = value
+		case "hosts":
+			profile.Hosts = splitHosts(value)
+		case "username":
+			profile.Username = value
+		case "password":
+			profile.Password = value
+		case "api_token_id":
+			profile.ApiTokenId = value
+		case "api_token_secret":
+			profile.ApiTokenSecret = value
+		case "totp_secret":
+			profile.TotpSecret = value
+		case "ca_cert_file":
+			profile.CaCertFile = value
+		case "tls_fingerprint_sha256":
+			profile.TlsFingerprint = value
+		case "verify_tls", "insecure":
+			boolValue, parseError := strconv.ParseBool(value)
+			if parseError != nil {
+				return nil, errors.New(fmt.Sprintf("line %d: %s must be true or false", lineNumber, key))
+			}
+			if key == "verify_tls" {
+				profile.VerifyTls = &boolValue
+			} else {
+				profile.Insecure = &boolValue
+			}
+		default:
+			return nil, errors.New(fmt.Sprintf("line %d: unknown setting %s", lineNumber, key))
+		}
+		profiles[currentSection] = profile
+	}
+
+	return profiles, scanner.Err()
+}
+
+// verifyTlsSetting returns nil when the profile does not say anything about tls verification
+func (profile *providerProfile) verifyTlsSetting() *bool {
+	if profile.VerifyTls != nil {
+		return profile.VerifyTls
+	}
+	if profile.Insecure != nil {
+		verifyTls := !*profile.Insecure
+		return &verifyTls
+	}
+	return nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// splitHosts splits a comma separated list of hosts as used in ini profiles and PROXMOX_HOSTS
+func splitHosts(value string) []string {
+	var hosts []string
+	for _, host := range strings.Split(value, ",") {
+		if strings.TrimSpace(host) != "" {
+			hosts = append(hosts, strings.TrimSpace(host))
+		}
+	}
+	return hosts
+}
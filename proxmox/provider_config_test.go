@@ -0,0 +1,58 @@
+package proxmox
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadProviderProfileFromIni(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "proxmox.ini")
+	_ = os.WriteFile(configFile, []byte(`
+# lab cluster
+[default]
+host = 10.0.0.2:8006
+username = root@pam
+
+[staging]
+host = "pve-staging.example.com"
+api_token_id = terraform@pve!ci
+api_token_secret = 00000000-0000-0000-0000-000000000000
+insecure = true
+`), 0600)
+
+	profile, loadError := loadProviderProfile(configFile, "staging")
+
+	assert.NoError(t, loadError)
+	assert.Equal(t, "pve-staging.example.com", profile.Host)
+	assert.Equal(t, "terraform@pve!ci", profile.ApiTokenId)
+	assert.False(t, *profile.verifyTlsSetting())
+
+	defaultProfile, loadError := loadProviderProfile(configFile, "")
+
+	assert.NoError(t, loadError)
+	assert.Equal(t, "root@pam", defaultProfile.Username)
+	assert.Nil(t, defaultProfile.verifyTlsSetting())
+}
+
+func TestLoadProviderProfileFromYaml(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "proxmox.yaml")
+	_ = os.WriteFile(configFile, []byte(`
+production:
+  host: pve-01.example.com
+  username: root@pam
+  password: hunter2
+  verify_tls: true
+`), 0600)
+
+	profile, loadError := loadProviderProfile(configFile, "production")
+
+	assert.NoError(t, loadError)
+	assert.Equal(t, "hunter2", profile.Password)
+	assert.True(t, *profile.verifyTlsSetting())
+
+	_, loadError = loadProviderProfile(configFile, "missing")
+	assert.Error(t, loadError)
+}
@@ -0,0 +1,12 @@
+package proxmox
+
+import (
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+)
+
+// testAccProtoV6ProviderFactories serves the provider in process, acceptance tests point it at a fake_proxmox server
+// through the provider block of their config so they run without a cluster
+var testAccProtoV6ProviderFactories = map[string]func() (tfprotov6.ProviderServer, error){
+	"proxmox": providerserver.NewProtocol6WithError(New()),
+}
@@ -39,7 +39,7 @@ func (d *qemuImageDatasource) Read(ctx context.Context, request datasource.ReadR
 		return
 	}
 
-	images, listImagesError := d.storageService.ListImages(plan.StorageName.ValueStringPointer(), plan.Name.ValueStringPointer())
+	images, listImagesError := d.storageService.ListImages(ctx, plan.StorageName.ValueStringPointer(), plan.Name.ValueStringPointer())
 
 	if listImagesError != nil {
 		response.Diagnostics.AddError(fmt.Sprintf("Failed to list of images for name %s in storage %s", plan.Name.ValueString(), plan.StorageName.ValueString()), listImagesError.Error())
@@ -52,12 +52,12 @@ func (d *qemuImageDatasource) Read(ctx context.Context, request datasource.ReadR
 	response.Diagnostics.Append(diags...)
 }
 
-func (d *qemuImageDatasource) Configure(ctx context.Context, req datasource.ConfigureRequest, _ *datasource.ConfigureResponse) {
+func (d *qemuImageDatasource) Configure(_ context.Context, req datasource.ConfigureRequest, _ *datasource.ConfigureResponse) {
 	if req.ProviderData == nil {
 		return
 	}
 	client := req.ProviderData.(proxmox_client.ProxmoxClient)
-	d.storageService = services.NewNodeStorageService(client, ctx)
+	d.storageService = services.NewNodeStorageService(client)
 }
 
 func (d *qemuImageDatasource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
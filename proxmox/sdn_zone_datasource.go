@@ -37,7 +37,7 @@ func (d *sdnZoneDatasource) Read(ctx context.Context, request datasource.ReadReq
 		return
 	}
 
-	zoneResponse, getZoneError := d.client.GetSdnZone(plan.Zone.ValueString())
+	zoneResponse, getZoneError := d.client.GetSdnZone(ctx, plan.Zone.ValueString())
 
 	if getZoneError != nil {
 		response.Diagnostics.AddError(fmt.Sprintf("Failed to retrieve zone %s", plan.Zone.ValueString()), getZoneError.Error())
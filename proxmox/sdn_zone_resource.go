@@ -5,8 +5,9 @@ import (
 	"fmt"
 	"net/url"
 	"terraform-provider-proxmox/proxmox_client"
-	proxmoxTypes "terraform-provider-proxmox/types"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
@@ -26,11 +27,19 @@ func NewSdnZoneResource() resource.Resource {
 	return &sdnZoneResource{}
 }
 
+// defaultSdnZoneTimeout applies to every operation on a zone unless a timeouts block says otherwise
+const defaultSdnZoneTimeout = 5 * time.Minute
+
 // sdnZoneResource is the resource implementation.
 type sdnZoneResource struct {
 	client proxmox_client.ProxmoxClient
 }
 
+type sdnZoneResourceModel struct {
+	sdnZone
+	Timeouts timeouts.Value `tfsdk:"timeouts"`
+}
+
 // Configure adds the provider configured client to the resource.
 func (r *sdnZoneResource) Configure(_ context.Context, req resource.ConfigureRequest, _ *resource.ConfigureResponse) {
 	if req.ProviderData == nil {
@@ -46,8 +55,16 @@ func (r *sdnZoneResource) Metadata(_ context.Context, req resource.MetadataReque
 }
 
 // Schema defines the schema for the resource.
-func (r *sdnZoneResource) Schema(_ context.Context, _ resource.SchemaRequest, response *resource.SchemaResponse) {
+func (r *sdnZoneResource) Schema(ctx context.Context, _ resource.SchemaRequest, response *resource.SchemaResponse) {
 	response.Schema = schema.Schema{
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Update: true,
+				Delete: true,
+			}),
+		},
 		Attributes: map[string]schema.Attribute{
 			"type": schema.StringAttribute{Required: true},
 			"zone": schema.StringAttribute{
@@ -76,31 +93,40 @@ func (r *sdnZoneResource) Schema(_ context.Context, _ resource.SchemaRequest, re
 // Create creates the resource and sets the initial Terraform state.
 func (r *sdnZoneResource) Create(ctx context.Context, request resource.CreateRequest, response *resource.CreateResponse) {
 
-	var plan sdnZone
+	var plan sdnZoneResourceModel
 	diags := request.Plan.Get(ctx, &plan)
 	response.Diagnostics.Append(diags...)
 	if response.Diagnostics.HasError() {
 		return
 	}
 
+	createTimeout, diags := plan.Timeouts.Create(ctx, defaultSdnZoneTimeout)
+	response.Diagnostics.Append(diags...)
+	if response.Diagnostics.HasError() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, createTimeout)
+	defer cancel()
+
 	params := url.Values{}
 
-	assembleCreateSdnZoneRequest(&params, plan, ctx)
+	assembleCreateSdnZoneRequest(&params, plan.sdnZone, ctx)
 
-	createZoneError := r.client.CreateSdnZone(params)
+	createZoneError := r.client.CreateSdnZone(ctx, params)
 
 	if createZoneError != nil {
-		response.Diagnostics.AddError(fmt.Sprintf("Failed to create SDN %s Zone", plan.Type.ValueString()), createZoneError.Error())
+		addApiErrorDiagnostics(&response.Diagnostics, fmt.Sprintf("Failed to create SDN %s Zone", plan.Type.ValueString()), createZoneError, sdnZoneAttributePath)
+		return
 	}
 
-	zoneResponse, getZoneError := r.client.GetSdnZone(plan.Zone.ValueString())
+	zoneResponse, getZoneError := r.client.GetSdnZone(ctx, plan.Zone.ValueString())
 
 	if getZoneError != nil {
 		response.Diagnostics.AddError(fmt.Sprintf("Failed to retrieve zone %s", plan.Zone.ValueString()), getZoneError.Error())
 		return
 	}
 
-	updateSdnZoneFromResponse(&plan, ctx, *zoneResponse)
+	updateSdnZoneFromResponse(&plan.sdnZone, ctx, *zoneResponse)
 
 	diags = response.State.Set(ctx, plan)
 	response.Diagnostics.Append(diags...)
@@ -109,7 +135,7 @@ func (r *sdnZoneResource) Create(ctx context.Context, request resource.CreateReq
 // Read refreshes the Terraform state with the latest data.
 func (r *sdnZoneResource) Read(ctx context.Context, request resource.ReadRequest, response *resource.ReadResponse) {
 
-	var plan sdnZone
+	var plan sdnZoneResourceModel
 
 	diags := request.State.Get(ctx, &plan)
 	response.Diagnostics.Append(diags...)
@@ -117,14 +143,27 @@ func (r *sdnZoneResource) Read(ctx context.Context, request resource.ReadRequest
 		return
 	}
 
-	zoneResponse, getZoneError := r.client.GetSdnZone(plan.Zone.ValueString())
+	readTimeout, diags := plan.Timeouts.Read(ctx, defaultSdnZoneTimeout)
+	response.Diagnostics.Append(diags...)
+	if response.Diagnostics.HasError() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, readTimeout)
+	defer cancel()
+
+	zoneResponse, getZoneError := r.client.GetSdnZone(ctx, plan.Zone.ValueString())
+
+	if proxmox_client.IsNotFound(getZoneError) {
+		response.State.RemoveResource(ctx)
+		return
+	}
 
 	if getZoneError != nil {
 		response.Diagnostics.AddError(fmt.Sprintf("Failed to retrieve zone %s", plan.Zone.ValueString()), getZoneError.Error())
 		return
 	}
 
-	updateSdnZoneFromResponse(&plan, ctx, *zoneResponse)
+	updateSdnZoneFromResponse(&plan.sdnZone, ctx, *zoneResponse)
 
 	diags = response.State.Set(ctx, &plan)
 	response.Diagnostics.Append(diags...)
@@ -136,33 +175,42 @@ func (r *sdnZoneResource) Read(ctx context.Context, request resource.ReadRequest
 
 // Update updates the resource and sets the updated Terraform state on success.
 func (r *sdnZoneResource) Update(ctx context.Context, request resource.UpdateRequest, response *resource.UpdateResponse) {
-	var plan *sdnZone
+	var plan sdnZoneResourceModel
 	diags := request.Plan.Get(ctx, &plan)
 	response.Diagnostics.Append(diags...)
 	if response.Diagnostics.HasError() {
 		return
 	}
 
+	updateTimeout, diags := plan.Timeouts.Update(ctx, defaultSdnZoneTimeout)
+	response.Diagnostics.Append(diags...)
+	if response.Diagnostics.HasError() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, updateTimeout)
+	defer cancel()
+
 	params := url.Values{}
 
-	assembleCreateSdnZoneRequest(&params, *plan, ctx)
+	assembleCreateSdnZoneRequest(&params, plan.sdnZone, ctx)
 
 	params.Del("type")
 
-	createZoneError := r.client.UpdateSdnZone(params)
+	createZoneError := r.client.UpdateSdnZone(ctx, params)
 
 	if createZoneError != nil {
-		response.Diagnostics.AddError(fmt.Sprintf("Failed to update SDN %s Zone", plan.Type.ValueString()), createZoneError.Error())
+		addApiErrorDiagnostics(&response.Diagnostics, fmt.Sprintf("Failed to update SDN %s Zone", plan.Type.ValueString()), createZoneError, sdnZoneAttributePath)
+		return
 	}
 
-	zoneResponse, getZoneError := r.client.GetSdnZone(plan.Zone.ValueString())
+	zoneResponse, getZoneError := r.client.GetSdnZone(ctx, plan.Zone.ValueString())
 
 	if getZoneError != nil {
 		response.Diagnostics.AddError(fmt.Sprintf("Failed to retrieve zone %s", plan.Zone.ValueString()), getZoneError.Error())
 		return
 	}
 
-	updateSdnZoneFromResponse(plan, ctx, *zoneResponse)
+	updateSdnZoneFromResponse(&plan.sdnZone, ctx, *zoneResponse)
 
 	diags = response.State.Set(ctx, &plan)
 	response.Diagnostics.Append(diags...)
@@ -171,11 +219,22 @@ func (r *sdnZoneResource) Update(ctx context.Context, request resource.UpdateReq
 // Delete deletes the resource and removes the Terraform state on success.
 func (r *sdnZoneResource) Delete(ctx context.Context, request resource.DeleteRequest, response *resource.DeleteResponse) {
 
-	var plan sdnZone
+	var plan sdnZoneResourceModel
 	diags := request.State.Get(ctx, &plan)
 	response.Diagnostics.Append(diags...)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	deleteTimeout, diags := plan.Timeouts.Delete(ctx, defaultSdnZoneTimeout)
+	response.Diagnostics.Append(diags...)
+	if response.Diagnostics.HasError() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, deleteTimeout)
+	defer cancel()
 
-	deleteZoneError := r.client.DeleteSdnZone(plan.Zone.ValueString())
+	deleteZoneError := r.client.DeleteSdnZone(ctx, plan.Zone.ValueString())
 
 	if deleteZoneError != nil {
 		response.Diagnostics.AddError(fmt.Sprintf("Failed to delete SDN Zone %s", plan.Zone.ValueString()), deleteZoneError.Error())
@@ -191,9 +250,6 @@ func (r *sdnZoneResource) Delete(ctx context.Context, request resource.DeleteReq
 }
 
 func (r *sdnZoneResource) ImportState(ctx context.Context, request resource.ImportStateRequest, response *resource.ImportStateResponse) {
-	// Retrieve import ID and save to id attribute
-	var plan proxmoxTypes.VmModel
-
-	response.State.Set(ctx, plan)
-	resource.ImportStatePassthroughID(ctx, path.Root("vm_id"), request, response)
+	// the zone name is the import id, read fills in the rest
+	resource.ImportStatePassthroughID(ctx, path.Root("zone"), request, response)
 }
@@ -0,0 +1,63 @@
+package proxmox
+
+import (
+	"fmt"
+	"strings"
+	"terraform-provider-proxmox/fake_proxmox"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+)
+
+func testAccSdnZoneConfig(server *fake_proxmox.Server, peers ...string) string {
+	return server.ProviderConfig() + fmt.Sprintf(`
+resource "proxmox_sdn_zone" "test" {
+  zone  = "fakezone"
+  type  = "vxlan"
+  nodes = ["pve"]
+  peers = ["%s"]
+}
+`, strings.Join(peers, `", "`))
+}
+
+func TestAccSdnZoneResource(t *testing.T) {
+	server := fake_proxmox.NewServer()
+	defer server.Close()
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy: func(_ *terraform.State) error {
+			if _, found := server.SdnZone("fakezone"); found {
+				return fmt.Errorf("zone fakezone still exists")
+			}
+			return nil
+		},
+		Steps: []resource.TestStep{
+			{
+				Config: testAccSdnZoneConfig(server, "10.0.0.1", "10.0.0.2"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("proxmox_sdn_zone.test", "ipam", "pve"),
+					resource.TestCheckResourceAttr("proxmox_sdn_zone.test", "peers.#", "2"),
+				),
+			},
+			{
+				Config: testAccSdnZoneConfig(server, "10.0.0.1", "10.0.0.2", "10.0.0.3"),
+				Check: func(_ *terraform.State) error {
+					zone, _ := server.SdnZone("fakezone")
+					if zone["peers"] != "10.0.0.1,10.0.0.2,10.0.0.3" {
+						return fmt.Errorf("expected the new peer to be sent to proxmox, got %q", zone["peers"])
+					}
+					return nil
+				},
+			},
+			{
+				ResourceName:                         "proxmox_sdn_zone.test",
+				ImportState:                          true,
+				ImportStateId:                        "fakezone",
+				ImportStateVerify:                    true,
+				ImportStateVerifyIdentifierAttribute: "zone",
+			},
+		},
+	})
+}
@@ -5,6 +5,9 @@ import (
 	"fmt"
 	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"net/netip"
+	"regexp"
+	"slices"
 	"strings"
 )
 
@@ -40,3 +43,170 @@ func (validator sshKeyListValidator) ValidateList(ctx context.Context, request v
 	}
 
 }
+
+// stringOneOfValidator rejects values that are not in allowedValues
+type stringOneOfValidator struct {
+	allowedValues []string
+}
+
+func (validator stringOneOfValidator) Description(ctx context.Context) string {
+	return fmt.Sprintf("value must be one of %s", strings.Join(validator.allowedValues, ", "))
+}
+
+func (validator stringOneOfValidator) MarkdownDescription(ctx context.Context) string {
+	return fmt.Sprintf("value must be one of `%s`", strings.Join(validator.allowedValues, "`, `"))
+}
+
+func (validator stringOneOfValidator) ValidateString(ctx context.Context, request validator.StringRequest, response *validator.StringResponse) {
+	if request.ConfigValue.IsUnknown() || request.ConfigValue.IsNull() {
+		return
+	}
+
+	for _, allowedValue := range validator.allowedValues {
+		if request.ConfigValue.ValueString() == allowedValue {
+			return
+		}
+	}
+	response.Diagnostics.AddAttributeError(
+		request.Path,
+		"Invalid Value",
+		fmt.Sprintf("%s is not supported, %s", request.ConfigValue.ValueString(), validator.Description(ctx)),
+	)
+}
+
+// stringSetOneOfValidator rejects sets with elements that are not in allowedValues
+type stringSetOneOfValidator struct {
+	allowedValues []string
+}
+
+func (validator stringSetOneOfValidator) Description(ctx context.Context) string {
+	return fmt.Sprintf("values must be one of %s", strings.Join(validator.allowedValues, ", "))
+}
+
+func (validator stringSetOneOfValidator) MarkdownDescription(ctx context.Context) string {
+	return fmt.Sprintf("values must be one of `%s`", strings.Join(validator.allowedValues, "`, `"))
+}
+
+func (validator stringSetOneOfValidator) ValidateSet(ctx context.Context, request validator.SetRequest, response *validator.SetResponse) {
+	if request.ConfigValue.IsUnknown() || request.ConfigValue.IsNull() {
+		return
+	}
+
+	values := make([]types.String, 0, len(request.ConfigValue.Elements()))
+	_ = request.ConfigValue.ElementsAs(ctx, &values, false)
+
+	for _, value := range values {
+		if value.IsUnknown() || slices.Contains(validator.allowedValues, value.ValueString()) {
+			continue
+		}
+		response.Diagnostics.AddAttributeError(
+			request.Path,
+			"Invalid Value",
+			fmt.Sprintf("%s is not supported, %s", value.ValueString(), validator.Description(ctx)),
+		)
+	}
+}
+
+// snippetVolumeValidator rejects references that don't point at the snippets of a storage
+type snippetVolumeValidator struct{}
+
+func (validator snippetVolumeValidator) Description(ctx context.Context) string {
+	return "value must reference a snippet as storage:snippets/file"
+}
+
+func (validator snippetVolumeValidator) MarkdownDescription(ctx context.Context) string {
+	return "value must reference a snippet as `storage:snippets/file`"
+}
+
+func (validator snippetVolumeValidator) ValidateString(ctx context.Context, request validator.StringRequest, response *validator.StringResponse) {
+	if request.ConfigValue.IsUnknown() || request.ConfigValue.IsNull() {
+		return
+	}
+
+	storage, file, isVolume := strings.Cut(request.ConfigValue.ValueString(), ":")
+	if !isVolume || storage == "" || !strings.HasPrefix(file, "snippets/") || file == "snippets/" || strings.ContainsAny(file, ",=") {
+		response.Diagnostics.AddAttributeError(
+			request.Path,
+			"Invalid Snippet",
+			fmt.Sprintf("%s is not a snippet, %s", request.ConfigValue.ValueString(), validator.Description(ctx)),
+		)
+	}
+}
+
+// snippetFileNameValidator rejects file names proxmox would alter or that can't be referenced by cicustom
+type snippetFileNameValidator struct{}
+
+var snippetFileNamePattern = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9._-]*$`)
+
+func (validator snippetFileNameValidator) Description(ctx context.Context) string {
+	return "value must be a file name of letters, digits, dots, dashes and underscores that does not start with a dot or dash"
+}
+
+func (validator snippetFileNameValidator) MarkdownDescription(ctx context.Context) string {
+	return validator.Description(ctx)
+}
+
+func (validator snippetFileNameValidator) ValidateString(ctx context.Context, request validator.StringRequest, response *validator.StringResponse) {
+	if request.ConfigValue.IsUnknown() || request.ConfigValue.IsNull() {
+		return
+	}
+
+	if !snippetFileNamePattern.MatchString(request.ConfigValue.ValueString()) {
+		response.Diagnostics.AddAttributeError(
+			request.Path,
+			"Invalid Snippet File Name",
+			fmt.Sprintf("%s is not supported, %s", request.ConfigValue.ValueString(), validator.Description(ctx)),
+		)
+	}
+}
+
+// ipConfigValidator rejects addresses and gateways proxmox doesn't take for the ip family of the ip config
+type ipConfigValidator struct {
+	ipv6    bool
+	gateway bool
+}
+
+func (validator ipConfigValidator) Description(ctx context.Context) string {
+	if validator.gateway && validator.ipv6 {
+		return "value must be an ipv6 address"
+	}
+	if validator.gateway {
+		return "value must be an ipv4 address"
+	}
+	if validator.ipv6 {
+		return "value must be an ipv6 address in cidr notation, dhcp or auto"
+	}
+	return "value must be an ipv4 address in cidr notation or dhcp"
+}
+
+func (validator ipConfigValidator) MarkdownDescription(ctx context.Context) string {
+	return validator.Description(ctx)
+}
+
+func (validator ipConfigValidator) ValidateString(ctx context.Context, request validator.StringRequest, response *validator.StringResponse) {
+	if request.ConfigValue.IsUnknown() || request.ConfigValue.IsNull() {
+		return
+	}
+
+	value := request.ConfigValue.ValueString()
+	var address netip.Addr
+	var parseError error
+	switch {
+	case !validator.gateway && (value == "dhcp" || (validator.ipv6 && value == "auto")):
+		return
+	case validator.gateway:
+		address, parseError = netip.ParseAddr(value)
+	default:
+		var prefix netip.Prefix
+		prefix, parseError = netip.ParsePrefix(value)
+		address = prefix.Addr()
+	}
+
+	if parseError != nil || address.Is6() != validator.ipv6 || address.Is4In6() {
+		response.Diagnostics.AddAttributeError(
+			request.Path,
+			"Invalid Value",
+			fmt.Sprintf("%s is not supported, %s", value, validator.Description(ctx)),
+		)
+	}
+}
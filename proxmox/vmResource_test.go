@@ -1,8 +1,779 @@
 package proxmox
 
-import "testing"
-import "github.com/stretchr/testify/assert"
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"terraform-provider-proxmox/fake_proxmox"
+	"terraform-provider-proxmox/proxmox_client"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+	"github.com/hashicorp/terraform-plugin-testing/tfversion"
+	"github.com/stretchr/testify/assert"
+)
 
 func TestNothing(t *testing.T) {
 	assert.True(t, true)
 }
+
+// testAccVm is the proxmox_vm named test rendered by testAccVmConfig
+type testAccVm struct {
+	// attributes hold hcl expressions by name, e.g. "memory": "4096" or "power_state": `"running"`
+	attributes map[string]string
+	// blocks is hcl appended to the body of the resource, every disk and network interface is one
+	blocks []string
+}
+
+// testAccVmConfig returns the provider config and a stopped vm with an 8G disk and a network interface, the modifiers
+// change only what a test needs
+func testAccVmConfig(server *fake_proxmox.Server, vmId int, modifiers ...func(vm *testAccVm)) string {
+	vm := testAccVm{
+		attributes: map[string]string{
+			"name":        `"fake-vm"`,
+			"vm_id":       fmt.Sprintf(`"%d"`, vmId),
+			"node_name":   `"pve"`,
+			"cores":       "2",
+			"memory":      "2048",
+			"os_type":     `"l26"`,
+			"cpu_type":    `"host"`,
+			"nameserver":  `"1.1.1.1"`,
+			"boot_order":  `["scsi0"]`,
+			"power_state": `"stopped"`,
+		},
+		blocks: []string{testAccVmDisk(0, "8G"), testAccVmNetworkInterface(vmId, 0)},
+	}
+	for _, modifier := range modifiers {
+		modifier(&vm)
+	}
+
+	var body strings.Builder
+	for _, name := range slices.Sorted(maps.Keys(vm.attributes)) {
+		body.WriteString(fmt.Sprintf("  %s = %s\n", name, vm.attributes[name]))
+	}
+	for _, block := range vm.blocks {
+		body.WriteString("\n" + strings.Trim(block, "\n") + "\n")
+	}
+	return server.ProviderConfig() + fmt.Sprintf("\nresource \"proxmox_vm\" \"test\" {\n%s}\n", body.String())
+}
+
+// testAccVmDisk returns a disk block on local-zfs, options are further attributes of the disk
+func testAccVmDisk(order int, size string, options ...string) string {
+	var extra string
+	for _, option := range options {
+		extra += fmt.Sprintf("    %s\n", option)
+	}
+	return fmt.Sprintf(`  disk {
+    storage_location = "local-zfs"
+    size             = %q
+    order            = %d
+%s  }`, size, order, extra)
+}
+
+// testAccVmNetworkInterface returns a network interface block with a mac address made from the vm id and the order
+func testAccVmNetworkInterface(vmId int, order int) string {
+	return fmt.Sprintf(`  network_interface {
+    mac_address = "BC:24:11:00:%02d:%02d"
+    bridge      = "vmbr0"
+    order       = %d
+  }`, (vmId+order)/100, (vmId+order)%100, order)
+}
+
+func TestAccVmResource(t *testing.T) {
+	server := fake_proxmox.NewServer()
+	defer server.Close()
+
+	config := func(memory int, powerState string) string {
+		return testAccVmConfig(server, 100, func(vm *testAccVm) {
+			vm.attributes["memory"] = strconv.Itoa(memory)
+			vm.attributes["power_state"] = strconv.Quote(powerState)
+			vm.attributes["ssh_keys"] = `["ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIFakeKeyForTheFakeServer"]`
+		})
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy: func(_ *terraform.State) error {
+			if _, _, found := server.Vm(100); found {
+				return fmt.Errorf("vm 100 still exists")
+			}
+			return nil
+		},
+		Steps: []resource.TestStep{
+			{
+				Config: config(2048, "stopped"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("proxmox_vm.test", "disk.0.size", "8G"),
+					resource.TestCheckResourceAttr("proxmox_vm.test", "disk.0.id", "0"),
+					resource.TestCheckResourceAttr("proxmox_vm.test", "power_state", "stopped"),
+					resource.TestCheckResourceAttrSet("proxmox_vm.test", "vmgenid"),
+				),
+			},
+			{
+				Config: config(4096, "running"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("proxmox_vm.test", "memory", "4096"),
+					resource.TestCheckResourceAttr("proxmox_vm.test", "power_state", "running"),
+					func(_ *terraform.State) error {
+						config, _, _ := server.Vm(100)
+						if config["memory"] != "4096" || server.VmStatus(100) != "running" {
+							return fmt.Errorf("expected the running vm to have 4096 memory, got %s and %s", config["memory"], server.VmStatus(100))
+						}
+						return nil
+					},
+				),
+			},
+		},
+	})
+}
+
+func TestAccVmResourceFromClone(t *testing.T) {
+	server := fake_proxmox.NewServer()
+	defer server.Close()
+	templateError := server.AddVm("pve", 9000, map[string]string{
+		"name":     "ubuntu-template",
+		"template": "1",
+		"memory":   "1024",
+		"scsi0":    "local-zfs:8,iothread=1",
+		"ide2":     "local-zfs:cloudinit,media=cdrom",
+		"net0":     "virtio,bridge=vmbr0",
+	})
+	if templateError != nil {
+		t.Fatal(templateError)
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy: func(_ *terraform.State) error {
+			if _, _, found := server.Vm(120); found {
+				return fmt.Errorf("vm 120 still exists")
+			}
+			if _, _, found := server.Vm(9000); !found {
+				return fmt.Errorf("the template must outlive its clone")
+			}
+			return nil
+		},
+		Steps: []resource.TestStep{
+			{
+				Config: testAccVmConfig(server, 120, func(vm *testAccVm) {
+					vm.attributes["name"] = `"cloned-vm"`
+					delete(vm.attributes, "power_state")
+					vm.blocks = []string{`  clone {
+    source_name = "ubuntu-template"
+    full_clone  = true
+  }`, testAccVmDisk(0, "16G"), testAccVmDisk(1, "4G"), testAccVmNetworkInterface(120, 0)}
+				}),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("proxmox_vm.test", "disk.0.size", "16G"),
+					resource.TestCheckResourceAttr("proxmox_vm.test", "disk.1.size", "4G"),
+					resource.TestCheckResourceAttr("proxmox_vm.test", "memory", "2048"),
+					func(_ *terraform.State) error {
+						config, _, _ := server.Vm(120)
+						if config["scsi0"] != "local-zfs:vm-120-disk-0,iothread=1,size=16G" || config["ide2"] != "local-zfs:vm-120-cloudinit,media=cdrom" {
+							return fmt.Errorf("expected the disks of the template to be copied and grown, got %q and %q", config["scsi0"], config["ide2"])
+						}
+						if config["name"] != "cloned-vm" || config["net0"] != "virtio=BC:24:11:00:01:20,bridge=vmbr0,firewall=1,mtu=0" || config["template"] != "" {
+							return fmt.Errorf("expected the config of the plan to be applied to the clone, got %v", config)
+						}
+						return nil
+					},
+				),
+			},
+		},
+	})
+}
+
+func TestAccVmResourceFromCloneRemovesNicsOfTheSource(t *testing.T) {
+	server := fake_proxmox.NewServer()
+	defer server.Close()
+	templateError := server.AddVm("pve", 9000, map[string]string{
+		"name":     "ubuntu-template",
+		"template": "1",
+		"scsi0":    "local-zfs:8,iothread=1",
+		"net0":     "virtio,bridge=vmbr0",
+	})
+	if templateError != nil {
+		t.Fatal(templateError)
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccVmConfig(server, 121, func(vm *testAccVm) {
+					vm.blocks = []string{`  clone {
+    source_name = "ubuntu-template"
+  }`, testAccVmDisk(0, "8G"), testAccVmNetworkInterface(121, 1)}
+				}),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("proxmox_vm.test", "network_interface.#", "1"),
+					resource.TestCheckResourceAttr("proxmox_vm.test", "network_interface.0.order", "1"),
+					func(_ *terraform.State) error {
+						config, _, _ := server.Vm(121)
+						if _, hasNet0 := config["net0"]; hasNet0 || config["net1"] == "" {
+							return fmt.Errorf("expected the nic of the template to be replaced by the planned one, got net0 %q and net1 %q", config["net0"], config["net1"])
+						}
+						return nil
+					},
+				),
+			},
+		},
+	})
+}
+
+func TestAccVmResourceWithoutVmId(t *testing.T) {
+	server := fake_proxmox.NewServer()
+	defer server.Close()
+	if addVmError := server.AddVm("pve", 2000, map[string]string{"name": "another-team"}); addVmError != nil {
+		t.Fatal(addVmError)
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: server.ProviderConfig(`
+  vm_id_range {
+    first = 2000
+    last  = 2099
+  }`) + `
+resource "proxmox_vm" "test" {
+  name        = "no-id-vm"
+  node_name   = "pve"
+  cores       = 2
+  memory      = 2048
+  os_type     = "l26"
+  cpu_type    = "host"
+  nameserver  = "1.1.1.1"
+  boot_order  = ["scsi0"]
+
+  disk {
+    storage_location = "local-zfs"
+    size             = "8G"
+    order            = 0
+  }
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("proxmox_vm.test", "vm_id", "2001"),
+					func(_ *terraform.State) error {
+						if config, _, found := server.Vm(2001); !found || config["name"] != "no-id-vm" {
+							return fmt.Errorf("expected the vm to be created with the first free id of the range")
+						}
+						return nil
+					},
+				),
+			},
+		},
+	})
+}
+
+func TestAccVmResourceLiveDiskChanges(t *testing.T) {
+	server := fake_proxmox.NewServer()
+	defer server.Close()
+
+	config := func(size string, cache string, allowReboot bool) string {
+		return testAccVmConfig(server, 110, func(vm *testAccVm) {
+			vm.attributes["power_state"] = `"running"`
+			vm.attributes["allow_reboot_for_changes"] = strconv.FormatBool(allowReboot)
+			vm.blocks[0] = testAccVmDisk(0, size, fmt.Sprintf("cache = %q", cache))
+		})
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: config("8G", "default", false),
+				Check:  resource.TestCheckResourceAttr("proxmox_vm.test", "power_state", "running"),
+			},
+			{
+				PreConfig: func() {
+					server.SetTaskOutcome("qmshutdown", "the vm must not be shut down to grow a disk")
+					server.SetTaskOutcome("qmstop", "the vm must not be stopped to grow a disk")
+				},
+				Config: config("16G", "default", false),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("proxmox_vm.test", "disk.0.size", "16G"),
+					resource.TestCheckResourceAttr("proxmox_vm.test", "power_state", "running"),
+				),
+			},
+			{
+				Config:      config("16G", "writeback", false),
+				ExpectError: regexp.MustCompile(`changing the options of scsi0 can't be applied live`),
+			},
+			{
+				PreConfig: func() {
+					server.SetTaskOutcome("qmshutdown", "")
+					server.SetTaskOutcome("qmstop", "")
+				},
+				Config: config("16G", "writeback", true),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("proxmox_vm.test", "disk.0.cache", "writeback"),
+					resource.TestCheckResourceAttr("proxmox_vm.test", "power_state", "running"),
+					func(_ *terraform.State) error {
+						config, _, _ := server.Vm(110)
+						if !strings.Contains(config["scsi0"], "cache=writeback") || server.VmStatus(110) != "running" {
+							return fmt.Errorf("expected the restarted vm to use writeback, got %s and %s", config["scsi0"], server.VmStatus(110))
+						}
+						return nil
+					},
+				),
+			},
+		},
+	})
+}
+
+func TestAccVmResourcePendingChanges(t *testing.T) {
+	server := fake_proxmox.NewServer()
+	defer server.Close()
+
+	config := func(memory int, applyPending bool) string {
+		return testAccVmConfig(server, 120, func(vm *testAccVm) {
+			vm.attributes["memory"] = strconv.Itoa(memory)
+			vm.attributes["power_state"] = `"running"`
+			vm.attributes["apply_pending_with_reboot"] = strconv.FormatBool(applyPending)
+		})
+	}
+
+	liveMemory := func(expected string) resource.TestCheckFunc {
+		return func(_ *terraform.State) error {
+			config, _, _ := server.Vm(120)
+			if config["memory"] != expected {
+				return fmt.Errorf("expected the running vm to use %s memory, got %s", expected, config["memory"])
+			}
+			return nil
+		}
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: config(2048, false),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("proxmox_vm.test", "reboot_required", "false"),
+					resource.TestCheckResourceAttr("proxmox_vm.test", "pending_changes.%", "0"),
+				),
+			},
+			{
+				Config: config(4096, false),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("proxmox_vm.test", "memory", "4096"),
+					resource.TestCheckResourceAttr("proxmox_vm.test", "reboot_required", "true"),
+					resource.TestCheckResourceAttr("proxmox_vm.test", "pending_changes.memory", "4096"),
+					liveMemory("2048"),
+				),
+			},
+			{
+				Config: config(4096, true),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("proxmox_vm.test", "reboot_required", "false"),
+					resource.TestCheckResourceAttr("proxmox_vm.test", "pending_changes.%", "0"),
+					resource.TestCheckResourceAttr("proxmox_vm.test", "power_state", "running"),
+					liveMemory("4096"),
+				),
+			},
+			{
+				PreConfig: func() {
+					// a change made outside of terraform to an option the resource doesn't manage
+					client, clientError := proxmox_client.NewClient([]string{server.URL}, &proxmox_client.AuthStruct{TokenId: fake_proxmox.TokenId, TokenSecret: fake_proxmox.TokenSecret}, &proxmox_client.TlsOptions{VerifyTls: false}, nil, nil, nil, nil)
+					if clientError != nil {
+						t.Fatal(clientError)
+					}
+					nodeName, vmId := "pve", "120"
+					if _, updateError := client.UpdateVm(context.Background(), url.Values{"machine": {"q35"}}, &nodeName, &vmId); updateError != nil {
+						t.Fatal(updateError)
+					}
+				},
+				Config: config(4096, true),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("proxmox_vm.test", "reboot_required", "false"),
+					func(_ *terraform.State) error {
+						if config, _, _ := server.Vm(120); config["machine"] != "q35" {
+							return fmt.Errorf("expected the reboot to apply the pending machine type, got %q", config["machine"])
+						}
+						return nil
+					},
+				),
+			},
+			{
+				PreConfig: func() {
+					// a change that is still pending after the reboot is reported instead of failing the apply
+					server.KeepPendingOnReboot("machine")
+					client, clientError := proxmox_client.NewClient([]string{server.URL}, &proxmox_client.AuthStruct{TokenId: fake_proxmox.TokenId, TokenSecret: fake_proxmox.TokenSecret}, &proxmox_client.TlsOptions{VerifyTls: false}, nil, nil, nil, nil)
+					if clientError != nil {
+						t.Fatal(clientError)
+					}
+					nodeName, vmId := "pve", "120"
+					if _, updateError := client.UpdateVm(context.Background(), url.Values{"machine": {"pc"}}, &nodeName, &vmId); updateError != nil {
+						t.Fatal(updateError)
+					}
+				},
+				Config: config(4096, true),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("proxmox_vm.test", "reboot_required", "true"),
+					resource.TestCheckResourceAttr("proxmox_vm.test", "pending_changes.machine", "pc"),
+				),
+				// the next plan tries to reboot again
+				ExpectNonEmptyPlan: true,
+			},
+		},
+	})
+}
+
+func TestAccVmResourceHotplug(t *testing.T) {
+	server := fake_proxmox.NewServer()
+	defer server.Close()
+
+	config := func(memory int, numa bool, extraDisk bool) string {
+		return testAccVmConfig(server, 130, func(vm *testAccVm) {
+			vm.attributes["memory"] = strconv.Itoa(memory)
+			vm.attributes["power_state"] = `"running"`
+			vm.attributes["numa_active"] = strconv.FormatBool(numa)
+			vm.attributes["hotplug"] = `["disk", "memory", "network", "usb"]`
+			if extraDisk {
+				vm.blocks = append(vm.blocks, testAccVmDisk(1, "4G"))
+			}
+		})
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config:      config(2048, false, false),
+				ExpectError: regexp.MustCompile(`Memory hotplug requires NUMA`),
+			},
+			{
+				Config: config(2048, true, false),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("proxmox_vm.test", "hotplug.#", "4"),
+					resource.TestCheckTypeSetElemAttr("proxmox_vm.test", "hotplug.*", "memory"),
+					resource.TestCheckResourceAttr("proxmox_vm.test", "power_state", "running"),
+				),
+			},
+			{
+				PreConfig: func() {
+					server.SetTaskOutcome("qmshutdown", "the vm must not be shut down for hot pluggable changes")
+					server.SetTaskOutcome("qmreboot", "the vm must not be rebooted for hot pluggable changes")
+				},
+				Config: config(4096, true, true),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("proxmox_vm.test", "reboot_required", "false"),
+					resource.TestCheckResourceAttr("proxmox_vm.test", "disk.#", "2"),
+					func(_ *terraform.State) error {
+						config, _, _ := server.Vm(130)
+						if config["memory"] != "4096" || config["scsi1"] == "" || server.VmStatus(130) != "running" {
+							return fmt.Errorf("expected the memory and the disk to be hot plugged, got %s and %q", config["memory"], config["scsi1"])
+						}
+						return nil
+					},
+				),
+			},
+			{
+				PreConfig: func() {
+					server.SetTaskOutcome("qmshutdown", "")
+					server.SetTaskOutcome("qmreboot", "")
+				},
+				Config:   config(4096, true, true),
+				PlanOnly: true,
+			},
+		},
+	})
+}
+
+func TestAccVmResourceWithoutHotplug(t *testing.T) {
+	server := fake_proxmox.NewServer()
+	defer server.Close()
+
+	config := func(hotplug string) string {
+		return testAccVmConfig(server, 131, func(vm *testAccVm) {
+			if hotplug != "" {
+				vm.attributes["hotplug"] = hotplug
+			}
+		})
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: config(""),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("proxmox_vm.test", "hotplug.#", "2"),
+					func(_ *terraform.State) error {
+						if config, _, _ := server.Vm(131); config["hotplug"] != "network,usb" {
+							return fmt.Errorf("expected new vms to hot plug network and usb devices, got %q", config["hotplug"])
+						}
+						return nil
+					},
+				),
+			},
+			{
+				Config: config(`["disk", "network", "usb"]`),
+				Check:  resource.TestCheckResourceAttr("proxmox_vm.test", "hotplug.#", "3"),
+			},
+			{
+				Config:   config(""),
+				PlanOnly: true,
+			},
+		},
+	})
+}
+
+func TestAccVmResourceCloudInitDrive(t *testing.T) {
+	server := fake_proxmox.NewServer()
+	defer server.Close()
+
+	config := func(cloudInit string) string {
+		return testAccVmConfig(server, 140, func(vm *testAccVm) {
+			vm.blocks = append(vm.blocks, "  cloud_init {\n"+cloudInit+"\n  }")
+		})
+	}
+
+	cloudInitDrive := func(key string, storage string) resource.TestCheckFunc {
+		return func(_ *terraform.State) error {
+			config, _, _ := server.Vm(140)
+			for configKey, value := range config {
+				if strings.Contains(value, "cloudinit") && (configKey != key || !strings.HasPrefix(value, storage+":")) {
+					return fmt.Errorf("expected the cloud-init drive on %s in %s, found %s = %s", key, storage, configKey, value)
+				}
+			}
+			if key != "" && !strings.Contains(config[key], "cloudinit") {
+				return fmt.Errorf("expected a cloud-init drive on %s, got %q", key, config[key])
+			}
+			return nil
+		}
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config:      config(`    bus  = "scsi"` + "\n" + `    slot = 0`),
+				ExpectError: regexp.MustCompile(`scsi0 is already used by a disk`),
+			},
+			{
+				Config: config(`    storage = "local"`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("proxmox_vm.test", "cloud_init.enabled", "true"),
+					resource.TestCheckResourceAttr("proxmox_vm.test", "cloud_init.bus", "ide"),
+					resource.TestCheckResourceAttr("proxmox_vm.test", "cloud_init.slot", "2"),
+					cloudInitDrive("ide2", "local"),
+				),
+			},
+			{
+				Config: config(`    bus = "sata"`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("proxmox_vm.test", "cloud_init.storage", "local"),
+					resource.TestCheckResourceAttr("proxmox_vm.test", "cloud_init.slot", "0"),
+					cloudInitDrive("sata0", "local"),
+				),
+			},
+			{
+				Config: config(`    enabled = false`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("proxmox_vm.test", "cloud_init.enabled", "false"),
+					cloudInitDrive("", ""),
+				),
+			},
+			{
+				Config:   config(`    enabled = false`),
+				PlanOnly: true,
+			},
+		},
+	})
+}
+
+func TestAccVmResourceCloudInitSettings(t *testing.T) {
+	server := fake_proxmox.NewServer()
+	defer server.Close()
+
+	config := func(settings string) string {
+		return testAccVmConfig(server, 150, func(vm *testAccVm) {
+			vm.attributes["os_type"] = `"win11"`
+			vm.attributes["treat_warnings_as_errors"] = "true"
+			vm.blocks = append(vm.blocks, settings)
+		})
+	}
+
+	vmConfig := func(expected map[string]string) resource.TestCheckFunc {
+		return func(_ *terraform.State) error {
+			config, _, _ := server.Vm(150)
+			for key, value := range expected {
+				if config[key] != value {
+					return fmt.Errorf("expected %s to be %q, got %q", key, value, config[key])
+				}
+			}
+			return nil
+		}
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		TerraformVersionChecks:   []tfversion.TerraformVersionCheck{tfversion.SkipBelow(tfversion.Version1_11_0)},
+		Steps: []resource.TestStep{
+			{
+				Config: config(`
+  cloud_init_type     = "configdrive2"
+  cloud_init_custom {
+    vendor = "local-zfs:snippets/vendor.yaml"
+  }
+`),
+				ExpectError: regexp.MustCompile(`storage 'local-zfs' does not support snippets`),
+			},
+			{
+				Config: config(`
+  search_domain               = "example.com"
+  cloud_init_type             = "configdrive2"
+  cloud_init_password         = "first-password"
+  cloud_init_password_version = 1
+  cloud_init_custom {
+    vendor = "local:snippets/vendor.yaml"
+  }
+`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("proxmox_vm.test", "search_domain", "example.com"),
+					resource.TestCheckResourceAttr("proxmox_vm.test", "cloud_init_custom.vendor", "local:snippets/vendor.yaml"),
+					resource.TestCheckNoResourceAttr("proxmox_vm.test", "cloud_init_custom.user"),
+					resource.TestCheckNoResourceAttr("proxmox_vm.test", "cloud_init_password"),
+					vmConfig(map[string]string{
+						"searchdomain": "example.com",
+						"citype":       "configdrive2",
+						"cicustom":     "vendor=local:snippets/vendor.yaml",
+						"cipassword":   "first-password",
+					}),
+				),
+			},
+			{
+				Config: config(`
+  cloud_init_password         = "second-password"
+  cloud_init_password_version = 1
+`),
+				Check: vmConfig(map[string]string{"searchdomain": "", "citype": "", "cicustom": "", "cipassword": "first-password"}),
+			},
+			{
+				Config: config(`
+  cloud_init_password         = "second-password"
+  cloud_init_password_version = 2
+`),
+				Check: vmConfig(map[string]string{"cipassword": "second-password"}),
+			},
+			{
+				Config: config(`
+  cloud_init_password         = "second-password"
+  cloud_init_password_version = 2
+`),
+				PlanOnly: true,
+			},
+			{
+				Config: config(`
+  cloud_init_password_version = 3
+`),
+				Check: vmConfig(map[string]string{"cipassword": ""}),
+			},
+			{
+				// removing a password the vm no longer has must not make proxmox warn
+				Config: config(`
+  cloud_init_password_version = 4
+`),
+				Check: vmConfig(map[string]string{"cipassword": ""}),
+			},
+		},
+	})
+}
+
+func TestAccVmResourceIpConfig(t *testing.T) {
+	server := fake_proxmox.NewServer()
+	defer server.Close()
+
+	config := func(ipConfigs string) string {
+		return testAccVmConfig(server, 160, func(vm *testAccVm) {
+			vm.blocks = append(vm.blocks, testAccVmNetworkInterface(160, 1), ipConfigs)
+		})
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: config(`
+  ip_config {
+    ip_address = "dhcp"
+    gateway    = "10.0.0.1"
+    order      = 0
+  }`),
+				ExpectError: regexp.MustCompile(`gateway only applies to a static ip_address`),
+			},
+			{
+				Config: config(`
+  ip_config {
+    ipv6_address = "10.0.0.5/24"
+    order        = 0
+  }`),
+				ExpectError: regexp.MustCompile(`value must be an ipv6 address in cidr notation`),
+			},
+			{
+				Config: config(`
+  ip_config {
+    ip_address = "dhcp"
+    order      = 2
+  }`),
+				ExpectError: regexp.MustCompile(`there is no network_interface with order 2`),
+			},
+			{
+				Config: config(`
+  ip_config {
+    ip_address   = "10.0.0.5/24"
+    gateway      = "10.0.0.1"
+    ipv6_address = "2001:db8::5/64"
+    ipv6_gateway = "2001:db8::1"
+    order        = 1
+  }
+
+  ip_config {
+    ip_address = "dhcp"
+    order      = 0
+  }`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("proxmox_vm.test", "ip_config.0.ipv6_gateway", "2001:db8::1"),
+					resource.TestCheckResourceAttr("proxmox_vm.test", "ip_config.1.ip_address", "dhcp"),
+					resource.TestCheckNoResourceAttr("proxmox_vm.test", "ip_config.1.gateway"),
+					func(_ *terraform.State) error {
+						config, _, _ := server.Vm(160)
+						if config["ipconfig0"] != "ip=dhcp" || config["ipconfig1"] != "gw=10.0.0.1,gw6=2001:db8::1,ip=10.0.0.5/24,ip6=2001:db8::5/64" {
+							return fmt.Errorf("unexpected ip configs %q and %q", config["ipconfig0"], config["ipconfig1"])
+						}
+						return nil
+					},
+				),
+			},
+			{
+				Config: config(`
+  ip_config {
+    ipv6_address = "auto"
+    order        = 1
+  }`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("proxmox_vm.test", "ip_config.#", "1"),
+					resource.TestCheckNoResourceAttr("proxmox_vm.test", "ip_config.0.ip_address"),
+					resource.TestCheckResourceAttr("proxmox_vm.test", "ip_config.0.ipv6_address", "auto"),
+					func(_ *terraform.State) error {
+						config, _, _ := server.Vm(160)
+						if _, found := config["ipconfig0"]; found || config["ipconfig1"] != "ip6=auto" {
+							return fmt.Errorf("expected only the ip config of net1, got %q and %q", config["ipconfig0"], config["ipconfig1"])
+						}
+						return nil
+					},
+				),
+			},
+		},
+	})
+}
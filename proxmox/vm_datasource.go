@@ -41,7 +41,7 @@ func (d *qemuDataSource) Read(ctx context.Context, request datasource.ReadReques
 
 	tflog.Debug(ctx, fmt.Sprintf("Node name is %s", plan.NodeName.ValueString()))
 
-	qemuResponse, nodeName, getVmError := d.vmService.GetVm(plan.NodeName.ValueStringPointer(), plan.VmId.ValueStringPointer())
+	qemuResponse, nodeName, getVmError := d.vmService.GetVm(ctx, plan.NodeName.ValueStringPointer(), plan.VmId.ValueStringPointer())
 
 	if getVmError != nil {
 		response.Diagnostics.AddError("Failed to find requested vm", getVmError.Error())
@@ -51,7 +51,8 @@ func (d *qemuDataSource) Read(ctx context.Context, request datasource.ReadReques
 	currentState.NodeName = types.StringValue(*nodeName)
 
 	d.vmService.UpdateVmModelFromResponse(&currentState, &plan, qemuResponse)
-	updatePowerStateError := d.vmService.UpdatePowerState(&currentState)
+	currentState.CloudInit = d.vmService.MapCloudInitFromQemuResponse(qemuResponse.Data.OtherFields, &proxmoxTypes.VmCloudInit{})
+	updatePowerStateError := d.vmService.UpdatePowerState(ctx, &currentState)
 
 	if updatePowerStateError != nil {
 		response.Diagnostics.AddError("Failed to update VM power state.", updatePowerStateError.Error())
@@ -80,6 +81,22 @@ func (d *qemuDataSource) Configure(ctx context.Context, req datasource.Configure
 func (d *qemuDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
 	resp.Schema = schema.Schema{
 		Blocks: map[string]schema.Block{
+			"cloud_init": schema.SingleNestedBlock{
+				Attributes: map[string]schema.Attribute{
+					"enabled": schema.BoolAttribute{Computed: true},
+					"storage": schema.StringAttribute{Computed: true},
+					"bus":     schema.StringAttribute{Computed: true},
+					"slot":    schema.Int64Attribute{Computed: true},
+				},
+			},
+			"cloud_init_custom": schema.SingleNestedBlock{
+				Attributes: map[string]schema.Attribute{
+					"user":    schema.StringAttribute{Computed: true},
+					"network": schema.StringAttribute{Computed: true},
+					"vendor":  schema.StringAttribute{Computed: true},
+					"meta":    schema.StringAttribute{Computed: true},
+				},
+			},
 			"ip_config": schema.ListNestedBlock{
 				NestedObject: schema.NestedBlockObject{
 					Attributes: map[string]schema.Attribute{
@@ -89,6 +106,12 @@ func (d *qemuDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, r
 						"gateway": schema.StringAttribute{
 							Computed: true,
 						},
+						"ipv6_address": schema.StringAttribute{
+							Computed: true,
+						},
+						"ipv6_gateway": schema.StringAttribute{
+							Computed: true,
+						},
 						"order": schema.Int64Attribute{
 							Computed: true,
 						},
@@ -229,6 +252,10 @@ func (d *qemuDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, r
 			"host_startup_order": schema.Int64Attribute{
 				Computed: true,
 			},
+			"hotplug": schema.SetAttribute{
+				Computed:    true,
+				ElementType: types.StringType,
+			},
 			"ssh_keys": schema.ListAttribute{
 				Computed:    true,
 				ElementType: types.StringType,
@@ -236,7 +263,9 @@ func (d *qemuDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, r
 			"protection": schema.BoolAttribute{
 				Computed: true,
 			},
-			"default_user": schema.StringAttribute{Computed: true},
+			"default_user":    schema.StringAttribute{Computed: true},
+			"search_domain":   schema.StringAttribute{Computed: true},
+			"cloud_init_type": schema.StringAttribute{Computed: true},
 			"cloud_init_storage_name": schema.StringAttribute{
 				Computed: true,
 			},
@@ -2,17 +2,29 @@ package proxmox
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
+	"strings"
 	"terraform-provider-proxmox/proxmox_client"
 	"terraform-provider-proxmox/services"
 	"terraform-provider-proxmox/services/vm"
 	proxmoxTypes "terraform-provider-proxmox/types"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64default"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/objectplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/setplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
@@ -21,21 +33,47 @@ import (
 )
 
 var (
-	_ resource.Resource                = &vmResource{}
-	_ resource.ResourceWithConfigure   = &vmResource{}
-	_ resource.ResourceWithImportState = &vmResource{}
+	_ resource.Resource                   = &vmResource{}
+	_ resource.ResourceWithConfigure      = &vmResource{}
+	_ resource.ResourceWithImportState    = &vmResource{}
+	_ resource.ResourceWithValidateConfig = &vmResource{}
+	_ resource.ResourceWithModifyPlan     = &vmResource{}
 )
 
 func NewVmResource() resource.Resource {
 	return &vmResource{}
 }
 
+// hotplugOptions are the device types proxmox can hot plug
+var hotplugOptions = []string{"cloudinit", "cpu", "disk", "memory", "network", "usb"}
+
+const (
+	defaultVmCreateTimeout = 30 * time.Minute
+	defaultVmReadTimeout   = 5 * time.Minute
+	defaultVmUpdateTimeout = 30 * time.Minute
+	defaultVmDeleteTimeout = 10 * time.Minute
+)
+
 // vmResource is the resource implementation.
 type vmResource struct {
 	vmService   vm.VmService
 	diskService vm.DiskService
 }
 
+// vmResourceModel adds the settings that only exist on the resource to the model shared with the vm datasource
+type vmResourceModel struct {
+	proxmoxTypes.VmModel
+	TreatWarningsAsErrors    types.Bool            `tfsdk:"treat_warnings_as_errors"`
+	AllowRebootForChanges    types.Bool            `tfsdk:"allow_reboot_for_changes"`
+	ApplyPendingWithReboot   types.Bool            `tfsdk:"apply_pending_with_reboot"`
+	PendingChanges           types.Map             `tfsdk:"pending_changes"`
+	RebootRequired           types.Bool            `tfsdk:"reboot_required"`
+	CloudInitPassword        types.String          `tfsdk:"cloud_init_password"`
+	CloudInitPasswordVersion types.Int64           `tfsdk:"cloud_init_password_version"`
+	Clone                    *proxmoxTypes.VmClone `tfsdk:"clone"`
+	Timeouts                 timeouts.Value        `tfsdk:"timeouts"`
+}
+
 // Configure adds the provider configured client to the resource.
 func (r *vmResource) Configure(ctx context.Context, req resource.ConfigureRequest, _ *resource.ConfigureResponse) {
 	if req.ProviderData == nil {
@@ -54,20 +92,138 @@ func (r *vmResource) Metadata(_ context.Context, req resource.MetadataRequest, r
 }
 
 // Schema defines the schema for the resource.
-func (r *vmResource) Schema(_ context.Context, _ resource.SchemaRequest, response *resource.SchemaResponse) {
+func (r *vmResource) Schema(ctx context.Context, _ resource.SchemaRequest, response *resource.SchemaResponse) {
 	response.Schema = schema.Schema{
 		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Update: true,
+				Delete: true,
+			}),
+			"clone": schema.SingleNestedBlock{
+				Description: "creates the vm as a clone of an existing vm or template instead of from scratch. Disks, network interfaces and the rest of the config are then changed to match this resource, disks of the source that are not listed are removed from the clone",
+				PlanModifiers: []planmodifier.Object{
+					objectplanmodifier.RequiresReplace(),
+				},
+				Attributes: map[string]schema.Attribute{
+					"source_vm_id": schema.StringAttribute{
+						Optional:    true,
+						Description: "id of the vm or template to clone, either this or source_name is required",
+					},
+					"source_name": schema.StringAttribute{
+						Optional:    true,
+						Description: "name of the vm or template to clone, the name has to be unique within the cluster or on source_node",
+					},
+					"source_node": schema.StringAttribute{
+						Optional:    true,
+						Description: "node the source is on, searched across the cluster when not set",
+					},
+					"full_clone": schema.BoolAttribute{
+						Optional:    true,
+						Description: "copy every disk of the source instead of creating a linked clone, defaults to true. Linked clones can only be created from templates",
+					},
+					"target_storage": schema.StringAttribute{
+						Optional:    true,
+						Description: "storage the disks of a full clone are copied to, defaults to the storage of each source disk",
+					},
+					"pool": schema.StringAttribute{
+						Optional:    true,
+						Description: "resource pool the clone is added to",
+					},
+					"snapshot_name": schema.StringAttribute{
+						Optional:    true,
+						Description: "clone the source as of this snapshot instead of its current state",
+					},
+				},
+			},
+			"cloud_init": schema.SingleNestedBlock{
+				Description: "the cloud-init drive of the vm. Without this block the drive goes to the first free scsi slot on cloud_init_storage_name",
+				Attributes: map[string]schema.Attribute{
+					"enabled": schema.BoolAttribute{
+						Optional:    true,
+						Computed:    true,
+						Default:     booldefault.StaticBool(true),
+						Description: "whether the vm has a cloud-init drive, removes the drive when set to false",
+					},
+					"storage": schema.StringAttribute{
+						Optional:    true,
+						Computed:    true,
+						Description: "storage the drive is created on, defaults to cloud_init_storage_name",
+						PlanModifiers: []planmodifier.String{
+							stringplanmodifier.UseStateForUnknown(),
+						},
+					},
+					"bus": schema.StringAttribute{
+						Optional:    true,
+						Computed:    true,
+						Default:     stringdefault.StaticString("ide"),
+						Description: "bus the drive is attached to, one of ide, sata or scsi",
+						Validators: []validator.String{
+							stringOneOfValidator{allowedValues: []string{"ide", "sata", "scsi"}},
+						},
+					},
+					"slot": schema.Int64Attribute{
+						Optional:    true,
+						Computed:    true,
+						Description: "slot of the bus the drive is attached to, defaults to the first slot no disk uses and to 2 on ide",
+						PlanModifiers: []planmodifier.Int64{
+							int64planmodifier.UseStateForUnknown(),
+						},
+					},
+				},
+			},
+			"cloud_init_custom": schema.SingleNestedBlock{
+				Description: "snippets that replace the cloud-init data proxmox generates, each takes the form storage:snippets/file and has to be on a storage with the snippets content type",
+				Attributes: map[string]schema.Attribute{
+					"user": schema.StringAttribute{
+						Optional:    true,
+						Description: "user data, replaces default_user, ssh_keys and cloud_init_password",
+						Validators:  []validator.String{snippetVolumeValidator{}},
+					},
+					"network": schema.StringAttribute{
+						Optional:    true,
+						Description: "network config, replaces ip_config, nameserver and search_domain",
+						Validators:  []validator.String{snippetVolumeValidator{}},
+					},
+					"vendor": schema.StringAttribute{
+						Optional:    true,
+						Description: "vendor data, e.g. packages to install",
+						Validators:  []validator.String{snippetVolumeValidator{}},
+					},
+					"meta": schema.StringAttribute{
+						Optional:    true,
+						Description: "meta data",
+						Validators:  []validator.String{snippetVolumeValidator{}},
+					},
+				},
+			},
 			"ip_config": schema.ListNestedBlock{
 				NestedObject: schema.NestedBlockObject{
 					Attributes: map[string]schema.Attribute{
 						"ip_address": schema.StringAttribute{
-							Required: true,
+							Optional:    true,
+							Description: "ipv4 address in cidr notation or dhcp, at least one of ip_address and ipv6_address is required",
+							Validators:  []validator.String{ipConfigValidator{}},
 						},
 						"gateway": schema.StringAttribute{
-							Required: true,
+							Optional:    true,
+							Description: "ipv4 gateway of a static ip_address",
+							Validators:  []validator.String{ipConfigValidator{gateway: true}},
+						},
+						"ipv6_address": schema.StringAttribute{
+							Optional:    true,
+							Description: "ipv6 address in cidr notation, dhcp or auto for slaac",
+							Validators:  []validator.String{ipConfigValidator{ipv6: true}},
+						},
+						"ipv6_gateway": schema.StringAttribute{
+							Optional:    true,
+							Description: "ipv6 gateway of a static ipv6_address",
+							Validators:  []validator.String{ipConfigValidator{ipv6: true, gateway: true}},
 						},
 						"order": schema.Int64Attribute{
-							Required: true,
+							Required:    true,
+							Description: "order of the network_interface the ip config applies to",
 						},
 					},
 				},
@@ -93,6 +249,9 @@ func (r *vmResource) Schema(_ context.Context, _ resource.SchemaRequest, respons
 							Optional: true,
 							Computed: true,
 							Default:  stringdefault.StaticString("scsi"),
+							Validators: []validator.String{
+								stringOneOfValidator{allowedValues: []string{"scsi", "virtio", "sata"}},
+							},
 						},
 						"storage_location": schema.StringAttribute{
 							Required: true,
@@ -213,7 +372,12 @@ func (r *vmResource) Schema(_ context.Context, _ resource.SchemaRequest, respons
 				Required: true,
 			},
 			"vm_id": schema.StringAttribute{
-				Required: true,
+				Optional:    true,
+				Computed:    true,
+				Description: "id of the vm, the next free id within the vm_id_range of the provider is used when not set",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
 			},
 			"start_on_boot": schema.BoolAttribute{
 				Optional: true,
@@ -283,6 +447,19 @@ func (r *vmResource) Schema(_ context.Context, _ resource.SchemaRequest, respons
 				Computed: true,
 				Default:  int64default.StaticInt64(0),
 			},
+			"hotplug": schema.SetAttribute{
+				Optional:    true,
+				Computed:    true,
+				ElementType: types.StringType,
+				// new vms hot plug network and usb devices, existing vms keep what they have when the options are not set
+				PlanModifiers: []planmodifier.Set{
+					setplanmodifier.UseStateForUnknown(),
+				},
+				Validators: []validator.Set{
+					stringSetOneOfValidator{allowedValues: hotplugOptions},
+				},
+				Description: "devices that are added, removed or changed while the vm runs, changes to the others wait for the next restart. Memory hotplug requires numa_active. New vms hot plug network and usb devices when not set, existing vms keep their options",
+			},
 			"ssh_keys": schema.ListAttribute{
 				Optional:    true,
 				ElementType: types.StringType,
@@ -300,46 +477,336 @@ func (r *vmResource) Schema(_ context.Context, _ resource.SchemaRequest, respons
 				Computed: true,
 				Default:  stringdefault.StaticString(""),
 			},
+			"search_domain": schema.StringAttribute{
+				Optional:    true,
+				Computed:    true,
+				Default:     stringdefault.StaticString(""),
+				Description: "dns search domain handed to the vm by cloud-init",
+			},
+			"cloud_init_type": schema.StringAttribute{
+				Optional:    true,
+				Description: "format of the cloud-init data, proxmox picks configdrive2 for windows and nocloud otherwise when not set",
+				Validators: []validator.String{
+					stringOneOfValidator{allowedValues: proxmoxTypes.CloudInitTypes},
+				},
+			},
+			"cloud_init_password": schema.StringAttribute{
+				Optional:    true,
+				Sensitive:   true,
+				WriteOnly:   true,
+				Description: "password of the cloud-init user, it is never stored in the state and only sent when the vm is created or cloud_init_password_version changes",
+			},
+			"cloud_init_password_version": schema.Int64Attribute{
+				Optional:    true,
+				Description: "change this to send cloud_init_password to an existing vm, removing the password from the config and changing this removes it from the vm",
+			},
 			"cloud_init_storage_name": schema.StringAttribute{
-				Optional: true,
-				Computed: true,
-				Default:  stringdefault.StaticString("local-zfs"),
+				Optional:    true,
+				Computed:    true,
+				Default:     stringdefault.StaticString("local-zfs"),
+				Description: "storage of the cloud-init drive unless the cloud_init block names one",
 			},
 			"power_state": schema.StringAttribute{
 				Optional: true,
 				Computed: true,
 				Default:  stringdefault.StaticString("stopped"),
 			},
+			"treat_warnings_as_errors": schema.BoolAttribute{
+				Optional:    true,
+				Computed:    true,
+				Default:     booldefault.StaticBool(false),
+				Description: "fail the operation when a proxmox task completes with warnings instead of reporting them as warnings",
+			},
+			"allow_reboot_for_changes": schema.BoolAttribute{
+				Optional:    true,
+				Computed:    true,
+				Default:     booldefault.StaticBool(false),
+				Description: "allow the provider to shut a running vm down for changes it can't apply live, the plan fails on such changes otherwise",
+			},
+			"apply_pending_with_reboot": schema.BoolAttribute{
+				Optional:    true,
+				Computed:    true,
+				Default:     booldefault.StaticBool(false),
+				Description: "reboot a running vm after an update when proxmox queued some of the changes, e.g. memory or cpu, until the next restart",
+			},
+			"pending_changes": schema.MapAttribute{
+				Computed:    true,
+				ElementType: types.StringType,
+				Description: "the options proxmox applies on the next restart of the vm with their new values, an empty value removes the option",
+			},
+			"reboot_required": schema.BoolAttribute{
+				Computed:    true,
+				Description: "whether the vm runs with a config that differs from the one terraform manages until it is restarted",
+			},
 		},
 	}
 }
 
+// ValidateConfig checks the combinations of settings proxmox would only reject once the apply is under way
+func (r *vmResource) ValidateConfig(ctx context.Context, request resource.ValidateConfigRequest, response *resource.ValidateConfigResponse) {
+	var hotplug types.Set
+	var numa types.Bool
+	response.Diagnostics.Append(request.Config.GetAttribute(ctx, path.Root("hotplug"), &hotplug)...)
+	response.Diagnostics.Append(request.Config.GetAttribute(ctx, path.Root("numa_active"), &numa)...)
+	if response.Diagnostics.HasError() {
+		return
+	}
+	if !numa.IsUnknown() && !numa.ValueBool() && slices.Contains(hotplug.Elements(), attr.Value(types.StringValue("memory"))) {
+		response.Diagnostics.AddAttributeError(path.Root("hotplug"), "Memory hotplug requires NUMA", "proxmox refuses to start a vm with memory hotplug unless numa_active is set")
+	}
+
+	var cloudInit *proxmoxTypes.VmCloudInit
+	var disks []proxmoxTypes.VmDisk
+	response.Diagnostics.Append(request.Config.GetAttribute(ctx, path.Root("cloud_init"), &cloudInit)...)
+	response.Diagnostics.Append(request.Config.GetAttribute(ctx, path.Root("disk"), &disks)...)
+	if response.Diagnostics.HasError() {
+		return
+	}
+	validateCloudInitSlot(cloudInit, disks, &response.Diagnostics)
+
+	var ipConfigs []proxmoxTypes.VmIpConfig
+	var networkInterfaces []proxmoxTypes.VmNetworkInterface
+	response.Diagnostics.Append(request.Config.GetAttribute(ctx, path.Root("ip_config"), &ipConfigs)...)
+	response.Diagnostics.Append(request.Config.GetAttribute(ctx, path.Root("network_interface"), &networkInterfaces)...)
+	if response.Diagnostics.HasError() {
+		return
+	}
+	validateIpConfigs(ipConfigs, networkInterfaces, &response.Diagnostics)
+
+	var clone *proxmoxTypes.VmClone
+	response.Diagnostics.Append(request.Config.GetAttribute(ctx, path.Root("clone"), &clone)...)
+	if response.Diagnostics.HasError() || clone == nil {
+		return
+	}
+
+	if clone.SourceVmId.IsUnknown() || clone.SourceName.IsUnknown() {
+		return
+	}
+	if clone.SourceVmId.IsNull() == clone.SourceName.IsNull() {
+		response.Diagnostics.AddAttributeError(path.Root("clone"), "Invalid clone source", "exactly one of source_vm_id or source_name has to be set")
+	}
+	if !clone.FullClone.IsNull() && !clone.FullClone.ValueBool() && !clone.TargetStorage.IsNull() {
+		response.Diagnostics.AddAttributeError(path.Root("clone").AtName("target_storage"), "Invalid clone storage", "linked clones stay on the storage of the template, target_storage requires full_clone")
+	}
+}
+
+// validateCloudInitSlot checks that the slot of the cloud-init drive exists on its bus and isn't taken by a disk
+func validateCloudInitSlot(cloudInit *proxmoxTypes.VmCloudInit, disks []proxmoxTypes.VmDisk, diagnostics *diag.Diagnostics) {
+	if cloudInit == nil || cloudInit.Slot.IsNull() || cloudInit.Slot.IsUnknown() || cloudInit.Bus.IsUnknown() {
+		return
+	}
+	bus := cloudInit.Bus.ValueString()
+	if bus == "" {
+		bus = "ide"
+	}
+	maxSlot, isKnownBus := proxmoxTypes.CloudInitBusSlots[bus]
+	if !isKnownBus {
+		return
+	}
+
+	slot := cloudInit.Slot.ValueInt64()
+	if slot < 0 || slot > maxSlot {
+		diagnostics.AddAttributeError(path.Root("cloud_init").AtName("slot"), "Invalid cloud-init slot", fmt.Sprintf("the %s bus has the slots 0 to %d", bus, maxSlot))
+		return
+	}
+	for _, disk := range disks {
+		diskBus := disk.BusType.ValueString()
+		if disk.BusType.IsNull() {
+			diskBus = "scsi"
+		}
+		if diskBus == bus && !disk.Order.IsUnknown() && disk.Order.ValueInt64() == slot {
+			diagnostics.AddAttributeError(path.Root("cloud_init").AtName("slot"), "Invalid cloud-init slot", fmt.Sprintf("%s%d is already used by a disk", bus, slot))
+			return
+		}
+	}
+}
+
+// validateIpConfigs checks that every ip config belongs to its own network interface, has an address and only sets
+// gateways for static addresses
+func validateIpConfigs(ipConfigs []proxmoxTypes.VmIpConfig, networkInterfaces []proxmoxTypes.VmNetworkInterface, diagnostics *diag.Diagnostics) {
+	nicOrders := map[int64]bool{}
+	nicOrdersKnown := true
+	for _, networkInterface := range networkInterfaces {
+		nicOrdersKnown = nicOrdersKnown && !networkInterface.Order.IsUnknown()
+		nicOrders[networkInterface.Order.ValueInt64()] = true
+	}
+
+	ipConfigOrders := map[int64]bool{}
+	for index, ipConfig := range ipConfigs {
+		ipConfigPath := path.Root("ip_config").AtListIndex(index)
+		if !ipConfig.Order.IsUnknown() {
+			order := ipConfig.Order.ValueInt64()
+			if ipConfigOrders[order] {
+				diagnostics.AddAttributeError(ipConfigPath.AtName("order"), "Invalid ip config", fmt.Sprintf("there is more than one ip config for the network_interface with order %d", order))
+			}
+			if nicOrdersKnown && !nicOrders[order] {
+				diagnostics.AddAttributeError(ipConfigPath.AtName("order"), "Invalid ip config", fmt.Sprintf("there is no network_interface with order %d, the order of an ip config selects the network_interface it applies to", order))
+			}
+			ipConfigOrders[order] = true
+		}
+		if ipConfig.IpAddress.IsNull() && ipConfig.Ipv6Address.IsNull() {
+			diagnostics.AddAttributeError(ipConfigPath, "Invalid ip config", "at least one of ip_address and ipv6_address has to be set")
+		}
+		if isDynamicIpConfigAddress(ipConfig.IpAddress) && !ipConfig.Gateway.IsNull() {
+			diagnostics.AddAttributeError(ipConfigPath.AtName("gateway"), "Invalid ip config", "gateway only applies to a static ip_address, dhcp provides its own")
+		}
+		if isDynamicIpConfigAddress(ipConfig.Ipv6Address) && !ipConfig.Ipv6Gateway.IsNull() {
+			diagnostics.AddAttributeError(ipConfigPath.AtName("ipv6_gateway"), "Invalid ip config", "ipv6_gateway only applies to a static ipv6_address, dhcp and auto provide their own")
+		}
+	}
+}
+
+// isDynamicIpConfigAddress reports whether the address is unset or assigned by the network, unknown addresses are not
+func isDynamicIpConfigAddress(address types.String) bool {
+	return address.IsNull() || address.ValueString() == "dhcp" || address.ValueString() == "auto"
+}
+
+// ModifyPlan plans a reboot for pending changes when that is allowed, picks a new slot for a cloud-init drive that
+// changes its bus and fails the plan when a running vm would have to be shut down for its disk changes and that isn't
+// allowed
+func (r *vmResource) ModifyPlan(ctx context.Context, request resource.ModifyPlanRequest, response *resource.ModifyPlanResponse) {
+	if request.State.Raw.IsNull() || request.Plan.Raw.IsNull() {
+		return
+	}
+
+	var state, plan vmResourceModel
+	response.Diagnostics.Append(request.State.Get(ctx, &state)...)
+	response.Diagnostics.Append(request.Plan.Get(ctx, &plan)...)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	// pending changes made outside of terraform leave the config unchanged, the update only runs because of this. Both
+	// stay unknown since proxmox may keep some changes pending after the reboot
+	if state.RebootRequired.ValueBool() && plan.ApplyPendingWithReboot.ValueBool() && plan.PowerState.ValueString() == "running" {
+		response.Diagnostics.Append(response.Plan.SetAttribute(ctx, path.Root("reboot_required"), types.BoolUnknown())...)
+		response.Diagnostics.Append(response.Plan.SetAttribute(ctx, path.Root("pending_changes"), types.MapUnknown(types.StringType))...)
+	}
+
+	// the slot kept from the state belongs to the old bus, a new one is picked unless the config sets it
+	if state.CloudInit != nil && plan.CloudInit != nil && state.CloudInit.Bus.ValueString() != plan.CloudInit.Bus.ValueString() {
+		var configSlot types.Int64
+		response.Diagnostics.Append(request.Config.GetAttribute(ctx, path.Root("cloud_init").AtName("slot"), &configSlot)...)
+		if configSlot.IsNull() {
+			response.Diagnostics.Append(response.Plan.SetAttribute(ctx, path.Root("cloud_init").AtName("slot"), types.Int64Unknown())...)
+		}
+	}
+
+	if r.diskService == nil {
+		return
+	}
+
+	if plan.AllowRebootForChanges.ValueBool() || state.PowerState.ValueString() != "running" || plan.PowerState.ValueString() != "running" {
+		return
+	}
+
+	diskChanges := r.diskService.CompareVmDisks(&state.VmModel, &plan.VmModel)
+	if len(diskChanges.ToBeAdded)+len(diskChanges.ToBeUpdated)+len(diskChanges.ToBeRemove) == 0 {
+		return
+	}
+
+	// the update checks again with the hotplug options the vm has by then
+	var hotplug []string
+	response.Diagnostics.Append(state.Hotplug.ElementsAs(ctx, &hotplug, false)...)
+	coldChanges := r.diskService.ColdDiskChanges(state.Disks, diskChanges, hotplug)
+	if len(coldChanges) > 0 {
+		response.Diagnostics.AddAttributeError(path.Root("disk"), "Disk changes require a shutdown", rebootRequiredDetail(state.VmId.ValueString(), coldChanges))
+	}
+}
+
+// rebootRequiredDetail explains which changes can't be applied to the running vm and how to apply them anyway
+func rebootRequiredDetail(vmId string, coldChanges []string) string {
+	return fmt.Sprintf("vm %s is running and %s can't be applied live. Set allow_reboot_for_changes to let the provider shut the vm down or stop it with power_state first.", vmId, strings.Join(coldChanges, ", "))
+}
+
+// updatePendingChanges reads the changes proxmox applies on the next restart of the vm into the model
+func (r *vmResource) updatePendingChanges(ctx context.Context, model *vmResourceModel) error {
+	pendingChanges, getPendingError := r.vmService.GetPendingChanges(ctx, model.NodeName.ValueStringPointer(), model.VmId.ValueStringPointer())
+	if getPendingError != nil {
+		return getPendingError
+	}
+
+	pendingChangesValue, diags := types.MapValueFrom(ctx, types.StringType, pendingChanges)
+	if diags.HasError() {
+		return errors.New(fmt.Sprintf("failed to map the pending changes of vm %s", model.VmId.ValueString()))
+	}
+	model.PendingChanges = pendingChangesValue
+	model.RebootRequired = types.BoolValue(len(pendingChanges) > 0 && model.PowerState.ValueString() == "running")
+	return nil
+}
+
 // Create creates the resource and sets the initial Terraform state.
 func (r *vmResource) Create(ctx context.Context, request resource.CreateRequest, response *resource.CreateResponse) {
 
-	var plan proxmoxTypes.VmModel
+	var plan vmResourceModel
 
 	diags := request.Plan.Get(ctx, &plan)
 	response.Diagnostics.Append(diags...)
 	if response.Diagnostics.HasError() {
 		return
 	}
-	var currentState = plan
-	createVmError := r.vmService.CreateVm(&plan)
 
-	if createVmError != nil {
-		response.Diagnostics.AddError("Failed to create vm", createVmError.Error())
+	createTimeout, diags := plan.Timeouts.Create(ctx, defaultVmCreateTimeout)
+	response.Diagnostics.Append(diags...)
+	if response.Diagnostics.HasError() {
 		return
 	}
+	ctx, cancel := context.WithTimeout(ctx, createTimeout)
+	defer cancel()
 
-	resizeDisksError := r.diskService.ResizeImportedDisks(plan.VmId.ValueStringPointer(), plan.NodeName.ValueStringPointer(), plan.Disks)
+	ctx, taskWarnings := services.WithTaskWarningCollector(ctx, plan.TreatWarningsAsErrors.ValueBool())
+	defer addTaskWarningDiagnostics(&response.Diagnostics, taskWarnings)
 
-	if resizeDisksError != nil {
-		response.Diagnostics.AddError("Failed to resize imported disks", resizeDisksError.Error())
-		return
+	// a new vm has nothing pending, this is what ends up in the state should the create fail half way
+	plan.PendingChanges = types.MapNull(types.StringType)
+	plan.RebootRequired = types.BoolValue(false)
+
+	if plan.Clone != nil {
+		cloneVmError := r.vmService.CloneVm(ctx, plan.Clone, &plan.VmModel)
+		if cloneVmError != nil {
+			addApiErrorDiagnostics(&response.Diagnostics, "Failed to clone vm", cloneVmError, vmAttributePath(&plan.VmModel))
+			return
+		}
+
+		// the clone exists from here on, it is kept in the state even if it can't be brought in line with the plan
+		summary, reconcileError := r.reconcileClonedVm(ctx, &plan.VmModel)
+		if reconcileError != nil {
+			addApiErrorDiagnostics(&response.Diagnostics, summary, reconcileError, vmAttributePath(&plan.VmModel))
+			response.Diagnostics.Append(response.State.Set(ctx, &plan)...)
+			return
+		}
+	} else {
+		createVmError := r.vmService.CreateVm(ctx, &plan.VmModel)
+
+		if createVmError != nil {
+			addApiErrorDiagnostics(&response.Diagnostics, "Failed to create vm", createVmError, vmAttributePath(&plan.VmModel))
+			return
+		}
+
+		resizeDisksError := r.diskService.ResizeImportedDisks(ctx, plan.VmId.ValueStringPointer(), plan.NodeName.ValueStringPointer(), plan.Disks)
+
+		if resizeDisksError != nil {
+			addApiErrorDiagnostics(&response.Diagnostics, "Failed to resize imported disks", resizeDisksError, vmAttributePath(&plan.VmModel))
+			return
+		}
+	}
+
+	var cloudInitPassword types.String
+	response.Diagnostics.Append(request.Config.GetAttribute(ctx, path.Root("cloud_init_password"), &cloudInitPassword)...)
+
+	if cloudInitPassword.ValueString() != "" {
+		passwordError := r.vmService.SetCloudInitPassword(ctx, plan.NodeName.ValueStringPointer(), plan.VmId.ValueStringPointer(), cloudInitPassword.ValueString(), nil)
+		if passwordError != nil {
+			addApiErrorDiagnostics(&response.Diagnostics, "Failed to set the cloud-init password", passwordError, vmAttributePath(&plan.VmModel))
+			response.Diagnostics.Append(response.State.Set(ctx, &plan)...)
+			return
+		}
 	}
 
-	qemuResponse, _, getVmStateError := r.vmService.GetVm(plan.NodeName.ValueStringPointer(), plan.VmId.ValueStringPointer())
+	// without a configured vm_id the plan only has an id once the vm exists
+	var currentState = plan
+
+	qemuResponse, _, getVmStateError := r.vmService.GetVm(ctx, plan.NodeName.ValueStringPointer(), plan.VmId.ValueStringPointer())
 
 	if getVmStateError != nil {
 		response.Diagnostics.AddError("Failed to refresh vm state after creation", getVmStateError.Error())
@@ -348,9 +815,9 @@ func (r *vmResource) Create(ctx context.Context, request resource.CreateRequest,
 		return
 	}
 
-	r.vmService.UpdateVmModelFromResponse(&currentState, &plan, qemuResponse)
+	r.vmService.UpdateVmModelFromResponse(&currentState.VmModel, &plan.VmModel, qemuResponse)
 
-	updatePowerStateError := r.vmService.UpdatePowerState(&currentState)
+	updatePowerStateError := r.vmService.UpdatePowerState(ctx, &currentState.VmModel)
 
 	if updatePowerStateError != nil {
 		response.Diagnostics.AddError("Failed to refresh vm current power state after creation", updatePowerStateError.Error())
@@ -359,12 +826,18 @@ func (r *vmResource) Create(ctx context.Context, request resource.CreateRequest,
 		return
 	}
 
-	matchPowerStateError := r.vmService.MatchVmPowerState(&plan, &currentState)
+	matchPowerStateError := r.vmService.MatchVmPowerState(ctx, &plan.VmModel, &currentState.VmModel)
 
 	if matchPowerStateError != nil {
 		response.Diagnostics.AddError("Failed to match requested power state after vm creation", matchPowerStateError.Error())
 	}
 
+	pendingChangesError := r.updatePendingChanges(ctx, &currentState)
+
+	if pendingChangesError != nil {
+		response.Diagnostics.AddError("Failed to read the pending changes of the vm after creation", pendingChangesError.Error())
+	}
+
 	diags = response.State.Set(ctx, &currentState)
 	response.Diagnostics.Append(diags...)
 	if response.Diagnostics.HasError() {
@@ -375,15 +848,32 @@ func (r *vmResource) Create(ctx context.Context, request resource.CreateRequest,
 // Read refreshes the Terraform state with the latest data.
 func (r *vmResource) Read(ctx context.Context, request resource.ReadRequest, response *resource.ReadResponse) {
 
-	var state proxmoxTypes.VmModel
+	var state vmResourceModel
 
 	diags := request.State.Get(ctx, &state)
 	response.Diagnostics.Append(diags...)
+	if response.Diagnostics.HasError() {
+		return
+	}
 	tflog.Debug(ctx, fmt.Sprintf("Node name is %s", state.NodeName.ValueString()))
 
+	readTimeout, diags := state.Timeouts.Read(ctx, defaultVmReadTimeout)
+	response.Diagnostics.Append(diags...)
+	if response.Diagnostics.HasError() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, readTimeout)
+	defer cancel()
+
 	var currentState = state
 
-	qemuResponse, nodeName, getVmError := r.vmService.GetVm(state.NodeName.ValueStringPointer(), state.VmId.ValueStringPointer())
+	qemuResponse, nodeName, getVmError := r.vmService.GetVm(ctx, state.NodeName.ValueStringPointer(), state.VmId.ValueStringPointer())
+
+	if errors.Is(getVmError, vm.ErrVmNotFound) {
+		tflog.Warn(ctx, fmt.Sprintf("VM %s no longer exists, removing it from state", state.VmId.ValueString()))
+		response.State.RemoveResource(ctx)
+		return
+	}
 
 	if getVmError != nil {
 		response.Diagnostics.AddError("Failed to find requested vm", getVmError.Error())
@@ -392,13 +882,19 @@ func (r *vmResource) Read(ctx context.Context, request resource.ReadRequest, res
 	currentState.VmId = state.VmId
 	currentState.NodeName = types.StringValue(*nodeName)
 
-	r.vmService.UpdateVmModelFromResponse(&currentState, &state, qemuResponse)
-	updatePowerStateError := r.vmService.UpdatePowerState(&currentState)
+	r.vmService.UpdateVmModelFromResponse(&currentState.VmModel, &state.VmModel, qemuResponse)
+	updatePowerStateError := r.vmService.UpdatePowerState(ctx, &currentState.VmModel)
 
 	if updatePowerStateError != nil {
 		response.Diagnostics.AddError("Failed to update VM power state.", updatePowerStateError.Error())
 	}
 
+	pendingChangesError := r.updatePendingChanges(ctx, &currentState)
+
+	if pendingChangesError != nil {
+		response.Diagnostics.AddError("Failed to read the pending changes of the vm", pendingChangesError.Error())
+	}
+
 	diags = response.State.Set(ctx, &currentState)
 	response.Diagnostics.Append(diags...)
 	if response.Diagnostics.HasError() {
@@ -409,116 +905,124 @@ func (r *vmResource) Read(ctx context.Context, request resource.ReadRequest, res
 
 // Update updates the resource and sets the updated Terraform state on success.
 func (r *vmResource) Update(ctx context.Context, request resource.UpdateRequest, response *resource.UpdateResponse) {
-	var current, plan, state proxmoxTypes.VmModel
+	var current, plan, state vmResourceModel
 	response.Diagnostics.Append(request.State.Get(ctx, &state)...)
 	response.Diagnostics.Append(request.Plan.Get(ctx, &plan)...)
 	if response.Diagnostics.HasError() {
 		return
 	}
 
-	current = plan
-
-	qemuResponse, _, getVmError := r.vmService.GetVm(state.NodeName.ValueStringPointer(), state.VmId.ValueStringPointer())
-
-	if getVmError != nil {
-		response.Diagnostics.AddError("Failed to refresh vm info prior to update", getVmError.Error())
+	updateTimeout, diags := plan.Timeouts.Update(ctx, defaultVmUpdateTimeout)
+	response.Diagnostics.Append(diags...)
+	if response.Diagnostics.HasError() {
 		return
 	}
+	ctx, cancel := context.WithTimeout(ctx, updateTimeout)
+	defer cancel()
+
+	ctx, taskWarnings := services.WithTaskWarningCollector(ctx, plan.TreatWarningsAsErrors.ValueBool())
+	defer addTaskWarningDiagnostics(&response.Diagnostics, taskWarnings)
 
-	r.vmService.UpdateVmModelFromResponse(&current, &plan, qemuResponse)
+	current = plan
+	// until the update got far enough to read them again the pending changes are the ones from before
+	current.PendingChanges = state.PendingChanges
+	current.RebootRequired = state.RebootRequired
 
-	updateVmError := r.vmService.UpdateVm(&plan, state.NodeName.ValueStringPointer(), state.VmId.ValueStringPointer())
+	qemuResponse, _, getVmError := r.vmService.GetVm(ctx, state.NodeName.ValueStringPointer(), state.VmId.ValueStringPointer())
 
-	if updateVmError != nil {
-		response.Diagnostics.AddError("Failed to update VM", updateVmError.Error())
+	if getVmError != nil {
+		response.Diagnostics.AddError("Failed to refresh vm info prior to update", getVmError.Error())
 		return
 	}
 
-	diskChanges := r.diskService.CompareVmDisks(&state, &plan)
+	r.vmService.UpdateVmModelFromResponse(&current.VmModel, &plan.VmModel, qemuResponse)
 
-	var toBeAdded, toBeUpdated, toBeRemoved, toBeResized []proxmoxTypes.VmDisk
-	toBeAdded = diskChanges.ToBeAdded
-	toBeUpdated = diskChanges.ToBeUpdated
-	toBeResized = diskChanges.ToBeResized
-	toBeRemoved = diskChanges.ToBeRemove
-	toBeMigrated := diskChanges.ToBeMigrated
+	diskChanges := r.diskService.CompareVmDisks(&state.VmModel, &plan.VmModel)
+	hotplug := r.vmService.MapHotplugFromQemuResponse(qemuResponse.Data.OtherFields)
+	coldChanges := r.diskService.ColdDiskChanges(state.Disks, diskChanges, hotplug)
 
-	migrationCount := 0
-	for key, _ := range toBeMigrated {
-		key.StorageLocation.ValueStringPointer()
-		migrationCount += 1
-	}
-
-	if len(toBeAdded)+len(toBeUpdated)+len(toBeRemoved)+len(toBeResized)+migrationCount > 0 {
-		tflog.Info(ctx, "Shutting down VM in order to provision disk changes")
-		shutdownError := r.vmService.ShutdownVm(state.NodeName.ValueStringPointer(), state.VmId.ValueStringPointer())
-		if shutdownError != nil {
-			tflog.Error(ctx, "Cannot perform disk updates, shutdown failed to complete")
-			response.Diagnostics.AddError("Failed to shutdown Vm", shutdownError.Error())
-			response.Diagnostics.Append(response.State.Set(ctx, &current)...)
+	if len(coldChanges) > 0 {
+		updatePowerStateError := r.vmService.UpdatePowerState(ctx, &current.VmModel)
+		if updatePowerStateError != nil {
+			response.Diagnostics.AddError("Failed to update VM power state", updatePowerStateError.Error())
 			return
 		}
-	}
-	moveDiskError := r.diskService.MoveDiskStorage(toBeMigrated, state.NodeName.ValueStringPointer(), state.VmId.ValueStringPointer())
 
-	if moveDiskError != nil {
-		response.Diagnostics.AddError("Failed to move vm disk", moveDiskError.Error())
-		response.Diagnostics.Append(response.State.Set(ctx, &current)...)
-		return
+		if current.PowerState.ValueString() == "running" {
+			// the vm may have been started outside of terraform since the plan was made
+			if plan.PowerState.ValueString() == "running" && !plan.AllowRebootForChanges.ValueBool() {
+				response.Diagnostics.AddAttributeError(path.Root("disk"), "Disk changes require a shutdown", rebootRequiredDetail(state.VmId.ValueString(), coldChanges))
+				response.Diagnostics.Append(response.State.Set(ctx, &current)...)
+				return
+			}
+
+			tflog.Info(ctx, fmt.Sprintf("Shutting down VM in order to provision disk changes: %s", strings.Join(coldChanges, ", ")))
+			shutdownError := r.vmService.ShutdownVm(ctx, state.NodeName.ValueStringPointer(), state.VmId.ValueStringPointer())
+			if shutdownError != nil {
+				tflog.Error(ctx, "Cannot perform disk updates, shutdown failed to complete")
+				response.Diagnostics.AddError("Failed to shutdown Vm", shutdownError.Error())
+				response.Diagnostics.Append(response.State.Set(ctx, &current)...)
+				return
+			}
+		}
 	}
 
-	tflog.Info(ctx, fmt.Sprintf("There are %d disks to remove", len(toBeRemoved)))
-
-	diskDeletionError := r.diskService.DeleteVmDisks(toBeRemoved, state.NodeName.ValueStringPointer(), state.VmId.ValueStringPointer())
+	updateVmError := r.vmService.UpdateVm(ctx, &plan.VmModel, qemuResponse.Data.OtherFields, state.NodeName.ValueStringPointer(), state.VmId.ValueStringPointer())
 
-	if diskDeletionError != nil {
-		response.Diagnostics.AddError("Failed to delete Vm disk", diskDeletionError.Error())
-		response.Diagnostics.Append(response.State.Set(ctx, &current)...)
+	if updateVmError != nil {
+		addApiErrorDiagnostics(&response.Diagnostics, "Failed to update VM", updateVmError, vmAttributePath(&plan.VmModel))
 		return
 	}
 
-	tflog.Info(ctx, fmt.Sprintf("There are %d disks to add", len(toBeAdded)))
+	if !plan.CloudInitPasswordVersion.Equal(state.CloudInitPasswordVersion) {
+		var cloudInitPassword types.String
+		response.Diagnostics.Append(request.Config.GetAttribute(ctx, path.Root("cloud_init_password"), &cloudInitPassword)...)
+		passwordError := r.vmService.SetCloudInitPassword(ctx, state.NodeName.ValueStringPointer(), state.VmId.ValueStringPointer(), cloudInitPassword.ValueString(), qemuResponse.Data.OtherFields)
 
-	addDisksError := r.diskService.AddVmDisks(toBeAdded, state.NodeName.ValueStringPointer(), state.VmId.ValueStringPointer())
-
-	if addDisksError != nil {
-		response.Diagnostics.AddError("Failed to add Vm disks", addDisksError.Error())
-		response.Diagnostics.Append(response.State.Set(ctx, &current)...)
-		return
+		if passwordError != nil {
+			addApiErrorDiagnostics(&response.Diagnostics, "Failed to set the cloud-init password", passwordError, vmAttributePath(&plan.VmModel))
+			response.Diagnostics.Append(response.State.Set(ctx, &current)...)
+			return
+		}
 	}
 
-	tflog.Info(ctx, fmt.Sprintf("There are %d disks to update", len(toBeUpdated)))
+	// the drive goes before the disks, a new disk may take the slot it leaves
+	cloudInitError := r.vmService.UpdateCloudInitDrive(ctx, qemuResponse.Data.OtherFields, &plan.VmModel, state.NodeName.ValueStringPointer(), state.VmId.ValueStringPointer())
 
-	updateDisksError := r.diskService.UpdateVmDisks(toBeUpdated, state.NodeName.ValueStringPointer(), state.VmId.ValueStringPointer())
-
-	if updateDisksError != nil {
-		response.Diagnostics.AddError("Failed to update vm disk configs", updateDisksError.Error())
+	if cloudInitError != nil {
+		addApiErrorDiagnostics(&response.Diagnostics, "Failed to update the cloud-init drive", cloudInitError, vmAttributePath(&plan.VmModel))
 		response.Diagnostics.Append(response.State.Set(ctx, &current)...)
 		return
 	}
 
-	resizeDisksError := r.diskService.ResizeVmDisks(toBeResized, state.NodeName.ValueStringPointer(), state.VmId.ValueStringPointer())
+	summary, diskChangesError := r.applyDiskChanges(ctx, diskChanges, state.NodeName.ValueStringPointer(), state.VmId.ValueStringPointer())
 
-	if resizeDisksError != nil {
-		response.Diagnostics.AddError("Failed to resize VM Disks", resizeDisksError.Error())
+	if diskChangesError != nil {
+		addApiErrorDiagnostics(&response.Diagnostics, summary, diskChangesError, vmAttributePath(&plan.VmModel))
 		response.Diagnostics.Append(response.State.Set(ctx, &current)...)
 		return
 	}
 
 	if state.NodeName.ValueString() != plan.NodeName.ValueString() {
-		migrationError := r.vmService.MigrateVm(state.NodeName.ValueStringPointer(), plan.NodeName.ValueStringPointer(), state.VmId.ValueStringPointer())
+		migrationError := r.vmService.MigrateVm(ctx, state.NodeName.ValueStringPointer(), plan.NodeName.ValueStringPointer(), state.VmId.ValueStringPointer())
 		if migrationError != nil {
-			response.Diagnostics.AddError("Failed to migrate VM", migrationError.Error())
+			addApiErrorDiagnostics(&response.Diagnostics, "Failed to migrate VM", migrationError, vmAttributePath(&plan.VmModel))
 			response.Diagnostics.Append(response.State.Set(ctx, &current)...)
 			return
 		}
 	}
 
-	qemuResponse, _, getVmError = r.vmService.GetVm(plan.NodeName.ValueStringPointer(), plan.VmId.ValueStringPointer())
+	qemuResponse, _, getVmError = r.vmService.GetVm(ctx, plan.NodeName.ValueStringPointer(), plan.VmId.ValueStringPointer())
 
-	r.vmService.UpdateVmModelFromResponse(&current, &plan, qemuResponse)
+	if getVmError != nil {
+		response.Diagnostics.AddError("Failed to refresh vm state after the update", getVmError.Error())
+		response.Diagnostics.Append(response.State.Set(ctx, &current)...)
+		return
+	}
 
-	updatePowerStateError := r.vmService.UpdatePowerState(&current)
+	r.vmService.UpdateVmModelFromResponse(&current.VmModel, &plan.VmModel, qemuResponse)
+
+	updatePowerStateError := r.vmService.UpdatePowerState(ctx, &current.VmModel)
 
 	if updatePowerStateError != nil {
 		response.Diagnostics.AddError("Failed to update VM power state", updatePowerStateError.Error())
@@ -527,7 +1031,7 @@ func (r *vmResource) Update(ctx context.Context, request resource.UpdateRequest,
 	}
 
 	if current.PowerState.ValueString() == "stopped" && plan.PowerState.ValueString() == "running" {
-		startVmError := r.vmService.StartVm(plan.NodeName.ValueStringPointer(), plan.VmId.ValueStringPointer())
+		startVmError := r.vmService.StartVm(ctx, plan.NodeName.ValueStringPointer(), plan.VmId.ValueStringPointer())
 		if startVmError != nil {
 			response.Diagnostics.AddError("Failed to start VM", startVmError.Error())
 			response.Diagnostics.Append(response.State.Set(ctx, &current)...)
@@ -535,7 +1039,7 @@ func (r *vmResource) Update(ctx context.Context, request resource.UpdateRequest,
 		}
 	}
 
-	updatePowerStateError = r.vmService.UpdatePowerState(&current)
+	updatePowerStateError = r.vmService.UpdatePowerState(ctx, &current.VmModel)
 
 	if updatePowerStateError != nil {
 		response.Diagnostics.AddError("Failed to update VM power state", updatePowerStateError.Error())
@@ -543,20 +1047,138 @@ func (r *vmResource) Update(ctx context.Context, request resource.UpdateRequest,
 		return
 	}
 
+	pendingChangesError := r.updatePendingChanges(ctx, &current)
+
+	if pendingChangesError != nil {
+		response.Diagnostics.AddError("Failed to read the pending changes of the vm", pendingChangesError.Error())
+		response.Diagnostics.Append(response.State.Set(ctx, &current)...)
+		return
+	}
+
+	if current.RebootRequired.ValueBool() && plan.ApplyPendingWithReboot.ValueBool() && plan.PowerState.ValueString() == "running" {
+		tflog.Info(ctx, fmt.Sprintf("Rebooting VM in order to apply pending changes to %s", strings.Join(slices.Sorted(maps.Keys(current.PendingChanges.Elements())), ", ")))
+		rebootVmError := r.vmService.RebootVm(ctx, plan.NodeName.ValueStringPointer(), plan.VmId.ValueStringPointer())
+		if rebootVmError != nil {
+			response.Diagnostics.AddError("Failed to reboot VM", rebootVmError.Error())
+			response.Diagnostics.Append(response.State.Set(ctx, &current)...)
+			return
+		}
+
+		pendingChangesError = r.updatePendingChanges(ctx, &current)
+
+		if pendingChangesError != nil {
+			response.Diagnostics.AddError("Failed to read the pending changes of the vm", pendingChangesError.Error())
+			response.Diagnostics.Append(response.State.Set(ctx, &current)...)
+			return
+		}
+	}
+
 	response.Diagnostics.Append(response.State.Set(ctx, &current)...)
 	if response.Diagnostics.HasError() {
 		return
 	}
 }
 
+// applyDiskChanges
+/**
+ * @description moves, removes, adds, updates and resizes disks in that order. The vm has to be stopped unless every
+ * change can be hot plugged.
+ *
+ * @return the summary of the step that failed together with its error
+ */
+func (r *vmResource) applyDiskChanges(ctx context.Context, diskChanges *proxmoxTypes.DiskChanges, nodeName *string, vmId *string) (string, error) {
+	moveDiskError := r.diskService.MoveDiskStorage(ctx, diskChanges.ToBeMigrated, nodeName, vmId)
+
+	if moveDiskError != nil {
+		return "Failed to move vm disk", moveDiskError
+	}
+
+	tflog.Info(ctx, fmt.Sprintf("There are %d disks to remove", len(diskChanges.ToBeRemove)))
+
+	diskDeletionError := r.diskService.DeleteVmDisks(ctx, diskChanges.ToBeRemove, nodeName, vmId)
+
+	if diskDeletionError != nil {
+		return "Failed to delete Vm disk", diskDeletionError
+	}
+
+	tflog.Info(ctx, fmt.Sprintf("There are %d disks to add", len(diskChanges.ToBeAdded)))
+
+	addDisksError := r.diskService.AddVmDisks(ctx, diskChanges.ToBeAdded, nodeName, vmId)
+
+	if addDisksError != nil {
+		return "Failed to add Vm disks", addDisksError
+	}
+
+	tflog.Info(ctx, fmt.Sprintf("There are %d disks to update", len(diskChanges.ToBeUpdated)))
+
+	updateDisksError := r.diskService.UpdateVmDisks(ctx, diskChanges.ToBeUpdated, nodeName, vmId)
+
+	if updateDisksError != nil {
+		return "Failed to update vm disk configs", updateDisksError
+	}
+
+	resizeDisksError := r.diskService.ResizeVmDisks(ctx, diskChanges.ToBeResized, nodeName, vmId)
+
+	if resizeDisksError != nil {
+		return "Failed to resize VM Disks", resizeDisksError
+	}
+	return "", nil
+}
+
+// reconcileClonedVm changes the disks and the config of a fresh clone to match the plan, the clone is still stopped
+func (r *vmResource) reconcileClonedVm(ctx context.Context, plan *proxmoxTypes.VmModel) (string, error) {
+	qemuResponse, _, getVmError := r.vmService.GetVm(ctx, plan.NodeName.ValueStringPointer(), plan.VmId.ValueStringPointer())
+
+	if getVmError != nil {
+		return "Failed to read the config of the clone", getVmError
+	}
+
+	cloned := *plan
+	r.vmService.UpdateVmModelFromResponse(&cloned, plan, qemuResponse)
+
+	cloudInitError := r.vmService.UpdateCloudInitDrive(ctx, qemuResponse.Data.OtherFields, plan, plan.NodeName.ValueStringPointer(), plan.VmId.ValueStringPointer())
+
+	if cloudInitError != nil {
+		return "Failed to update the cloud-init drive of the clone", cloudInitError
+	}
+
+	// disks go first, the boot order of the plan may refer to disks the source doesn't have
+	summary, diskChangesError := r.applyDiskChanges(ctx, r.diskService.CompareVmDisks(&cloned, plan), plan.NodeName.ValueStringPointer(), plan.VmId.ValueStringPointer())
+
+	if diskChangesError != nil {
+		return summary, diskChangesError
+	}
+
+	updateVmError := r.vmService.UpdateVm(ctx, plan, qemuResponse.Data.OtherFields, plan.NodeName.ValueStringPointer(), plan.VmId.ValueStringPointer())
+
+	if updateVmError != nil {
+		return "Failed to apply the config to the clone", updateVmError
+	}
+	return "", nil
+}
+
 // Delete deletes the resource and removes the Terraform state on success.
 func (r *vmResource) Delete(ctx context.Context, request resource.DeleteRequest, response *resource.DeleteResponse) {
 
-	var plan proxmoxTypes.VmModel
+	var plan vmResourceModel
 	diags := request.State.Get(ctx, &plan)
 	response.Diagnostics.Append(diags...)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	deleteTimeout, diags := plan.Timeouts.Delete(ctx, defaultVmDeleteTimeout)
+	response.Diagnostics.Append(diags...)
+	if response.Diagnostics.HasError() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, deleteTimeout)
+	defer cancel()
+
+	ctx, taskWarnings := services.WithTaskWarningCollector(ctx, plan.TreatWarningsAsErrors.ValueBool())
+	defer addTaskWarningDiagnostics(&response.Diagnostics, taskWarnings)
 
-	deleteVmError := r.vmService.DeleteVm(plan.NodeName.ValueStringPointer(), plan.VmId.ValueStringPointer())
+	deleteVmError := r.vmService.DeleteVm(ctx, plan.NodeName.ValueStringPointer(), plan.VmId.ValueStringPointer())
 
 	if deleteVmError != nil {
 		response.Diagnostics.AddError("Failed to delete vm", deleteVmError.Error())
@@ -584,7 +1206,7 @@ func (r *vmResource) ImportState(ctx context.Context, request resource.ImportSta
 	//
 	//	plan.VmId = types.StringValue(request.ID)
 	//
-	//	nodeList, listNodesError := r.client.ListNodes()
+	//	nodeList, listNodesError := r.client.ListNodes(ctx)
 	//
 	//	if listNodesError != nil {
 	//		response.Diagnostics.AddError("Failed to list nodes in proxmox cluster", listNodesError.Error())
@@ -596,7 +1218,7 @@ func (r *vmResource) ImportState(ctx context.Context, request resource.ImportSta
 	//
 	//		tflog.Debug(ctx, fmt.Sprintf("Node name is %s", node.Node))
 	//
-	//		vmResponse, searchVmError := r.client.GetVmById(node.Node, plan.VmId.ValueString())
+	//		vmResponse, searchVmError := r.client.GetVmById(ctx, node.Node, plan.VmId.ValueString())
 	//
 	//		if searchVmError != nil && !strings.Contains(searchVmError.Error(), fmt.Sprintf("500 Configuration file 'nodes/%s/qemu-server/%d.conf' does not exist", node.Node, vmId)) {
 	//			response.Diagnostics.AddError("Failed to search for node that VM lives on", searchVmError.Error())
@@ -0,0 +1,324 @@
+package proxmox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"terraform-provider-proxmox/proxmox_client"
+	"terraform-provider-proxmox/services"
+	"terraform-provider-proxmox/services/vm"
+	proxmoxTypes "terraform-provider-proxmox/types"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+var (
+	_ resource.Resource                = &vmTemplateResource{}
+	_ resource.ResourceWithConfigure   = &vmTemplateResource{}
+	_ resource.ResourceWithImportState = &vmTemplateResource{}
+	_ resource.ResourceWithModifyPlan  = &vmTemplateResource{}
+)
+
+func NewVmTemplateResource() resource.Resource {
+	return &vmTemplateResource{}
+}
+
+// defaultVmTemplateTimeout applies to every operation on a template unless a timeouts block says otherwise, converting
+// only renames the volumes of the vm but that can still take a while on slow storage
+const defaultVmTemplateTimeout = 10 * time.Minute
+
+// vmTemplateResource converts an existing vm into a template. Proxmox can't turn a template back into a vm, so the
+// resource never changes a template in place and leaves it behind when it is destroyed.
+type vmTemplateResource struct {
+	vmService vm.VmService
+}
+
+type vmTemplateResourceModel struct {
+	proxmoxTypes.VmTemplate
+	TreatWarningsAsErrors types.Bool     `tfsdk:"treat_warnings_as_errors"`
+	Timeouts              timeouts.Value `tfsdk:"timeouts"`
+}
+
+// Configure adds the provider configured client to the resource.
+func (r *vmTemplateResource) Configure(ctx context.Context, req resource.ConfigureRequest, _ *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	proxmoxClient := req.ProviderData.(proxmox_client.ProxmoxClient)
+	proxmoxUtils := services.NewProxmoxUtilService()
+	taskService := services.NewTaskService(proxmoxClient)
+	diskService := vm.NewDiskService(ctx, proxmoxClient, proxmoxUtils, taskService)
+	r.vmService = vm.NewVmService(ctx, proxmoxClient, diskService, proxmoxUtils, taskService)
+}
+
+// Metadata returns the resource type name.
+func (r *vmTemplateResource) Metadata(_ context.Context, req resource.MetadataRequest, response *resource.MetadataResponse) {
+	response.TypeName = req.ProviderTypeName + "_vm_template"
+}
+
+// Schema defines the schema for the resource.
+func (r *vmTemplateResource) Schema(ctx context.Context, _ resource.SchemaRequest, response *resource.SchemaResponse) {
+	response.Schema = schema.Schema{
+		Description: "converts an existing vm, e.g. one created by proxmox_vm from an imported cloud image, into a template. Templates are read only, changing the vm, node or disk of an existing template is refused and destroying the resource leaves the template in place",
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Update: true,
+				Delete: true,
+			}),
+		},
+		Attributes: map[string]schema.Attribute{
+			"vm_id": schema.StringAttribute{
+				Required:    true,
+				Description: "id of the stopped vm to convert",
+			},
+			"node_name": schema.StringAttribute{
+				Required:    true,
+				Description: "node the vm is on",
+			},
+			"disk": schema.StringAttribute{
+				Optional:    true,
+				Description: "only convert this disk, e.g. scsi0, to a base image instead of every disk of the vm",
+			},
+			"name": schema.StringAttribute{
+				Computed:    true,
+				Description: "name of the template",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"disks": schema.ListNestedAttribute{
+				Computed:    true,
+				Description: "disks of the template, cdroms and the cloudinit drive are left out",
+				PlanModifiers: []planmodifier.List{
+					listplanmodifier.UseStateForUnknown(),
+				},
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"key": schema.StringAttribute{
+							Computed:    true,
+							Description: "drive the disk is attached to, e.g. scsi0",
+						},
+						"volume": schema.StringAttribute{
+							Computed:    true,
+							Description: "volume id of the disk, e.g. local-zfs:base-9000-disk-0",
+						},
+						"storage_location": schema.StringAttribute{
+							Computed: true,
+						},
+						"size": schema.StringAttribute{
+							Computed: true,
+						},
+					},
+				},
+			},
+			"treat_warnings_as_errors": schema.BoolAttribute{
+				Optional:    true,
+				Computed:    true,
+				Default:     booldefault.StaticBool(false),
+				Description: "fail the operation when a proxmox task completes with warnings instead of reporting them as warnings",
+			},
+		},
+	}
+}
+
+// ModifyPlan refuses to change an existing template, a template can't be converted back and converting another vm
+// in its place would leave the old template behind without terraform knowing about it
+func (r *vmTemplateResource) ModifyPlan(ctx context.Context, request resource.ModifyPlanRequest, response *resource.ModifyPlanResponse) {
+	if request.State.Raw.IsNull() || request.Plan.Raw.IsNull() {
+		return
+	}
+
+	var state, plan vmTemplateResourceModel
+	response.Diagnostics.Append(request.State.Get(ctx, &state)...)
+	response.Diagnostics.Append(request.Plan.Get(ctx, &plan)...)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	addReadOnlyTemplateErrors(&response.Diagnostics, &state.VmTemplate, &plan.VmTemplate)
+}
+
+// Create creates the resource and sets the initial Terraform state.
+func (r *vmTemplateResource) Create(ctx context.Context, request resource.CreateRequest, response *resource.CreateResponse) {
+	var plan vmTemplateResourceModel
+	diags := request.Plan.Get(ctx, &plan)
+	response.Diagnostics.Append(diags...)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	createTimeout, diags := plan.Timeouts.Create(ctx, defaultVmTemplateTimeout)
+	response.Diagnostics.Append(diags...)
+	if response.Diagnostics.HasError() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, createTimeout)
+	defer cancel()
+
+	ctx, taskWarnings := services.WithTaskWarningCollector(ctx, plan.TreatWarningsAsErrors.ValueBool())
+	defer addTaskWarningDiagnostics(&response.Diagnostics, taskWarnings)
+
+	qemuResponse, nodeName, getVmError := r.vmService.GetVm(ctx, plan.NodeName.ValueStringPointer(), plan.VmId.ValueStringPointer())
+
+	if getVmError != nil {
+		response.Diagnostics.AddError("Failed to find the vm to convert to a template", getVmError.Error())
+		return
+	}
+	if *nodeName != plan.NodeName.ValueString() {
+		response.Diagnostics.AddAttributeError(path.Root("node_name"), "Failed to find the vm to convert to a template", fmt.Sprintf("vm %s is on node %s, not on %s", plan.VmId.ValueString(), *nodeName, plan.NodeName.ValueString()))
+		return
+	}
+
+	if qemuResponse.Data.Template == 1 && plan.Disk.ValueString() == "" {
+		tflog.Info(ctx, fmt.Sprintf("VM %s already is a template, adopting it", plan.VmId.ValueString()))
+	} else {
+		convertError := r.vmService.ConvertVmToTemplate(ctx, plan.NodeName.ValueStringPointer(), plan.VmId.ValueStringPointer(), plan.Disk.ValueStringPointer())
+
+		if convertError != nil {
+			addApiErrorDiagnostics(&response.Diagnostics, "Failed to convert vm to a template", convertError, vmTemplateAttributePath)
+			return
+		}
+
+		qemuResponse, _, getVmError = r.vmService.GetVm(ctx, plan.NodeName.ValueStringPointer(), plan.VmId.ValueStringPointer())
+
+		if getVmError != nil {
+			response.Diagnostics.AddError("Failed to refresh template state after conversion", getVmError.Error())
+			return
+		}
+	}
+
+	updateTemplateError := r.vmService.UpdateVmTemplateFromResponse(&plan.VmTemplate, qemuResponse)
+
+	if updateTemplateError != nil {
+		response.Diagnostics.AddError("Failed to refresh template state after conversion", updateTemplateError.Error())
+		return
+	}
+
+	diags = response.State.Set(ctx, plan)
+	response.Diagnostics.Append(diags...)
+}
+
+// Read refreshes the Terraform state with the latest data.
+func (r *vmTemplateResource) Read(ctx context.Context, request resource.ReadRequest, response *resource.ReadResponse) {
+	var state vmTemplateResourceModel
+	diags := request.State.Get(ctx, &state)
+	response.Diagnostics.Append(diags...)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	readTimeout, diags := state.Timeouts.Read(ctx, defaultVmTemplateTimeout)
+	response.Diagnostics.Append(diags...)
+	if response.Diagnostics.HasError() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, readTimeout)
+	defer cancel()
+
+	qemuResponse, nodeName, getVmError := r.vmService.GetVm(ctx, state.NodeName.ValueStringPointer(), state.VmId.ValueStringPointer())
+
+	if errors.Is(getVmError, vm.ErrVmNotFound) {
+		tflog.Warn(ctx, fmt.Sprintf("Template %s no longer exists, removing it from state", state.VmId.ValueString()))
+		response.State.RemoveResource(ctx)
+		return
+	}
+
+	if getVmError != nil {
+		response.Diagnostics.AddError("Failed to find requested template", getVmError.Error())
+		return
+	}
+
+	// the vm was replaced by one that is not a template, e.g. because the vm resource it came from was recreated
+	if qemuResponse.Data.Template != 1 {
+		tflog.Warn(ctx, fmt.Sprintf("VM %s is no longer a template, removing it from state", state.VmId.ValueString()))
+		response.State.RemoveResource(ctx)
+		return
+	}
+
+	state.NodeName = types.StringValue(*nodeName)
+	if state.TreatWarningsAsErrors.IsNull() {
+		// imported, the default is not applied to the state
+		state.TreatWarningsAsErrors = types.BoolValue(false)
+	}
+	updateTemplateError := r.vmService.UpdateVmTemplateFromResponse(&state.VmTemplate, qemuResponse)
+
+	if updateTemplateError != nil {
+		response.Diagnostics.AddError("Failed to refresh template state", updateTemplateError.Error())
+		return
+	}
+
+	diags = response.State.Set(ctx, &state)
+	response.Diagnostics.Append(diags...)
+}
+
+// Update only ever changes settings of the resource itself, ModifyPlan refuses changes to the template
+func (r *vmTemplateResource) Update(ctx context.Context, request resource.UpdateRequest, response *resource.UpdateResponse) {
+	var plan, state vmTemplateResourceModel
+	response.Diagnostics.Append(request.State.Get(ctx, &state)...)
+	response.Diagnostics.Append(request.Plan.Get(ctx, &plan)...)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	// values that were unknown during the plan are only known now
+	addReadOnlyTemplateErrors(&response.Diagnostics, &state.VmTemplate, &plan.VmTemplate)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	plan.Name = state.Name
+	plan.Disks = state.Disks
+	response.Diagnostics.Append(response.State.Set(ctx, &plan)...)
+}
+
+// Delete removes the template from the state, the template itself is left in place.
+func (r *vmTemplateResource) Delete(ctx context.Context, request resource.DeleteRequest, response *resource.DeleteResponse) {
+	var state vmTemplateResourceModel
+	diags := request.State.Get(ctx, &state)
+	response.Diagnostics.Append(diags...)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	tflog.Info(ctx, fmt.Sprintf("Leaving template %s on node %s in place", state.VmId.ValueString(), state.NodeName.ValueString()))
+	response.State.RemoveResource(ctx)
+}
+
+func (r *vmTemplateResource) ImportState(ctx context.Context, request resource.ImportStateRequest, response *resource.ImportStateResponse) {
+	// the vm id is the import id, read finds the node and fills in the rest
+	resource.ImportStatePassthroughID(ctx, path.Root("vm_id"), request, response)
+}
+
+// addReadOnlyTemplateErrors reports every setting of the plan that would change the existing template
+func addReadOnlyTemplateErrors(diagnostics *diag.Diagnostics, state *proxmoxTypes.VmTemplate, plan *proxmoxTypes.VmTemplate) {
+	changes := map[string][2]types.String{
+		"vm_id":     {state.VmId, plan.VmId},
+		"node_name": {state.NodeName, plan.NodeName},
+		"disk":      {state.Disk, plan.Disk},
+	}
+	for _, attribute := range []string{"vm_id", "node_name", "disk"} {
+		current, planned := changes[attribute][0], changes[attribute][1]
+		// unknown values are checked again once they are known, a disk missing from the state was imported
+		if planned.IsUnknown() || current.IsNull() || current.Equal(planned) {
+			continue
+		}
+		diagnostics.AddAttributeError(
+			path.Root(attribute),
+			"Templates are read only",
+			fmt.Sprintf("%s of template %s can't be changed from %s to %s, proxmox can't turn a template back into a vm. Add a new proxmox_vm_template resource for the other vm instead", attribute, state.VmId.ValueString(), current.String(), planned.String()),
+		)
+	}
+}
@@ -0,0 +1,89 @@
+package proxmox
+
+import (
+	"fmt"
+	"regexp"
+	"terraform-provider-proxmox/fake_proxmox"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+)
+
+func testAccVmTemplateConfig(server *fake_proxmox.Server, templateNode string) string {
+	return server.ProviderConfig() + fmt.Sprintf(`
+resource "proxmox_vm" "image" {
+  name        = "ubuntu-image"
+  vm_id       = "100"
+  node_name   = "pve"
+  cores       = 2
+  memory      = 2048
+  os_type     = "l26"
+  cpu_type    = "host"
+  nameserver  = "1.1.1.1"
+  boot_order  = ["scsi0"]
+
+  disk {
+    storage_location = "local-zfs"
+    size             = "8G"
+    order            = 0
+  }
+
+  network_interface {
+    mac_address = "BC:24:11:00:00:01"
+    bridge      = "vmbr0"
+    order       = 0
+  }
+}
+
+resource "proxmox_vm_template" "image" {
+  vm_id     = proxmox_vm.image.vm_id
+  node_name = %q
+}
+`, templateNode)
+}
+
+func TestAccVmTemplateResource(t *testing.T) {
+	server := fake_proxmox.NewServer()
+	defer server.Close()
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy: func(_ *terraform.State) error {
+			if _, _, found := server.Vm(100); found {
+				return fmt.Errorf("vm 100 still exists")
+			}
+			return nil
+		},
+		Steps: []resource.TestStep{
+			{
+				Config: testAccVmTemplateConfig(server, "pve"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("proxmox_vm_template.image", "name", "ubuntu-image"),
+					resource.TestCheckResourceAttr("proxmox_vm_template.image", "disks.#", "1"),
+					resource.TestCheckResourceAttr("proxmox_vm_template.image", "disks.0.key", "scsi0"),
+					resource.TestCheckResourceAttr("proxmox_vm_template.image", "disks.0.volume", "local-zfs:base-100-disk-0"),
+					resource.TestCheckResourceAttr("proxmox_vm_template.image", "disks.0.size", "8G"),
+					func(_ *terraform.State) error {
+						config, _, _ := server.Vm(100)
+						if config["template"] != "1" || !server.HasVolume("pve", "local-zfs:base-100-disk-0") {
+							return fmt.Errorf("expected vm 100 to be a template with a base image, got %v", config)
+						}
+						return nil
+					},
+				),
+			},
+			{
+				Config:      testAccVmTemplateConfig(server, "pve2"),
+				ExpectError: regexp.MustCompile("Templates are read only"),
+			},
+			{
+				ResourceName:                         "proxmox_vm_template.image",
+				ImportState:                          true,
+				ImportStateId:                        "100",
+				ImportStateVerify:                    true,
+				ImportStateVerifyIdentifierAttribute: "vm_id",
+			},
+		},
+	})
+}
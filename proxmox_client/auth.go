@@ -0,0 +1,187 @@
+package proxmox_client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	proxmoxTypes "terraform-provider-proxmox/types"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// proxmox tickets are valid for two hours, renew well before that so long applies never send an expired ticket
+const ticketRenewalAge = 90 * time.Minute
+
+type AuthStruct struct {
+	Username    string `json:"username"`
+	Password    string `json:"password"`
+	TokenId     string `json:"tokenid"`
+	TokenSecret string `json:"tokensecret"`
+	TotpSecret  string `json:"totpsecret"`
+}
+
+type authTicket struct {
+	ticket              string
+	csrfPreventionToken string
+	issuedAt            time.Time
+}
+
+// UsesApiToken
+/**
+ * @description api token ids take the form user@realm!tokenname, older configurations passed them in through username
+ * and password so those are still treated as a token when the username contains a token id.
+ */
+func (auth *AuthStruct) UsesApiToken() bool {
+	return auth.TokenId != "" || strings.Contains(auth.Username, "!")
+}
+
+func (auth *AuthStruct) apiTokenHeader() string {
+	if auth.TokenId != "" {
+		return fmt.Sprintf("PVEAPIToken=%s=%s", auth.TokenId, auth.TokenSecret)
+	}
+	return fmt.Sprintf("PVEAPIToken=%s=%s", auth.Username, auth.Password)
+}
+
+func (c *Client) authenticateRequest(req *http.Request) error {
+	if c.Auth.UsesApiToken() {
+		req.Header.Set("Authorization", c.Auth.apiTokenHeader())
+		return nil
+	}
+
+	ticket, ticketError := c.getTicket(req.Context())
+	if ticketError != nil {
+		return ticketError
+	}
+
+	// the same request is sent again on retries and failover, drop the ticket of the previous attempt
+	req.Header.Del("Cookie")
+	req.AddCookie(&http.Cookie{Name: "PVEAuthCookie", Value: ticket.ticket})
+	if req.Method != http.MethodGet {
+		req.Header.Set("CSRFPreventionToken", ticket.csrfPreventionToken)
+	}
+	return nil
+}
+
+func (c *Client) getTicket(ctx context.Context) (*authTicket, error) {
+	c.ticketLock.Lock()
+	defer c.ticketLock.Unlock()
+
+	if c.ticket != nil && time.Since(c.ticket.issuedAt) < ticketRenewalAge {
+		return c.ticket, nil
+	}
+
+	if c.ticket != nil {
+		renewedTicket, renewError := c.renewTicket(ctx, c.ticket)
+		if renewError == nil {
+			c.ticket = renewedTicket
+			return c.ticket, nil
+		}
+		tflog.Warn(ctx, fmt.Sprintf("Failed to renew proxmox ticket, logging in again: %s", renewError.Error()))
+	}
+
+	newTicket, loginError := c.login(ctx)
+	if loginError != nil {
+		return nil, loginError
+	}
+	c.ticket = newTicket
+	return c.ticket, nil
+}
+
+func (c *Client) login(ctx context.Context) (*authTicket, error) {
+	params := url.Values{}
+	params.Add("username", c.Auth.Username)
+	params.Add("password", c.Auth.Password)
+
+	ticketResponse, loginError := c.requestTicket(ctx, params)
+	if loginError != nil {
+		return nil, fmt.Errorf("failed to log in to proxmox as %s: %w", c.Auth.Username, loginError)
+	}
+
+	if ticketResponse.Data.NeedTFA == 1 {
+		if c.Auth.TotpSecret == "" {
+			return nil, errors.New(fmt.Sprintf("proxmox requires a second factor for %s but no totp secret was configured", c.Auth.Username))
+		}
+
+		code, generateCodeError := GenerateTotpCode(c.Auth.TotpSecret, time.Now())
+		if generateCodeError != nil {
+			return nil, generateCodeError
+		}
+
+		tfaParams := url.Values{}
+		tfaParams.Add("username", c.Auth.Username)
+		tfaParams.Add("tfa-challenge", ticketResponse.Data.Ticket)
+		tfaParams.Add("password", fmt.Sprintf("totp:%s", code))
+
+		ticketResponse, loginError = c.requestTicket(ctx, tfaParams)
+		if loginError != nil {
+			return nil, errors.New(fmt.Sprintf("proxmox rejected the second factor for %s: %s", c.Auth.Username, loginError.Error()))
+		}
+	}
+
+	return &authTicket{
+		ticket:              ticketResponse.Data.Ticket,
+		csrfPreventionToken: ticketResponse.Data.CSRFPreventionToken,
+		issuedAt:            time.Now(),
+	}, nil
+}
+
+// renewTicket a still valid ticket can be exchanged for a new one by using it as the password, this also skips the second factor
+func (c *Client) renewTicket(ctx context.Context, current *authTicket) (*authTicket, error) {
+	params := url.Values{}
+	params.Add("username", c.Auth.Username)
+	params.Add("password", current.ticket)
+
+	ticketResponse, renewError := c.requestTicket(ctx, params)
+	if renewError != nil {
+		return nil, renewError
+	}
+
+	return &authTicket{
+		ticket:              ticketResponse.Data.Ticket,
+		csrfPreventionToken: ticketResponse.Data.CSRFPreventionToken,
+		issuedAt:            time.Now(),
+	}, nil
+}
+
+func (c *Client) requestTicket(ctx context.Context, params url.Values) (*proxmoxTypes.TicketResponse, error) {
+	request, requestCreationError := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/access/ticket", c.HostURL), bytes.NewBufferString(params.Encode()))
+	if requestCreationError != nil {
+		return nil, requestCreationError
+	}
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Content-Type", FormUrlEncoded)
+	c.endpoints.current().applyTo(request)
+
+	res, responseError := c.HTTPClient.Do(request)
+	if responseError != nil {
+		return nil, responseError
+	}
+	defer res.Body.Close()
+	body, readError := io.ReadAll(res.Body)
+	if readError != nil {
+		return nil, readError
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, newApiError(request, res, body)
+	}
+
+	var ticketResponse proxmoxTypes.TicketResponse
+	unmarshallingError := json.Unmarshal(body, &ticketResponse)
+	if unmarshallingError != nil {
+		return nil, unmarshallingError
+	}
+
+	if ticketResponse.Data.Ticket == "" {
+		return nil, errors.New("proxmox did not return a ticket")
+	}
+
+	return &ticketResponse, nil
+}
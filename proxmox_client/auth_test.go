@@ -0,0 +1,94 @@
+package proxmox_client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTicketLoginWithSecondFactor(t *testing.T) {
+	var ticketRequests []string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		switch request.URL.Path {
+		case "/api2/json/access/ticket":
+			_ = request.ParseForm()
+			ticketRequests = append(ticketRequests, request.PostForm.Get("password"))
+			if request.PostForm.Get("tfa-challenge") == "" {
+				_, _ = fmt.Fprint(writer, `{"data":{"username":"root@pam","ticket":"partial","CSRFPreventionToken":"csrf","NeedTFA":1}}`)
+				return
+			}
+			_, _ = fmt.Fprint(writer, `{"data":{"username":"root@pam","ticket":"full","CSRFPreventionToken":"csrf"}}`)
+		case "/api2/json/nodes":
+			cookie, cookieError := request.Cookie("PVEAuthCookie")
+			if cookieError != nil || cookie.Value != "full" {
+				writer.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_, _ = fmt.Fprint(writer, `{"data":[{"node":"pve-01"}]}`)
+		}
+	}))
+	defer server.Close()
+
+	client, newClientError := NewClient([]string{server.URL}, &AuthStruct{
+		Username:   "root@pam",
+		Password:   "hunter2",
+		TotpSecret: "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ",
+	}, &TlsOptions{FingerprintSha256: serverFingerprint(server)}, nil, nil, nil, nil)
+	assert.NoError(t, newClientError)
+
+	nodes, listNodesError := client.ListNodes(context.Background())
+
+	assert.NoError(t, listNodesError)
+	assert.Equal(t, "pve-01", nodes.Data[0].Node)
+	assert.Len(t, ticketRequests, 2)
+	assert.Equal(t, "hunter2", ticketRequests[0])
+	assert.Regexp(t, "^totp:\\d{6}$", ticketRequests[1])
+}
+
+func TestRetriedRequestSendsOneTicketCookie(t *testing.T) {
+	var sentCookies [][]*http.Cookie
+	server := httptest.NewTLSServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		switch request.URL.Path {
+		case "/api2/json/access/ticket":
+			_, _ = fmt.Fprint(writer, `{"data":{"username":"root@pam","ticket":"ticket","CSRFPreventionToken":"csrf"}}`)
+		case "/api2/json/nodes":
+			sentCookies = append(sentCookies, request.Cookies())
+			if len(sentCookies) == 1 {
+				writer.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			_, _ = fmt.Fprint(writer, `{"data":[{"node":"pve-01"}]}`)
+		}
+	}))
+	defer server.Close()
+
+	client, newClientError := NewClient([]string{server.URL}, &AuthStruct{
+		Username: "root@pam",
+		Password: "hunter2",
+	}, &TlsOptions{FingerprintSha256: serverFingerprint(server)}, &RetryPolicy{
+		MaxAttempts:    2,
+		InitialBackoff: time.Millisecond,
+	}, nil, nil, nil)
+	assert.NoError(t, newClientError)
+
+	_, listNodesError := client.ListNodes(context.Background())
+
+	assert.NoError(t, listNodesError)
+	assert.Len(t, sentCookies, 2)
+	for _, cookies := range sentCookies {
+		assert.Len(t, cookies, 1)
+		assert.Equal(t, "PVEAuthCookie", cookies[0].Name)
+	}
+}
+
+func TestLegacyTokenInUsernameUsesApiTokenHeader(t *testing.T) {
+	auth := AuthStruct{Username: "terraform@pve!ci", Password: "secret"}
+
+	assert.True(t, auth.UsesApiToken())
+	assert.Equal(t, "PVEAPIToken=terraform@pve!ci=secret", auth.apiTokenHeader())
+}
@@ -0,0 +1,209 @@
+package proxmox_client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+const (
+	// CassetteModeEnv selects record or replay, the cassette is disabled when it is not set
+	CassetteModeEnv = "PROXMOX_CASSETTE_MODE"
+	// CassetteFileEnv is the fixture file interactions are recorded to or replayed from
+	CassetteFileEnv = "PROXMOX_CASSETTE_FILE"
+
+	CassetteRecord = "record"
+	CassetteReplay = "replay"
+
+	redacted = "REDACTED"
+)
+
+// sensitiveFormFields are scrubbed from recorded request bodies
+var sensitiveFormFields = []string{"password", "new-password", "tfa-challenge", "cipassword"}
+
+// sensitiveJsonFieldRegex matches response fields that hold credentials or identify a certificate
+var sensitiveJsonFieldRegex = regexp.MustCompile(`"(ticket|CSRFPreventionToken|ssl_fingerprint|fingerprint)"\s*:\s*"[^"]*"`)
+
+type cassetteRequest struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	Query  string `json:"query,omitempty"`
+	Body   string `json:"body,omitempty"`
+}
+
+type cassetteResponse struct {
+	StatusCode int    `json:"status_code"`
+	Status     string `json:"status"`
+	Body       string `json:"body"`
+}
+
+type cassetteInteraction struct {
+	Request  cassetteRequest  `json:"request"`
+	Response cassetteResponse `json:"response"`
+	replayed bool
+}
+
+type cassetteFile struct {
+	// Comment describes where the interactions come from, e.g. that a fixture was written by hand
+	Comment      string                 `json:"comment,omitempty"`
+	Interactions []*cassetteInteraction `json:"interactions"`
+}
+
+// cassette
+/**
+ * @description records every request and response that passes through the client into a fixture file, or answers
+ * requests from such a file without contacting proxmox at all. Hosts, auth headers, passwords, tickets and certificate
+ * fingerprints are never written to the file, so recordings of a lab cluster can be committed as test fixtures.
+ */
+type cassette struct {
+	mode      string
+	path      string
+	next      http.RoundTripper
+	lock      sync.Mutex
+	recording cassetteFile
+}
+
+// newCassetteFromEnv wraps next in a cassette when PROXMOX_CASSETTE_MODE is set, otherwise next is returned as is
+func newCassetteFromEnv(next http.RoundTripper) (http.RoundTripper, error) {
+	mode := os.Getenv(CassetteModeEnv)
+	if mode == "" {
+		return next, nil
+	}
+
+	path := os.Getenv(CassetteFileEnv)
+	if path == "" {
+		return nil, errors.New(fmt.Sprintf("%s is set to %s but %s is not set", CassetteModeEnv, mode, CassetteFileEnv))
+	}
+
+	recorder := cassette{mode: mode, path: path, next: next}
+	switch mode {
+	case CassetteRecord:
+		return &recorder, nil
+	case CassetteReplay:
+		content, readError := os.ReadFile(path)
+		if readError != nil {
+			return nil, fmt.Errorf("failed to read cassette %s: %w", path, readError)
+		}
+		if unmarshallingError := json.Unmarshal(content, &recorder.recording); unmarshallingError != nil {
+			return nil, fmt.Errorf("failed to parse cassette %s: %w", path, unmarshallingError)
+		}
+		return &recorder, nil
+	}
+	return nil, errors.New(fmt.Sprintf("%s must be %s or %s, got %s", CassetteModeEnv, CassetteRecord, CassetteReplay, mode))
+}
+
+func (recorder *cassette) RoundTrip(req *http.Request) (*http.Response, error) {
+	recordedRequest, readError := newCassetteRequest(req)
+	if readError != nil {
+		return nil, readError
+	}
+
+	if recorder.mode == CassetteReplay {
+		return recorder.replay(req, recordedRequest)
+	}
+	return recorder.record(req, recordedRequest)
+}
+
+func (recorder *cassette) record(req *http.Request, recordedRequest cassetteRequest) (*http.Response, error) {
+	res, responseError := recorder.next.RoundTrip(req)
+	if responseError != nil {
+		return nil, responseError
+	}
+	defer res.Body.Close()
+
+	body, readError := io.ReadAll(res.Body)
+	if readError != nil {
+		return nil, readError
+	}
+	res.Body = io.NopCloser(bytes.NewReader(body))
+
+	recorder.lock.Lock()
+	defer recorder.lock.Unlock()
+
+	recorder.recording.Interactions = append(recorder.recording.Interactions, &cassetteInteraction{
+		Request: recordedRequest,
+		Response: cassetteResponse{
+			StatusCode: res.StatusCode,
+			Status:     res.Status,
+			Body:       sensitiveJsonFieldRegex.ReplaceAllString(string(body), fmt.Sprintf(`"$1":"%s"`, redacted)),
+		},
+	})
+
+	// the provider has no shutdown hook, so the file is rewritten after every interaction
+	content, marshallingError := json.MarshalIndent(recorder.recording, "", "  ")
+	if marshallingError != nil {
+		return nil, marshallingError
+	}
+	if writeError := os.WriteFile(recorder.path, content, 0o600); writeError != nil {
+		return nil, fmt.Errorf("failed to write cassette %s: %w", recorder.path, writeError)
+	}
+
+	return res, nil
+}
+
+// replay answers with the first recorded interaction for the same request that has not been replayed yet, so
+// repeated requests such as task status polls are answered in the order they were recorded
+func (recorder *cassette) replay(req *http.Request, recordedRequest cassetteRequest) (*http.Response, error) {
+	recorder.lock.Lock()
+	defer recorder.lock.Unlock()
+
+	for _, interaction := range recorder.recording.Interactions {
+		if interaction.replayed || interaction.Request != recordedRequest {
+			continue
+		}
+		interaction.replayed = true
+
+		return &http.Response{
+			StatusCode:    interaction.Response.StatusCode,
+			Status:        interaction.Response.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        http.Header{"Content-Type": []string{"application/json;charset=UTF-8"}},
+			Body:          io.NopCloser(strings.NewReader(interaction.Response.Body)),
+			ContentLength: int64(len(interaction.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+
+	return nil, errors.New(fmt.Sprintf("cassette %s has no recorded response left for %s %s", recorder.path, req.Method, req.URL.Path))
+}
+
+// newCassetteRequest reads the parts of the request that identify it, the body is restored so it can still be sent
+func newCassetteRequest(req *http.Request) (cassetteRequest, error) {
+	recordedRequest := cassetteRequest{Method: req.Method, Path: req.URL.Path, Query: req.URL.RawQuery}
+	if req.Body == nil {
+		return recordedRequest, nil
+	}
+
+	body, readError := io.ReadAll(req.Body)
+	if readError != nil {
+		return recordedRequest, readError
+	}
+	_ = req.Body.Close()
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	recordedRequest.Body = scrubFormBody(string(body))
+	return recordedRequest, nil
+}
+
+func scrubFormBody(body string) string {
+	values, parseError := url.ParseQuery(body)
+	if parseError != nil {
+		return body
+	}
+	for _, field := range sensitiveFormFields {
+		if values.Has(field) {
+			values.Set(field, redacted)
+		}
+	}
+	return values.Encode()
+}
@@ -0,0 +1,62 @@
+package proxmox_client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCassetteRecordsSanitizedInteractionsAndReplaysThem(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		switch request.URL.Path {
+		case "/api2/json/access/ticket":
+			_, _ = fmt.Fprint(writer, `{"data":{"username":"root@pam","ticket":"PVE:root@pam:secret-ticket","CSRFPreventionToken":"secret-csrf"}}`)
+		case "/api2/json/nodes":
+			_, _ = fmt.Fprint(writer, `{"data":[{"node":"pve-01","ssl_fingerprint":"AA:BB:CC"}]}`)
+		}
+	}))
+	defer server.Close()
+
+	cassettePath := filepath.Join(t.TempDir(), "cassette.json")
+	auth := AuthStruct{Username: "root@pam", Password: "hunter2"}
+	t.Setenv(CassetteFileEnv, cassettePath)
+
+	t.Setenv(CassetteModeEnv, CassetteRecord)
+	recordingClient, newClientError := NewClient([]string{server.URL}, &auth, &TlsOptions{FingerprintSha256: serverFingerprint(server)}, nil, nil, nil, nil)
+	assert.NoError(t, newClientError)
+	recordedNodes, recordError := recordingClient.ListNodes(context.Background())
+	assert.NoError(t, recordError)
+	assert.Equal(t, "AA:BB:CC", recordedNodes.Data[0].SslFingerprint)
+
+	recording, readError := os.ReadFile(cassettePath)
+	assert.NoError(t, readError)
+	for _, secret := range []string{"hunter2", "secret-ticket", "secret-csrf", "AA:BB:CC", server.Listener.Addr().String()} {
+		assert.NotContains(t, string(recording), secret)
+	}
+
+	server.Close()
+	t.Setenv(CassetteModeEnv, CassetteReplay)
+	replayingClient, newClientError := NewClient([]string{server.URL}, &auth, nil, nil, nil, nil, nil)
+	assert.NoError(t, newClientError)
+	replayedNodes, replayError := replayingClient.ListNodes(context.Background())
+	assert.NoError(t, replayError)
+	assert.Equal(t, "pve-01", replayedNodes.Data[0].Node)
+
+	_, exhaustedError := replayingClient.ListNodes(context.Background())
+	assert.ErrorContains(t, exhaustedError, "no recorded response left")
+}
+
+func TestCassetteModeMustBeKnown(t *testing.T) {
+	t.Setenv(CassetteModeEnv, "rewind")
+	t.Setenv(CassetteFileEnv, filepath.Join(t.TempDir(), "cassette.json"))
+
+	_, newClientError := NewClient([]string{"pve.invalid"}, &AuthStruct{TokenId: "root@pam!ci", TokenSecret: "secret"}, nil, nil, nil, nil, nil)
+
+	assert.ErrorContains(t, newClientError, "must be record or replay")
+}
@@ -2,12 +2,12 @@ package proxmox_client
 
 import (
 	"context"
-	"crypto/tls"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
-	"strings"
+	"sync"
 	proxmoxTypes "terraform-provider-proxmox/types"
 	"time"
 
@@ -19,79 +19,121 @@ const FormUrlEncoded = "application/x-www-form-urlencoded"
 type ProxmoxClient interface {
 	DoRequest(req *http.Request, contentType string) ([]byte, error)
 	DoRequestWithResponseStatus(req *http.Request, expectedResponseStatus int, contentType string) ([]byte, error)
-	GetTaskStatusByUpid(nodeName *string, upid *string) (*proxmoxTypes.TaskStatus, error)
-	UpdateVm(vmCreationBody url.Values, nodeName *string, vmId *string) (*string, error)
-	CreateVm(vmCreationBody url.Values, nodeName string) (*string, error)
-	GetVmById(nodeName *string, vmId *string) (*proxmoxTypes.QemuResponse, error)
-	DeleteVmById(nodeName *string, vmId *string) (*string, error)
-	ResizeVmDisk(diskResizeRequest url.Values, nodeName *string, vmId *string) (*string, error)
-	GetVmStatus(nodeName *string, vmId *string) (string, error)
-	StartVm(nodeName *string, vmId *string) (*string, error)
-	ShutdownVm(nodeName *string, vmId *string) (*string, error)
-	ListNodes() (*proxmoxTypes.NodeListResponse, error)
-	GetNodeNetworkConfig(nodeName string) (*proxmoxTypes.NodeNetworkConfig, error)
-	CreateSdnZone(sdnZoneCreationBody url.Values) error
-	GetSdnZone(zone string) (*proxmoxTypes.SdnZoneResponse, error)
-	DeleteSdnZone(zone string) error
-	UpdateSdnZone(sdnZoneCreationBody url.Values) error
-	MoveVmDisk(diskName *string, nodeName *string, vmId *string, newStorageName *string) (*string, error)
-	MigrateVm(currentNode *string, newNode *string, vmId *string) (*string, error)
-	ListStorageDestinations(nodeName *string) (*proxmoxTypes.NodeStorageResponse, error)
-	ListStorageContent(nodeName *string, storageName *string) (*proxmoxTypes.QemuImageResponse, error)
+	GetTaskStatusByUpid(ctx context.Context, nodeName *string, upid *string) (*proxmoxTypes.TaskStatus, error)
+	GetTaskLog(ctx context.Context, nodeName *string, upid *string, start int, limit int) (*proxmoxTypes.TaskLogResponse, error)
+	UpdateVm(ctx context.Context, vmCreationBody url.Values, nodeName *string, vmId *string) (*string, error)
+	CreateVm(ctx context.Context, vmCreationBody url.Values, nodeName string) (*string, error)
+	GetVmById(ctx context.Context, nodeName *string, vmId *string) (*proxmoxTypes.QemuResponse, error)
+	DeleteVmById(ctx context.Context, nodeName *string, vmId *string) (*string, error)
+	ResizeVmDisk(ctx context.Context, diskResizeRequest url.Values, nodeName *string, vmId *string) (*string, error)
+	GetVmStatus(ctx context.Context, nodeName *string, vmId *string) (string, error)
+	GetVmPending(ctx context.Context, nodeName *string, vmId *string) (*proxmoxTypes.VmPendingResponse, error)
+	StartVm(ctx context.Context, nodeName *string, vmId *string) (*string, error)
+	ShutdownVm(ctx context.Context, nodeName *string, vmId *string) (*string, error)
+	ListNodes(ctx context.Context) (*proxmoxTypes.NodeListResponse, error)
+	GetNodeNetworkConfig(ctx context.Context, nodeName string) (*proxmoxTypes.NodeNetworkConfig, error)
+	CreateSdnZone(ctx context.Context, sdnZoneCreationBody url.Values) error
+	GetSdnZone(ctx context.Context, zone string) (*proxmoxTypes.SdnZoneResponse, error)
+	DeleteSdnZone(ctx context.Context, zone string) error
+	UpdateSdnZone(ctx context.Context, sdnZoneCreationBody url.Values) error
+	MoveVmDisk(ctx context.Context, diskName *string, nodeName *string, vmId *string, newStorageName *string) (*string, error)
+	MigrateVm(ctx context.Context, currentNode *string, newNode *string, vmId *string) (*string, error)
+	CloneVm(ctx context.Context, cloneRequest url.Values, nodeName *string, vmId *string) (*string, error)
+	ListClusterVms(ctx context.Context) (*proxmoxTypes.ClusterVmListResponse, error)
+	ConvertVmToTemplate(ctx context.Context, nodeName *string, vmId *string, disk *string) (*string, error)
+	RebootVm(ctx context.Context, nodeName *string, vmId *string) (*string, error)
+	GetNextVmId(ctx context.Context, vmId *string) (*string, error)
+	ReserveVmId(ctx context.Context) (*string, func(), error)
+	ListStorageDestinations(ctx context.Context, nodeName *string) (*proxmoxTypes.NodeStorageResponse, error)
+	ListStorageContent(ctx context.Context, nodeName *string, storageName *string) (*proxmoxTypes.QemuImageResponse, error)
+	UploadStorageFile(ctx context.Context, nodeName *string, storageName *string, content string, fileName string, data []byte) (*string, error)
+	DeleteStorageVolume(ctx context.Context, nodeName *string, storageName *string, volid *string) (*string, error)
+	GetStorageConfig(ctx context.Context, storageName *string) (*proxmoxTypes.StorageConfigResponse, error)
+	GetClusterStatus(ctx context.Context) (*proxmoxTypes.ClusterStatusResponse, error)
+	WriteNodeFile(ctx context.Context, nodeName string, filePath string, data []byte) error
+	ChecksumNodeFile(ctx context.Context, nodeName string, filePath string) (string, error)
+	AcquireTaskSlot(ctx context.Context, nodeName string) (func(), error)
+	DiscoverClusterEndpoints(ctx context.Context) error
 }
 
 type Client struct {
-	HostURL               string
-	HTTPClient            *http.Client
-	Token                 string
-	Auth                  AuthStruct
-	EnableTLSVerification bool
-	Context               context.Context
+	HostURL     string
+	HTTPClient  *http.Client
+	Auth        AuthStruct
+	RetryPolicy RetryPolicy
+	ticket      *authTicket
+	ticketLock  sync.Mutex
+	limiter     *requestLimiter
+	endpoints   *endpointPool
+	vmIds       *vmIdReservations
+	ssh         *SshOptions
+
+	pinnedFingerprints *fingerprintSet
 }
 
-type AuthStruct struct {
-	Username string `json:"username"`
-	Password string `json:"password"`
-}
-
-// NewClient -
-func NewClient(host *string, username *string, password *string, verifyTls *bool, ctx context.Context) ProxmoxClient {
-	if host == nil {
+// NewClient - hosts are api endpoints of the same cluster, requests fail over between them in order. Without
+// sshOptions everything that needs a shell on the nodes fails with ErrSshNotConfigured.
+func NewClient(hosts []string, auth *AuthStruct, tlsOptions *TlsOptions, retryPolicy *RetryPolicy, limitOptions *LimitOptions, vmIdRange *VmIdRange, sshOptions *SshOptions) (ProxmoxClient, error) {
+	if len(hosts) == 0 {
 		panic("Host Not Provided!!!!")
 	}
 
-	if username == nil {
-		panic("Username Not Provided!!!!")
+	if auth == nil {
+		panic("Credentials Not Provided!!!!")
+	}
+
+	if tlsOptions == nil {
+		tlsOptions = &TlsOptions{VerifyTls: true}
+	}
+
+	if retryPolicy == nil {
+		defaultRetryPolicy := DefaultRetryPolicy()
+		retryPolicy = &defaultRetryPolicy
 	}
 
-	if password == nil {
-		panic("Password Not Provided!!!!")
+	if limitOptions == nil {
+		limitOptions = &LimitOptions{}
 	}
 
-	localTlsVerify := true
+	if vmIdRange == nil {
+		vmIdRange = &VmIdRange{}
+	}
+
+	vmIds, vmIdRangeError := newVmIdReservations(*vmIdRange)
+	if vmIdRangeError != nil {
+		return nil, vmIdRangeError
+	}
 
-	if verifyTls != nil {
-		localTlsVerify = *verifyTls
+	transport, pinnedFingerprints, transportError := newHttpTransport(tlsOptions)
+	if transportError != nil {
+		return nil, transportError
 	}
 
-	localHost := fmt.Sprintf("%s/api2/json", *host)
-	if !strings.Contains(*host, "https") {
-		localHost = fmt.Sprintf("https://%s/api2/json", *host)
+	roundTripper, cassetteError := newCassetteFromEnv(transport)
+	if cassetteError != nil {
+		return nil, cassetteError
+	}
+
+	endpoints, endpointsError := newEndpointPool(hosts)
+	if endpointsError != nil {
+		return nil, endpointsError
 	}
 
 	c := Client{
-		HTTPClient: &http.Client{Timeout: 10 * time.Second},
-		// Default Hashicups URL
-		HostURL: localHost,
-		Auth: AuthStruct{
-			Username: *username,
-			Password: *password,
-		},
-		EnableTLSVerification: localTlsVerify,
-		Context:               ctx,
-	}
-
-	return &c
+		// no overall timeout, uploads and slow calls are bounded by the deadline of the request context instead
+		HTTPClient:  &http.Client{Transport: roundTripper},
+		HostURL:     normalizeHostUrl(hosts[0]),
+		Auth:        *auth,
+		RetryPolicy: *retryPolicy,
+		limiter:     newRequestLimiter(*limitOptions),
+		endpoints:   endpoints,
+		vmIds:       vmIds,
+		ssh:         sshOptions,
+
+		pinnedFingerprints: pinnedFingerprints,
+	}
+
+	return &c, nil
 }
 
 func (c *Client) DoRequest(req *http.Request, contentType string) ([]byte, error) {
@@ -99,13 +141,79 @@ func (c *Client) DoRequest(req *http.Request, contentType string) ([]byte, error
 }
 
 func (c *Client) DoRequestWithResponseStatus(req *http.Request, expectedResponseStatus int, contentType string) ([]byte, error) {
-	tflog.Debug(c.Context, fmt.Sprintf("Making %s request to %s", req.Method, req.URL))
-	req.Header.Set("Authorization", fmt.Sprintf("PVEAPIToken=%s=%s", c.Auth.Username, c.Auth.Password))
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("Content-Type", contentType)
-	if !c.EnableTLSVerification {
-		http.DefaultTransport.(*http.Transport).TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+
+	for attempt := 1; ; attempt++ {
+		body, responseError := c.sendRequest(req, expectedResponseStatus)
+		if responseError == nil {
+			return body, nil
+		}
+
+		if attempt >= c.RetryPolicy.MaxAttempts || !IsRetryable(req.Method, responseError) {
+			return body, responseError
+		}
+
+		backoff := c.RetryPolicy.Backoff(attempt)
+		tflog.Warn(req.Context(), fmt.Sprintf("%s request to %s failed on attempt %d of %d, retrying in %s: %s", req.Method, req.URL.Path, attempt, c.RetryPolicy.MaxAttempts, backoff, responseError.Error()))
+		select {
+		case <-req.Context().Done():
+			return body, errors.Join(responseError, req.Context().Err())
+		case <-time.After(backoff):
+		}
+
+		if req.GetBody != nil {
+			requestBody, getBodyError := req.GetBody()
+			if getBodyError != nil {
+				return nil, getBodyError
+			}
+			req.Body = requestBody
+		}
+	}
+}
+
+// sendRequest sends the request to the active endpoint, moving on to the next endpoint of the cluster when it cannot be reached
+func (c *Client) sendRequest(req *http.Request, expectedResponseStatus int) ([]byte, error) {
+	endpointCount := c.endpoints.size()
+	for attempt := 1; ; attempt++ {
+		target := c.endpoints.current()
+		target.applyTo(req)
+
+		body, responseError := c.sendToEndpoint(req, expectedResponseStatus)
+		if responseError == nil || !isEndpointFailure(req.Method, responseError) {
+			c.endpoints.markHealthy(target)
+			return body, responseError
+		}
+
+		next := c.failover(req.Context(), target)
+		if attempt >= endpointCount {
+			return body, responseError
+		}
+		tflog.Warn(req.Context(), fmt.Sprintf("proxmox endpoint %s is unavailable, failing over to %s: %s", target, next, responseError.Error()))
+
+		if req.GetBody != nil {
+			requestBody, getBodyError := req.GetBody()
+			if getBodyError != nil {
+				return nil, getBodyError
+			}
+			req.Body = requestBody
+		}
+	}
+}
+
+func (c *Client) sendToEndpoint(req *http.Request, expectedResponseStatus int) ([]byte, error) {
+	ctx := req.Context()
+	tflog.Debug(ctx, fmt.Sprintf("Making %s request to %s", req.Method, req.URL))
+	authenticationError := c.authenticateRequest(req)
+	if authenticationError != nil {
+		return nil, authenticationError
+	}
+	releaseRequestSlot, limiterError := c.limiter.acquireRequestSlot(ctx)
+	if limiterError != nil {
+		return nil, limiterError
 	}
+	defer releaseRequestSlot()
+
 	res, err := c.HTTPClient.Do(req)
 	if err != nil {
 		return nil, err
@@ -116,11 +224,21 @@ func (c *Client) DoRequestWithResponseStatus(req *http.Request, expectedResponse
 		return nil, err
 	}
 
-	tflog.Debug(c.Context, fmt.Sprintf("status code was %d for url %s", res.StatusCode, req.URL.Path))
+	tflog.Debug(ctx, fmt.Sprintf("status code was %d for url %s", res.StatusCode, req.URL.Path))
 	if res.StatusCode != expectedResponseStatus {
-		tflog.Error(c.Context, fmt.Sprintf("statusCode: %d, status:%s, body: %s", res.StatusCode, res.Status, body))
-		return body, fmt.Errorf("status: %s, body: %s", res.Status, body)
+		tflog.Error(ctx, fmt.Sprintf("statusCode: %d, status:%s, body: %s", res.StatusCode, res.Status, body))
+		return body, newApiError(req, res, body)
 	}
 
 	return body, err
 }
+
+// AcquireTaskSlot
+/**
+ * @description waits until another long-running task (clone, disk move, migration) may be started on the node.
+ * Call the returned function once the task has completed to free the slot. Fails when the context is cancelled while waiting.
+ */
+func (c *Client) AcquireTaskSlot(ctx context.Context, nodeName string) (func(), error) {
+	tflog.Debug(ctx, fmt.Sprintf("waiting for a free task slot on node %s", nodeName))
+	return c.limiter.acquireTaskSlot(ctx, nodeName)
+}
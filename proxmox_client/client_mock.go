@@ -0,0 +1,595 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: proxmox_client/client.go
+//
+// Generated by this command:
+//
+//	mockgen -source=proxmox_client/client.go -destination=proxmox_client/client_mock.go -package=proxmox_client -self_package=terraform-provider-proxmox/proxmox_client
+//
+
+// Package proxmox_client is a generated GoMock package.
+package proxmox_client
+
+import (
+	context "context"
+	http "net/http"
+	url "net/url"
+	reflect "reflect"
+	types "terraform-provider-proxmox/types"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockProxmoxClient is a mock of ProxmoxClient interface.
+type MockProxmoxClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockProxmoxClientMockRecorder
+	isgomock struct{}
+}
+
+// MockProxmoxClientMockRecorder is the mock recorder for MockProxmoxClient.
+type MockProxmoxClientMockRecorder struct {
+	mock *MockProxmoxClient
+}
+
+// NewMockProxmoxClient creates a new mock instance.
+func NewMockProxmoxClient(ctrl *gomock.Controller) *MockProxmoxClient {
+	mock := &MockProxmoxClient{ctrl: ctrl}
+	mock.recorder = &MockProxmoxClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockProxmoxClient) EXPECT() *MockProxmoxClientMockRecorder {
+	return m.recorder
+}
+
+// AcquireTaskSlot mocks base method.
+func (m *MockProxmoxClient) AcquireTaskSlot(ctx context.Context, nodeName string) (func(), error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AcquireTaskSlot", ctx, nodeName)
+	ret0, _ := ret[0].(func())
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AcquireTaskSlot indicates an expected call of AcquireTaskSlot.
+func (mr *MockProxmoxClientMockRecorder) AcquireTaskSlot(ctx, nodeName any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcquireTaskSlot", reflect.TypeOf((*MockProxmoxClient)(nil).AcquireTaskSlot), ctx, nodeName)
+}
+
+// ChecksumNodeFile mocks base method.
+func (m *MockProxmoxClient) ChecksumNodeFile(ctx context.Context, nodeName, filePath string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChecksumNodeFile", ctx, nodeName, filePath)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChecksumNodeFile indicates an expected call of ChecksumNodeFile.
+func (mr *MockProxmoxClientMockRecorder) ChecksumNodeFile(ctx, nodeName, filePath any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChecksumNodeFile", reflect.TypeOf((*MockProxmoxClient)(nil).ChecksumNodeFile), ctx, nodeName, filePath)
+}
+
+// CloneVm mocks base method.
+func (m *MockProxmoxClient) CloneVm(ctx context.Context, cloneRequest url.Values, nodeName, vmId *string) (*string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloneVm", ctx, cloneRequest, nodeName, vmId)
+	ret0, _ := ret[0].(*string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CloneVm indicates an expected call of CloneVm.
+func (mr *MockProxmoxClientMockRecorder) CloneVm(ctx, cloneRequest, nodeName, vmId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloneVm", reflect.TypeOf((*MockProxmoxClient)(nil).CloneVm), ctx, cloneRequest, nodeName, vmId)
+}
+
+// ConvertVmToTemplate mocks base method.
+func (m *MockProxmoxClient) ConvertVmToTemplate(ctx context.Context, nodeName, vmId, disk *string) (*string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConvertVmToTemplate", ctx, nodeName, vmId, disk)
+	ret0, _ := ret[0].(*string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConvertVmToTemplate indicates an expected call of ConvertVmToTemplate.
+func (mr *MockProxmoxClientMockRecorder) ConvertVmToTemplate(ctx, nodeName, vmId, disk any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConvertVmToTemplate", reflect.TypeOf((*MockProxmoxClient)(nil).ConvertVmToTemplate), ctx, nodeName, vmId, disk)
+}
+
+// CreateSdnZone mocks base method.
+func (m *MockProxmoxClient) CreateSdnZone(ctx context.Context, sdnZoneCreationBody url.Values) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSdnZone", ctx, sdnZoneCreationBody)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateSdnZone indicates an expected call of CreateSdnZone.
+func (mr *MockProxmoxClientMockRecorder) CreateSdnZone(ctx, sdnZoneCreationBody any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSdnZone", reflect.TypeOf((*MockProxmoxClient)(nil).CreateSdnZone), ctx, sdnZoneCreationBody)
+}
+
+// CreateVm mocks base method.
+func (m *MockProxmoxClient) CreateVm(ctx context.Context, vmCreationBody url.Values, nodeName string) (*string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateVm", ctx, vmCreationBody, nodeName)
+	ret0, _ := ret[0].(*string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateVm indicates an expected call of CreateVm.
+func (mr *MockProxmoxClientMockRecorder) CreateVm(ctx, vmCreationBody, nodeName any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateVm", reflect.TypeOf((*MockProxmoxClient)(nil).CreateVm), ctx, vmCreationBody, nodeName)
+}
+
+// DeleteSdnZone mocks base method.
+func (m *MockProxmoxClient) DeleteSdnZone(ctx context.Context, zone string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSdnZone", ctx, zone)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSdnZone indicates an expected call of DeleteSdnZone.
+func (mr *MockProxmoxClientMockRecorder) DeleteSdnZone(ctx, zone any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSdnZone", reflect.TypeOf((*MockProxmoxClient)(nil).DeleteSdnZone), ctx, zone)
+}
+
+// DeleteStorageVolume mocks base method.
+func (m *MockProxmoxClient) DeleteStorageVolume(ctx context.Context, nodeName, storageName, volid *string) (*string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteStorageVolume", ctx, nodeName, storageName, volid)
+	ret0, _ := ret[0].(*string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteStorageVolume indicates an expected call of DeleteStorageVolume.
+func (mr *MockProxmoxClientMockRecorder) DeleteStorageVolume(ctx, nodeName, storageName, volid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteStorageVolume", reflect.TypeOf((*MockProxmoxClient)(nil).DeleteStorageVolume), ctx, nodeName, storageName, volid)
+}
+
+// DeleteVmById mocks base method.
+func (m *MockProxmoxClient) DeleteVmById(ctx context.Context, nodeName, vmId *string) (*string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteVmById", ctx, nodeName, vmId)
+	ret0, _ := ret[0].(*string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteVmById indicates an expected call of DeleteVmById.
+func (mr *MockProxmoxClientMockRecorder) DeleteVmById(ctx, nodeName, vmId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteVmById", reflect.TypeOf((*MockProxmoxClient)(nil).DeleteVmById), ctx, nodeName, vmId)
+}
+
+// DiscoverClusterEndpoints mocks base method.
+func (m *MockProxmoxClient) DiscoverClusterEndpoints(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DiscoverClusterEndpoints", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DiscoverClusterEndpoints indicates an expected call of DiscoverClusterEndpoints.
+func (mr *MockProxmoxClientMockRecorder) DiscoverClusterEndpoints(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DiscoverClusterEndpoints", reflect.TypeOf((*MockProxmoxClient)(nil).DiscoverClusterEndpoints), ctx)
+}
+
+// DoRequest mocks base method.
+func (m *MockProxmoxClient) DoRequest(req *http.Request, contentType string) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DoRequest", req, contentType)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DoRequest indicates an expected call of DoRequest.
+func (mr *MockProxmoxClientMockRecorder) DoRequest(req, contentType any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoRequest", reflect.TypeOf((*MockProxmoxClient)(nil).DoRequest), req, contentType)
+}
+
+// DoRequestWithResponseStatus mocks base method.
+func (m *MockProxmoxClient) DoRequestWithResponseStatus(req *http.Request, expectedResponseStatus int, contentType string) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DoRequestWithResponseStatus", req, expectedResponseStatus, contentType)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DoRequestWithResponseStatus indicates an expected call of DoRequestWithResponseStatus.
+func (mr *MockProxmoxClientMockRecorder) DoRequestWithResponseStatus(req, expectedResponseStatus, contentType any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoRequestWithResponseStatus", reflect.TypeOf((*MockProxmoxClient)(nil).DoRequestWithResponseStatus), req, expectedResponseStatus, contentType)
+}
+
+// GetClusterStatus mocks base method.
+func (m *MockProxmoxClient) GetClusterStatus(ctx context.Context) (*types.ClusterStatusResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetClusterStatus", ctx)
+	ret0, _ := ret[0].(*types.ClusterStatusResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetClusterStatus indicates an expected call of GetClusterStatus.
+func (mr *MockProxmoxClientMockRecorder) GetClusterStatus(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetClusterStatus", reflect.TypeOf((*MockProxmoxClient)(nil).GetClusterStatus), ctx)
+}
+
+// GetNextVmId mocks base method.
+func (m *MockProxmoxClient) GetNextVmId(ctx context.Context, vmId *string) (*string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNextVmId", ctx, vmId)
+	ret0, _ := ret[0].(*string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNextVmId indicates an expected call of GetNextVmId.
+func (mr *MockProxmoxClientMockRecorder) GetNextVmId(ctx, vmId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNextVmId", reflect.TypeOf((*MockProxmoxClient)(nil).GetNextVmId), ctx, vmId)
+}
+
+// GetNodeNetworkConfig mocks base method.
+func (m *MockProxmoxClient) GetNodeNetworkConfig(ctx context.Context, nodeName string) (*types.NodeNetworkConfig, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNodeNetworkConfig", ctx, nodeName)
+	ret0, _ := ret[0].(*types.NodeNetworkConfig)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNodeNetworkConfig indicates an expected call of GetNodeNetworkConfig.
+func (mr *MockProxmoxClientMockRecorder) GetNodeNetworkConfig(ctx, nodeName any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNodeNetworkConfig", reflect.TypeOf((*MockProxmoxClient)(nil).GetNodeNetworkConfig), ctx, nodeName)
+}
+
+// GetSdnZone mocks base method.
+func (m *MockProxmoxClient) GetSdnZone(ctx context.Context, zone string) (*types.SdnZoneResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSdnZone", ctx, zone)
+	ret0, _ := ret[0].(*types.SdnZoneResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSdnZone indicates an expected call of GetSdnZone.
+func (mr *MockProxmoxClientMockRecorder) GetSdnZone(ctx, zone any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSdnZone", reflect.TypeOf((*MockProxmoxClient)(nil).GetSdnZone), ctx, zone)
+}
+
+// GetStorageConfig mocks base method.
+func (m *MockProxmoxClient) GetStorageConfig(ctx context.Context, storageName *string) (*types.StorageConfigResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStorageConfig", ctx, storageName)
+	ret0, _ := ret[0].(*types.StorageConfigResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStorageConfig indicates an expected call of GetStorageConfig.
+func (mr *MockProxmoxClientMockRecorder) GetStorageConfig(ctx, storageName any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStorageConfig", reflect.TypeOf((*MockProxmoxClient)(nil).GetStorageConfig), ctx, storageName)
+}
+
+// GetTaskLog mocks base method.
+func (m *MockProxmoxClient) GetTaskLog(ctx context.Context, nodeName, upid *string, start, limit int) (*types.TaskLogResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTaskLog", ctx, nodeName, upid, start, limit)
+	ret0, _ := ret[0].(*types.TaskLogResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTaskLog indicates an expected call of GetTaskLog.
+func (mr *MockProxmoxClientMockRecorder) GetTaskLog(ctx, nodeName, upid, start, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTaskLog", reflect.TypeOf((*MockProxmoxClient)(nil).GetTaskLog), ctx, nodeName, upid, start, limit)
+}
+
+// GetTaskStatusByUpid mocks base method.
+func (m *MockProxmoxClient) GetTaskStatusByUpid(ctx context.Context, nodeName, upid *string) (*types.TaskStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTaskStatusByUpid", ctx, nodeName, upid)
+	ret0, _ := ret[0].(*types.TaskStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTaskStatusByUpid indicates an expected call of GetTaskStatusByUpid.
+func (mr *MockProxmoxClientMockRecorder) GetTaskStatusByUpid(ctx, nodeName, upid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTaskStatusByUpid", reflect.TypeOf((*MockProxmoxClient)(nil).GetTaskStatusByUpid), ctx, nodeName, upid)
+}
+
+// GetVmById mocks base method.
+func (m *MockProxmoxClient) GetVmById(ctx context.Context, nodeName, vmId *string) (*types.QemuResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetVmById", ctx, nodeName, vmId)
+	ret0, _ := ret[0].(*types.QemuResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetVmById indicates an expected call of GetVmById.
+func (mr *MockProxmoxClientMockRecorder) GetVmById(ctx, nodeName, vmId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVmById", reflect.TypeOf((*MockProxmoxClient)(nil).GetVmById), ctx, nodeName, vmId)
+}
+
+// GetVmPending mocks base method.
+func (m *MockProxmoxClient) GetVmPending(ctx context.Context, nodeName, vmId *string) (*types.VmPendingResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetVmPending", ctx, nodeName, vmId)
+	ret0, _ := ret[0].(*types.VmPendingResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetVmPending indicates an expected call of GetVmPending.
+func (mr *MockProxmoxClientMockRecorder) GetVmPending(ctx, nodeName, vmId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVmPending", reflect.TypeOf((*MockProxmoxClient)(nil).GetVmPending), ctx, nodeName, vmId)
+}
+
+// GetVmStatus mocks base method.
+func (m *MockProxmoxClient) GetVmStatus(ctx context.Context, nodeName, vmId *string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetVmStatus", ctx, nodeName, vmId)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetVmStatus indicates an expected call of GetVmStatus.
+func (mr *MockProxmoxClientMockRecorder) GetVmStatus(ctx, nodeName, vmId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVmStatus", reflect.TypeOf((*MockProxmoxClient)(nil).GetVmStatus), ctx, nodeName, vmId)
+}
+
+// ListClusterVms mocks base method.
+func (m *MockProxmoxClient) ListClusterVms(ctx context.Context) (*types.ClusterVmListResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListClusterVms", ctx)
+	ret0, _ := ret[0].(*types.ClusterVmListResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListClusterVms indicates an expected call of ListClusterVms.
+func (mr *MockProxmoxClientMockRecorder) ListClusterVms(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListClusterVms", reflect.TypeOf((*MockProxmoxClient)(nil).ListClusterVms), ctx)
+}
+
+// ListNodes mocks base method.
+func (m *MockProxmoxClient) ListNodes(ctx context.Context) (*types.NodeListResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListNodes", ctx)
+	ret0, _ := ret[0].(*types.NodeListResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListNodes indicates an expected call of ListNodes.
+func (mr *MockProxmoxClientMockRecorder) ListNodes(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListNodes", reflect.TypeOf((*MockProxmoxClient)(nil).ListNodes), ctx)
+}
+
+// ListStorageContent mocks base method.
+func (m *MockProxmoxClient) ListStorageContent(ctx context.Context, nodeName, storageName *string) (*types.QemuImageResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListStorageContent", ctx, nodeName, storageName)
+	ret0, _ := ret[0].(*types.QemuImageResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListStorageContent indicates an expected call of ListStorageContent.
+func (mr *MockProxmoxClientMockRecorder) ListStorageContent(ctx, nodeName, storageName any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListStorageContent", reflect.TypeOf((*MockProxmoxClient)(nil).ListStorageContent), ctx, nodeName, storageName)
+}
+
+// ListStorageDestinations mocks base method.
+func (m *MockProxmoxClient) ListStorageDestinations(ctx context.Context, nodeName *string) (*types.NodeStorageResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListStorageDestinations", ctx, nodeName)
+	ret0, _ := ret[0].(*types.NodeStorageResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListStorageDestinations indicates an expected call of ListStorageDestinations.
+func (mr *MockProxmoxClientMockRecorder) ListStorageDestinations(ctx, nodeName any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListStorageDestinations", reflect.TypeOf((*MockProxmoxClient)(nil).ListStorageDestinations), ctx, nodeName)
+}
+
+// MigrateVm mocks base method.
+func (m *MockProxmoxClient) MigrateVm(ctx context.Context, currentNode, newNode, vmId *string) (*string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MigrateVm", ctx, currentNode, newNode, vmId)
+	ret0, _ := ret[0].(*string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MigrateVm indicates an expected call of MigrateVm.
+func (mr *MockProxmoxClientMockRecorder) MigrateVm(ctx, currentNode, newNode, vmId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MigrateVm", reflect.TypeOf((*MockProxmoxClient)(nil).MigrateVm), ctx, currentNode, newNode, vmId)
+}
+
+// MoveVmDisk mocks base method.
+func (m *MockProxmoxClient) MoveVmDisk(ctx context.Context, diskName, nodeName, vmId, newStorageName *string) (*string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MoveVmDisk", ctx, diskName, nodeName, vmId, newStorageName)
+	ret0, _ := ret[0].(*string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MoveVmDisk indicates an expected call of MoveVmDisk.
+func (mr *MockProxmoxClientMockRecorder) MoveVmDisk(ctx, diskName, nodeName, vmId, newStorageName any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MoveVmDisk", reflect.TypeOf((*MockProxmoxClient)(nil).MoveVmDisk), ctx, diskName, nodeName, vmId, newStorageName)
+}
+
+// RebootVm mocks base method.
+func (m *MockProxmoxClient) RebootVm(ctx context.Context, nodeName, vmId *string) (*string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RebootVm", ctx, nodeName, vmId)
+	ret0, _ := ret[0].(*string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RebootVm indicates an expected call of RebootVm.
+func (mr *MockProxmoxClientMockRecorder) RebootVm(ctx, nodeName, vmId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RebootVm", reflect.TypeOf((*MockProxmoxClient)(nil).RebootVm), ctx, nodeName, vmId)
+}
+
+// ReserveVmId mocks base method.
+func (m *MockProxmoxClient) ReserveVmId(ctx context.Context) (*string, func(), error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReserveVmId", ctx)
+	ret0, _ := ret[0].(*string)
+	ret1, _ := ret[1].(func())
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ReserveVmId indicates an expected call of ReserveVmId.
+func (mr *MockProxmoxClientMockRecorder) ReserveVmId(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReserveVmId", reflect.TypeOf((*MockProxmoxClient)(nil).ReserveVmId), ctx)
+}
+
+// ResizeVmDisk mocks base method.
+func (m *MockProxmoxClient) ResizeVmDisk(ctx context.Context, diskResizeRequest url.Values, nodeName, vmId *string) (*string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResizeVmDisk", ctx, diskResizeRequest, nodeName, vmId)
+	ret0, _ := ret[0].(*string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResizeVmDisk indicates an expected call of ResizeVmDisk.
+func (mr *MockProxmoxClientMockRecorder) ResizeVmDisk(ctx, diskResizeRequest, nodeName, vmId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResizeVmDisk", reflect.TypeOf((*MockProxmoxClient)(nil).ResizeVmDisk), ctx, diskResizeRequest, nodeName, vmId)
+}
+
+// ShutdownVm mocks base method.
+func (m *MockProxmoxClient) ShutdownVm(ctx context.Context, nodeName, vmId *string) (*string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ShutdownVm", ctx, nodeName, vmId)
+	ret0, _ := ret[0].(*string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ShutdownVm indicates an expected call of ShutdownVm.
+func (mr *MockProxmoxClientMockRecorder) ShutdownVm(ctx, nodeName, vmId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ShutdownVm", reflect.TypeOf((*MockProxmoxClient)(nil).ShutdownVm), ctx, nodeName, vmId)
+}
+
+// StartVm mocks base method.
+func (m *MockProxmoxClient) StartVm(ctx context.Context, nodeName, vmId *string) (*string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartVm", ctx, nodeName, vmId)
+	ret0, _ := ret[0].(*string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StartVm indicates an expected call of StartVm.
+func (mr *MockProxmoxClientMockRecorder) StartVm(ctx, nodeName, vmId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartVm", reflect.TypeOf((*MockProxmoxClient)(nil).StartVm), ctx, nodeName, vmId)
+}
+
+// UpdateSdnZone mocks base method.
+func (m *MockProxmoxClient) UpdateSdnZone(ctx context.Context, sdnZoneCreationBody url.Values) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateSdnZone", ctx, sdnZoneCreationBody)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateSdnZone indicates an expected call of UpdateSdnZone.
+func (mr *MockProxmoxClientMockRecorder) UpdateSdnZone(ctx, sdnZoneCreationBody any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSdnZone", reflect.TypeOf((*MockProxmoxClient)(nil).UpdateSdnZone), ctx, sdnZoneCreationBody)
+}
+
+// UpdateVm mocks base method.
+func (m *MockProxmoxClient) UpdateVm(ctx context.Context, vmCreationBody url.Values, nodeName, vmId *string) (*string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateVm", ctx, vmCreationBody, nodeName, vmId)
+	ret0, _ := ret[0].(*string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateVm indicates an expected call of UpdateVm.
+func (mr *MockProxmoxClientMockRecorder) UpdateVm(ctx, vmCreationBody, nodeName, vmId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateVm", reflect.TypeOf((*MockProxmoxClient)(nil).UpdateVm), ctx, vmCreationBody, nodeName, vmId)
+}
+
+// UploadStorageFile mocks base method.
+func (m *MockProxmoxClient) UploadStorageFile(ctx context.Context, nodeName, storageName *string, content, fileName string, data []byte) (*string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UploadStorageFile", ctx, nodeName, storageName, content, fileName, data)
+	ret0, _ := ret[0].(*string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UploadStorageFile indicates an expected call of UploadStorageFile.
+func (mr *MockProxmoxClientMockRecorder) UploadStorageFile(ctx, nodeName, storageName, content, fileName, data any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UploadStorageFile", reflect.TypeOf((*MockProxmoxClient)(nil).UploadStorageFile), ctx, nodeName, storageName, content, fileName, data)
+}
+
+// WriteNodeFile mocks base method.
+func (m *MockProxmoxClient) WriteNodeFile(ctx context.Context, nodeName, filePath string, data []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WriteNodeFile", ctx, nodeName, filePath, data)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WriteNodeFile indicates an expected call of WriteNodeFile.
+func (mr *MockProxmoxClientMockRecorder) WriteNodeFile(ctx, nodeName, filePath, data any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteNodeFile", reflect.TypeOf((*MockProxmoxClient)(nil).WriteNodeFile), ctx, nodeName, filePath, data)
+}
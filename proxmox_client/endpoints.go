@@ -0,0 +1,258 @@
+package proxmox_client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	proxmoxTypes "terraform-provider-proxmox/types"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// endpointCooldown is how long an unreachable endpoint is passed over before it is probed again
+const endpointCooldown = time.Minute
+
+// endpointProbeTimeout bounds the request that checks an endpoint is back before requests are sent to it again
+const endpointProbeTimeout = 10 * time.Second
+
+const defaultApiPort = "8006"
+
+type endpoint struct {
+	scheme   string
+	host     string
+	failedAt time.Time
+}
+
+func (e *endpoint) applyTo(req *http.Request) {
+	req.URL.Scheme = e.scheme
+	req.URL.Host = e.host
+	req.Host = e.host
+}
+
+func (e *endpoint) String() string {
+	return fmt.Sprintf("%s://%s", e.scheme, e.host)
+}
+
+// endpointPool
+/**
+ * @description holds every api endpoint of the cluster. Requests stick to the active endpoint until it becomes
+ * unreachable, only then the next endpoint that has not failed recently takes over. The client probes endpoints that
+ * failed before handing requests back to them.
+ */
+type endpointPool struct {
+	lock      sync.Mutex
+	endpoints []*endpoint
+	active    int
+}
+
+func newEndpointPool(hostUrls []string) (*endpointPool, error) {
+	pool := endpointPool{}
+	for _, hostUrl := range hostUrls {
+		if _, addError := pool.add(hostUrl); addError != nil {
+			return nil, addError
+		}
+	}
+	return &pool, nil
+}
+
+// normalizeHostUrl accepts a bare host, host:port or a full url and returns the api base url for it
+func normalizeHostUrl(host string) string {
+	host = strings.TrimSuffix(strings.TrimSpace(host), "/")
+	if !strings.Contains(host, "https") {
+		return fmt.Sprintf("https://%s/api2/json", host)
+	}
+	return fmt.Sprintf("%s/api2/json", host)
+}
+
+func (pool *endpointPool) add(hostUrl string) (bool, error) {
+	parsedUrl, parseError := url.Parse(normalizeHostUrl(hostUrl))
+	if parseError != nil {
+		return false, errors.New(fmt.Sprintf("%s is not a valid proxmox host: %s", hostUrl, parseError.Error()))
+	}
+	if parsedUrl.Host == "" {
+		return false, errors.New(fmt.Sprintf("%s is not a valid proxmox host", hostUrl))
+	}
+
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	for _, existing := range pool.endpoints {
+		if existing.host == parsedUrl.Host {
+			return false, nil
+		}
+	}
+	pool.endpoints = append(pool.endpoints, &endpoint{scheme: parsedUrl.Scheme, host: parsedUrl.Host})
+	return true, nil
+}
+
+func (pool *endpointPool) size() int {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+	return len(pool.endpoints)
+}
+
+func (pool *endpointPool) current() *endpoint {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+	return pool.endpoints[pool.active]
+}
+
+// failover marks the endpoint as unreachable and moves on to the next endpoint that is not cooling down
+func (pool *endpointPool) failover(failed *endpoint) *endpoint {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	failed.failedAt = time.Now()
+	if pool.endpoints[pool.active] != failed {
+		// another request already moved away from the failed endpoint
+		return pool.endpoints[pool.active]
+	}
+
+	next := (pool.active + 1) % len(pool.endpoints)
+	for offset := 1; offset < len(pool.endpoints); offset++ {
+		candidate := (pool.active + offset) % len(pool.endpoints)
+		if time.Since(pool.endpoints[candidate].failedAt) > endpointCooldown {
+			next = candidate
+			break
+		}
+	}
+	pool.active = next
+	return pool.endpoints[pool.active]
+}
+
+func (pool *endpointPool) markHealthy(healthy *endpoint) {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+	healthy.failedAt = time.Time{}
+}
+
+// hasFailed reports endpoints that were unreachable and have not answered a request since
+func (pool *endpointPool) hasFailed(candidate *endpoint) bool {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+	return !candidate.failedAt.IsZero()
+}
+
+// failover
+/**
+ * @description moves on from the unreachable endpoint. An endpoint that failed before is probed with a GET of
+ * /version first, so requests only go back to it once it answers again. When every probe fails the endpoint the pool
+ * ends up on is returned anyway.
+ */
+func (c *Client) failover(ctx context.Context, failed *endpoint) *endpoint {
+	next := c.endpoints.failover(failed)
+	for probes := 1; probes < c.endpoints.size() && c.endpoints.hasFailed(next); probes++ {
+		probeError := c.probeEndpoint(ctx, next)
+		if probeError == nil {
+			c.endpoints.markHealthy(next)
+			break
+		}
+		tflog.Warn(ctx, fmt.Sprintf("proxmox endpoint %s is still unavailable: %s", next, probeError.Error()))
+		next = c.endpoints.failover(next)
+	}
+	return next
+}
+
+// probeEndpoint checks the endpoint answers again. The probe is not authenticated, any answer counts like it does for
+// requests, proxmox answers 401 here
+func (c *Client) probeEndpoint(ctx context.Context, candidate *endpoint) error {
+	probeCtx, cancel := context.WithTimeout(ctx, endpointProbeTimeout)
+	defer cancel()
+
+	request, requestCreationError := http.NewRequestWithContext(probeCtx, http.MethodGet, fmt.Sprintf("%s/version", c.HostURL), nil)
+	if requestCreationError != nil {
+		return requestCreationError
+	}
+	candidate.applyTo(request)
+
+	response, probeError := c.HTTPClient.Do(request)
+	if probeError != nil {
+		return probeError
+	}
+	return response.Body.Close()
+}
+
+// isEndpointFailure reports errors that mean the endpoint itself could not serve the request, answers from proxmox never are
+func isEndpointFailure(method string, requestError error) bool {
+	if _, isApiError := AsApiError(requestError); isApiError {
+		return false
+	}
+	return IsRetryable(method, requestError)
+}
+
+// DiscoverClusterEndpoints
+/**
+ * @description adds every online node reported by /cluster/status as a failover endpoint, using the port of the
+ * configured host. When certificates are pinned the fingerprints proxmox reports for the nodes are trusted as well,
+ * they are read over the already pinned connection.
+ */
+func (c *Client) DiscoverClusterEndpoints(ctx context.Context) error {
+	clusterStatus, clusterStatusError := c.GetClusterStatus(ctx)
+	if clusterStatusError != nil {
+		return fmt.Errorf("failed to discover cluster nodes: %w", clusterStatusError)
+	}
+
+	current := c.endpoints.current()
+	port := defaultApiPort
+	if _, configuredPort, splitError := net.SplitHostPort(current.host); splitError == nil {
+		port = configuredPort
+	}
+
+	for _, item := range clusterStatus.Data {
+		if item.Type != "node" || item.Online != 1 || item.Ip == "" {
+			continue
+		}
+		added, addError := c.endpoints.add(fmt.Sprintf("%s://%s", current.scheme, net.JoinHostPort(item.Ip, port)))
+		if addError != nil {
+			return addError
+		}
+		if added {
+			tflog.Info(ctx, fmt.Sprintf("discovered proxmox node %s at %s", item.Name, item.Ip))
+		}
+	}
+
+	if c.pinnedFingerprints == nil {
+		return nil
+	}
+
+	nodes, listNodesError := c.ListNodes(ctx)
+	if listNodesError != nil {
+		return fmt.Errorf("failed to read node certificate fingerprints: %w", listNodesError)
+	}
+	for _, node := range nodes.Data {
+		if node.SslFingerprint == "" {
+			continue
+		}
+		if pinError := c.pinnedFingerprints.add(node.SslFingerprint); pinError != nil {
+			return pinError
+		}
+	}
+	return nil
+}
+
+// GetClusterStatus lists the cluster and its nodes with their addresses, a standalone node reports only itself
+func (c *Client) GetClusterStatus(ctx context.Context) (*proxmoxTypes.ClusterStatusResponse, error) {
+	request, requestCreationError := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/cluster/status", c.HostURL), nil)
+	if requestCreationError != nil {
+		return nil, requestCreationError
+	}
+
+	body, responseError := c.DoRequest(request, "")
+	if responseError != nil {
+		return nil, responseError
+	}
+
+	var clusterStatus proxmoxTypes.ClusterStatusResponse
+	unmarshallingError := json.Unmarshal(body, &clusterStatus)
+	if unmarshallingError != nil {
+		return nil, unmarshallingError
+	}
+	return &clusterStatus, nil
+}
@@ -0,0 +1,72 @@
+package proxmox_client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestsFailOverToNextHostAndStayThere(t *testing.T) {
+	unreachable := newNodeListServer()
+	unreachableUrl := unreachable.URL
+	unreachable.Close()
+
+	server := newNodeListServer()
+	defer server.Close()
+
+	client, newClientError := NewClient([]string{unreachableUrl, server.URL}, &AuthStruct{TokenId: "root@pam!test", TokenSecret: "secret"}, &TlsOptions{
+		FingerprintSha256: serverFingerprint(server),
+	}, &RetryPolicy{MaxAttempts: 1}, nil, nil, nil)
+	assert.NoError(t, newClientError)
+
+	nodes, listNodesError := client.ListNodes(context.Background())
+	assert.NoError(t, listNodesError)
+	assert.Equal(t, "pve-01", nodes.Data[0].Node)
+
+	assert.Equal(t, server.Listener.Addr().String(), client.(*Client).endpoints.current().host)
+}
+
+func TestEndpointPoolSkipsRecentlyFailedEndpoints(t *testing.T) {
+	pool, poolError := newEndpointPool([]string{"pve-01:8006", "pve-02:8006", "https://pve-03:8006"})
+	assert.NoError(t, poolError)
+
+	first := pool.current()
+	second := pool.failover(first)
+	assert.Equal(t, "pve-02:8006", second.host)
+
+	third := pool.failover(second)
+	assert.Equal(t, "pve-03:8006", third.host)
+
+	// both other endpoints are cooling down, so the next one in line is used anyway
+	assert.Equal(t, "pve-01:8006", pool.failover(third).host)
+}
+
+func TestFailoverProbesEndpointsBeforeReturningToThem(t *testing.T) {
+	stillDown := newNodeListServer()
+	stillDownUrl := stillDown.URL
+	stillDown.Close()
+	goingDown := newNodeListServer()
+	goingDownUrl := goingDown.URL
+	goingDown.Close()
+
+	server := newNodeListServer()
+	defer server.Close()
+
+	client, newClientError := NewClient([]string{stillDownUrl, server.URL, goingDownUrl}, &AuthStruct{TokenId: "root@pam!test", TokenSecret: "secret"}, &TlsOptions{
+		FingerprintSha256: serverFingerprint(server),
+	}, &RetryPolicy{MaxAttempts: 1}, nil, nil, nil)
+	assert.NoError(t, newClientError)
+	pool := client.(*Client).endpoints
+	// both other endpoints failed long enough ago to be tried again, only one of them is back
+	pool.endpoints[0].failedAt = time.Now().Add(-2 * endpointCooldown)
+	pool.endpoints[1].failedAt = time.Now().Add(-2 * endpointCooldown)
+	pool.active = 2
+
+	next := client.(*Client).failover(context.Background(), pool.endpoints[2])
+
+	assert.Equal(t, server.Listener.Addr().String(), next.host)
+	assert.False(t, pool.hasFailed(next))
+	assert.True(t, pool.hasFailed(pool.endpoints[0]))
+}
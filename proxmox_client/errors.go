@@ -0,0 +1,100 @@
+package proxmox_client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// ApiError is returned whenever proxmox responds with a status other than the one that was expected
+type ApiError struct {
+	StatusCode int
+	Status     string
+	// Message is the reason proxmox gave, either from the response body or the http status line
+	Message string
+	// Errors holds per parameter validation messages keyed by the proxmox parameter name, e.g. memory or scsi0
+	Errors map[string]string
+	Method string
+	Path   string
+	Body   []byte
+}
+
+type apiErrorResponse struct {
+	Message string            `json:"message"`
+	Errors  map[string]string `json:"errors"`
+}
+
+func newApiError(req *http.Request, res *http.Response, body []byte) *ApiError {
+	apiError := ApiError{
+		StatusCode: res.StatusCode,
+		Status:     res.Status,
+		Message:    strings.TrimSpace(strings.TrimPrefix(res.Status, fmt.Sprintf("%d", res.StatusCode))),
+		Method:     req.Method,
+		Path:       req.URL.Path,
+		Body:       body,
+	}
+
+	var errorResponse apiErrorResponse
+	if json.Unmarshal(body, &errorResponse) == nil {
+		if strings.TrimSpace(errorResponse.Message) != "" {
+			apiError.Message = strings.TrimSpace(errorResponse.Message)
+		}
+		apiError.Errors = errorResponse.Errors
+	}
+
+	return &apiError
+}
+
+func (apiError *ApiError) Error() string {
+	message := fmt.Sprintf("%s %s failed with status %d: %s", apiError.Method, apiError.Path, apiError.StatusCode, apiError.Message)
+	for _, parameter := range apiError.Parameters() {
+		message += fmt.Sprintf("\n  %s: %s", parameter, strings.TrimSpace(apiError.Errors[parameter]))
+	}
+	return message
+}
+
+// Parameters returns the names of the parameters proxmox rejected in a stable order
+func (apiError *ApiError) Parameters() []string {
+	parameters := make([]string, 0, len(apiError.Errors))
+	for parameter := range apiError.Errors {
+		parameters = append(parameters, parameter)
+	}
+	sort.Strings(parameters)
+	return parameters
+}
+
+func AsApiError(err error) (*ApiError, bool) {
+	var apiError *ApiError
+	if errors.As(err, &apiError) {
+		return apiError, true
+	}
+	return nil, false
+}
+
+// IsNotFound proxmox reports most missing objects as a 500 with a "does not exist" message rather than a 404
+func IsNotFound(err error) bool {
+	apiError, isApiError := AsApiError(err)
+	if !isApiError {
+		return false
+	}
+	return apiError.StatusCode == http.StatusNotFound || strings.Contains(apiError.Message, "does not exist")
+}
+
+func IsLockTimeout(err error) bool {
+	apiError, isApiError := AsApiError(err)
+	if !isApiError {
+		return false
+	}
+	return isLockTimeoutMessage(apiError.Message) || isLockTimeoutMessage(string(apiError.Body))
+}
+
+func IsPermissionDenied(err error) bool {
+	apiError, isApiError := AsApiError(err)
+	if !isApiError {
+		return false
+	}
+	return apiError.StatusCode == http.StatusForbidden || strings.Contains(apiError.Message, "Permission check failed")
+}
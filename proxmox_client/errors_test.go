@@ -0,0 +1,42 @@
+package proxmox_client
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestApiError(statusCode int, status string, body string) error {
+	request := &http.Request{Method: http.MethodPost, URL: &url.URL{Path: "/api2/json/nodes/pve-01/qemu"}}
+	response := &http.Response{StatusCode: statusCode, Status: status}
+	return fmt.Errorf("failed to create vm: %w", newApiError(request, response, []byte(body)))
+}
+
+func TestApiErrorParsesParameterErrors(t *testing.T) {
+	err := newTestApiError(400, "400 Parameter verification failed.", `{"errors":{"memory":"value must have a minimum value of 16\n","cores":"type check ('integer') failed"},"data":null,"message":"Parameter verification failed.\n"}`)
+
+	apiError, isApiError := AsApiError(err)
+
+	assert.True(t, isApiError)
+	assert.Equal(t, "Parameter verification failed.", apiError.Message)
+	assert.Equal(t, []string{"cores", "memory"}, apiError.Parameters())
+	assert.Contains(t, err.Error(), "memory: value must have a minimum value of 16")
+	assert.False(t, IsNotFound(err))
+}
+
+func TestApiErrorHelpers(t *testing.T) {
+	notFound := newTestApiError(500, "500 Configuration file 'nodes/pve-01/qemu-server/100.conf' does not exist", `{"data":null}`)
+	lockTimeout := newTestApiError(500, "500 can't lock file '/var/lock/qemu-server/lock-100.conf' - got timeout", `{"data":null}`)
+	permissionDenied := newTestApiError(403, "403 Permission check failed (/vms/100, VM.Config.Memory)", `{"data":null}`)
+
+	assert.True(t, IsNotFound(notFound))
+	assert.True(t, IsLockTimeout(lockTimeout))
+	assert.False(t, IsLockTimeout(notFound))
+	assert.True(t, IsPermissionDenied(permissionDenied))
+
+	apiError, _ := AsApiError(permissionDenied)
+	assert.Equal(t, "Permission check failed (/vms/100, VM.Config.Memory)", apiError.Message)
+}
@@ -0,0 +1,111 @@
+package proxmox_client
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// LimitOptions controls how hard the client is allowed to hit the proxmox api. A zero value disables the limit
+type LimitOptions struct {
+	MaxConcurrentRequests     int
+	RequestsPerSecond         float64
+	MaxConcurrentTasksPerNode int
+}
+
+type requestLimiter struct {
+	requestSlots     chan struct{}
+	interval         time.Duration
+	nextRequest      time.Time
+	pacingLock       sync.Mutex
+	maxTasksPerNode  int
+	taskSlotsPerNode map[string]chan struct{}
+	taskSlotsLock    sync.Mutex
+}
+
+func newRequestLimiter(options LimitOptions) *requestLimiter {
+	limiter := requestLimiter{
+		maxTasksPerNode:  options.MaxConcurrentTasksPerNode,
+		taskSlotsPerNode: map[string]chan struct{}{},
+	}
+
+	if options.MaxConcurrentRequests > 0 {
+		limiter.requestSlots = make(chan struct{}, options.MaxConcurrentRequests)
+	}
+
+	if options.RequestsPerSecond > 0 {
+		limiter.interval = time.Duration(float64(time.Second) / options.RequestsPerSecond)
+	}
+
+	return &limiter
+}
+
+// acquireRequestSlot
+/**
+ * @description blocks until the request is allowed to be sent, both in terms of requests in flight and requests
+ * per second. The returned function must be called once the response has been read.
+ */
+func (limiter *requestLimiter) acquireRequestSlot(ctx context.Context) (func(), error) {
+	pacingError := limiter.waitForPacing(ctx)
+	if pacingError != nil {
+		return nil, pacingError
+	}
+
+	if limiter.requestSlots == nil {
+		return func() {}, nil
+	}
+
+	return acquireSlot(ctx, limiter.requestSlots)
+}
+
+func (limiter *requestLimiter) waitForPacing(ctx context.Context) error {
+	if limiter.interval == 0 {
+		return nil
+	}
+
+	limiter.pacingLock.Lock()
+	now := time.Now()
+	if limiter.nextRequest.Before(now) {
+		limiter.nextRequest = now
+	}
+	wait := limiter.nextRequest.Sub(now)
+	limiter.nextRequest = limiter.nextRequest.Add(limiter.interval)
+	limiter.pacingLock.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(wait):
+		return nil
+	}
+}
+
+// acquireTaskSlot
+/**
+ * @description blocks until fewer than the configured number of long-running tasks are running on the node.
+ * The returned function must be called once the task has finished.
+ */
+func (limiter *requestLimiter) acquireTaskSlot(ctx context.Context, nodeName string) (func(), error) {
+	if limiter.maxTasksPerNode <= 0 {
+		return func() {}, nil
+	}
+
+	limiter.taskSlotsLock.Lock()
+	slots, found := limiter.taskSlotsPerNode[nodeName]
+	if !found {
+		slots = make(chan struct{}, limiter.maxTasksPerNode)
+		limiter.taskSlotsPerNode[nodeName] = slots
+	}
+	limiter.taskSlotsLock.Unlock()
+
+	return acquireSlot(ctx, slots)
+}
+
+func acquireSlot(ctx context.Context, slots chan struct{}) (func(), error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case slots <- struct{}{}:
+		return func() { <-slots }, nil
+	}
+}
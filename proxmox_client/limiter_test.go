@@ -0,0 +1,73 @@
+package proxmox_client
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRequestLimiterPacesRequests(t *testing.T) {
+	limiter := newRequestLimiter(LimitOptions{RequestsPerSecond: 50})
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		release, acquireError := limiter.acquireRequestSlot(context.Background())
+		if acquireError != nil {
+			t.Fatal(acquireError)
+		}
+		release()
+	}
+
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Fatalf("expected 5 requests at 50/s to take at least 80ms, took %s", elapsed)
+	}
+}
+
+func TestRequestLimiterTaskSlotsArePerNode(t *testing.T) {
+	limiter := newRequestLimiter(LimitOptions{MaxConcurrentTasksPerNode: 2})
+
+	var running, maxRunning int32
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release, _ := limiter.acquireTaskSlot(context.Background(), "pve1")
+			defer release()
+
+			current := atomic.AddInt32(&running, 1)
+			for {
+				observed := atomic.LoadInt32(&maxRunning)
+				if current <= observed || atomic.CompareAndSwapInt32(&maxRunning, observed, current) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			atomic.AddInt32(&running, -1)
+		}()
+	}
+
+	// a different node must not be blocked by the tasks above
+	release, _ := limiter.acquireTaskSlot(context.Background(), "pve2")
+	release()
+	wg.Wait()
+
+	if maxRunning != 2 {
+		t.Fatalf("expected at most 2 concurrent tasks on pve1, observed %d", maxRunning)
+	}
+}
+
+func TestRequestLimiterStopsWaitingWhenCancelled(t *testing.T) {
+	limiter := newRequestLimiter(LimitOptions{MaxConcurrentTasksPerNode: 1})
+	_, _ = limiter.acquireTaskSlot(context.Background(), "pve1")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, acquireError := limiter.acquireTaskSlot(ctx, "pve1")
+	if acquireError != context.DeadlineExceeded {
+		t.Fatalf("expected the deadline to abort the wait, got %v", acquireError)
+	}
+}
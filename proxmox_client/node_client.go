@@ -1,6 +1,7 @@
 package proxmox_client
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -10,8 +11,8 @@ import (
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 )
 
-func (c *Client) ListNodes() (*proxmoxTypes.NodeListResponse, error) {
-	request, requestCreationError := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/nodes", c.HostURL), nil)
+func (c *Client) ListNodes(ctx context.Context) (*proxmoxTypes.NodeListResponse, error) {
+	request, requestCreationError := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/nodes", c.HostURL), nil)
 	if requestCreationError != nil {
 		return nil, requestCreationError
 	}
@@ -24,7 +25,7 @@ func (c *Client) ListNodes() (*proxmoxTypes.NodeListResponse, error) {
 
 	var nodeList proxmoxTypes.NodeListResponse
 
-	tflog.Debug(c.Context, fmt.Sprintf("Get Nodes Response is %s", string(body)))
+	tflog.Debug(ctx, fmt.Sprintf("Get Nodes Response is %s", string(body)))
 
 	unmarshallingError := json.Unmarshal(body, &nodeList)
 	if unmarshallingError != nil {
@@ -34,8 +35,8 @@ func (c *Client) ListNodes() (*proxmoxTypes.NodeListResponse, error) {
 	return &nodeList, nil
 }
 
-func (c *Client) GetNodeNetworkConfig(nodeName string) (*proxmoxTypes.NodeNetworkConfig, error) {
-	request, requestCreationError := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/nodes/%s/network", c.HostURL, url.PathEscape(nodeName)), nil)
+func (c *Client) GetNodeNetworkConfig(ctx context.Context, nodeName string) (*proxmoxTypes.NodeNetworkConfig, error) {
+	request, requestCreationError := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/nodes/%s/network", c.HostURL, url.PathEscape(nodeName)), nil)
 	if requestCreationError != nil {
 		return nil, requestCreationError
 	}
@@ -48,7 +49,7 @@ func (c *Client) GetNodeNetworkConfig(nodeName string) (*proxmoxTypes.NodeNetwor
 
 	var nodeList proxmoxTypes.NodeNetworkConfig
 
-	tflog.Debug(c.Context, fmt.Sprintf("Get Nodes Response is %s", string(body)))
+	tflog.Debug(ctx, fmt.Sprintf("Get Nodes Response is %s", string(body)))
 
 	unmarshallingError := json.Unmarshal(body, &nodeList)
 	if unmarshallingError != nil {
@@ -1,26 +1,30 @@
 package proxmox_client
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"mime/multipart"
 	"net/http"
+	"net/url"
 	proxmoxTypes "terraform-provider-proxmox/types"
 
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 )
 
-func (c *Client) ListStorageContent(nodeName *string, storageName *string) (*proxmoxTypes.QemuImageResponse, error) {
-	request, requestCreationError := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/nodes/%s/storage/%s/content", c.HostURL, *nodeName, *storageName), nil)
+func (c *Client) ListStorageContent(ctx context.Context, nodeName *string, storageName *string) (*proxmoxTypes.QemuImageResponse, error) {
+	request, requestCreationError := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/nodes/%s/storage/%s/content", c.HostURL, *nodeName, *storageName), nil)
 
 	if requestCreationError != nil {
-		tflog.Error(c.Context, fmt.Sprintf("Failed to create list storage content request: %s", requestCreationError.Error()))
+		tflog.Error(ctx, fmt.Sprintf("Failed to create list storage content request: %s", requestCreationError.Error()))
 		return nil, requestCreationError
 	}
 
 	body, responseError := c.DoRequest(request, FormUrlEncoded)
 
 	if responseError != nil {
-		tflog.Error(c.Context, fmt.Sprintf("Failed to List Storage contents %s, on node %s: %s", *storageName, *nodeName, responseError.Error()))
+		tflog.Error(ctx, fmt.Sprintf("Failed to List Storage contents %s, on node %s: %s", *storageName, *nodeName, responseError.Error()))
 		return nil, responseError
 	}
 
@@ -28,25 +32,25 @@ func (c *Client) ListStorageContent(nodeName *string, storageName *string) (*pro
 	unmarshallingError := json.Unmarshal(body, &images)
 
 	if unmarshallingError != nil {
-		tflog.Error(c.Context, fmt.Sprintf("Failed to unmarshal list storage content response: %s", unmarshallingError.Error()))
+		tflog.Error(ctx, fmt.Sprintf("Failed to unmarshal list storage content response: %s", unmarshallingError.Error()))
 		return nil, unmarshallingError
 	}
 
 	return &images, nil
 }
 
-func (c *Client) ListStorageDestinations(nodeName *string) (*proxmoxTypes.NodeStorageResponse, error) {
-	request, requestCreationError := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/nodes/%s/storage/", c.HostURL, *nodeName), nil)
+func (c *Client) ListStorageDestinations(ctx context.Context, nodeName *string) (*proxmoxTypes.NodeStorageResponse, error) {
+	request, requestCreationError := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/nodes/%s/storage/", c.HostURL, *nodeName), nil)
 
 	if requestCreationError != nil {
-		tflog.Error(c.Context, fmt.Sprintf("Failed to create list node storage request : %s", requestCreationError.Error()))
+		tflog.Error(ctx, fmt.Sprintf("Failed to create list node storage request : %s", requestCreationError.Error()))
 		return nil, requestCreationError
 	}
 
 	body, responseError := c.DoRequest(request, FormUrlEncoded)
 
 	if responseError != nil {
-		tflog.Error(c.Context, fmt.Sprintf("Failed to List Storage on node %s: %s", *nodeName, responseError.Error()))
+		tflog.Error(ctx, fmt.Sprintf("Failed to List Storage on node %s: %s", *nodeName, responseError.Error()))
 		return nil, responseError
 	}
 
@@ -54,9 +58,95 @@ func (c *Client) ListStorageDestinations(nodeName *string) (*proxmoxTypes.NodeSt
 	unmarshallingError := json.Unmarshal(body, &storageList)
 
 	if unmarshallingError != nil {
-		tflog.Error(c.Context, fmt.Sprintf("Failed to unmarshal list node storage response: %s", unmarshallingError.Error()))
+		tflog.Error(ctx, fmt.Sprintf("Failed to unmarshal list node storage response: %s", unmarshallingError.Error()))
 		return nil, unmarshallingError
 	}
 
 	return &storageList, nil
 }
+
+// UploadStorageFile uploads a file of the content type to the storage of the node, the upload runs as a task
+func (c *Client) UploadStorageFile(ctx context.Context, nodeName *string, storageName *string, content string, fileName string, data []byte) (*string, error) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	_ = form.WriteField("content", content)
+	filePart, createPartError := form.CreateFormFile("filename", fileName)
+	if createPartError != nil {
+		return nil, createPartError
+	}
+	_, _ = filePart.Write(data)
+	closeError := form.Close()
+	if closeError != nil {
+		return nil, closeError
+	}
+
+	request, requestCreationError := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/nodes/%s/storage/%s/upload", c.HostURL, *nodeName, *storageName), &body)
+
+	if requestCreationError != nil {
+		tflog.Error(ctx, fmt.Sprintf("Failed to create storage upload request: %s", requestCreationError.Error()))
+		return nil, requestCreationError
+	}
+
+	responseBody, responseError := c.DoRequest(request, form.FormDataContentType())
+
+	if responseError != nil {
+		tflog.Error(ctx, fmt.Sprintf("Failed to upload %s to storage %s on node %s: %s", fileName, *storageName, *nodeName, responseError.Error()))
+		return nil, responseError
+	}
+
+	var uploadResponse proxmoxTypes.TaskCreationResponse
+	unmarshallingError := json.Unmarshal(responseBody, &uploadResponse)
+
+	if unmarshallingError != nil {
+		return nil, unmarshallingError
+	}
+
+	return &uploadResponse.Upid, nil
+}
+
+// DeleteStorageVolume removes a volume such as an uploaded file from the storage of the node
+func (c *Client) DeleteStorageVolume(ctx context.Context, nodeName *string, storageName *string, volid *string) (*string, error) {
+	request, requestCreationError := http.NewRequestWithContext(ctx, http.MethodDelete, fmt.Sprintf("%s/nodes/%s/storage/%s/content/%s", c.HostURL, *nodeName, *storageName, url.PathEscape(*volid)), nil)
+
+	if requestCreationError != nil {
+		return nil, requestCreationError
+	}
+
+	body, responseError := c.DoRequest(request, FormUrlEncoded)
+
+	if responseError != nil {
+		return nil, responseError
+	}
+
+	var deleteResponse proxmoxTypes.TaskCreationResponse
+	unmarshallingError := json.Unmarshal(body, &deleteResponse)
+
+	if unmarshallingError != nil {
+		return nil, unmarshallingError
+	}
+
+	return &deleteResponse.Upid, nil
+}
+
+func (c *Client) GetStorageConfig(ctx context.Context, storageName *string) (*proxmoxTypes.StorageConfigResponse, error) {
+	request, requestCreationError := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/storage/%s", c.HostURL, *storageName), nil)
+
+	if requestCreationError != nil {
+		return nil, requestCreationError
+	}
+
+	body, responseError := c.DoRequest(request, FormUrlEncoded)
+
+	if responseError != nil {
+		return nil, responseError
+	}
+
+	var storageConfig proxmoxTypes.StorageConfigResponse
+	unmarshallingError := json.Unmarshal(body, &storageConfig)
+
+	if unmarshallingError != nil {
+		return nil, unmarshallingError
+	}
+
+	return &storageConfig, nil
+}
@@ -2,18 +2,19 @@ package proxmox_client
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
+	"strconv"
 	proxmoxTypes "terraform-provider-proxmox/types"
 
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 )
 
-func (c *Client) GetVmById(nodeName *string, vmId *string) (*proxmoxTypes.QemuResponse, error) {
-	request, requestCreationError := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/nodes/%s/qemu/%s/config", c.HostURL, *nodeName, *vmId), nil)
+func (c *Client) GetVmById(ctx context.Context, nodeName *string, vmId *string) (*proxmoxTypes.QemuResponse, error) {
+	request, requestCreationError := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/nodes/%s/qemu/%s/config", c.HostURL, *nodeName, *vmId), nil)
 	if requestCreationError != nil {
 		return nil, requestCreationError
 	}
@@ -26,7 +27,7 @@ func (c *Client) GetVmById(nodeName *string, vmId *string) (*proxmoxTypes.QemuRe
 
 	var qemuResponse proxmoxTypes.QemuResponse
 
-	tflog.Debug(c.Context, fmt.Sprintf("Get Vm Response is %s", string(body)))
+	tflog.Debug(ctx, fmt.Sprintf("Get Vm Response is %s", string(body)))
 
 	unmarshallingError := json.Unmarshal(body, &qemuResponse)
 	if unmarshallingError != nil {
@@ -40,13 +41,13 @@ func (c *Client) GetVmById(nodeName *string, vmId *string) (*proxmoxTypes.QemuRe
 	}
 
 	qemuResponse.Data.OtherFields = otherFields["data"].(map[string]interface{})
-	tflog.Debug(c.Context, "VM Gen ID is "+qemuResponse.Data.VmGenId)
+	tflog.Debug(ctx, "VM Gen ID is "+qemuResponse.Data.VmGenId)
 	return &qemuResponse, nil
 }
 
-func (c *Client) CreateVm(vmCreationBody url.Values, nodeName string) (*string, error) {
+func (c *Client) CreateVm(ctx context.Context, vmCreationBody url.Values, nodeName string) (*string, error) {
 
-	request, requestCreationError := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/nodes/%s/qemu", c.HostURL, nodeName), bytes.NewBufferString(vmCreationBody.Encode()))
+	request, requestCreationError := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/nodes/%s/qemu", c.HostURL, nodeName), bytes.NewBufferString(vmCreationBody.Encode()))
 
 	if requestCreationError != nil {
 		return nil, requestCreationError
@@ -54,7 +55,7 @@ func (c *Client) CreateVm(vmCreationBody url.Values, nodeName string) (*string,
 
 	body, responseError := c.DoRequest(request, FormUrlEncoded)
 	if responseError != nil {
-		return nil, errors.Join(responseError, errors.New(string(body)))
+		return nil, responseError
 	}
 
 	var qemuCreationResponse = proxmoxTypes.TaskCreationResponse{}
@@ -67,11 +68,11 @@ func (c *Client) CreateVm(vmCreationBody url.Values, nodeName string) (*string,
 	return &qemuCreationResponse.Upid, nil
 }
 
-func (c *Client) UpdateVm(vmCreationBody url.Values, nodeName *string, vmId *string) (*string, error) {
+func (c *Client) UpdateVm(ctx context.Context, vmCreationBody url.Values, nodeName *string, vmId *string) (*string, error) {
 
-	tflog.Debug(c.Context, fmt.Sprintf("Request Body: %s", vmCreationBody.Encode()))
+	tflog.Debug(ctx, fmt.Sprintf("Request Body: %s", vmCreationBody.Encode()))
 
-	request, requestCreationError := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/nodes/%s/qemu/%s/config", c.HostURL, *nodeName, *vmId), bytes.NewBufferString(vmCreationBody.Encode()))
+	request, requestCreationError := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/nodes/%s/qemu/%s/config", c.HostURL, *nodeName, *vmId), bytes.NewBufferString(vmCreationBody.Encode()))
 
 	if requestCreationError != nil {
 		return nil, requestCreationError
@@ -79,7 +80,7 @@ func (c *Client) UpdateVm(vmCreationBody url.Values, nodeName *string, vmId *str
 
 	body, responseError := c.DoRequest(request, FormUrlEncoded)
 	if responseError != nil {
-		return nil, errors.Join(responseError, errors.New(string(body)))
+		return nil, responseError
 	}
 
 	var qemuCreationResponse = proxmoxTypes.TaskCreationResponse{}
@@ -92,8 +93,8 @@ func (c *Client) UpdateVm(vmCreationBody url.Values, nodeName *string, vmId *str
 	return &qemuCreationResponse.Upid, nil
 }
 
-func (c *Client) GetTaskStatusByUpid(nodeName *string, upid *string) (*proxmoxTypes.TaskStatus, error) {
-	request, requestCreationError := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/nodes/%s/tasks/%s/status", c.HostURL, *nodeName, *upid), nil)
+func (c *Client) GetTaskStatusByUpid(ctx context.Context, nodeName *string, upid *string) (*proxmoxTypes.TaskStatus, error) {
+	request, requestCreationError := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/nodes/%s/tasks/%s/status", c.HostURL, *nodeName, *upid), nil)
 	if requestCreationError != nil {
 		return nil, requestCreationError
 	}
@@ -114,12 +115,39 @@ func (c *Client) GetTaskStatusByUpid(nodeName *string, upid *string) (*proxmoxTy
 	return &taskStatus, nil
 }
 
-func (c *Client) DeleteVmById(nodeName *string, vmId *string) (*string, error) {
+// GetTaskLog returns up to limit lines of the task log, starting at the zero based line offset start
+func (c *Client) GetTaskLog(ctx context.Context, nodeName *string, upid *string, start int, limit int) (*proxmoxTypes.TaskLogResponse, error) {
+	params := url.Values{}
+	params.Add("start", strconv.Itoa(start))
+	params.Add("limit", strconv.Itoa(limit))
+
+	request, requestCreationError := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/nodes/%s/tasks/%s/log?%s", c.HostURL, *nodeName, *upid, params.Encode()), nil)
+	if requestCreationError != nil {
+		return nil, requestCreationError
+	}
+
+	body, responseError := c.DoRequest(request, FormUrlEncoded)
+
+	if responseError != nil {
+		return nil, responseError
+	}
+
+	var taskLog proxmoxTypes.TaskLogResponse
+
+	unmarshallingError := json.Unmarshal(body, &taskLog)
+	if unmarshallingError != nil {
+		return nil, unmarshallingError
+	}
+
+	return &taskLog, nil
+}
+
+func (c *Client) DeleteVmById(ctx context.Context, nodeName *string, vmId *string) (*string, error) {
 	params := url.Values{}
 	params.Add("destroy-unreferenced-disks", "1")
 	params.Add("purge", "1")
 
-	request, requestCreationError := http.NewRequest(http.MethodDelete, fmt.Sprintf("%s/nodes/%s/qemu/%s?%s", c.HostURL, *nodeName, *vmId, params.Encode()), nil)
+	request, requestCreationError := http.NewRequestWithContext(ctx, http.MethodDelete, fmt.Sprintf("%s/nodes/%s/qemu/%s?%s", c.HostURL, *nodeName, *vmId, params.Encode()), nil)
 	if requestCreationError != nil {
 		return nil, requestCreationError
 	}
@@ -132,7 +160,7 @@ func (c *Client) DeleteVmById(nodeName *string, vmId *string) (*string, error) {
 
 	var deleteVmResponse proxmoxTypes.TaskCreationResponse
 
-	tflog.Debug(c.Context, fmt.Sprintf("Get Vm Response is %s", string(body)))
+	tflog.Debug(ctx, fmt.Sprintf("Get Vm Response is %s", string(body)))
 
 	unmarshallingError := json.Unmarshal(body, &deleteVmResponse)
 	if unmarshallingError != nil {
@@ -142,9 +170,9 @@ func (c *Client) DeleteVmById(nodeName *string, vmId *string) (*string, error) {
 	return &deleteVmResponse.Upid, nil
 }
 
-func (c *Client) ResizeVmDisk(diskResizeRequest url.Values, nodeName *string, vmId *string) (*string, error) {
-	tflog.Debug(c.Context, diskResizeRequest.Encode())
-	request, requestCreationError := http.NewRequest(http.MethodPut, fmt.Sprintf("%s/nodes/%s/qemu/%s/resize", c.HostURL, *nodeName, *vmId), bytes.NewBufferString(diskResizeRequest.Encode()))
+func (c *Client) ResizeVmDisk(ctx context.Context, diskResizeRequest url.Values, nodeName *string, vmId *string) (*string, error) {
+	tflog.Debug(ctx, diskResizeRequest.Encode())
+	request, requestCreationError := http.NewRequestWithContext(ctx, http.MethodPut, fmt.Sprintf("%s/nodes/%s/qemu/%s/resize", c.HostURL, *nodeName, *vmId), bytes.NewBufferString(diskResizeRequest.Encode()))
 
 	if requestCreationError != nil {
 		return nil, requestCreationError
@@ -152,7 +180,7 @@ func (c *Client) ResizeVmDisk(diskResizeRequest url.Values, nodeName *string, vm
 
 	body, responseError := c.DoRequest(request, FormUrlEncoded)
 	if responseError != nil {
-		return nil, errors.Join(responseError, errors.New(string(body)))
+		return nil, responseError
 	}
 
 	var diskResizeResponse = proxmoxTypes.TaskCreationResponse{}
@@ -165,18 +193,18 @@ func (c *Client) ResizeVmDisk(diskResizeRequest url.Values, nodeName *string, vm
 	return &diskResizeResponse.Upid, nil
 }
 
-func (c *Client) GetVmStatus(nodeName *string, vmId *string) (string, error) {
-	request, requestCreationError := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/nodes/%s/qemu/%s/status/current", c.HostURL, *nodeName, *vmId), nil)
+func (c *Client) GetVmStatus(ctx context.Context, nodeName *string, vmId *string) (string, error) {
+	request, requestCreationError := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/nodes/%s/qemu/%s/status/current", c.HostURL, *nodeName, *vmId), nil)
 
 	if requestCreationError != nil {
-		tflog.Error(c.Context, fmt.Sprintf("Failed to create GetVmStatus http request: %s", requestCreationError.Error()))
+		tflog.Error(ctx, fmt.Sprintf("Failed to create GetVmStatus http request: %s", requestCreationError.Error()))
 		return "", requestCreationError
 	}
 
 	body, responseError := c.DoRequest(request, "application/json")
 
 	if responseError != nil {
-		tflog.Error(c.Context, fmt.Sprintf("Failed to get status of VM %s, from node %s: %s", *vmId, *nodeName, responseError.Error()))
+		tflog.Error(ctx, fmt.Sprintf("Failed to get status of VM %s, from node %s: %s", *vmId, *nodeName, responseError.Error()))
 		return "", responseError
 	}
 
@@ -184,25 +212,25 @@ func (c *Client) GetVmStatus(nodeName *string, vmId *string) (string, error) {
 	unmarshallingError := json.Unmarshal(body, &vmStatus)
 
 	if unmarshallingError != nil {
-		tflog.Error(c.Context, fmt.Sprintf("Failed to unmarshal get vm status response: %s", unmarshallingError.Error()))
+		tflog.Error(ctx, fmt.Sprintf("Failed to unmarshal get vm status response: %s", unmarshallingError.Error()))
 		return "", unmarshallingError
 	}
 
 	return vmStatus.Data.Status, nil
 }
 
-func (c *Client) StartVm(nodeName *string, vmId *string) (*string, error) {
-	request, requestCreationError := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/nodes/%s/qemu/%s/status/start", c.HostURL, *nodeName, *vmId), nil)
+func (c *Client) StartVm(ctx context.Context, nodeName *string, vmId *string) (*string, error) {
+	request, requestCreationError := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/nodes/%s/qemu/%s/status/start", c.HostURL, *nodeName, *vmId), nil)
 
 	if requestCreationError != nil {
-		tflog.Error(c.Context, fmt.Sprintf("Failed to create Start Vm Request http request: %s", requestCreationError.Error()))
+		tflog.Error(ctx, fmt.Sprintf("Failed to create Start Vm Request http request: %s", requestCreationError.Error()))
 		return nil, requestCreationError
 	}
 
 	body, responseError := c.DoRequest(request, FormUrlEncoded)
 
 	if responseError != nil {
-		tflog.Error(c.Context, fmt.Sprintf("Failed to start VM %s, on node %s: %s", *vmId, *nodeName, responseError.Error()))
+		tflog.Error(ctx, fmt.Sprintf("Failed to start VM %s, on node %s: %s", *vmId, *nodeName, responseError.Error()))
 		return nil, responseError
 	}
 
@@ -210,25 +238,25 @@ func (c *Client) StartVm(nodeName *string, vmId *string) (*string, error) {
 	unmarshallingError := json.Unmarshal(body, &vmStatus)
 
 	if unmarshallingError != nil {
-		tflog.Error(c.Context, fmt.Sprintf("Failed to unmarshal start vm response: %s", unmarshallingError.Error()))
+		tflog.Error(ctx, fmt.Sprintf("Failed to unmarshal start vm response: %s", unmarshallingError.Error()))
 		return nil, unmarshallingError
 	}
 
 	return &vmStatus.Upid, nil
 }
 
-func (c *Client) ShutdownVm(nodeName *string, vmId *string) (*string, error) {
-	request, requestCreationError := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/nodes/%s/qemu/%s/status/shutdown", c.HostURL, *nodeName, *vmId), nil)
+func (c *Client) ShutdownVm(ctx context.Context, nodeName *string, vmId *string) (*string, error) {
+	request, requestCreationError := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/nodes/%s/qemu/%s/status/shutdown", c.HostURL, *nodeName, *vmId), nil)
 
 	if requestCreationError != nil {
-		tflog.Error(c.Context, fmt.Sprintf("Failed to create Shutdown Vm Request http request: %s", requestCreationError.Error()))
+		tflog.Error(ctx, fmt.Sprintf("Failed to create Shutdown Vm Request http request: %s", requestCreationError.Error()))
 		return nil, requestCreationError
 	}
 
 	body, responseError := c.DoRequest(request, FormUrlEncoded)
 
 	if responseError != nil {
-		tflog.Error(c.Context, fmt.Sprintf("Failed to shutdown VM %s, on node %s: %s", *vmId, *nodeName, responseError.Error()))
+		tflog.Error(ctx, fmt.Sprintf("Failed to shutdown VM %s, on node %s: %s", *vmId, *nodeName, responseError.Error()))
 		return nil, responseError
 	}
 
@@ -236,28 +264,28 @@ func (c *Client) ShutdownVm(nodeName *string, vmId *string) (*string, error) {
 	unmarshallingError := json.Unmarshal(body, &vmStatus)
 
 	if unmarshallingError != nil {
-		tflog.Error(c.Context, fmt.Sprintf("Failed to unmarshal shutdown vm response: %s", unmarshallingError.Error()))
+		tflog.Error(ctx, fmt.Sprintf("Failed to unmarshal shutdown vm response: %s", unmarshallingError.Error()))
 		return nil, unmarshallingError
 	}
 
 	return &vmStatus.Upid, nil
 }
 
-func (c *Client) MoveVmDisk(diskName *string, nodeName *string, vmId *string, newStorageName *string) (*string, error) {
+func (c *Client) MoveVmDisk(ctx context.Context, diskName *string, nodeName *string, vmId *string, newStorageName *string) (*string, error) {
 	params := url.Values{}
 	params.Add("storage", *newStorageName)
 	params.Add("disk", *diskName)
-	request, requestCreationError := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/nodes/%s/qemu/%s/move_disk", c.HostURL, *nodeName, *vmId), bytes.NewBufferString(params.Encode()))
+	request, requestCreationError := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/nodes/%s/qemu/%s/move_disk", c.HostURL, *nodeName, *vmId), bytes.NewBufferString(params.Encode()))
 
 	if requestCreationError != nil {
-		tflog.Error(c.Context, fmt.Sprintf("Failed to create vm move vm disk http request: %s", requestCreationError.Error()))
+		tflog.Error(ctx, fmt.Sprintf("Failed to create vm move vm disk http request: %s", requestCreationError.Error()))
 		return nil, requestCreationError
 	}
 
 	body, responseError := c.DoRequest(request, FormUrlEncoded)
 
 	if responseError != nil {
-		tflog.Error(c.Context, fmt.Sprintf("Failed to move VM disk %s, on node %s: %s", *vmId, *nodeName, responseError.Error()))
+		tflog.Error(ctx, fmt.Sprintf("Failed to move VM disk %s, on node %s: %s", *vmId, *nodeName, responseError.Error()))
 		return nil, responseError
 	}
 
@@ -265,27 +293,168 @@ func (c *Client) MoveVmDisk(diskName *string, nodeName *string, vmId *string, ne
 	unmarshallingError := json.Unmarshal(body, &vmStatus)
 
 	if unmarshallingError != nil {
-		tflog.Error(c.Context, fmt.Sprintf("Failed to unmarshal migrate vm disk response: %s", unmarshallingError.Error()))
+		tflog.Error(ctx, fmt.Sprintf("Failed to unmarshal migrate vm disk response: %s", unmarshallingError.Error()))
 		return nil, unmarshallingError
 	}
 
 	return &vmStatus.Upid, nil
 }
 
-func (c *Client) MigrateVm(currentNode *string, newNode *string, vmId *string) (*string, error) {
+func (c *Client) MigrateVm(ctx context.Context, currentNode *string, newNode *string, vmId *string) (*string, error) {
 	params := url.Values{}
 	params.Add("target", *newNode)
-	request, requestCreationError := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/nodes/%s/qemu/%s/migrate", c.HostURL, *currentNode, *vmId), bytes.NewBufferString(params.Encode()))
+	request, requestCreationError := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/nodes/%s/qemu/%s/migrate", c.HostURL, *currentNode, *vmId), bytes.NewBufferString(params.Encode()))
+
+	if requestCreationError != nil {
+		tflog.Error(ctx, fmt.Sprintf("Failed to create migrate vm http request: %s", requestCreationError.Error()))
+		return nil, requestCreationError
+	}
+
+	body, responseError := c.DoRequest(request, FormUrlEncoded)
+
+	if responseError != nil {
+		tflog.Error(ctx, fmt.Sprintf("Failed to migrate VM %s, on node %s: %s", *vmId, *currentNode, responseError.Error()))
+		return nil, responseError
+	}
+
+	var vmStatus = proxmoxTypes.TaskCreationResponse{}
+	unmarshallingError := json.Unmarshal(body, &vmStatus)
+
+	if unmarshallingError != nil {
+		tflog.Error(ctx, fmt.Sprintf("Failed to unmarshal migrate vm response: %s", unmarshallingError.Error()))
+		return nil, unmarshallingError
+	}
+
+	return &vmStatus.Upid, nil
+}
+
+// CloneVm clones the vm or template vmId on nodeName, the id of the new vm is passed as newid in cloneRequest
+func (c *Client) CloneVm(ctx context.Context, cloneRequest url.Values, nodeName *string, vmId *string) (*string, error) {
+	request, requestCreationError := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/nodes/%s/qemu/%s/clone", c.HostURL, *nodeName, *vmId), bytes.NewBufferString(cloneRequest.Encode()))
+
+	if requestCreationError != nil {
+		tflog.Error(ctx, fmt.Sprintf("Failed to create clone vm http request: %s", requestCreationError.Error()))
+		return nil, requestCreationError
+	}
+
+	body, responseError := c.DoRequest(request, FormUrlEncoded)
+
+	if responseError != nil {
+		tflog.Error(ctx, fmt.Sprintf("Failed to clone VM %s, on node %s: %s", *vmId, *nodeName, responseError.Error()))
+		return nil, responseError
+	}
+
+	var cloneResponse = proxmoxTypes.TaskCreationResponse{}
+	unmarshallingError := json.Unmarshal(body, &cloneResponse)
+
+	if unmarshallingError != nil {
+		tflog.Error(ctx, fmt.Sprintf("Failed to unmarshal clone vm response: %s", unmarshallingError.Error()))
+		return nil, unmarshallingError
+	}
+
+	return &cloneResponse.Upid, nil
+}
+
+// ListClusterVms lists the vms and templates of every node in the cluster
+func (c *Client) ListClusterVms(ctx context.Context) (*proxmoxTypes.ClusterVmListResponse, error) {
+	request, requestCreationError := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/cluster/resources?type=vm", c.HostURL), nil)
+	if requestCreationError != nil {
+		return nil, requestCreationError
+	}
+
+	body, responseError := c.DoRequest(request, "")
+	if responseError != nil {
+		return nil, responseError
+	}
+
+	var vmList proxmoxTypes.ClusterVmListResponse
+	unmarshallingError := json.Unmarshal(body, &vmList)
+	if unmarshallingError != nil {
+		return nil, unmarshallingError
+	}
+
+	// type=vm also returns containers
+	vms := vmList.Data[:0]
+	for _, vm := range vmList.Data {
+		if vm.Type == "qemu" {
+			vms = append(vms, vm)
+		}
+	}
+	vmList.Data = vms
+	return &vmList, nil
+}
+
+// ConvertVmToTemplate turns the vm into a template, when disk is set only that disk is converted to a base image
+func (c *Client) ConvertVmToTemplate(ctx context.Context, nodeName *string, vmId *string, disk *string) (*string, error) {
+	params := url.Values{}
+	if disk != nil && *disk != "" {
+		params.Add("disk", *disk)
+	}
+	request, requestCreationError := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/nodes/%s/qemu/%s/template", c.HostURL, *nodeName, *vmId), bytes.NewBufferString(params.Encode()))
+
+	if requestCreationError != nil {
+		tflog.Error(ctx, fmt.Sprintf("Failed to create template http request: %s", requestCreationError.Error()))
+		return nil, requestCreationError
+	}
+
+	body, responseError := c.DoRequest(request, FormUrlEncoded)
+
+	if responseError != nil {
+		tflog.Error(ctx, fmt.Sprintf("Failed to convert VM %s on node %s to a template: %s", *vmId, *nodeName, responseError.Error()))
+		return nil, responseError
+	}
+
+	var templateResponse = proxmoxTypes.TaskCreationResponse{}
+	unmarshallingError := json.Unmarshal(body, &templateResponse)
+
+	if unmarshallingError != nil {
+		tflog.Error(ctx, fmt.Sprintf("Failed to unmarshal template response: %s", unmarshallingError.Error()))
+		return nil, unmarshallingError
+	}
+
+	return &templateResponse.Upid, nil
+}
+
+// GetVmPending returns the options of the vm config together with the values that only apply after a restart
+func (c *Client) GetVmPending(ctx context.Context, nodeName *string, vmId *string) (*proxmoxTypes.VmPendingResponse, error) {
+	request, requestCreationError := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/nodes/%s/qemu/%s/pending", c.HostURL, *nodeName, *vmId), nil)
+
+	if requestCreationError != nil {
+		tflog.Error(ctx, fmt.Sprintf("Failed to create GetVmPending http request: %s", requestCreationError.Error()))
+		return nil, requestCreationError
+	}
+
+	body, responseError := c.DoRequest(request, "application/json")
+
+	if responseError != nil {
+		tflog.Error(ctx, fmt.Sprintf("Failed to get pending changes of VM %s, from node %s: %s", *vmId, *nodeName, responseError.Error()))
+		return nil, responseError
+	}
+
+	var pendingResponse = proxmoxTypes.VmPendingResponse{}
+	unmarshallingError := json.Unmarshal(body, &pendingResponse)
+
+	if unmarshallingError != nil {
+		tflog.Error(ctx, fmt.Sprintf("Failed to unmarshal pending vm changes response: %s", unmarshallingError.Error()))
+		return nil, unmarshallingError
+	}
+
+	return &pendingResponse, nil
+}
+
+// RebootVm shuts the vm down and starts it again, which applies its pending changes
+func (c *Client) RebootVm(ctx context.Context, nodeName *string, vmId *string) (*string, error) {
+	request, requestCreationError := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/nodes/%s/qemu/%s/status/reboot", c.HostURL, *nodeName, *vmId), nil)
 
 	if requestCreationError != nil {
-		tflog.Error(c.Context, fmt.Sprintf("Failed to create migrate vm http request: %s", requestCreationError.Error()))
+		tflog.Error(ctx, fmt.Sprintf("Failed to create Reboot Vm Request http request: %s", requestCreationError.Error()))
 		return nil, requestCreationError
 	}
 
 	body, responseError := c.DoRequest(request, FormUrlEncoded)
 
 	if responseError != nil {
-		tflog.Error(c.Context, fmt.Sprintf("Failed to migrate VM %s, on node %s: %s", *vmId, *currentNode, responseError.Error()))
+		tflog.Error(ctx, fmt.Sprintf("Failed to reboot VM %s, on node %s: %s", *vmId, *nodeName, responseError.Error()))
 		return nil, responseError
 	}
 
@@ -293,7 +462,7 @@ func (c *Client) MigrateVm(currentNode *string, newNode *string, vmId *string) (
 	unmarshallingError := json.Unmarshal(body, &vmStatus)
 
 	if unmarshallingError != nil {
-		tflog.Error(c.Context, fmt.Sprintf("Failed to unmarshal migrate vm response: %s", unmarshallingError.Error()))
+		tflog.Error(ctx, fmt.Sprintf("Failed to unmarshal reboot vm response: %s", unmarshallingError.Error()))
 		return nil, unmarshallingError
 	}
 
@@ -0,0 +1,102 @@
+package proxmox_client
+
+import (
+	"errors"
+	"io"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// lockTimeoutMessages are returned by proxmox when the cluster filesystem lock or a config file lock could not be
+// acquired in time. The request was rejected before anything was changed, so it is always safe to send again.
+var lockTimeoutMessages = []string{
+	"cfs-lock",
+	"got timeout",
+	"can't lock file",
+	"unable to acquire lock",
+	"lock request timeout",
+}
+
+type RetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Jitter         float64
+}
+
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    4,
+		InitialBackoff: time.Second,
+		MaxBackoff:     30 * time.Second,
+		Jitter:         0.2,
+	}
+}
+
+// Backoff
+/**
+ * @description exponential backoff starting at InitialBackoff and capped at MaxBackoff, the result is then spread by up
+ * to Jitter in either direction so parallel resources that failed together don't all retry together
+ * @param attempt: the attempt that just failed, starting at 1
+ */
+func (policy *RetryPolicy) Backoff(attempt int) time.Duration {
+	backoff := float64(policy.InitialBackoff) * math.Pow(2, float64(attempt-1))
+	if policy.MaxBackoff > 0 && backoff > float64(policy.MaxBackoff) {
+		backoff = float64(policy.MaxBackoff)
+	}
+	if policy.Jitter > 0 {
+		backoff += backoff * policy.Jitter * (rand.Float64()*2 - 1)
+	}
+	return time.Duration(backoff)
+}
+
+func isLockTimeoutMessage(message string) bool {
+	lowerMessage := strings.ToLower(message)
+	for _, lockTimeoutMessage := range lockTimeoutMessages {
+		if strings.Contains(lowerMessage, lockTimeoutMessage) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsRetryable
+/**
+ * @description decides if a failed request can be sent again without risking a change being applied twice. GETs are
+ * retried on any server side or connection failure, everything else only when proxmox reports a lock timeout or the
+ * connection could not be established before the request could be delivered. Missing objects are reported as a 500
+ * too, asking again will not make them appear.
+ */
+func IsRetryable(method string, requestError error) bool {
+	if apiError, isApiError := AsApiError(requestError); isApiError {
+		if IsLockTimeout(apiError) {
+			return true
+		}
+		if IsNotFound(apiError) {
+			return false
+		}
+		return isIdempotent(method) && apiError.StatusCode >= http.StatusInternalServerError
+	}
+
+	var opError *net.OpError
+	if errors.Is(requestError, syscall.ECONNREFUSED) || (errors.As(requestError, &opError) && opError.Op == "dial") {
+		return true
+	}
+
+	var netError net.Error
+	isTimeout := errors.As(requestError, &netError) && netError.Timeout()
+	if isTimeout || errors.Is(requestError, syscall.ECONNRESET) || errors.Is(requestError, io.EOF) || errors.Is(requestError, io.ErrUnexpectedEOF) {
+		return isIdempotent(method)
+	}
+
+	return false
+}
+
+func isIdempotent(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead
+}
@@ -0,0 +1,77 @@
+package proxmox_client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsRetryable(t *testing.T) {
+	lockTimeout := &ApiError{StatusCode: 500, Message: "cfs-lock 'file-qemu_conf' error: got lock request timeout"}
+	serverError := &ApiError{StatusCode: 500, Message: "Internal Server Error"}
+	badRequest := &ApiError{StatusCode: 400, Message: "Parameter verification failed."}
+	missingConfig := &ApiError{StatusCode: 500, Message: "Configuration file 'nodes/pve/qemu-server/100.conf' does not exist"}
+
+	testCases := []struct {
+		name     string
+		method   string
+		err      error
+		expected bool
+	}{
+		{"get server error", http.MethodGet, serverError, true},
+		{"post server error", http.MethodPost, serverError, false},
+		{"post lock timeout", http.MethodPost, lockTimeout, true},
+		{"put lock timeout", http.MethodPut, lockTimeout, true},
+		{"get bad request", http.MethodGet, badRequest, false},
+		{"get missing config", http.MethodGet, missingConfig, false},
+		{"get connection reset", http.MethodGet, fmt.Errorf("read: %w", io.ErrUnexpectedEOF), true},
+		{"post connection reset", http.MethodPost, fmt.Errorf("read: %w", io.ErrUnexpectedEOF), false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			assert.Equal(t, testCase.expected, IsRetryable(testCase.method, testCase.err))
+		})
+	}
+}
+
+func TestBackoffIsCapped(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 10, InitialBackoff: time.Second, MaxBackoff: 5 * time.Second}
+
+	assert.Equal(t, time.Second, policy.Backoff(1))
+	assert.Equal(t, 4*time.Second, policy.Backoff(3))
+	assert.Equal(t, 5*time.Second, policy.Backoff(8))
+}
+
+func TestLockTimeoutIsRetriedWithBody(t *testing.T) {
+	var receivedBodies []string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := io.ReadAll(request.Body)
+		receivedBodies = append(receivedBodies, string(body))
+		if len(receivedBodies) == 1 {
+			writer.WriteHeader(http.StatusInternalServerError)
+			_, _ = fmt.Fprint(writer, `{"data":null,"message":"can't lock file '/var/lock/qemu-server/lock-100.conf' - got timeout"}`)
+			return
+		}
+		_, _ = fmt.Fprint(writer, `{"data":null}`)
+	}))
+	defer server.Close()
+
+	client, _ := NewClient([]string{server.URL}, &AuthStruct{TokenId: "root@pam!test"}, &TlsOptions{FingerprintSha256: serverFingerprint(server)}, &RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+	}, nil, nil, nil)
+
+	request, _ := http.NewRequestWithContext(context.Background(), http.MethodPost, fmt.Sprintf("%s/api2/json/nodes/pve-01/qemu/100/config", server.URL), bytes.NewBufferString("memory=2048"))
+	_, requestError := client.DoRequest(request, FormUrlEncoded)
+
+	assert.NoError(t, requestError)
+	assert.Equal(t, []string{"memory=2048", "memory=2048"}, receivedBodies)
+}
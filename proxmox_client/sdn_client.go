@@ -2,8 +2,8 @@ package proxmox_client
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -12,25 +12,25 @@ import (
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 )
 
-func (c *Client) CreateSdnZone(sdnZoneCreationBody url.Values) error {
+func (c *Client) CreateSdnZone(ctx context.Context, sdnZoneCreationBody url.Values) error {
 
-	request, requestCreationError := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/cluster/sdn/zones", c.HostURL), bytes.NewBufferString(sdnZoneCreationBody.Encode()))
+	request, requestCreationError := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/cluster/sdn/zones", c.HostURL), bytes.NewBufferString(sdnZoneCreationBody.Encode()))
 
 	if requestCreationError != nil {
 		return requestCreationError
 	}
 
-	body, responseError := c.DoRequest(request, FormUrlEncoded)
+	_, responseError := c.DoRequest(request, FormUrlEncoded)
 	if responseError != nil {
-		return errors.Join(responseError, errors.New(string(body)))
+		return responseError
 	}
 
 	return nil
 }
 
-func (c *Client) GetSdnZone(zone string) (*proxmoxTypes.SdnZoneResponse, error) {
+func (c *Client) GetSdnZone(ctx context.Context, zone string) (*proxmoxTypes.SdnZoneResponse, error) {
 
-	request, requestCreationError := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/cluster/sdn/zones/%s", c.HostURL, url.PathEscape(zone)), nil)
+	request, requestCreationError := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/cluster/sdn/zones/%s", c.HostURL, url.PathEscape(zone)), nil)
 
 	if requestCreationError != nil {
 		return nil, requestCreationError
@@ -38,9 +38,9 @@ func (c *Client) GetSdnZone(zone string) (*proxmoxTypes.SdnZoneResponse, error)
 
 	body, responseError := c.DoRequest(request, FormUrlEncoded)
 	if responseError != nil {
-		return nil, errors.Join(responseError, errors.New(string(body)))
+		return nil, responseError
 	}
-	tflog.Debug(c.Context, string(body))
+	tflog.Debug(ctx, string(body))
 	var zoneCreationResponse = proxmoxTypes.SdnZoneResponse{}
 
 	unmarshallingError := json.Unmarshal(body, &zoneCreationResponse)
@@ -51,33 +51,41 @@ func (c *Client) GetSdnZone(zone string) (*proxmoxTypes.SdnZoneResponse, error)
 	return &zoneCreationResponse, nil
 }
 
-func (c *Client) DeleteSdnZone(zone string) error {
+func (c *Client) DeleteSdnZone(ctx context.Context, zone string) error {
 
-	request, requestCreationError := http.NewRequest(http.MethodDelete, fmt.Sprintf("%s/cluster/sdn/zones/%s", c.HostURL, url.PathEscape(zone)), nil)
+	request, requestCreationError := http.NewRequestWithContext(ctx, http.MethodDelete, fmt.Sprintf("%s/cluster/sdn/zones/%s", c.HostURL, url.PathEscape(zone)), nil)
 
 	if requestCreationError != nil {
 		return requestCreationError
 	}
 
-	body, responseError := c.DoRequest(request, FormUrlEncoded)
+	_, responseError := c.DoRequest(request, FormUrlEncoded)
 	if responseError != nil {
-		return errors.Join(responseError, errors.New(string(body)))
+		return responseError
 	}
 
 	return nil
 }
 
-func (c *Client) UpdateSdnZone(sdnZoneCreationBody url.Values) error {
+// UpdateSdnZone the zone is taken from the zone parameter, proxmox expects it in the path rather than the body
+func (c *Client) UpdateSdnZone(ctx context.Context, sdnZoneCreationBody url.Values) error {
+	zone := sdnZoneCreationBody.Get("zone")
+	updateBody := url.Values{}
+	for key, values := range sdnZoneCreationBody {
+		if key != "zone" {
+			updateBody[key] = values
+		}
+	}
 
-	request, requestCreationError := http.NewRequest(http.MethodPut, fmt.Sprintf("%s/cluster/sdn/zones", c.HostURL), bytes.NewBufferString(sdnZoneCreationBody.Encode()))
+	request, requestCreationError := http.NewRequestWithContext(ctx, http.MethodPut, fmt.Sprintf("%s/cluster/sdn/zones/%s", c.HostURL, url.PathEscape(zone)), bytes.NewBufferString(updateBody.Encode()))
 
 	if requestCreationError != nil {
 		return requestCreationError
 	}
 
-	body, responseError := c.DoRequest(request, FormUrlEncoded)
+	_, responseError := c.DoRequest(request, FormUrlEncoded)
 	if responseError != nil {
-		return errors.Join(responseError, errors.New(string(body)))
+		return responseError
 	}
 
 	return nil
@@ -0,0 +1,194 @@
+package proxmox_client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// SshOptions give access to the shell of the nodes for the few things the api can't do, such as writing snippets
+type SshOptions struct {
+	Username       string
+	Password       string
+	PrivateKey     string
+	Port           int
+	HostKeySha256  string
+	KnownHostsFile string
+}
+
+var ErrSshNotConfigured = errors.New("ssh access to the nodes is not configured, set the ssh block of the provider")
+
+const defaultSshPort = 22
+
+// clientConfig authenticates with the private key and the password, whichever are set. Host keys are checked against
+// the pinned fingerprint or else against the known hosts file.
+func (options *SshOptions) clientConfig() (*ssh.ClientConfig, error) {
+	var authMethods []ssh.AuthMethod
+	if options.PrivateKey != "" {
+		signer, parseError := ssh.ParsePrivateKey([]byte(options.PrivateKey))
+		if parseError != nil {
+			return nil, fmt.Errorf("failed to parse the ssh private key: %w", parseError)
+		}
+		authMethods = append(authMethods, ssh.PublicKeys(signer))
+	}
+	if options.Password != "" {
+		authMethods = append(authMethods, ssh.Password(options.Password))
+	}
+	if len(authMethods) == 0 {
+		return nil, errors.New("ssh needs a private key or a password")
+	}
+
+	var hostKeyCallback ssh.HostKeyCallback
+	if options.HostKeySha256 != "" {
+		pinned := strings.TrimPrefix(strings.TrimSpace(options.HostKeySha256), "SHA256:")
+		hostKeyCallback = func(hostname string, _ net.Addr, key ssh.PublicKey) error {
+			if strings.TrimPrefix(ssh.FingerprintSHA256(key), "SHA256:") != pinned {
+				return errors.New(fmt.Sprintf("the host key of %s does not match host_key_sha256, got %s", hostname, ssh.FingerprintSHA256(key)))
+			}
+			return nil
+		}
+	} else {
+		knownHostsFile := options.KnownHostsFile
+		if knownHostsFile == "" {
+			home, homeError := os.UserHomeDir()
+			if homeError != nil {
+				return nil, homeError
+			}
+			knownHostsFile = filepath.Join(home, ".ssh", "known_hosts")
+		}
+		knownHostsCallback, knownHostsError := knownhosts.New(knownHostsFile)
+		if knownHostsError != nil {
+			return nil, fmt.Errorf("failed to read the ssh known hosts: %w", knownHostsError)
+		}
+		hostKeyCallback = knownHostsCallback
+	}
+
+	username := options.Username
+	if username == "" {
+		username = "root"
+	}
+	return &ssh.ClientConfig{
+		User:            username,
+		Auth:            authMethods,
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         10 * time.Second,
+	}, nil
+}
+
+// nodeAddress returns the address cluster status reports for the node, the host of the active api endpoint when it
+// reports none
+func (c *Client) nodeAddress(ctx context.Context, nodeName string) (string, error) {
+	clusterStatus, clusterStatusError := c.GetClusterStatus(ctx)
+	if clusterStatusError != nil {
+		return "", clusterStatusError
+	}
+	for _, item := range clusterStatus.Data {
+		if item.Type == "node" && item.Name == nodeName && item.Ip != "" {
+			return item.Ip, nil
+		}
+	}
+
+	// the active endpoint is the one that just answered, the first configured host may be down
+	activeUrl := url.URL{Host: c.endpoints.current().host}
+	return activeUrl.Hostname(), nil
+}
+
+// WriteNodeFile
+/**
+ * @description writes the file to the node over ssh, creating its directory when needed. An existing file is
+ * overwritten.
+ */
+func (c *Client) WriteNodeFile(ctx context.Context, nodeName string, filePath string, data []byte) error {
+	tflog.Debug(ctx, fmt.Sprintf("writing %s on node %s over ssh", filePath, nodeName))
+	command := fmt.Sprintf("mkdir -p %s && cat > %s", shellQuote(path.Dir(filePath)), shellQuote(filePath))
+	_, runError := c.runNodeCommand(ctx, nodeName, command, data)
+	if runError != nil {
+		return fmt.Errorf("failed to write %s on node %s: %w", filePath, nodeName, runError)
+	}
+	return nil
+}
+
+// ChecksumNodeFile returns the hex encoded sha256 checksum of the file on the node, read over ssh
+func (c *Client) ChecksumNodeFile(ctx context.Context, nodeName string, filePath string) (string, error) {
+	tflog.Debug(ctx, fmt.Sprintf("reading the checksum of %s on node %s over ssh", filePath, nodeName))
+	output, runError := c.runNodeCommand(ctx, nodeName, fmt.Sprintf("sha256sum %s", shellQuote(filePath)), nil)
+	if runError != nil {
+		return "", fmt.Errorf("failed to read the checksum of %s on node %s: %w", filePath, nodeName, runError)
+	}
+	checksum, _, _ := strings.Cut(string(output), " ")
+	if len(checksum) != 64 {
+		return "", errors.New(fmt.Sprintf("sha256sum printed no checksum for %s on node %s: %s", filePath, nodeName, strings.TrimSpace(string(output))))
+	}
+	return checksum, nil
+}
+
+// runNodeCommand runs the command with a posix shell on the node over ssh and returns what it printed. stdin is passed
+// to the command when it is not nil.
+func (c *Client) runNodeCommand(ctx context.Context, nodeName string, command string, stdin []byte) ([]byte, error) {
+	if c.ssh == nil {
+		return nil, ErrSshNotConfigured
+	}
+
+	config, configError := c.ssh.clientConfig()
+	if configError != nil {
+		return nil, configError
+	}
+	address, addressError := c.nodeAddress(ctx, nodeName)
+	if addressError != nil {
+		return nil, addressError
+	}
+	port := c.ssh.Port
+	if port == 0 {
+		port = defaultSshPort
+	}
+	hostPort := net.JoinHostPort(address, strconv.Itoa(port))
+
+	dialer := net.Dialer{Timeout: config.Timeout}
+	connection, dialError := dialer.DialContext(ctx, "tcp", hostPort)
+	if dialError != nil {
+		return nil, fmt.Errorf("failed to connect to node %s over ssh: %w", nodeName, dialError)
+	}
+	sshConnection, channels, requests, handshakeError := ssh.NewClientConn(connection, hostPort, config)
+	if handshakeError != nil {
+		_ = connection.Close()
+		return nil, fmt.Errorf("failed to connect to node %s over ssh: %w", nodeName, handshakeError)
+	}
+	client := ssh.NewClient(sshConnection, channels, requests)
+	defer client.Close()
+
+	session, sessionError := client.NewSession()
+	if sessionError != nil {
+		return nil, sessionError
+	}
+	defer session.Close()
+
+	var stdout, stderr bytes.Buffer
+	if stdin != nil {
+		session.Stdin = bytes.NewReader(stdin)
+	}
+	session.Stdout = &stdout
+	session.Stderr = &stderr
+	runError := session.Run(command)
+	if runError != nil {
+		return nil, fmt.Errorf("%w %s", runError, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
+
+// shellQuote quotes the value for a posix shell
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
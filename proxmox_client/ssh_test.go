@@ -0,0 +1,161 @@
+package proxmox_client
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+)
+
+// newShellServer starts an ssh server that runs exec requests with the local shell, it accepts the password secret
+func newShellServer(t *testing.T) (net.Listener, ssh.PublicKey) {
+	_, hostPrivateKey, _ := ed25519.GenerateKey(rand.Reader)
+	hostSigner, _ := ssh.NewSignerFromKey(hostPrivateKey)
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(metadata ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if metadata.User() == "root" && string(password) == "secret" {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("access denied")
+		},
+	}
+	config.AddHostKey(hostSigner)
+
+	listener, listenError := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, listenError)
+	go func() {
+		for {
+			connection, acceptError := listener.Accept()
+			if acceptError != nil {
+				return
+			}
+			go serveShell(connection, config)
+		}
+	}()
+	return listener, hostSigner.PublicKey()
+}
+
+func serveShell(connection net.Conn, config *ssh.ServerConfig) {
+	_, channels, requests, handshakeError := ssh.NewServerConn(connection, config)
+	if handshakeError != nil {
+		return
+	}
+	go ssh.DiscardRequests(requests)
+	for newChannel := range channels {
+		channel, channelRequests, _ := newChannel.Accept()
+		go func() {
+			defer channel.Close()
+			for request := range channelRequests {
+				if request.Type != "exec" {
+					_ = request.Reply(false, nil)
+					continue
+				}
+				_ = request.Reply(true, nil)
+				command := exec.Command("sh", "-c", string(request.Payload[4:]))
+				command.Stdin = channel
+				command.Stdout = channel
+				command.Stderr = channel.Stderr()
+				exitStatus := 0
+				if runError := command.Run(); runError != nil {
+					exitStatus = 1
+				}
+				status := make([]byte, 4)
+				binary.BigEndian.PutUint32(status, uint32(exitStatus))
+				_, _ = channel.SendRequest("exit-status", false, status)
+				return
+			}
+		}()
+	}
+}
+
+func newClusterStatusServer() *httptest.Server {
+	return httptest.NewTLSServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		_, _ = fmt.Fprint(writer, `{"data":[{"type":"cluster","name":"test"},{"type":"node","name":"pve-01","ip":"127.0.0.1"}]}`)
+	}))
+}
+
+func TestWriteNodeFile(t *testing.T) {
+	sshListener, hostKey := newShellServer(t)
+	defer sshListener.Close()
+	server := newClusterStatusServer()
+	defer server.Close()
+
+	newSshClient := func(sshOptions *SshOptions) ProxmoxClient {
+		client, newClientError := NewClient([]string{server.URL}, &AuthStruct{TokenId: "root@pam!test", TokenSecret: "secret"}, &TlsOptions{
+			FingerprintSha256: serverFingerprint(server),
+		}, nil, nil, nil, sshOptions)
+		assert.NoError(t, newClientError)
+		return client
+	}
+	port := sshListener.Addr().(*net.TCPAddr).Port
+	filePath := filepath.Join(t.TempDir(), "snippets", "it's.yaml")
+
+	client := newSshClient(&SshOptions{Password: "secret", Port: port, HostKeySha256: ssh.FingerprintSHA256(hostKey)})
+	assert.NoError(t, client.WriteNodeFile(context.Background(), "pve-01", filePath, []byte("#cloud-config\n")))
+	written, _ := os.ReadFile(filePath)
+	assert.Equal(t, "#cloud-config\n", string(written))
+
+	client = newSshClient(&SshOptions{Password: "secret", Port: port, HostKeySha256: "SHA256:AAAA"})
+	assert.ErrorContains(t, client.WriteNodeFile(context.Background(), "pve-01", filePath, nil), "does not match host_key_sha256")
+
+	client = newSshClient(nil)
+	assert.ErrorIs(t, client.WriteNodeFile(context.Background(), "pve-01", filePath, nil), ErrSshNotConfigured)
+}
+
+func TestChecksumNodeFile(t *testing.T) {
+	sshListener, hostKey := newShellServer(t)
+	defer sshListener.Close()
+	server := newClusterStatusServer()
+	defer server.Close()
+
+	client, newClientError := NewClient([]string{server.URL}, &AuthStruct{TokenId: "root@pam!test", TokenSecret: "secret"}, &TlsOptions{
+		FingerprintSha256: serverFingerprint(server),
+	}, nil, nil, nil, &SshOptions{Password: "secret", Port: sshListener.Addr().(*net.TCPAddr).Port, HostKeySha256: ssh.FingerprintSHA256(hostKey)})
+	assert.NoError(t, newClientError)
+	filePath := filepath.Join(t.TempDir(), "web.yaml")
+	assert.NoError(t, os.WriteFile(filePath, []byte("#cloud-config\n"), 0o644))
+
+	checksum, checksumError := client.ChecksumNodeFile(context.Background(), "pve-01", filePath)
+
+	assert.NoError(t, checksumError)
+	expected := sha256.Sum256([]byte("#cloud-config\n"))
+	assert.Equal(t, hex.EncodeToString(expected[:]), checksum)
+
+	_, missingError := client.ChecksumNodeFile(context.Background(), "pve-01", filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.ErrorContains(t, missingError, "No such file")
+}
+
+func TestNodeAddressFallsBackToTheActiveEndpoint(t *testing.T) {
+	unreachable := newClusterStatusServer()
+	unreachableUrl := strings.Replace(unreachable.URL, "127.0.0.1", "localhost", 1)
+	unreachable.Close()
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		_, _ = fmt.Fprint(writer, `{"data":[{"type":"node","name":"pve-01"}]}`)
+	}))
+	defer server.Close()
+
+	client, newClientError := NewClient([]string{unreachableUrl, server.URL}, &AuthStruct{TokenId: "root@pam!test", TokenSecret: "secret"}, &TlsOptions{
+		FingerprintSha256: serverFingerprint(server),
+	}, &RetryPolicy{MaxAttempts: 1}, nil, nil, nil)
+	assert.NoError(t, newClientError)
+
+	address, addressError := client.(*Client).nodeAddress(context.Background(), "pve-01")
+
+	assert.NoError(t, addressError)
+	assert.Equal(t, "127.0.0.1", address)
+}
@@ -0,0 +1,125 @@
+package proxmox_client
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+type TlsOptions struct {
+	VerifyTls         bool
+	CaCertPem         string
+	CaCertFile        string
+	FingerprintSha256 string
+}
+
+// NormalizeFingerprint
+/**
+ * @description proxmox reports certificate fingerprints as colon separated upper case hex, this strips the separators so
+ * fingerprints copied from the ui, pveum or the node datasource can be compared with each other
+ */
+func NormalizeFingerprint(fingerprint string) (string, error) {
+	normalized := strings.ToLower(strings.NewReplacer(":", "", " ", "").Replace(strings.TrimSpace(fingerprint)))
+	decoded, decodeError := hex.DecodeString(normalized)
+	if decodeError != nil || len(decoded) != sha256.Size {
+		return "", errors.New(fmt.Sprintf("%s is not a valid sha256 fingerprint", fingerprint))
+	}
+	return normalized, nil
+}
+
+// fingerprintSet holds the pinned certificate fingerprints, discovered cluster nodes add theirs at runtime
+type fingerprintSet struct {
+	lock         sync.RWMutex
+	fingerprints map[string]bool
+}
+
+func (set *fingerprintSet) add(fingerprint string) error {
+	normalized, fingerprintError := NormalizeFingerprint(fingerprint)
+	if fingerprintError != nil {
+		return fingerprintError
+	}
+	set.lock.Lock()
+	defer set.lock.Unlock()
+	set.fingerprints[normalized] = true
+	return nil
+}
+
+func (set *fingerprintSet) contains(fingerprint string) bool {
+	set.lock.RLock()
+	defer set.lock.RUnlock()
+	return set.fingerprints[fingerprint]
+}
+
+// connectTimeout bounds connecting to a node, the tls handshake is bounded by the default transport
+const connectTimeout = 10 * time.Second
+
+// newHttpTransport builds a transport owned by a single client so tls settings never leak into other http clients in the process.
+// The returned fingerprint set is nil unless certificates are pinned
+func newHttpTransport(options *TlsOptions) (*http.Transport, *fingerprintSet, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// requests have no overall timeout, so unreachable nodes have to fail fast while connecting to fail over in time
+	transport.DialContext = (&net.Dialer{Timeout: connectTimeout, KeepAlive: 30 * time.Second}).DialContext
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	var pinnedFingerprints *fingerprintSet
+
+	if options.CaCertPem != "" || options.CaCertFile != "" {
+		certPool, certPoolError := x509.SystemCertPool()
+		if certPoolError != nil {
+			certPool = x509.NewCertPool()
+		}
+
+		if options.CaCertPem != "" && !certPool.AppendCertsFromPEM([]byte(options.CaCertPem)) {
+			return nil, nil, errors.New("no certificates could be parsed from the provided ca certificate pem")
+		}
+
+		if options.CaCertFile != "" {
+			caCert, readError := os.ReadFile(options.CaCertFile)
+			if readError != nil {
+				return nil, nil, errors.New(fmt.Sprintf("failed to read ca certificate file: %s", readError.Error()))
+			}
+			if !certPool.AppendCertsFromPEM(caCert) {
+				return nil, nil, errors.New(fmt.Sprintf("no certificates could be parsed from %s", options.CaCertFile))
+			}
+		}
+		tlsConfig.RootCAs = certPool
+	}
+
+	if options.FingerprintSha256 != "" {
+		pinnedFingerprints = &fingerprintSet{fingerprints: map[string]bool{}}
+		fingerprintError := pinnedFingerprints.add(options.FingerprintSha256)
+		if fingerprintError != nil {
+			return nil, nil, fingerprintError
+		}
+		// the chain is not verified when pinning, self signed node certificates are the main reason to pin
+		tlsConfig.InsecureSkipVerify = true
+		tlsConfig.VerifyConnection = func(state tls.ConnectionState) error {
+			return verifyPinnedFingerprint(state, pinnedFingerprints)
+		}
+	} else if !options.VerifyTls {
+		tlsConfig.InsecureSkipVerify = true
+	}
+
+	transport.TLSClientConfig = tlsConfig
+	return transport, pinnedFingerprints, nil
+}
+
+func verifyPinnedFingerprint(state tls.ConnectionState, pinnedFingerprints *fingerprintSet) error {
+	if len(state.PeerCertificates) == 0 {
+		return errors.New("server did not present a certificate")
+	}
+	sum := sha256.Sum256(state.PeerCertificates[0].Raw)
+	actualFingerprint := hex.EncodeToString(sum[:])
+	if !pinnedFingerprints.contains(actualFingerprint) {
+		return errors.New(fmt.Sprintf("certificate fingerprint %s does not match the pinned fingerprint", actualFingerprint))
+	}
+	return nil
+}
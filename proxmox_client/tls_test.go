@@ -0,0 +1,107 @@
+package proxmox_client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func serverFingerprint(server *httptest.Server) string {
+	sum := sha256.Sum256(server.Certificate().Raw)
+	var parts []string
+	for _, b := range sum {
+		parts = append(parts, fmt.Sprintf("%02X", b))
+	}
+	return strings.Join(parts, ":")
+}
+
+func newNodeListServer() *httptest.Server {
+	return httptest.NewTLSServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		_, _ = fmt.Fprint(writer, `{"data":[{"node":"pve-01"}]}`)
+	}))
+}
+
+func TestPinnedFingerprintIsTrusted(t *testing.T) {
+	server := newNodeListServer()
+	defer server.Close()
+
+	client, newClientError := NewClient([]string{server.URL}, &AuthStruct{TokenId: "root@pam!test", TokenSecret: "secret"}, &TlsOptions{
+		VerifyTls:         true,
+		FingerprintSha256: serverFingerprint(server),
+	}, nil, nil, nil, nil)
+	assert.NoError(t, newClientError)
+
+	_, listNodesError := client.ListNodes(context.Background())
+	assert.NoError(t, listNodesError)
+}
+
+func TestMismatchedFingerprintIsRejected(t *testing.T) {
+	server := newNodeListServer()
+	defer server.Close()
+
+	client, newClientError := NewClient([]string{server.URL}, &AuthStruct{TokenId: "root@pam!test", TokenSecret: "secret"}, &TlsOptions{
+		VerifyTls:         true,
+		FingerprintSha256: strings.Repeat("AB:", 31) + "AB",
+	}, nil, nil, nil, nil)
+	assert.NoError(t, newClientError)
+
+	_, listNodesError := client.ListNodes(context.Background())
+	assert.ErrorContains(t, listNodesError, "does not match the pinned fingerprint")
+}
+
+func TestCustomCaIsTrusted(t *testing.T) {
+	server := newNodeListServer()
+	defer server.Close()
+	caPem := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+	// the httptest certificate is only valid for example.com and loopback addresses so the host has to stay an ip
+	client, newClientError := NewClient([]string{server.URL}, &AuthStruct{TokenId: "root@pam!test", TokenSecret: "secret"}, &TlsOptions{
+		VerifyTls: true,
+		CaCertPem: string(caPem),
+	}, nil, nil, nil, nil)
+	assert.NoError(t, newClientError)
+
+	_, listNodesError := client.ListNodes(context.Background())
+	assert.NoError(t, listNodesError)
+}
+
+func TestDefaultTransportIsNotModified(t *testing.T) {
+	host := "localhost:8006"
+	_, newClientError := NewClient([]string{host}, &AuthStruct{TokenId: "root@pam!test"}, &TlsOptions{VerifyTls: false}, nil, nil, nil, nil)
+	assert.NoError(t, newClientError)
+
+	tlsConfig := http.DefaultTransport.(*http.Transport).TLSClientConfig
+	assert.True(t, tlsConfig == nil || !tlsConfig.InsecureSkipVerify)
+}
+
+func TestSlowRequestIsBoundedByTheContextOnly(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		select {
+		case <-request.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer server.Close()
+
+	client, _ := NewClient([]string{server.URL}, &AuthStruct{TokenId: "root@pam!test"}, &TlsOptions{FingerprintSha256: serverFingerprint(server)}, &RetryPolicy{MaxAttempts: 1}, nil, nil, nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, listNodesError := client.ListNodes(ctx)
+
+	assert.Zero(t, client.(*Client).HTTPClient.Timeout)
+	assert.ErrorIs(t, listNodesError, context.DeadlineExceeded)
+}
+
+func TestNormalizeFingerprintRejectsGarbage(t *testing.T) {
+	_, normalizeError := NormalizeFingerprint("AB:CD")
+	assert.Error(t, normalizeError)
+}
@@ -0,0 +1,49 @@
+package proxmox_client
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const totpPeriod = 30 * time.Second
+const totpDigits = 6
+
+// GenerateTotpCode
+/**
+ * @description generates an RFC 6238 time based one time password, this matches what proxmox expects from an authenticator app
+ * @param secret: the base32 encoded secret shown when the second factor was registered in proxmox
+ * @param at: the point in time the code should be valid for
+ *
+ * @return the six digit code
+ */
+func GenerateTotpCode(secret string, at time.Time) (string, error) {
+	normalizedSecret := strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(secret), " ", ""))
+	normalizedSecret = strings.TrimRight(normalizedSecret, "=")
+	key, decodeError := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(normalizedSecret)
+	if decodeError != nil {
+		return "", errors.New(fmt.Sprintf("totp secret is not valid base32: %s", decodeError.Error()))
+	}
+
+	counter := make([]byte, 8)
+	binary.BigEndian.PutUint64(counter, uint64(at.Unix()/int64(totpPeriod.Seconds())))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	truncated := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	modulus := uint32(1)
+	for i := 0; i < totpDigits; i++ {
+		modulus *= 10
+	}
+
+	return fmt.Sprintf("%0*d", totpDigits, truncated%modulus), nil
+}
@@ -0,0 +1,32 @@
+package proxmox_client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// test vectors taken from RFC 6238 appendix B, truncated to six digits
+func TestGenerateTotpCode(t *testing.T) {
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	testCases := map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	}
+
+	for unixTime, expected := range testCases {
+		code, generateError := GenerateTotpCode(secret, time.Unix(unixTime, 0))
+		assert.NoError(t, generateError)
+		assert.Equal(t, expected, code)
+	}
+}
+
+func TestGenerateTotpCodeRejectsInvalidSecret(t *testing.T) {
+	_, generateError := GenerateTotpCode("not base32!", time.Unix(59, 0))
+	assert.Error(t, generateError)
+}
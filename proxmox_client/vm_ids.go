@@ -0,0 +1,186 @@
+package proxmox_client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"sync"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+const (
+	// minVmId and maxVmId are the lowest and highest ids proxmox accepts for vms and containers
+	minVmId = 100
+	maxVmId = 999999999
+)
+
+// vmIdConflictRegex matches the errors of creates and clones whose id was taken after it was handed out
+var vmIdConflictRegex = regexp.MustCompile(`VM \d+ already exists|config file already exists`)
+
+// VmIdRange limits the ids handed out to vms created without an id. Both ends are inclusive, a zero value leaves that
+// end at the limit proxmox enforces.
+type VmIdRange struct {
+	First int
+	Last  int
+}
+
+// vmIdReservations keeps the ids handed out to creates that have not completed yet, so parallel creates within the
+// same run never get the same id. Other clients are only noticed once their vm exists.
+type vmIdReservations struct {
+	lock     sync.Mutex
+	first    int
+	last     int
+	reserved map[int]bool
+}
+
+func newVmIdReservations(idRange VmIdRange) (*vmIdReservations, error) {
+	reservations := vmIdReservations{first: idRange.First, last: idRange.Last, reserved: map[int]bool{}}
+	if reservations.first == 0 {
+		reservations.first = minVmId
+	}
+	if reservations.last == 0 {
+		reservations.last = maxVmId
+	}
+	if reservations.first < minVmId || reservations.last > maxVmId || reservations.first > reservations.last {
+		return nil, errors.New(fmt.Sprintf("invalid vm id range %d-%d, ids have to be between %d and %d", reservations.first, reservations.last, minVmId, maxVmId))
+	}
+	return &reservations, nil
+}
+
+// taskExitStatusError is implemented by the errors of failed tasks, the race for an id can also be lost after the
+// create or clone task has been started
+type taskExitStatusError interface {
+	error
+	TaskExitStatus() string
+}
+
+// IsVmIdConflict reports whether a create or clone failed because another client created a vm or container with the
+// same id in the meantime
+func IsVmIdConflict(err error) bool {
+	if apiError, isApiError := AsApiError(err); isApiError {
+		return vmIdConflictRegex.MatchString(apiError.Message)
+	}
+	var taskError taskExitStatusError
+	if errors.As(err, &taskError) {
+		return vmIdConflictRegex.MatchString(taskError.TaskExitStatus())
+	}
+	return false
+}
+
+// GetNextVmId returns the lowest free id of the cluster. When vmId is set proxmox checks that id instead and rejects
+// it with a vmid parameter error when it is taken.
+func (c *Client) GetNextVmId(ctx context.Context, vmId *string) (*string, error) {
+	requestUrl := fmt.Sprintf("%s/cluster/nextid", c.HostURL)
+	if vmId != nil {
+		requestUrl = fmt.Sprintf("%s?vmid=%s", requestUrl, url.QueryEscape(*vmId))
+	}
+	request, requestCreationError := http.NewRequestWithContext(ctx, http.MethodGet, requestUrl, nil)
+	if requestCreationError != nil {
+		return nil, requestCreationError
+	}
+
+	body, responseError := c.DoRequest(request, "")
+	if responseError != nil {
+		return nil, responseError
+	}
+
+	// the id is returned as a string by current versions and as a number by older ones
+	var nextIdResponse struct {
+		Data json.Number `json:"data"`
+	}
+	unmarshallingError := json.Unmarshal(body, &nextIdResponse)
+	if unmarshallingError != nil {
+		return nil, unmarshallingError
+	}
+
+	nextId := nextIdResponse.Data.String()
+	return &nextId, nil
+}
+
+// ReserveVmId
+/**
+ * @description finds a free id within the configured range that no other create of this client holds. Ids of
+ * existing vms and containers are skipped and the chosen id is confirmed with proxmox. Call the returned function once
+ * the vm has been created or the create has failed. Another client may still take the id before the vm is created,
+ * check for IsVmIdConflict and reserve another id in that case.
+ */
+func (c *Client) ReserveVmId(ctx context.Context) (*string, func(), error) {
+	c.vmIds.lock.Lock()
+	defer c.vmIds.lock.Unlock()
+
+	usedIds, listError := c.listUsedVmIds(ctx)
+	if listError != nil {
+		return nil, nil, listError
+	}
+
+	candidate := c.vmIds.first
+	if candidate == minVmId {
+		// without a lower bound proxmox knows best, this also honours the next-id setting of the datacenter
+		nextId, nextIdError := c.GetNextVmId(ctx, nil)
+		if nextIdError != nil {
+			return nil, nil, nextIdError
+		}
+		candidate, _ = strconv.Atoi(*nextId)
+	}
+
+	for ; candidate <= c.vmIds.last; candidate++ {
+		if usedIds[candidate] || c.vmIds.reserved[candidate] {
+			continue
+		}
+
+		vmId := strconv.Itoa(candidate)
+		_, checkIdError := c.GetNextVmId(ctx, &vmId)
+		if apiError, isApiError := AsApiError(checkIdError); isApiError && apiError.Errors["vmid"] != "" {
+			tflog.Debug(ctx, fmt.Sprintf("vm id %s is taken: %s", vmId, apiError.Errors["vmid"]))
+			continue
+		}
+		if checkIdError != nil {
+			return nil, nil, checkIdError
+		}
+
+		c.vmIds.reserved[candidate] = true
+		reservedId := candidate
+		return &vmId, func() {
+			c.vmIds.lock.Lock()
+			defer c.vmIds.lock.Unlock()
+			delete(c.vmIds.reserved, reservedId)
+		}, nil
+	}
+
+	return nil, nil, errors.New(fmt.Sprintf("there is no free vm id left between %d and %d", c.vmIds.first, c.vmIds.last))
+}
+
+// listUsedVmIds returns the ids of every vm and container of the cluster, they share the same ids
+func (c *Client) listUsedVmIds(ctx context.Context) (map[int]bool, error) {
+	request, requestCreationError := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/cluster/resources?type=vm", c.HostURL), nil)
+	if requestCreationError != nil {
+		return nil, requestCreationError
+	}
+
+	body, responseError := c.DoRequest(request, "")
+	if responseError != nil {
+		return nil, responseError
+	}
+
+	var resources struct {
+		Data []struct {
+			VmId int `json:"vmid"`
+		} `json:"data"`
+	}
+	unmarshallingError := json.Unmarshal(body, &resources)
+	if unmarshallingError != nil {
+		return nil, unmarshallingError
+	}
+
+	usedIds := map[int]bool{}
+	for _, resource := range resources.Data {
+		usedIds[resource.VmId] = true
+	}
+	return usedIds, nil
+}
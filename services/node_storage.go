@@ -18,19 +18,17 @@ type NodeStorageService interface {
 		imageName *string,
 		storageName *string,
 		imageResponse *proxmox_types.QemuImageResponseData) *proxmox_types.QemuImage
-	ListImages(storageName *string, imageName *string) ([]proxmox_types.QemuImage, error)
+	ListImages(ctx context.Context, storageName *string, imageName *string) ([]proxmox_types.QemuImage, error)
 	GetLatestImageFromImages(images []proxmox_types.QemuImage) *proxmox_types.QemuImage
 }
 
 type NodeStorageServiceImpl struct {
 	client proxmox_client.ProxmoxClient
-	tfCtx  context.Context
 }
 
-func NewNodeStorageService(client proxmox_client.ProxmoxClient, tfCtx context.Context) NodeStorageService {
+func NewNodeStorageService(client proxmox_client.ProxmoxClient) NodeStorageService {
 	return &NodeStorageServiceImpl{
 		client: client,
-		tfCtx:  tfCtx,
 	}
 }
 
@@ -51,8 +49,8 @@ func (storageService *NodeStorageServiceImpl) QemuImageResponseDataToImage(
 	return &image
 }
 
-func (storageService *NodeStorageServiceImpl) getFirstNodeSupportingStorage(storageName *string) (*string, error) {
-	nodes, getNodesError := storageService.client.ListNodes()
+func (storageService *NodeStorageServiceImpl) getFirstNodeSupportingStorage(ctx context.Context, storageName *string) (*string, error) {
+	nodes, getNodesError := storageService.client.ListNodes(ctx)
 
 	if getNodesError != nil {
 		return nil, getNodesError
@@ -60,9 +58,9 @@ func (storageService *NodeStorageServiceImpl) getFirstNodeSupportingStorage(stor
 
 	for _, node := range nodes.Data {
 		nodeName := node.Node
-		nodeStorages, getNodeStorageError := storageService.client.ListStorageDestinations(&nodeName)
+		nodeStorages, getNodeStorageError := storageService.client.ListStorageDestinations(ctx, &nodeName)
 		if getNodeStorageError != nil && len(nodes.Data) > 1 {
-			tflog.Error(storageService.tfCtx, getNodeStorageError.Error())
+			tflog.Error(ctx, getNodeStorageError.Error())
 			continue
 		} else if getNodeStorageError != nil {
 			return nil, getNodeStorageError
@@ -78,15 +76,15 @@ func (storageService *NodeStorageServiceImpl) getFirstNodeSupportingStorage(stor
 	return nil, errors.New(fmt.Sprintf("storage with name %s could not be found on any node, please see logs for additional details.", *storageName))
 }
 
-func (storageService *NodeStorageServiceImpl) ListImages(storageName *string, imageName *string) ([]proxmox_types.QemuImage, error) {
+func (storageService *NodeStorageServiceImpl) ListImages(ctx context.Context, storageName *string, imageName *string) ([]proxmox_types.QemuImage, error) {
 
-	nodeName, getNodeForStorageError := storageService.getFirstNodeSupportingStorage(storageName)
+	nodeName, getNodeForStorageError := storageService.getFirstNodeSupportingStorage(ctx, storageName)
 
 	if getNodeForStorageError != nil {
 		return nil, getNodeForStorageError
 	}
 
-	content, listContentError := storageService.client.ListStorageContent(nodeName, storageName)
+	content, listContentError := storageService.client.ListStorageContent(ctx, nodeName, storageName)
 
 	if listContentError != nil {
 		return nil, listContentError
@@ -0,0 +1,96 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: services/proxmox_utils.go
+//
+// Generated by this command:
+//
+//	mockgen -source=services/proxmox_utils.go -destination=services/proxmox_utils_mock.go -package=services -self_package=terraform-provider-proxmox/services
+//
+
+// Package services is a generated GoMock package.
+package services
+
+import (
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockProxmoxUtilService is a mock of ProxmoxUtilService interface.
+type MockProxmoxUtilService struct {
+	ctrl     *gomock.Controller
+	recorder *MockProxmoxUtilServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockProxmoxUtilServiceMockRecorder is the mock recorder for MockProxmoxUtilService.
+type MockProxmoxUtilServiceMockRecorder struct {
+	mock *MockProxmoxUtilService
+}
+
+// NewMockProxmoxUtilService creates a new mock instance.
+func NewMockProxmoxUtilService(ctrl *gomock.Controller) *MockProxmoxUtilService {
+	mock := &MockProxmoxUtilService{ctrl: ctrl}
+	mock.recorder = &MockProxmoxUtilServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockProxmoxUtilService) EXPECT() *MockProxmoxUtilServiceMockRecorder {
+	return m.recorder
+}
+
+// ConvertSizeToGibibytes mocks base method.
+func (m *MockProxmoxUtilService) ConvertSizeToGibibytes(sizeString string) int64 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConvertSizeToGibibytes", sizeString)
+	ret0, _ := ret[0].(int64)
+	return ret0
+}
+
+// ConvertSizeToGibibytes indicates an expected call of ConvertSizeToGibibytes.
+func (mr *MockProxmoxUtilServiceMockRecorder) ConvertSizeToGibibytes(sizeString any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConvertSizeToGibibytes", reflect.TypeOf((*MockProxmoxUtilService)(nil).ConvertSizeToGibibytes), sizeString)
+}
+
+// MapBoolToProxmoxString mocks base method.
+func (m *MockProxmoxUtilService) MapBoolToProxmoxString(aBooleanValue bool) string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MapBoolToProxmoxString", aBooleanValue)
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// MapBoolToProxmoxString indicates an expected call of MapBoolToProxmoxString.
+func (mr *MockProxmoxUtilServiceMockRecorder) MapBoolToProxmoxString(aBooleanValue any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MapBoolToProxmoxString", reflect.TypeOf((*MockProxmoxUtilService)(nil).MapBoolToProxmoxString), aBooleanValue)
+}
+
+// MapKeyValuePairsToMap mocks base method.
+func (m *MockProxmoxUtilService) MapKeyValuePairsToMap(pairs []string) map[string]string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MapKeyValuePairsToMap", pairs)
+	ret0, _ := ret[0].(map[string]string)
+	return ret0
+}
+
+// MapKeyValuePairsToMap indicates an expected call of MapKeyValuePairsToMap.
+func (mr *MockProxmoxUtilServiceMockRecorder) MapKeyValuePairsToMap(pairs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MapKeyValuePairsToMap", reflect.TypeOf((*MockProxmoxUtilService)(nil).MapKeyValuePairsToMap), pairs)
+}
+
+// MapProxmoxStringToBool mocks base method.
+func (m *MockProxmoxUtilService) MapProxmoxStringToBool(proxmoxBoolString string) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MapProxmoxStringToBool", proxmoxBoolString)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// MapProxmoxStringToBool indicates an expected call of MapProxmoxStringToBool.
+func (mr *MockProxmoxUtilServiceMockRecorder) MapProxmoxStringToBool(proxmoxBoolString any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MapProxmoxStringToBool", reflect.TypeOf((*MockProxmoxUtilService)(nil).MapProxmoxStringToBool), proxmoxBoolString)
+}
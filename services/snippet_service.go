@@ -0,0 +1,237 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+	"terraform-provider-proxmox/proxmox_client"
+	proxmoxTypes "terraform-provider-proxmox/types"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"gopkg.in/yaml.v3"
+)
+
+const snippetContentType = "snippets"
+
+type SnippetService interface {
+	ValidateSnippetStorage(ctx context.Context, nodeName *string, storageName *string) error
+	UploadSnippet(ctx context.Context, snippet *proxmoxTypes.CloudInitSnippet) error
+	SnippetExists(ctx context.Context, snippet *proxmoxTypes.CloudInitSnippet) (bool, error)
+	SnippetChecksum(ctx context.Context, snippet *proxmoxTypes.CloudInitSnippet) (*string, error)
+	DeleteSnippet(ctx context.Context, snippet *proxmoxTypes.CloudInitSnippet) error
+}
+
+type SnippetServiceImpl struct {
+	client      proxmox_client.ProxmoxClient
+	taskService TaskService
+}
+
+func NewSnippetService(client proxmox_client.ProxmoxClient, taskService TaskService) SnippetService {
+	return &SnippetServiceImpl{
+		client:      client,
+		taskService: taskService,
+	}
+}
+
+// SnippetVolid returns the volume id cicustom references the snippet by
+func SnippetVolid(storageName string, fileName string) string {
+	return fmt.Sprintf("%s:%s/%s", storageName, snippetContentType, fileName)
+}
+
+// RenderSnippet
+/**
+ * @description returns the file written for the snippet. User and vendor data without a header get the #cloud-config
+ * header cloud-init needs to recognise them, content that is not a script has to be valid yaml.
+ */
+func RenderSnippet(snippetType string, content string) ([]byte, error) {
+	rendered := content
+	if (snippetType == "user" || snippetType == "vendor") && !strings.HasPrefix(content, "#") {
+		rendered = "#cloud-config\n" + content
+	}
+
+	if !strings.HasPrefix(rendered, "#!") {
+		var document interface{}
+		yamlError := yaml.Unmarshal([]byte(rendered), &document)
+		if yamlError != nil {
+			return nil, fmt.Errorf("the content of the %s snippet is not valid yaml: %w", snippetType, yamlError)
+		}
+	}
+
+	return []byte(rendered), nil
+}
+
+// ValidateSnippetStorage fails unless the storage is available on the node and allows the snippets content type
+func (snippetService *SnippetServiceImpl) ValidateSnippetStorage(ctx context.Context, nodeName *string, storageName *string) error {
+	nodeStorages, listStorageError := snippetService.client.ListStorageDestinations(ctx, nodeName)
+
+	if listStorageError != nil {
+		return listStorageError
+	}
+
+	for _, storage := range nodeStorages.Data {
+		if storage.Storage != *storageName {
+			continue
+		}
+		if !slices.Contains(strings.Split(storage.Content, ","), snippetContentType) {
+			return errors.New(fmt.Sprintf("storage %s does not allow snippets, its content types are %s. Add snippets to the content of the storage", *storageName, storage.Content))
+		}
+		return nil
+	}
+
+	return errors.New(fmt.Sprintf("storage %s could not be found on node %s", *storageName, *nodeName))
+}
+
+// UploadSnippet
+/**
+ * @description renders the snippet and writes it to the storage, overwriting an existing file of the same name. The
+ * snippet is uploaded through the api where proxmox accepts snippets as uploads and written to the directory of the
+ * storage over ssh where it doesn't. Sets the checksum and the volume id of the snippet.
+ */
+func (snippetService *SnippetServiceImpl) UploadSnippet(ctx context.Context, snippet *proxmoxTypes.CloudInitSnippet) error {
+	rendered, renderError := RenderSnippet(snippet.Type.ValueString(), snippet.Content.ValueString())
+
+	if renderError != nil {
+		return renderError
+	}
+
+	nodeName := snippet.NodeName.ValueString()
+	storageName := snippet.Storage.ValueString()
+	fileName := snippet.FileName.ValueString()
+	validateStorageError := snippetService.ValidateSnippetStorage(ctx, &nodeName, &storageName)
+
+	if validateStorageError != nil {
+		return validateStorageError
+	}
+
+	upid, uploadError := snippetService.client.UploadStorageFile(ctx, &nodeName, &storageName, snippetContentType, fileName, rendered)
+
+	if uploadError == nil {
+		waitError := snippetService.taskService.WaitForTaskCompletion(ctx, &nodeName, upid)
+		if waitError != nil {
+			return waitError
+		}
+	} else if isUploadRejected(uploadError) {
+		tflog.Info(ctx, fmt.Sprintf("storage %s does not accept snippets as uploads, writing %s over ssh: %s", storageName, fileName, uploadError.Error()))
+		writeError := snippetService.writeSnippetOverSsh(ctx, &nodeName, &storageName, fileName, rendered)
+		if errors.Is(writeError, proxmox_client.ErrSshNotConfigured) {
+			return fmt.Errorf("proxmox does not accept snippets as uploads, %w: %s", writeError, uploadError.Error())
+		}
+		if writeError != nil {
+			return writeError
+		}
+	} else {
+		return uploadError
+	}
+
+	checksum := sha256.Sum256(rendered)
+	snippet.Sha256 = types.StringValue(hex.EncodeToString(checksum[:]))
+	snippet.Volid = types.StringValue(SnippetVolid(storageName, fileName))
+	return nil
+}
+
+// writeSnippetOverSsh writes the snippet to the snippets directory below the path of the storage
+func (snippetService *SnippetServiceImpl) writeSnippetOverSsh(ctx context.Context, nodeName *string, storageName *string, fileName string, rendered []byte) error {
+	storageConfig, getStorageError := snippetService.client.GetStorageConfig(ctx, storageName)
+
+	if getStorageError != nil {
+		return getStorageError
+	}
+	if storageConfig.Data.Path == "" {
+		return errors.New(fmt.Sprintf("storage %s of type %s has no directory snippets can be written to", *storageName, storageConfig.Data.Type))
+	}
+
+	return snippetService.client.WriteNodeFile(ctx, *nodeName, path.Join(storageConfig.Data.Path, snippetContentType, fileName), rendered)
+}
+
+// isUploadRejected reports whether proxmox refused the upload because of its content type, up to version 8 the upload
+// api only takes isos, container templates and images to import
+func isUploadRejected(err error) bool {
+	apiError, isApiError := proxmox_client.AsApiError(err)
+	if !isApiError {
+		return false
+	}
+	if apiError.StatusCode == http.StatusNotImplemented {
+		return true
+	}
+	_, contentRejected := apiError.Errors["content"]
+	return apiError.StatusCode == http.StatusBadRequest && contentRejected
+}
+
+// SnippetExists checks the content of the storage for the snippet, a storage that no longer exists has no snippets
+func (snippetService *SnippetServiceImpl) SnippetExists(ctx context.Context, snippet *proxmoxTypes.CloudInitSnippet) (bool, error) {
+	nodeName := snippet.NodeName.ValueString()
+	storageName := snippet.Storage.ValueString()
+	content, listContentError := snippetService.client.ListStorageContent(ctx, &nodeName, &storageName)
+
+	if proxmox_client.IsNotFound(listContentError) {
+		return false, nil
+	}
+	if listContentError != nil {
+		return false, listContentError
+	}
+
+	volid := SnippetVolid(storageName, snippet.FileName.ValueString())
+	for _, item := range content.Data {
+		if item.Volid == volid {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// SnippetChecksum
+/**
+ * @description reads the sha256 checksum of the snippet file on the node, so changes made outside of terraform can be
+ * detected. The api has no way to read snippets, so this needs ssh access to the node and a storage with a directory.
+ *
+ * @return the hex encoded checksum, nil when it can't be read without ssh access or a storage directory
+ */
+func (snippetService *SnippetServiceImpl) SnippetChecksum(ctx context.Context, snippet *proxmoxTypes.CloudInitSnippet) (*string, error) {
+	storageName := snippet.Storage.ValueString()
+	storageConfig, getStorageError := snippetService.client.GetStorageConfig(ctx, &storageName)
+
+	if getStorageError != nil {
+		return nil, getStorageError
+	}
+	if storageConfig.Data.Path == "" {
+		return nil, nil
+	}
+
+	filePath := path.Join(storageConfig.Data.Path, snippetContentType, snippet.FileName.ValueString())
+	checksum, checksumError := snippetService.client.ChecksumNodeFile(ctx, snippet.NodeName.ValueString(), filePath)
+	if errors.Is(checksumError, proxmox_client.ErrSshNotConfigured) {
+		return nil, nil
+	}
+	if checksumError != nil {
+		return nil, checksumError
+	}
+	return &checksum, nil
+}
+
+// DeleteSnippet removes the snippet from the storage, a snippet that is already gone is not an error
+func (snippetService *SnippetServiceImpl) DeleteSnippet(ctx context.Context, snippet *proxmoxTypes.CloudInitSnippet) error {
+	nodeName := snippet.NodeName.ValueString()
+	storageName := snippet.Storage.ValueString()
+	volid := SnippetVolid(storageName, snippet.FileName.ValueString())
+	upid, deleteError := snippetService.client.DeleteStorageVolume(ctx, &nodeName, &storageName, &volid)
+
+	if proxmox_client.IsNotFound(deleteError) {
+		tflog.Warn(ctx, fmt.Sprintf("snippet %s no longer exists on node %s", volid, nodeName))
+		return nil
+	}
+	if deleteError != nil {
+		return deleteError
+	}
+	if *upid == "" {
+		return nil
+	}
+
+	return snippetService.taskService.WaitForTaskCompletion(ctx, &nodeName, upid)
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"terraform-provider-proxmox/proxmox_client"
+	proxmoxTypes "terraform-provider-proxmox/types"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestRenderSnippet(t *testing.T) {
+	rendered, renderError := RenderSnippet("user", "packages:\n- nginx\n")
+	assert.NoError(t, renderError)
+	assert.Equal(t, "#cloud-config\npackages:\n- nginx\n", string(rendered))
+
+	rendered, renderError = RenderSnippet("network", "version: 2\n")
+	assert.NoError(t, renderError)
+	assert.Equal(t, "version: 2\n", string(rendered))
+
+	rendered, renderError = RenderSnippet("user", "#!/bin/sh\necho: [\n")
+	assert.NoError(t, renderError)
+	assert.Equal(t, "#!/bin/sh\necho: [\n", string(rendered))
+
+	_, renderError = RenderSnippet("vendor", "packages: [nginx\n")
+	assert.ErrorContains(t, renderError, "not valid yaml")
+}
+
+func TestUploadSnippetFallsBackToSsh(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := proxmox_client.NewMockProxmoxClient(ctrl)
+	snippetService := NewSnippetService(client, NewMockTaskService(ctrl))
+	snippet := proxmoxTypes.CloudInitSnippet{
+		NodeName: types.StringValue("pve-01"),
+		Storage:  types.StringValue("local"),
+		FileName: types.StringValue("web.yaml"),
+		Type:     types.StringValue("user"),
+		Content:  types.StringValue("hostname: web\n"),
+	}
+	nodeName, storageName := "pve-01", "local"
+
+	client.EXPECT().ListStorageDestinations(gomock.Any(), &nodeName).Return(&proxmoxTypes.NodeStorageResponse{
+		Data: []proxmoxTypes.NodeStorageResponseItem{{Storage: "local", Content: "iso,snippets"}},
+	}, nil)
+	client.EXPECT().UploadStorageFile(gomock.Any(), &nodeName, &storageName, "snippets", "web.yaml", gomock.Any()).Return(nil, &proxmox_client.ApiError{
+		StatusCode: http.StatusBadRequest,
+		Errors:     map[string]string{"content": "value 'snippets' does not have a value in the enumeration 'iso, vztmpl, import'"},
+	})
+	client.EXPECT().GetStorageConfig(gomock.Any(), &storageName).Return(&proxmoxTypes.StorageConfigResponse{
+		Data: proxmoxTypes.StorageConfig{Storage: "local", Type: "dir", Path: "/var/lib/vz"},
+	}, nil)
+	client.EXPECT().WriteNodeFile(gomock.Any(), "pve-01", "/var/lib/vz/snippets/web.yaml", []byte("#cloud-config\nhostname: web\n")).Return(nil)
+
+	assert.NoError(t, snippetService.UploadSnippet(context.Background(), &snippet))
+	assert.Equal(t, "local:snippets/web.yaml", snippet.Volid.ValueString())
+	assert.Len(t, snippet.Sha256.ValueString(), 64)
+}
+
+func TestValidateSnippetStorage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := proxmox_client.NewMockProxmoxClient(ctrl)
+	snippetService := NewSnippetService(client, NewMockTaskService(ctrl))
+	nodeName, storageName, missingStorage := "pve-01", "local-zfs", "ceph"
+
+	client.EXPECT().ListStorageDestinations(gomock.Any(), &nodeName).Return(&proxmoxTypes.NodeStorageResponse{
+		Data: []proxmoxTypes.NodeStorageResponseItem{{Storage: "local-zfs", Content: "images,rootdir"}},
+	}, nil).Times(2)
+
+	assert.ErrorContains(t, snippetService.ValidateSnippetStorage(context.Background(), &nodeName, &storageName), "does not allow snippets")
+	assert.ErrorContains(t, snippetService.ValidateSnippetStorage(context.Background(), &nodeName, &missingStorage), "could not be found on node pve-01")
+}
+
+func TestSnippetChecksum(t *testing.T) {
+	checksum := "88c95955b024402aa9572b663f7eeb134f01343bb92af27b50e97e72b22c565f"
+	testCases := []struct {
+		name           string
+		storagePath    string
+		checksumError  error
+		expectChecksum *string
+	}{
+		{
+			name:           "read over ssh",
+			storagePath:    "/var/lib/vz",
+			expectChecksum: &checksum,
+		},
+		{
+			name:          "ssh not configured",
+			storagePath:   "/var/lib/vz",
+			checksumError: proxmox_client.ErrSshNotConfigured,
+		},
+		{
+			name: "storage without a directory",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			client := proxmox_client.NewMockProxmoxClient(ctrl)
+			snippetService := NewSnippetService(client, NewMockTaskService(ctrl))
+			snippet := proxmoxTypes.CloudInitSnippet{
+				NodeName: types.StringValue("pve-01"),
+				Storage:  types.StringValue("local"),
+				FileName: types.StringValue("web.yaml"),
+			}
+			storageName := "local"
+
+			client.EXPECT().GetStorageConfig(gomock.Any(), &storageName).Return(&proxmoxTypes.StorageConfigResponse{
+				Data: proxmoxTypes.StorageConfig{Storage: "local", Type: "dir", Path: testCase.storagePath},
+			}, nil)
+			if testCase.storagePath != "" {
+				client.EXPECT().ChecksumNodeFile(gomock.Any(), "pve-01", testCase.storagePath+"/snippets/web.yaml").Return(checksum, testCase.checksumError)
+			}
+
+			readChecksum, checksumError := snippetService.SnippetChecksum(context.Background(), &snippet)
+
+			assert.NoError(t, checksumError)
+			assert.Equal(t, testCase.expectChecksum, readChecksum)
+		})
+	}
+}
@@ -1,14 +1,27 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"strings"
+	"sync"
 	"terraform-provider-proxmox/proxmox_client"
 	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+const (
+	taskPollInterval = 3 * time.Second
+	// taskLogPageSize is the number of log lines requested from proxmox at once
+	taskLogPageSize = 500
+	// taskLogTailLines is the number of trailing log lines included when a task fails
+	taskLogTailLines = 20
 )
 
 type TaskService interface {
-	WaitForTaskCompletion(nodeName *string, taskUpid *string) error
+	WaitForTaskCompletion(ctx context.Context, nodeName *string, taskUpid *string) error
 }
 
 type TaskServiceImpl struct {
@@ -22,7 +35,121 @@ func NewTaskService(proxmoxClient proxmox_client.ProxmoxClient) TaskService {
 	return &taskService
 }
 
-func (taskService *TaskServiceImpl) WaitForTaskCompletion(nodeName *string, taskUpid *string) error {
+type TaskOutcome int
+
+const (
+	TaskOk TaskOutcome = iota
+	TaskWarnings
+	TaskError
+)
+
+// ClassifyExitStatus maps the exit status of a stopped task to its outcome, proxmox reports tasks that
+// completed with warnings as "WARNINGS: <count>"
+func ClassifyExitStatus(exitStatus string) TaskOutcome {
+	if exitStatus == "OK" {
+		return TaskOk
+	}
+	if strings.HasPrefix(exitStatus, "WARNINGS:") {
+		return TaskWarnings
+	}
+	return TaskError
+}
+
+// TaskWarning describes a task that completed, but logged warnings
+type TaskWarning struct {
+	NodeName   string
+	Upid       string
+	TaskType   string
+	ExitStatus string
+	LogLines   []string
+}
+
+// TaskWarningCollector gathers the warnings of all tasks run during a single terraform operation
+type TaskWarningCollector struct {
+	TreatAsErrors bool
+	lock          sync.Mutex
+	warnings      []TaskWarning
+}
+
+type taskWarningCollectorKey struct{}
+
+// WithTaskWarningCollector returns a context that collects the warnings of the tasks waited for with it
+func WithTaskWarningCollector(ctx context.Context, treatAsErrors bool) (context.Context, *TaskWarningCollector) {
+	collector := &TaskWarningCollector{TreatAsErrors: treatAsErrors}
+	return context.WithValue(ctx, taskWarningCollectorKey{}, collector), collector
+}
+
+func (collector *TaskWarningCollector) add(warning TaskWarning) {
+	collector.lock.Lock()
+	defer collector.lock.Unlock()
+	collector.warnings = append(collector.warnings, warning)
+}
+
+func (collector *TaskWarningCollector) Warnings() []TaskWarning {
+	collector.lock.Lock()
+	defer collector.lock.Unlock()
+	return append([]TaskWarning{}, collector.warnings...)
+}
+
+// TaskFailedError is returned when a proxmox task fails, or completes with warnings while those are treated as errors
+type TaskFailedError struct {
+	NodeName   string
+	Upid       string
+	TaskType   string
+	ExitStatus string
+	LogTail    []string
+}
+
+// TaskExitStatus lets proxmox_client classify task failures without depending on services
+func (taskError *TaskFailedError) TaskExitStatus() string {
+	return taskError.ExitStatus
+}
+
+func (taskError *TaskFailedError) Error() string {
+	message := fmt.Sprintf("proxmox %s task %s on node %s failed with exit status: %s", taskError.TaskType, taskError.Upid, taskError.NodeName, taskError.ExitStatus)
+	if len(taskError.LogTail) == 0 {
+		return message
+	}
+	return fmt.Sprintf("%s\n\nlast lines of the task log:\n%s", message, strings.Join(taskError.LogTail, "\n"))
+}
+
+// taskLogFollower keeps track of the lines of a task log that have already been read
+type taskLogFollower struct {
+	proxmoxClient proxmox_client.ProxmoxClient
+	nodeName      *string
+	taskUpid      *string
+	linesRead     int
+	tail          []string
+	warningLines  []string
+}
+
+// follow reads the log lines written since the last call, logs them and remembers the last few for error reporting
+func (follower *taskLogFollower) follow(ctx context.Context) error {
+	for {
+		taskLog, getTaskLogError := follower.proxmoxClient.GetTaskLog(ctx, follower.nodeName, follower.taskUpid, follower.linesRead, taskLogPageSize)
+		if getTaskLogError != nil {
+			return getTaskLogError
+		}
+
+		for _, line := range taskLog.Data {
+			tflog.Info(ctx, line.Text, map[string]interface{}{"upid": *follower.taskUpid, "node": *follower.nodeName})
+			follower.tail = append(follower.tail, line.Text)
+			if isTaskLogWarning(line.Text) {
+				follower.warningLines = append(follower.warningLines, line.Text)
+			}
+		}
+		if len(follower.tail) > taskLogTailLines {
+			follower.tail = follower.tail[len(follower.tail)-taskLogTailLines:]
+		}
+		follower.linesRead += len(taskLog.Data)
+
+		if len(taskLog.Data) < taskLogPageSize {
+			return nil
+		}
+	}
+}
+
+func (taskService *TaskServiceImpl) WaitForTaskCompletion(ctx context.Context, nodeName *string, taskUpid *string) error {
 	if nodeName == nil {
 		return errors.New("cannot wait for task completion if a node name is not provided")
 	}
@@ -31,20 +158,98 @@ func (taskService *TaskServiceImpl) WaitForTaskCompletion(nodeName *string, task
 		return errors.New("cannot wait for task to complete if no task is provided")
 	}
 
+	logFollower := taskLogFollower{proxmoxClient: taskService.proxmoxClient, nodeName: nodeName, taskUpid: taskUpid}
+
 	for {
 
-		taskStatus, getTaskStatusError := taskService.proxmoxClient.GetTaskStatusByUpid(nodeName, taskUpid)
+		taskStatus, getTaskStatusError := taskService.proxmoxClient.GetTaskStatusByUpid(ctx, nodeName, taskUpid)
 
 		if getTaskStatusError != nil {
+			if ctx.Err() != nil {
+				return taskAbandonedError(ctx, nodeName, taskUpid)
+			}
 			return getTaskStatusError
 		}
 
-		if taskStatus.Data.Status == "stopped" && taskStatus.Data.Exitstatus != "OK" {
-			return errors.New(fmt.Sprintf("proxmox failed to complete %s task, please check logs in the proxmox console for more details", taskStatus.Data.Type))
-		} else if taskStatus.Data.Status == "stopped" && taskStatus.Data.Exitstatus == "OK" {
-			break
+		// the log is only a convenience, a task must never fail because its log could not be read
+		followLogError := logFollower.follow(ctx)
+		if followLogError != nil {
+			tflog.Warn(ctx, fmt.Sprintf("Failed to read the log of task %s: %s", *taskUpid, followLogError.Error()))
+		}
+
+		if taskStatus.Data.Status == "stopped" {
+			return taskService.handleStoppedTask(ctx, taskStatus.Data.Type, taskStatus.Data.Exitstatus, &logFollower)
 		}
-		time.Sleep(3 * time.Second)
+
+		select {
+		case <-ctx.Done():
+			return taskAbandonedError(ctx, nodeName, taskUpid)
+		case <-time.After(taskPollInterval):
+		}
+	}
+}
+
+func (taskService *TaskServiceImpl) handleStoppedTask(ctx context.Context, taskType string, exitStatus string, logFollower *taskLogFollower) error {
+	switch ClassifyExitStatus(exitStatus) {
+	case TaskOk:
+		return nil
+	case TaskWarnings:
+		warning := TaskWarning{
+			NodeName:   *logFollower.nodeName,
+			Upid:       *logFollower.taskUpid,
+			TaskType:   taskType,
+			ExitStatus: exitStatus,
+			LogLines:   logFollower.warningLines,
+		}
+		if len(warning.LogLines) == 0 {
+			warning.LogLines = logFollower.tail
+		}
+		tflog.Warn(ctx, fmt.Sprintf("proxmox %s task %s completed with %s", taskType, warning.Upid, exitStatus))
+
+		collector, hasCollector := ctx.Value(taskWarningCollectorKey{}).(*TaskWarningCollector)
+		if !hasCollector {
+			return nil
+		}
+		if collector.TreatAsErrors {
+			return &TaskFailedError{
+				NodeName:   warning.NodeName,
+				Upid:       warning.Upid,
+				TaskType:   taskType,
+				ExitStatus: exitStatus,
+				LogTail:    warning.LogLines,
+			}
+		}
+		collector.add(warning)
+		return nil
+	default:
+		return &TaskFailedError{
+			NodeName:   *logFollower.nodeName,
+			Upid:       *logFollower.taskUpid,
+			TaskType:   taskType,
+			ExitStatus: exitStatus,
+			LogTail:    logFollower.tail,
+		}
+	}
+}
+
+func isTaskLogWarning(line string) bool {
+	lowerLine := strings.ToLower(line)
+	return strings.HasPrefix(lowerLine, "warn:") || strings.HasPrefix(lowerLine, "warning:")
+}
+
+// taskAbandonedError names the task that was still running when the context ended, so it can be found in the proxmox task log
+func taskAbandonedError(ctx context.Context, nodeName *string, taskUpid *string) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("timed out waiting for proxmox %s task %s on node %s, the task is still running in proxmox: %w", taskTypeFromUpid(*taskUpid), *taskUpid, *nodeName, ctx.Err())
+	}
+	return fmt.Errorf("stopped waiting for proxmox %s task %s on node %s, the task may still be running in proxmox: %w", taskTypeFromUpid(*taskUpid), *taskUpid, *nodeName, ctx.Err())
+}
+
+// taskTypeFromUpid reads the task type from a upid of the form UPID:node:pid:pstart:starttime:type:id:user:
+func taskTypeFromUpid(upid string) string {
+	parts := strings.Split(upid, ":")
+	if len(parts) < 7 || parts[0] != "UPID" {
+		return "unknown"
 	}
-	return nil
+	return parts[5]
 }
@@ -0,0 +1,55 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: services/task_service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=services/task_service.go -destination=services/task_service_mock.go -package=services -self_package=terraform-provider-proxmox/services
+//
+
+// Package services is a generated GoMock package.
+package services
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockTaskService is a mock of TaskService interface.
+type MockTaskService struct {
+	ctrl     *gomock.Controller
+	recorder *MockTaskServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockTaskServiceMockRecorder is the mock recorder for MockTaskService.
+type MockTaskServiceMockRecorder struct {
+	mock *MockTaskService
+}
+
+// NewMockTaskService creates a new mock instance.
+func NewMockTaskService(ctrl *gomock.Controller) *MockTaskService {
+	mock := &MockTaskService{ctrl: ctrl}
+	mock.recorder = &MockTaskServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTaskService) EXPECT() *MockTaskServiceMockRecorder {
+	return m.recorder
+}
+
+// WaitForTaskCompletion mocks base method.
+func (m *MockTaskService) WaitForTaskCompletion(ctx context.Context, nodeName, taskUpid *string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WaitForTaskCompletion", ctx, nodeName, taskUpid)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WaitForTaskCompletion indicates an expected call of WaitForTaskCompletion.
+func (mr *MockTaskServiceMockRecorder) WaitForTaskCompletion(ctx, nodeName, taskUpid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WaitForTaskCompletion", reflect.TypeOf((*MockTaskService)(nil).WaitForTaskCompletion), ctx, nodeName, taskUpid)
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"terraform-provider-proxmox/proxmox_client"
+	"testing"
+)
+
+func TestTaskTypeFromUpid(t *testing.T) {
+	upid := "UPID:pve-01:000B1A2C:0153F4D2:66A1B2C3:qmcreate:101:root@pam:"
+	if taskType := taskTypeFromUpid(upid); taskType != "qmcreate" {
+		t.Fatalf("expected qmcreate, got %s", taskType)
+	}
+	if taskType := taskTypeFromUpid("not a upid"); taskType != "unknown" {
+		t.Fatalf("expected unknown, got %s", taskType)
+	}
+}
+
+func TestTaskAbandonedErrorNamesTheTask(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	<-ctx.Done()
+
+	nodeName := "pve-01"
+	upid := "UPID:pve-01:000B1A2C:0153F4D2:66A1B2C3:qmmove:101:root@pam:"
+	abandonedError := taskAbandonedError(ctx, &nodeName, &upid)
+
+	if !errors.Is(abandonedError, context.DeadlineExceeded) {
+		t.Fatalf("expected the deadline to be wrapped, got %v", abandonedError)
+	}
+	for _, expected := range []string{"timed out", "qmmove", upid, "pve-01"} {
+		if !strings.Contains(abandonedError.Error(), expected) {
+			t.Fatalf("expected %q in %q", expected, abandonedError.Error())
+		}
+	}
+}
+
+func newTaskServer(exitStatus string, logLines []string) *httptest.Server {
+	return httptest.NewTLSServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if strings.HasSuffix(request.URL.Path, "/status") {
+			_, _ = fmt.Fprintf(writer, `{"data":{"status":"stopped","exitstatus":%q,"type":"qmcreate"}}`, exitStatus)
+			return
+		}
+		var lines []string
+		if request.URL.Query().Get("start") == "0" {
+			for i, line := range logLines {
+				lines = append(lines, fmt.Sprintf(`{"n":%d,"t":%q}`, i+1, line))
+			}
+		}
+		_, _ = fmt.Fprintf(writer, `{"data":[%s],"total":%d}`, strings.Join(lines, ","), len(logLines))
+	}))
+}
+
+func waitForTestTask(ctx context.Context, server *httptest.Server) error {
+	client, _ := proxmox_client.NewClient([]string{server.URL}, &proxmox_client.AuthStruct{TokenId: "root@pam!test"}, &proxmox_client.TlsOptions{VerifyTls: false}, nil, nil, nil, nil)
+	nodeName := "pve-01"
+	upid := "UPID:pve-01:000B1A2C:0153F4D2:66A1B2C3:qmcreate:101:root@pam:"
+	return NewTaskService(client).WaitForTaskCompletion(ctx, &nodeName, &upid)
+}
+
+func TestFailedTaskIncludesTheEndOfTheLog(t *testing.T) {
+	var logLines []string
+	for i := 1; i <= 30; i++ {
+		logLines = append(logLines, fmt.Sprintf("line %d", i))
+	}
+	server := newTaskServer("unable to create image", logLines)
+	defer server.Close()
+
+	waitError := waitForTestTask(context.Background(), server)
+
+	var taskFailedError *TaskFailedError
+	if !errors.As(waitError, &taskFailedError) {
+		t.Fatalf("expected a TaskFailedError, got %v", waitError)
+	}
+	if len(taskFailedError.LogTail) != taskLogTailLines || taskFailedError.LogTail[taskLogTailLines-1] != "line 30" {
+		t.Fatalf("expected the last %d log lines, got %v", taskLogTailLines, taskFailedError.LogTail)
+	}
+	if !strings.Contains(waitError.Error(), "unable to create image") {
+		t.Fatalf("expected the exit status in %q", waitError.Error())
+	}
+}
+
+func TestTaskWarningsAreCollected(t *testing.T) {
+	server := newTaskServer("WARNINGS: 1", []string{"creating disk", "WARN: volume is thin provisioned", "TASK WARNINGS: 1"})
+	defer server.Close()
+
+	ctx, collector := WithTaskWarningCollector(context.Background(), false)
+	waitError := waitForTestTask(ctx, server)
+
+	if waitError != nil {
+		t.Fatalf("expected a task with warnings to succeed, got %v", waitError)
+	}
+	warnings := collector.Warnings()
+	if len(warnings) != 1 || len(warnings[0].LogLines) != 1 || warnings[0].LogLines[0] != "WARN: volume is thin provisioned" {
+		t.Fatalf("expected the warning log line to be collected, got %v", warnings)
+	}
+}
+
+func TestTaskWarningsCanBeTreatedAsErrors(t *testing.T) {
+	server := newTaskServer("WARNINGS: 1", []string{"WARN: volume is thin provisioned"})
+	defer server.Close()
+
+	ctx, collector := WithTaskWarningCollector(context.Background(), true)
+	waitError := waitForTestTask(ctx, server)
+
+	var taskFailedError *TaskFailedError
+	if !errors.As(waitError, &taskFailedError) {
+		t.Fatalf("expected a TaskFailedError, got %v", waitError)
+	}
+	if len(collector.Warnings()) != 0 {
+		t.Fatalf("warnings treated as errors must not be reported twice")
+	}
+}
+
+func TestClassifyExitStatus(t *testing.T) {
+	if ClassifyExitStatus("OK") != TaskOk || ClassifyExitStatus("WARNINGS: 3") != TaskWarnings || ClassifyExitStatus("command failed") != TaskError {
+		t.Fatal("unexpected task outcome")
+	}
+}
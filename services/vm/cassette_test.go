@@ -0,0 +1,89 @@
+package vm
+
+import (
+	"context"
+	"path/filepath"
+	"terraform-provider-proxmox/proxmox_client"
+	"terraform-provider-proxmox/services"
+	proxmoxTypes "terraform-provider-proxmox/types"
+	"testing"
+)
+
+// replayVmConfig reads the config of a vm from a cassette, the fixtures in testdata/cassettes are synthetic and say so
+// in their comment field
+func replayVmConfig(t *testing.T, cassette string, nodeName string, vmId string) *proxmoxTypes.QemuResponse {
+	t.Setenv(proxmox_client.CassetteModeEnv, proxmox_client.CassetteReplay)
+	t.Setenv(proxmox_client.CassetteFileEnv, filepath.Join("testdata", "cassettes", cassette))
+
+	auth := proxmox_client.AuthStruct{TokenId: "root@pam!replay", TokenSecret: "replay"}
+	client, clientError := proxmox_client.NewClient([]string{"pve.invalid:8006"}, &auth, nil, nil, nil, nil, nil)
+	if clientError != nil {
+		t.Fatal(clientError)
+	}
+
+	response, getVmError := client.GetVmById(context.Background(), &nodeName, &vmId)
+	if getVmError != nil {
+		t.Fatalf("failed to replay the vm config: %v", getVmError)
+	}
+	return response
+}
+
+func TestUpdateDisksFromRecordedQcow2AndCdromConfig(t *testing.T) {
+	response := replayVmConfig(t, "qcow2_disks_and_cdrom.json", "pve", "100")
+	diskService := DiskServiceImpl{proxmoxUtilsService: services.NewProxmoxUtilService()}
+
+	disks := diskService.UpdateDisksFromQemuResponse(response.Data.OtherFields, &proxmoxTypes.VmModel{}, &proxmoxTypes.VmModel{})
+
+	if len(disks) != 2 {
+		t.Fatalf("expected the cdrom to be skipped and two disks to be read, got %+v", disks)
+	}
+	expected := []struct {
+		name     string
+		id       int64
+		storage  string
+		size     string
+		cache    string
+		backup   bool
+		discard  bool
+		ssd      bool
+		ioThread bool
+	}{
+		{name: "scsi0", id: 1, storage: "local", size: "32G", cache: "default", backup: true, discard: true, ssd: true, ioThread: true},
+		{name: "scsi2", id: 2, storage: "local-lvm", size: "100G", cache: "writeback", backup: false, discard: false, ssd: false, ioThread: true},
+	}
+	for i, expectedDisk := range expected {
+		disk := disks[i]
+		if diskService.GetDiskName(disk) != expectedDisk.name ||
+			disk.Id.ValueInt64() != expectedDisk.id ||
+			disk.StorageLocation.ValueString() != expectedDisk.storage ||
+			disk.Size.ValueString() != expectedDisk.size ||
+			disk.Cache.ValueString() != expectedDisk.cache ||
+			disk.Backup.ValueBool() != expectedDisk.backup ||
+			disk.Discard.ValueBool() != expectedDisk.discard ||
+			disk.SsdEmulation.ValueBool() != expectedDisk.ssd ||
+			disk.IoThread.ValueBool() != expectedDisk.ioThread {
+			t.Errorf("disk %d: expected %+v, got %+v", i, expectedDisk, disk)
+		}
+	}
+}
+
+func TestMapNetworkInterfacesFromRecordedConfig(t *testing.T) {
+	response := replayVmConfig(t, "many_network_interfaces.json", "pve", "101")
+	vmService := VmServiceImpl{proxmoxUtils: services.NewProxmoxUtilService()}
+
+	nics := vmService.MapNetworkInterfacesFromQemuResponse(response.Data.OtherFields)
+
+	if len(nics) != 11 {
+		t.Fatalf("expected 11 network interfaces, got %d", len(nics))
+	}
+	if nics[1].Bridge.ValueString() != "vmbr1" || nics[1].Firewall.ValueBool() || nics[1].MacAddress.ValueString() != "BC:24:11:00:00:01" {
+		t.Errorf("unexpected net1 %+v", nics[1])
+	}
+	if nics[2].MacAddress.ValueString() != "BC:24:11:00:00:02" {
+		t.Errorf("expected net2 to follow net1, got %+v", nics[2])
+	}
+	last := nics[10]
+	if last.Order.ValueInt64() != 10 || last.Type.ValueString() != "e1000" || last.MacAddress.ValueString() != "BC:24:11:00:00:0A" || last.Mtu.ValueInt64() != 9000 {
+		t.Errorf("expected net10 to come last, got %+v", last)
+	}
+}
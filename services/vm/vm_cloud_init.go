@@ -0,0 +1,248 @@
+package vm
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	proxmoxTypes "terraform-provider-proxmox/types"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// cloudInitKeyRegex matches the config keys of the buses the cloud-init drive can be attached to
+var cloudInitKeyRegex = regexp.MustCompile(`^(ide|sata|scsi)(\d+)$`)
+
+const (
+	// defaultCloudInitStorage is used when neither the cloud_init block nor cloud_init_storage_name name a storage
+	defaultCloudInitStorage = "local-zfs"
+	// legacyCloudInitBus is where vms without a cloud_init block get their cloud-init drive
+	legacyCloudInitBus = "scsi"
+	// preferredIdeCloudInitSlot is the slot proxmox itself puts the cloud-init drive on
+	preferredIdeCloudInitSlot = 2
+)
+
+// cloudInitDrive
+/**
+ * @description resolves where the cloud-init drive of the model goes. Settings missing from the cloud_init block
+ * fall back to cloud_init_storage_name and to the first slot of the bus no disk of the model uses, ide2 on ide.
+ *
+ * @return the drive with every field set, nil when the vm has no cloud-init drive
+ */
+func cloudInitDrive(vmModel *proxmoxTypes.VmModel) *proxmoxTypes.VmCloudInit {
+	drive := proxmoxTypes.VmCloudInit{Enabled: types.BoolValue(true), Bus: types.StringValue(legacyCloudInitBus)}
+	if vmModel.CloudInit != nil {
+		if !vmModel.CloudInit.Enabled.IsNull() && !vmModel.CloudInit.Enabled.IsUnknown() && !vmModel.CloudInit.Enabled.ValueBool() {
+			return nil
+		}
+		drive.Storage = vmModel.CloudInit.Storage
+		if vmModel.CloudInit.Bus.ValueString() != "" {
+			drive.Bus = vmModel.CloudInit.Bus
+		}
+		drive.Slot = vmModel.CloudInit.Slot
+	}
+
+	if drive.Storage.IsNull() || drive.Storage.IsUnknown() || drive.Storage.ValueString() == "" {
+		drive.Storage = types.StringValue(defaultCloudInitStorage)
+		if vmModel.CloudInitStorageName.ValueString() != "" {
+			drive.Storage = vmModel.CloudInitStorageName
+		}
+	}
+
+	if drive.Slot.IsNull() || drive.Slot.IsUnknown() {
+		drive.Slot = types.Int64Value(freeCloudInitSlot(drive.Bus.ValueString(), vmModel.Disks))
+	}
+	return &drive
+}
+
+// freeCloudInitSlot returns the first slot of the bus that none of the disks uses
+func freeCloudInitSlot(bus string, disks []proxmoxTypes.VmDisk) int64 {
+	usedSlots := map[int64]bool{}
+	for _, disk := range disks {
+		if disk.BusType.ValueString() == bus {
+			usedSlots[disk.Order.ValueInt64()] = true
+		}
+	}
+
+	if bus == "ide" && !usedSlots[preferredIdeCloudInitSlot] {
+		return preferredIdeCloudInitSlot
+	}
+	var slot int64
+	for usedSlots[slot] {
+		slot++
+	}
+	return slot
+}
+
+// findCloudInitDrive returns the config key and the storage of the cloud-init drive, the key is empty when the vm
+// has none
+func findCloudInitDrive(otherFields map[string]interface{}) (string, string) {
+	for key, value := range otherFields {
+		drive, isString := value.(string)
+		if !isString || !cloudInitKeyRegex.MatchString(key) {
+			continue
+		}
+		volume := strings.Split(drive, ",")[0]
+		storage, volumeName, _ := strings.Cut(volume, ":")
+		if strings.Contains(volumeName, "cloudinit") {
+			return key, storage
+		}
+	}
+	return "", ""
+}
+
+// MapCloudInitFromQemuResponse
+/**
+ * @description reads the cloud-init drive wherever it is attached. Vms managed without a cloud_init block keep
+ * reading back as such, otherwise a missing drive reads back as disabled.
+ */
+func (vmService *VmServiceImpl) MapCloudInitFromQemuResponse(otherFields map[string]interface{}, planned *proxmoxTypes.VmCloudInit) *proxmoxTypes.VmCloudInit {
+	if planned == nil {
+		return nil
+	}
+
+	key, storage := findCloudInitDrive(otherFields)
+	if key == "" {
+		cloudInit := proxmoxTypes.VmCloudInit{Enabled: types.BoolValue(false), Storage: planned.Storage, Bus: planned.Bus, Slot: planned.Slot}
+		if cloudInit.Storage.IsUnknown() {
+			cloudInit.Storage = types.StringNull()
+		}
+		if cloudInit.Bus.IsUnknown() {
+			cloudInit.Bus = types.StringNull()
+		}
+		if cloudInit.Slot.IsUnknown() {
+			cloudInit.Slot = types.Int64Null()
+		}
+		return &cloudInit
+	}
+
+	matches := cloudInitKeyRegex.FindStringSubmatch(key)
+	slot, _ := strconv.ParseInt(matches[2], 10, 64)
+	return &proxmoxTypes.VmCloudInit{
+		Enabled: types.BoolValue(true),
+		Storage: types.StringValue(storage),
+		Bus:     types.StringValue(matches[1]),
+		Slot:    types.Int64Value(slot),
+	}
+}
+
+// UpdateCloudInitDrive
+/**
+ * @description moves the cloud-init drive of an existing vm to where the cloud_init block of the plan puts it, or
+ * removes it when the block disables it. The drive is generated from the config, so it is recreated rather than moved.
+ * Vms without a cloud_init block are left alone.
+ */
+func (vmService *VmServiceImpl) UpdateCloudInitDrive(ctx context.Context, otherFields map[string]interface{}, plan *proxmoxTypes.VmModel, nodeName *string, vmId *string) error {
+	if plan.CloudInit == nil {
+		return nil
+	}
+
+	currentKey, currentStorage := findCloudInitDrive(otherFields)
+	planned := cloudInitDrive(plan)
+
+	plannedKey := ""
+	if planned != nil {
+		plannedKey = fmt.Sprintf("%s%d", planned.Bus.ValueString(), planned.Slot.ValueInt64())
+	}
+	if plannedKey == currentKey && (planned == nil || planned.Storage.ValueString() == currentStorage) {
+		return nil
+	}
+
+	if currentKey != "" {
+		tflog.Info(ctx, fmt.Sprintf("Removing cloud-init drive %s from vm %s", currentKey, *vmId))
+		params := url.Values{}
+		params.Add("delete", currentKey)
+		upid, deleteDriveError := vmService.proxmoxClient.UpdateVm(ctx, params, nodeName, vmId)
+		if deleteDriveError != nil {
+			return fmt.Errorf("Failed to remove the cloud-init drive %s: %w", currentKey, deleteDriveError)
+		}
+		waitForTaskError := vmService.taskService.WaitForTaskCompletion(ctx, nodeName, upid)
+		if waitForTaskError != nil {
+			return waitForTaskError
+		}
+	}
+
+	if planned == nil {
+		return nil
+	}
+
+	tflog.Info(ctx, fmt.Sprintf("Adding cloud-init drive %s on %s to vm %s", plannedKey, planned.Storage.ValueString(), *vmId))
+	params := url.Values{}
+	vmService.diskService.AttachVmDiskRequests(nil, &params, nil, planned, false)
+	upid, addDriveError := vmService.proxmoxClient.UpdateVm(ctx, params, nodeName, vmId)
+	if addDriveError != nil {
+		return fmt.Errorf("Failed to add the cloud-init drive %s: %w", plannedKey, addDriveError)
+	}
+	return vmService.taskService.WaitForTaskCompletion(ctx, nodeName, upid)
+}
+
+// cloudInitCustomParameter joins the snippets of the cloud_init_custom block into the cicustom option, empty when no
+// snippet is set
+func cloudInitCustomParameter(custom *proxmoxTypes.VmCloudInitCustom) string {
+	if custom == nil {
+		return ""
+	}
+	var snippets []string
+	for _, snippet := range []struct {
+		kind  string
+		volid types.String
+	}{{"meta", custom.Meta}, {"network", custom.Network}, {"user", custom.User}, {"vendor", custom.Vendor}} {
+		if snippet.volid.ValueString() != "" {
+			snippets = append(snippets, fmt.Sprintf("%s=%s", snippet.kind, snippet.volid.ValueString()))
+		}
+	}
+	return strings.Join(snippets, ",")
+}
+
+// mapCloudInitCustom reads the snippets of the cicustom option, the block stays unset when neither the vm nor the plan
+// has one
+func mapCloudInitCustom(cloudInitCustom string, planned *proxmoxTypes.VmCloudInitCustom) *proxmoxTypes.VmCloudInitCustom {
+	if cloudInitCustom == "" && planned == nil {
+		return nil
+	}
+
+	custom := proxmoxTypes.VmCloudInitCustom{User: types.StringNull(), Network: types.StringNull(), Vendor: types.StringNull(), Meta: types.StringNull()}
+	for _, snippet := range strings.Split(cloudInitCustom, ",") {
+		kind, volid, isSnippet := strings.Cut(snippet, "=")
+		if !isSnippet {
+			continue
+		}
+		switch kind {
+		case "user":
+			custom.User = types.StringValue(volid)
+		case "network":
+			custom.Network = types.StringValue(volid)
+		case "vendor":
+			custom.Vendor = types.StringValue(volid)
+		case "meta":
+			custom.Meta = types.StringValue(volid)
+		}
+	}
+	return &custom
+}
+
+// SetCloudInitPassword sets the password of the cloud-init user, an empty password removes it when otherFields, the
+// current config, has one. Proxmox only ever returns the password masked, so it is written on its own whenever it
+// changes.
+func (vmService *VmServiceImpl) SetCloudInitPassword(ctx context.Context, nodeName *string, vmId *string, password string, otherFields map[string]interface{}) error {
+	params := url.Values{}
+	if password == "" {
+		if _, isSet := otherFields["cipassword"]; !isSet {
+			return nil
+		}
+		params.Add("delete", "cipassword")
+	} else {
+		// the client logs the request body
+		ctx = tflog.MaskMessageStrings(ctx, password, url.QueryEscape(password))
+		params.Add("cipassword", password)
+	}
+
+	upid, updateVmError := vmService.proxmoxClient.UpdateVm(ctx, params, nodeName, vmId)
+	if updateVmError != nil {
+		return fmt.Errorf("Failed to set the cloud-init password of vm %s: %w", *vmId, updateVmError)
+	}
+	return vmService.taskService.WaitForTaskCompletion(ctx, nodeName, upid)
+}
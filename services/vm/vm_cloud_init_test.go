@@ -0,0 +1,77 @@
+package vm
+
+import (
+	"context"
+	proxmoxTypes "terraform-provider-proxmox/types"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCloudInitDrive(t *testing.T) {
+	disks := []proxmoxTypes.VmDisk{testDisk("scsi", 0, "local-zfs", "32G"), testDisk("ide", 2, "local-zfs", "8G"), testDisk("sata", 0, "local-zfs", "8G")}
+	testCases := []struct {
+		name      string
+		cloudInit *proxmoxTypes.VmCloudInit
+		expect    *proxmoxTypes.VmCloudInit
+	}{
+		{
+			name:   "without a cloud_init block",
+			expect: &proxmoxTypes.VmCloudInit{Enabled: types.BoolValue(true), Storage: types.StringValue("ceph"), Bus: types.StringValue("scsi"), Slot: types.Int64Value(1)},
+		},
+		{
+			name:      "disabled",
+			cloudInit: &proxmoxTypes.VmCloudInit{Enabled: types.BoolValue(false)},
+		},
+		{
+			name:      "first free sata slot",
+			cloudInit: &proxmoxTypes.VmCloudInit{Enabled: types.BoolValue(true), Storage: types.StringValue("local"), Bus: types.StringValue("sata"), Slot: types.Int64Unknown()},
+			expect:    &proxmoxTypes.VmCloudInit{Enabled: types.BoolValue(true), Storage: types.StringValue("local"), Bus: types.StringValue("sata"), Slot: types.Int64Value(1)},
+		},
+		{
+			name:      "ide2 taken by a disk",
+			cloudInit: &proxmoxTypes.VmCloudInit{Enabled: types.BoolValue(true), Storage: types.StringUnknown(), Bus: types.StringValue("ide"), Slot: types.Int64Unknown()},
+			expect:    &proxmoxTypes.VmCloudInit{Enabled: types.BoolValue(true), Storage: types.StringValue("ceph"), Bus: types.StringValue("ide"), Slot: types.Int64Value(0)},
+		},
+		{
+			name:      "configured slot",
+			cloudInit: &proxmoxTypes.VmCloudInit{Enabled: types.BoolValue(true), Storage: types.StringValue("local"), Bus: types.StringValue("ide"), Slot: types.Int64Value(3)},
+			expect:    &proxmoxTypes.VmCloudInit{Enabled: types.BoolValue(true), Storage: types.StringValue("local"), Bus: types.StringValue("ide"), Slot: types.Int64Value(3)},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			vmModel := proxmoxTypes.VmModel{Disks: disks, CloudInit: testCase.cloudInit, CloudInitStorageName: types.StringValue("ceph")}
+
+			assert.Equal(t, testCase.expect, cloudInitDrive(&vmModel))
+		})
+	}
+}
+
+func TestMapCloudInitFromQemuResponse(t *testing.T) {
+	vmService := VmServiceImpl{tfContext: context.Background()}
+	otherFields := map[string]interface{}{
+		"scsi0": "local-zfs:vm-140-disk-0,iothread=1,size=32G",
+		"ide0":  "local:iso/noble.iso,media=cdrom,size=2G",
+		"sata3": "local:140/vm-140-cloudinit.qcow2,media=cdrom",
+	}
+	planned := &proxmoxTypes.VmCloudInit{Enabled: types.BoolValue(true), Storage: types.StringUnknown(), Bus: types.StringValue("sata"), Slot: types.Int64Unknown()}
+
+	assert.Nil(t, vmService.MapCloudInitFromQemuResponse(otherFields, nil))
+	assert.Equal(t, &proxmoxTypes.VmCloudInit{
+		Enabled: types.BoolValue(true),
+		Storage: types.StringValue("local"),
+		Bus:     types.StringValue("sata"),
+		Slot:    types.Int64Value(3),
+	}, vmService.MapCloudInitFromQemuResponse(otherFields, planned))
+
+	delete(otherFields, "sata3")
+	assert.Equal(t, &proxmoxTypes.VmCloudInit{
+		Enabled: types.BoolValue(false),
+		Storage: types.StringNull(),
+		Bus:     types.StringValue("sata"),
+		Slot:    types.Int64Null(),
+	}, vmService.MapCloudInitFromQemuResponse(otherFields, planned))
+}
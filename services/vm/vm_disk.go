@@ -2,10 +2,10 @@ package vm
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"net/url"
 	"regexp"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -17,26 +17,36 @@ import (
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 )
 
+// diskNumberRegex also matches the base images a vm is left with once it is converted to a template
+var diskNumberRegex = regexp.MustCompile(`(?:vm|base)-\d+-disk-(\d+)`)
+
+// diskKeyRegex matches the config keys of the buses disks can be attached to and splits them into bus and order
+var diskKeyRegex = regexp.MustCompile(`^(scsi|virtio|sata)(\d+)$`)
+
+// hotPluggableBusTypes are the buses qemu can attach and detach disks on while the vm runs
+var hotPluggableBusTypes = []string{"scsi", "virtio"}
+
 type DiskService interface {
 	//AssignDiskIds(vmModel proxmoxTypes.VmModel) proxmoxTypes.VmModel
 	UpdateDisksFromQemuResponse(otherFields map[string]interface{}, vmModel *proxmoxTypes.VmModel, plan *proxmoxTypes.VmModel) []proxmoxTypes.VmDisk
-	AttachVmDiskRequests(disks []proxmoxTypes.VmDisk, params *url.Values, vmId *string, cloudInitEnabled bool, createNew bool)
+	AttachVmDiskRequests(disks []proxmoxTypes.VmDisk, params *url.Values, otherFields map[string]interface{}, cloudInit *proxmoxTypes.VmCloudInit, createNew bool)
 	GetDiskKeysFromJsonDict(dict map[string]interface{}) []string
 	GetDiskFromState(state proxmoxTypes.VmModel, diskName string) proxmoxTypes.VmDisk
 	MapPlannedDisksToExisting(plannedDisks []proxmoxTypes.VmDisk, existingDisks []proxmoxTypes.VmDisk) (map[int]int, []proxmoxTypes.VmDisk)
 	FindDiskIndex(diskSlice []proxmoxTypes.VmDisk, toBeFound proxmoxTypes.VmDisk) int
 	findDiskIndexHelper(diskSlice []proxmoxTypes.VmDisk, toBeFound proxmoxTypes.VmDisk, startIndex int, endIndex int) int
 	AreTheseDisksTheSame(disk1 proxmoxTypes.VmDisk, disk2 proxmoxTypes.VmDisk) bool
-	ResizeImportedDisks(vmIf *string, nodeName *string, disks []proxmoxTypes.VmDisk) error
+	ResizeImportedDisks(ctx context.Context, vmIf *string, nodeName *string, disks []proxmoxTypes.VmDisk) error
 	UpdateDisksWithUserValues(disks []proxmoxTypes.VmDisk, plan *proxmoxTypes.VmModel)
 	CompareVmDisks(current *proxmoxTypes.VmModel, planned *proxmoxTypes.VmModel) *proxmoxTypes.DiskChanges
-	DeleteVmDisk(disk *proxmoxTypes.VmDisk, nodeName *string, vmId *string) error
-	AddVmDisks(disks []proxmoxTypes.VmDisk, nodeName *string, vmId *string) error
-	ResizeDisk(disk *proxmoxTypes.VmDisk, nodeName *string, vmId *string) error
-	DeleteVmDisks(disks []proxmoxTypes.VmDisk, nodeName *string, vmId *string) error
-	UpdateVmDisks(toBeUpdated []proxmoxTypes.VmDisk, nodeName *string, vmId *string) error
-	ResizeVmDisks(disks []proxmoxTypes.VmDisk, nodeName *string, vmId *string) error
-	MoveDiskStorage(migrationMapping map[proxmoxTypes.VmDisk]proxmoxTypes.VmDisk, nodeName *string, vmId *string) error
+	ColdDiskChanges(existingDisks []proxmoxTypes.VmDisk, diskChanges *proxmoxTypes.DiskChanges, hotplug []string) []string
+	DeleteVmDisk(ctx context.Context, disk *proxmoxTypes.VmDisk, nodeName *string, vmId *string) error
+	AddVmDisks(ctx context.Context, disks []proxmoxTypes.VmDisk, nodeName *string, vmId *string) error
+	ResizeDisk(ctx context.Context, disk *proxmoxTypes.VmDisk, nodeName *string, vmId *string) error
+	DeleteVmDisks(ctx context.Context, disks []proxmoxTypes.VmDisk, nodeName *string, vmId *string) error
+	UpdateVmDisks(ctx context.Context, toBeUpdated []proxmoxTypes.VmDisk, nodeName *string, vmId *string) error
+	ResizeVmDisks(ctx context.Context, disks []proxmoxTypes.VmDisk, nodeName *string, vmId *string) error
+	MoveDiskStorage(ctx context.Context, migrationMapping map[proxmoxTypes.VmDisk]proxmoxTypes.VmDisk, nodeName *string, vmId *string) error
 }
 
 type DiskServiceImpl struct {
@@ -71,11 +81,16 @@ func (diskService *DiskServiceImpl) UpdateDisksFromQemuResponse(otherFields map[
 			cache = "default"
 		}
 
-		diskNumber, _ := strconv.ParseInt(strings.Split(strings.Split(diskParts[0], ":")[1], "-")[3], 10, 64)
-		order, _ := strconv.Atoi(key[4:len(key)])
+		// volumes on lvm and zfs are named vm-100-disk-1, on directory storage they are files like 100/vm-100-disk-1.qcow2
+		var diskNumber int64
+		if diskNumberMatch := diskNumberRegex.FindStringSubmatch(diskParts[0]); diskNumberMatch != nil {
+			diskNumber, _ = strconv.ParseInt(diskNumberMatch[1], 10, 64)
+		}
+		keyParts := diskKeyRegex.FindStringSubmatch(key)
+		order, _ := strconv.Atoi(keyParts[2])
 		newVmDisk := proxmoxTypes.VmDisk{
 			Id:              types.Int64Value(diskNumber),
-			BusType:         types.StringValue(key[:4]),
+			BusType:         types.StringValue(keyParts[1]),
 			StorageLocation: types.StringValue(storageLocation),
 			IoThread:        types.BoolValue(diskFieldMap["iothread"] == "1"),
 			Size:            types.StringValue(diskFieldMap["size"]),
@@ -103,15 +118,19 @@ func (diskService *DiskServiceImpl) UpdateDisksFromQemuResponse(otherFields map[
 	return disks
 }
 
-func (diskService *DiskServiceImpl) AttachVmDiskRequests(disks []proxmoxTypes.VmDisk, params *url.Values, vmId *string, cloudInitEnabled bool, createNew bool) {
+// AttachVmDiskRequests adds the disks to the create or update request, together with the cloud-init drive unless
+// cloudInit is nil. Disks that already exist keep the volume the current config in otherFields names for them.
+func (diskService *DiskServiceImpl) AttachVmDiskRequests(disks []proxmoxTypes.VmDisk, params *url.Values, otherFields map[string]interface{}, cloudInit *proxmoxTypes.VmCloudInit, createNew bool) {
 	for _, disk := range disks {
 		//local-zfs:vm-140-disk-0,aio=io_uring,backup=0,cache=directsync,discard=on,iothread=1,replicate=0,ro=1,size=32G,ssd=1
 		var diskString string
-		if createNew && disk.ImportFrom.ValueString() == "" {
-			diskString = fmt.Sprintf("%s:%s,size=%s", disk.StorageLocation.ValueString(), disk.Size.ValueString()[:len(disk.Size.ValueString())-1], disk.Size.ValueString())
+		if !createNew {
+			// the volume is vm-140-disk-0 on lvm and zfs but a file like 140/vm-140-disk-0.qcow2 on directory storage
+			currentDisk, _ := otherFields[diskService.GetDiskName(disk)].(string)
+			diskString = fmt.Sprintf("%s,size=%s", strings.Split(currentDisk, ",")[0], disk.Size.ValueString())
 		} else if disk.ImportFrom.ValueString() == "" {
-			diskString = fmt.Sprintf("%s:vm-%d-disk-%d,size=%s", disk.StorageLocation.ValueString(), vmId, disk.Id.ValueInt64(), disk.Size.ValueString())
-		} else if createNew && disk.ImportFrom.ValueString() != "" {
+			diskString = fmt.Sprintf("%s:%s,size=%s", disk.StorageLocation.ValueString(), disk.Size.ValueString()[:len(disk.Size.ValueString())-1], disk.Size.ValueString())
+		} else {
 			//size is ignored so we don't include it here
 			//for information on why it's this way see https://www.reddit.com/r/Proxmox/comments/y51x5h/comment/jujh7zi/?utm_source=share&utm_medium=web3x&utm_name=web3xcss&utm_term=1&utm_content=share_button
 			diskString = fmt.Sprintf("%s:0,import-from=%s:%s", disk.StorageLocation.ValueString(), disk.ImportFrom.ValueString(), disk.Path.ValueString())
@@ -129,29 +148,33 @@ func (diskService *DiskServiceImpl) AttachVmDiskRequests(disks []proxmoxTypes.Vm
 		if disk.Discard.ValueBool() {
 			diskString = fmt.Sprintf("%s,discard=on", diskString)
 		}
-		diskString = fmt.Sprintf("%s,iothread=%s", diskString, diskService.proxmoxUtilsService.MapBoolToProxmoxString(disk.IoThread.ValueBool()))
+		// sata has no io threads and virtio disks can't pretend to be an ssd, proxmox rejects those options
+		if disk.BusType.ValueString() != "sata" {
+			diskString = fmt.Sprintf("%s,iothread=%s", diskString, diskService.proxmoxUtilsService.MapBoolToProxmoxString(disk.IoThread.ValueBool()))
+		}
 		if !disk.Replicate.ValueBool() {
 			diskString = fmt.Sprintf("%s,replicate=0", diskString)
 		}
 		if disk.ReadOnly.ValueBool() {
 			diskString = fmt.Sprintf("%s,ro=1", diskString)
 		}
-		if disk.SsdEmulation.ValueBool() {
+		if disk.SsdEmulation.ValueBool() && disk.BusType.ValueString() != "virtio" {
 			diskString = fmt.Sprintf("%s,ssd=1", diskString)
 		}
 		params.Add(disk.BusType.ValueString()+disk.Order.String(), diskString)
 	}
-	if cloudInitEnabled {
-		params.Add(fmt.Sprintf("scsi%d", len(disks)), "local-zfs:cloudinit,media=cdrom")
+	if cloudInit != nil {
+		params.Add(fmt.Sprintf("%s%d", cloudInit.Bus.ValueString(), cloudInit.Slot.ValueInt64()), fmt.Sprintf("%s:cloudinit,media=cdrom", cloudInit.Storage.ValueString()))
 	}
 }
 
 func (diskService *DiskServiceImpl) GetDiskKeysFromJsonDict(dict map[string]interface{}) []string {
 	var keySlice []string
 	for key, value := range dict {
-		matched, _ := regexp.MatchString("scsi\\d+", key)
+		matched := diskKeyRegex.MatchString(key)
 
-		if matched && !strings.Contains(value.(string), "cloudinit") {
+		// cdrom drives hold an iso or nothing at all rather than a disk of the vm
+		if matched && !strings.Contains(value.(string), "cloudinit") && !strings.Contains(value.(string), "media=cdrom") {
 			keySlice = append(keySlice, key)
 		}
 	}
@@ -233,76 +256,56 @@ func (diskService *DiskServiceImpl) MapPlannedDisksToExisting(plannedDisks []pro
 //}
 
 func (diskService *DiskServiceImpl) FindDiskIndex(diskSlice []proxmoxTypes.VmDisk, toBeFound proxmoxTypes.VmDisk) int {
-	fmt.Printf("finding %s with ID %d\n", diskService.GetDiskName(toBeFound), toBeFound.Id.ValueInt64())
-	for _, disk := range diskSlice {
-		fmt.Print(diskService.GetDiskName(disk) + " ")
-	}
-	index := diskService.findDiskIndexHelper(diskSlice, toBeFound, 0, len(diskSlice)-1)
-	fmt.Printf("Found index is for %s is %d", diskService.GetDiskName(toBeFound), index)
-	return index
+	return diskService.findDiskIndexHelper(diskSlice, toBeFound, 0, len(diskSlice)-1)
 }
 
+// findDiskIndexHelper
+/**
+ * @description searches diskSlice between startIndex and endIndex for the disk on the same bus and order. The disks
+ * are in the order of the plan or of the config, which is not sorted by name once several buses are in use, so every
+ * disk in the range is compared.
+ *
+ * @return the index of the disk, -1 when it is not in the range
+ */
 func (diskService *DiskServiceImpl) findDiskIndexHelper(diskSlice []proxmoxTypes.VmDisk, toBeFound proxmoxTypes.VmDisk, startIndex int, endIndex int) int {
-
-	if startIndex < 0 || len(diskSlice) == 0 {
-		return -1
-	} else if startIndex == endIndex {
-		if diskService.AreTheseDisksTheSame(diskSlice[startIndex], toBeFound) {
-			return startIndex
-		} else {
-			return -1
-		}
-	} else if diskService.AreTheseDisksTheSame(diskSlice[startIndex], toBeFound) {
-		return startIndex
-	} else if diskService.AreTheseDisksTheSame(diskSlice[endIndex], toBeFound) {
-		return endIndex
-	} else if endIndex < startIndex {
+	if startIndex < 0 || endIndex >= len(diskSlice) {
 		return -1
 	}
-
-	indexDiff := endIndex - startIndex
-
-	halfWayIndex := indexDiff / 2
-	fmt.Printf("start %d, end %d, middle %d \n", startIndex, endIndex, halfWayIndex)
-	halfWayComparison := strings.Compare(diskService.GetDiskName(diskSlice[halfWayIndex]), diskService.GetDiskName(toBeFound))
-	if halfWayComparison > 0 {
-		return diskService.findDiskIndexHelper(diskSlice, toBeFound, 0, halfWayIndex)
-	} else if halfWayComparison < 0 {
-		return diskService.findDiskIndexHelper(diskSlice, toBeFound, endIndex-halfWayIndex, endIndex)
-	} else {
-		return halfWayIndex
+	for index := startIndex; index <= endIndex; index++ {
+		if diskService.AreTheseDisksTheSame(diskSlice[index], toBeFound) {
+			return index
+		}
 	}
+	return -1
 }
 
 func (diskService *DiskServiceImpl) AreTheseDisksTheSame(disk1 proxmoxTypes.VmDisk, disk2 proxmoxTypes.VmDisk) bool {
-	fmt.Printf("busType %s %s\n", disk1.BusType.ValueString(), disk2.BusType.ValueString())
-	fmt.Printf("Order %d %d\n", disk1.Order.ValueInt64(), disk2.Order.ValueInt64())
 	isEqual := disk1.BusType.ValueString() == disk2.BusType.ValueString()
 	isEqual = isEqual && disk1.Order.ValueInt64() == disk2.Order.ValueInt64()
 	return isEqual
 }
 
-func (diskService *DiskServiceImpl) ResizeImportedDisks(vmId *string, nodeName *string, disks []proxmoxTypes.VmDisk) error {
+func (diskService *DiskServiceImpl) ResizeImportedDisks(ctx context.Context, vmId *string, nodeName *string, disks []proxmoxTypes.VmDisk) error {
 	for _, disk := range disks {
-		tflog.Info(diskService.tfContext, fmt.Sprintf("Import from is %s", disk.ImportFrom.ValueString()))
+		tflog.Info(ctx, fmt.Sprintf("Import from is %s", disk.ImportFrom.ValueString()))
 
 		if disk.ImportFrom.ValueString() != "" {
 			params := url.Values{}
-			tflog.Info(diskService.tfContext, "Resizing imported disk")
+			tflog.Info(ctx, "Resizing imported disk")
 
 			params.Add("disk", fmt.Sprintf("%s%d", disk.BusType.ValueString(), disk.Order.ValueInt64()))
 			params.Add("size", disk.Size.ValueString())
 
-			taskResponse, resizeDiskError := diskService.proxmoxClient.ResizeVmDisk(params, nodeName, vmId)
+			taskResponse, resizeDiskError := diskService.proxmoxClient.ResizeVmDisk(ctx, params, nodeName, vmId)
 
 			if resizeDiskError != nil {
-				return errors.New(fmt.Sprintf("Failed to resize imported disk %s", resizeDiskError.Error()))
+				return fmt.Errorf("Failed to resize imported disk %w", resizeDiskError)
 			}
 
-			taskCompletionError := diskService.taskService.WaitForTaskCompletion(nodeName, taskResponse)
+			taskCompletionError := diskService.taskService.WaitForTaskCompletion(ctx, nodeName, taskResponse)
 
 			if taskCompletionError != nil {
-				return errors.New(fmt.Sprintf("Failed to wait for resize task completion %s", taskCompletionError.Error()))
+				return fmt.Errorf("Failed to wait for resize task completion %w", taskCompletionError)
 			}
 		}
 	}
@@ -340,6 +343,8 @@ func (diskService *DiskServiceImpl) CompareVmDisks(existing *proxmoxTypes.VmMode
 		}
 		existingDisk := existing.Disks[i]
 		plannedDisk := planned.Disks[plannedIndex]
+		// the id of the volume is only known from the state
+		plannedDisk.Id = existingDisk.Id
 
 		isEqual := existingDisk.ImportFrom.ValueString() == plannedDisk.ImportFrom.ValueString()
 		isEqual = isEqual && existingDisk.Path.ValueString() == plannedDisk.Path.ValueString()
@@ -355,11 +360,11 @@ func (diskService *DiskServiceImpl) CompareVmDisks(existing *proxmoxTypes.VmMode
 		isEqual = isEqual && existingDisk.ReadOnly.ValueBool() == plannedDisk.ReadOnly.ValueBool()
 
 		if !isEqual {
-			toBeUpdated = append(toBeUpdated, planned.Disks[i])
+			toBeUpdated = append(toBeUpdated, plannedDisk)
 		}
 
 		if existingDisk.Size.ValueString() != plannedDisk.Size.ValueString() || existingDisk.Path.ValueString() != plannedDisk.Path.ValueString() {
-			toBeResized = append(toBeResized, planned.Disks[i])
+			toBeResized = append(toBeResized, plannedDisk)
 		}
 
 		if existingDisk.StorageLocation.ValueString() != plannedDisk.StorageLocation.ValueString() {
@@ -383,17 +388,65 @@ func (diskService *DiskServiceImpl) CompareVmDisks(existing *proxmoxTypes.VmMode
 	return &diskChanges
 }
 
-func (diskService *DiskServiceImpl) DeleteVmDisk(disk *proxmoxTypes.VmDisk, nodeName *string, vmId *string) error {
+// ColdDiskChanges
+/**
+ * @description lists the disk changes proxmox can't apply while the vm is running. Disks are grown and moved to
+ * another storage online, scsi and virtio disks are attached and detached online as long as hotplug contains disk.
+ * Changing the options of a drive other than backup and replication only takes effect once qemu restarts.
+ *
+ * @return a description of every change that needs the vm to be stopped, empty when all of them can be applied live
+ */
+func (diskService *DiskServiceImpl) ColdDiskChanges(existingDisks []proxmoxTypes.VmDisk, diskChanges *proxmoxTypes.DiskChanges, hotplug []string) []string {
+	var coldChanges []string
+	diskHotplug := slices.Contains(hotplug, "disk")
+
+	for _, disk := range diskChanges.ToBeAdded {
+		if !diskHotplug || !slices.Contains(hotPluggableBusTypes, disk.BusType.ValueString()) {
+			coldChanges = append(coldChanges, "adding "+diskService.GetDiskName(disk))
+		}
+	}
+
+	for _, disk := range diskChanges.ToBeRemove {
+		if !diskHotplug || !slices.Contains(hotPluggableBusTypes, disk.BusType.ValueString()) {
+			coldChanges = append(coldChanges, "removing "+diskService.GetDiskName(disk))
+		}
+	}
+
+	for _, plannedDisk := range diskChanges.ToBeUpdated {
+		existingIndex := diskService.FindDiskIndex(existingDisks, plannedDisk)
+		if existingIndex == -1 {
+			continue
+		}
+		existingDisk := existingDisks[existingIndex]
+
+		isLive := existingDisk.ImportFrom.ValueString() == plannedDisk.ImportFrom.ValueString()
+		isLive = isLive && existingDisk.Path.ValueString() == plannedDisk.Path.ValueString()
+		isLive = isLive && existingDisk.AsyncIo.ValueString() == plannedDisk.AsyncIo.ValueString()
+		isLive = isLive && existingDisk.IoThread.ValueBool() == plannedDisk.IoThread.ValueBool()
+		isLive = isLive && existingDisk.Cache.ValueString() == plannedDisk.Cache.ValueString()
+		isLive = isLive && existingDisk.SsdEmulation.ValueBool() == plannedDisk.SsdEmulation.ValueBool()
+		isLive = isLive && existingDisk.Discard.ValueBool() == plannedDisk.Discard.ValueBool()
+		isLive = isLive && existingDisk.ReadOnly.ValueBool() == plannedDisk.ReadOnly.ValueBool()
+
+		if !isLive {
+			coldChanges = append(coldChanges, "changing the options of "+diskService.GetDiskName(plannedDisk))
+		}
+	}
+
+	return coldChanges
+}
+
+func (diskService *DiskServiceImpl) DeleteVmDisk(ctx context.Context, disk *proxmoxTypes.VmDisk, nodeName *string, vmId *string) error {
 	params := url.Values{}
 	params.Add("delete", fmt.Sprintf("%s%d", disk.BusType.ValueString(), disk.Order.ValueInt64()))
 
-	upid, updateVmErrror := diskService.proxmoxClient.UpdateVm(params, nodeName, vmId)
+	upid, updateVmErrror := diskService.proxmoxClient.UpdateVm(ctx, params, nodeName, vmId)
 
 	if updateVmErrror != nil {
 		return updateVmErrror
 	}
 
-	waitForTaskCompletionError := diskService.taskService.WaitForTaskCompletion(nodeName, upid)
+	waitForTaskCompletionError := diskService.taskService.WaitForTaskCompletion(ctx, nodeName, upid)
 
 	if waitForTaskCompletionError != nil {
 		return waitForTaskCompletionError
@@ -401,13 +454,13 @@ func (diskService *DiskServiceImpl) DeleteVmDisk(disk *proxmoxTypes.VmDisk, node
 
 	params.Set("delete", "unused0")
 
-	upid, updateVmErrror = diskService.proxmoxClient.UpdateVm(params, nodeName, vmId)
+	upid, updateVmErrror = diskService.proxmoxClient.UpdateVm(ctx, params, nodeName, vmId)
 
 	if updateVmErrror != nil {
 		return updateVmErrror
 	}
 
-	waitForTaskCompletionError = diskService.taskService.WaitForTaskCompletion(nodeName, upid)
+	waitForTaskCompletionError = diskService.taskService.WaitForTaskCompletion(ctx, nodeName, upid)
 
 	if waitForTaskCompletionError != nil {
 		return waitForTaskCompletionError
@@ -416,7 +469,7 @@ func (diskService *DiskServiceImpl) DeleteVmDisk(disk *proxmoxTypes.VmDisk, node
 	return nil
 }
 
-func (diskService *DiskServiceImpl) AddVmDisks(disks []proxmoxTypes.VmDisk, nodeName *string, vmId *string) error {
+func (diskService *DiskServiceImpl) AddVmDisks(ctx context.Context, disks []proxmoxTypes.VmDisk, nodeName *string, vmId *string) error {
 
 	if len(disks) == 0 {
 		return nil
@@ -424,15 +477,15 @@ func (diskService *DiskServiceImpl) AddVmDisks(disks []proxmoxTypes.VmDisk, node
 
 	params := url.Values{}
 
-	diskService.AttachVmDiskRequests(disks, &params, vmId, false, true)
+	diskService.AttachVmDiskRequests(disks, &params, nil, nil, true)
 
-	upid, vmUpdateError := diskService.proxmoxClient.UpdateVm(params, nodeName, vmId)
+	upid, vmUpdateError := diskService.proxmoxClient.UpdateVm(ctx, params, nodeName, vmId)
 
 	if vmUpdateError != nil {
 		return vmUpdateError
 	}
 
-	taskCompletionError := diskService.taskService.WaitForTaskCompletion(nodeName, upid)
+	taskCompletionError := diskService.taskService.WaitForTaskCompletion(ctx, nodeName, upid)
 
 	if taskCompletionError != nil {
 		return taskCompletionError
@@ -440,7 +493,7 @@ func (diskService *DiskServiceImpl) AddVmDisks(disks []proxmoxTypes.VmDisk, node
 
 	for _, disk := range disks {
 		if disk.ImportFrom.ValueString() != "" {
-			resizeDiskError := diskService.ResizeDisk(&disk, nodeName, vmId)
+			resizeDiskError := diskService.ResizeDisk(ctx, &disk, nodeName, vmId)
 			if resizeDiskError != nil {
 				return resizeDiskError
 			}
@@ -450,20 +503,20 @@ func (diskService *DiskServiceImpl) AddVmDisks(disks []proxmoxTypes.VmDisk, node
 	return nil
 }
 
-func (diskService *DiskServiceImpl) ResizeDisk(disk *proxmoxTypes.VmDisk, nodeName *string, vmId *string) error {
+func (diskService *DiskServiceImpl) ResizeDisk(ctx context.Context, disk *proxmoxTypes.VmDisk, nodeName *string, vmId *string) error {
 	params := url.Values{}
-	tflog.Info(diskService.tfContext, "Resizing imported disk")
+	tflog.Info(ctx, "Resizing imported disk")
 
 	params.Add("disk", fmt.Sprintf("%s%d", disk.BusType.ValueString(), disk.Order.ValueInt64()))
 	params.Add("size", disk.Size.ValueString())
 
-	taskResponse, resizeDiskError := diskService.proxmoxClient.ResizeVmDisk(params, nodeName, vmId)
+	taskResponse, resizeDiskError := diskService.proxmoxClient.ResizeVmDisk(ctx, params, nodeName, vmId)
 
 	if resizeDiskError != nil {
 		return resizeDiskError
 	}
 
-	taskCompletionError := diskService.taskService.WaitForTaskCompletion(nodeName, taskResponse)
+	taskCompletionError := diskService.taskService.WaitForTaskCompletion(ctx, nodeName, taskResponse)
 
 	if taskCompletionError != nil {
 		return taskCompletionError
@@ -471,9 +524,9 @@ func (diskService *DiskServiceImpl) ResizeDisk(disk *proxmoxTypes.VmDisk, nodeNa
 	return nil
 }
 
-func (diskService *DiskServiceImpl) DeleteVmDisks(disks []proxmoxTypes.VmDisk, nodeName *string, vmId *string) error {
+func (diskService *DiskServiceImpl) DeleteVmDisks(ctx context.Context, disks []proxmoxTypes.VmDisk, nodeName *string, vmId *string) error {
 	for _, disk := range disks {
-		diskDeletionError := diskService.DeleteVmDisk(&disk, nodeName, vmId)
+		diskDeletionError := diskService.DeleteVmDisk(ctx, &disk, nodeName, vmId)
 
 		if diskDeletionError != nil {
 
@@ -483,19 +536,27 @@ func (diskService *DiskServiceImpl) DeleteVmDisks(disks []proxmoxTypes.VmDisk, n
 	return nil
 }
 
-func (diskService *DiskServiceImpl) UpdateVmDisks(toBeUpdated []proxmoxTypes.VmDisk, nodeName *string, vmId *string) error {
+func (diskService *DiskServiceImpl) UpdateVmDisks(ctx context.Context, toBeUpdated []proxmoxTypes.VmDisk, nodeName *string, vmId *string) error {
 	if len(toBeUpdated) == 0 {
 		return nil
 	}
+	// the disks already exist, so the request has to name their volumes rather than allocate new ones. The config is read
+	// now since moving a disk to another storage gives it a new volume.
+	qemuResponse, getVmError := diskService.proxmoxClient.GetVmById(ctx, nodeName, vmId)
+
+	if getVmError != nil {
+		return getVmError
+	}
+
 	params := url.Values{}
-	diskService.AttachVmDiskRequests(toBeUpdated, &params, vmId, false, true)
-	upid, vmUpdateError := diskService.proxmoxClient.UpdateVm(params, nodeName, vmId)
+	diskService.AttachVmDiskRequests(toBeUpdated, &params, qemuResponse.Data.OtherFields, nil, false)
+	upid, vmUpdateError := diskService.proxmoxClient.UpdateVm(ctx, params, nodeName, vmId)
 
 	if vmUpdateError != nil {
 		return vmUpdateError
 	}
 
-	taskCompletionError := diskService.taskService.WaitForTaskCompletion(nodeName, upid)
+	taskCompletionError := diskService.taskService.WaitForTaskCompletion(ctx, nodeName, upid)
 
 	if taskCompletionError != nil {
 		return taskCompletionError
@@ -504,9 +565,9 @@ func (diskService *DiskServiceImpl) UpdateVmDisks(toBeUpdated []proxmoxTypes.VmD
 	return nil
 }
 
-func (diskService *DiskServiceImpl) ResizeVmDisks(disks []proxmoxTypes.VmDisk, nodeName *string, vmId *string) error {
+func (diskService *DiskServiceImpl) ResizeVmDisks(ctx context.Context, disks []proxmoxTypes.VmDisk, nodeName *string, vmId *string) error {
 	for _, disk := range disks {
-		resizeDiskError := diskService.ResizeDisk(&disk, nodeName, vmId)
+		resizeDiskError := diskService.ResizeDisk(ctx, &disk, nodeName, vmId)
 		if resizeDiskError != nil {
 			return resizeDiskError
 		}
@@ -514,20 +575,30 @@ func (diskService *DiskServiceImpl) ResizeVmDisks(disks []proxmoxTypes.VmDisk, n
 	return nil
 }
 
-func (diskService *DiskServiceImpl) MoveDiskStorage(migrationMapping map[proxmoxTypes.VmDisk]proxmoxTypes.VmDisk, nodeName *string, vmId *string) error {
+func (diskService *DiskServiceImpl) MoveDiskStorage(ctx context.Context, migrationMapping map[proxmoxTypes.VmDisk]proxmoxTypes.VmDisk, nodeName *string, vmId *string) error {
 	for key, value := range migrationMapping {
-		diskName := fmt.Sprintf("%s%d", key.BusType.ValueString(), key.Order.ValueInt64())
-		upid, moveVmDiskError := diskService.proxmoxClient.MoveVmDisk(&diskName, nodeName, vmId, value.StorageLocation.ValueStringPointer())
+		moveDiskError := diskService.moveDisk(ctx, &key, &value, nodeName, vmId)
 
-		if moveVmDiskError != nil {
-			return moveVmDiskError
+		if moveDiskError != nil {
+			return moveDiskError
 		}
+	}
+	return nil
+}
 
-		taskCompletionError := diskService.taskService.WaitForTaskCompletion(nodeName, upid)
+func (diskService *DiskServiceImpl) moveDisk(ctx context.Context, currentDisk *proxmoxTypes.VmDisk, newDisk *proxmoxTypes.VmDisk, nodeName *string, vmId *string) error {
+	releaseTaskSlot, acquireTaskSlotError := diskService.proxmoxClient.AcquireTaskSlot(ctx, *nodeName)
+	if acquireTaskSlotError != nil {
+		return acquireTaskSlotError
+	}
+	defer releaseTaskSlot()
 
-		if taskCompletionError != nil {
-			return taskCompletionError
-		}
+	diskName := fmt.Sprintf("%s%d", currentDisk.BusType.ValueString(), currentDisk.Order.ValueInt64())
+	upid, moveVmDiskError := diskService.proxmoxClient.MoveVmDisk(ctx, &diskName, nodeName, vmId, newDisk.StorageLocation.ValueStringPointer())
+
+	if moveVmDiskError != nil {
+		return moveVmDiskError
 	}
-	return nil
+
+	return diskService.taskService.WaitForTaskCompletion(ctx, nodeName, upid)
 }
@@ -0,0 +1,306 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: services/vm/vm_disk.go
+//
+// Generated by this command:
+//
+//	mockgen -source=services/vm/vm_disk.go -destination=services/vm/vm_disk_mock.go -package=vm -self_package=terraform-provider-proxmox/services/vm
+//
+
+// Package vm is a generated GoMock package.
+package vm
+
+import (
+	context "context"
+	url "net/url"
+	reflect "reflect"
+	types "terraform-provider-proxmox/types"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockDiskService is a mock of DiskService interface.
+type MockDiskService struct {
+	ctrl     *gomock.Controller
+	recorder *MockDiskServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockDiskServiceMockRecorder is the mock recorder for MockDiskService.
+type MockDiskServiceMockRecorder struct {
+	mock *MockDiskService
+}
+
+// NewMockDiskService creates a new mock instance.
+func NewMockDiskService(ctrl *gomock.Controller) *MockDiskService {
+	mock := &MockDiskService{ctrl: ctrl}
+	mock.recorder = &MockDiskServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDiskService) EXPECT() *MockDiskServiceMockRecorder {
+	return m.recorder
+}
+
+// AddVmDisks mocks base method.
+func (m *MockDiskService) AddVmDisks(ctx context.Context, disks []types.VmDisk, nodeName, vmId *string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddVmDisks", ctx, disks, nodeName, vmId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddVmDisks indicates an expected call of AddVmDisks.
+func (mr *MockDiskServiceMockRecorder) AddVmDisks(ctx, disks, nodeName, vmId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddVmDisks", reflect.TypeOf((*MockDiskService)(nil).AddVmDisks), ctx, disks, nodeName, vmId)
+}
+
+// AreTheseDisksTheSame mocks base method.
+func (m *MockDiskService) AreTheseDisksTheSame(disk1, disk2 types.VmDisk) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AreTheseDisksTheSame", disk1, disk2)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// AreTheseDisksTheSame indicates an expected call of AreTheseDisksTheSame.
+func (mr *MockDiskServiceMockRecorder) AreTheseDisksTheSame(disk1, disk2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AreTheseDisksTheSame", reflect.TypeOf((*MockDiskService)(nil).AreTheseDisksTheSame), disk1, disk2)
+}
+
+// AttachVmDiskRequests mocks base method.
+func (m *MockDiskService) AttachVmDiskRequests(disks []types.VmDisk, params *url.Values, otherFields map[string]any, cloudInit *types.VmCloudInit, createNew bool) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "AttachVmDiskRequests", disks, params, otherFields, cloudInit, createNew)
+}
+
+// AttachVmDiskRequests indicates an expected call of AttachVmDiskRequests.
+func (mr *MockDiskServiceMockRecorder) AttachVmDiskRequests(disks, params, otherFields, cloudInit, createNew any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AttachVmDiskRequests", reflect.TypeOf((*MockDiskService)(nil).AttachVmDiskRequests), disks, params, otherFields, cloudInit, createNew)
+}
+
+// ColdDiskChanges mocks base method.
+func (m *MockDiskService) ColdDiskChanges(existingDisks []types.VmDisk, diskChanges *types.DiskChanges, hotplug []string) []string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ColdDiskChanges", existingDisks, diskChanges, hotplug)
+	ret0, _ := ret[0].([]string)
+	return ret0
+}
+
+// ColdDiskChanges indicates an expected call of ColdDiskChanges.
+func (mr *MockDiskServiceMockRecorder) ColdDiskChanges(existingDisks, diskChanges, hotplug any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ColdDiskChanges", reflect.TypeOf((*MockDiskService)(nil).ColdDiskChanges), existingDisks, diskChanges, hotplug)
+}
+
+// CompareVmDisks mocks base method.
+func (m *MockDiskService) CompareVmDisks(current, planned *types.VmModel) *types.DiskChanges {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompareVmDisks", current, planned)
+	ret0, _ := ret[0].(*types.DiskChanges)
+	return ret0
+}
+
+// CompareVmDisks indicates an expected call of CompareVmDisks.
+func (mr *MockDiskServiceMockRecorder) CompareVmDisks(current, planned any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompareVmDisks", reflect.TypeOf((*MockDiskService)(nil).CompareVmDisks), current, planned)
+}
+
+// DeleteVmDisk mocks base method.
+func (m *MockDiskService) DeleteVmDisk(ctx context.Context, disk *types.VmDisk, nodeName, vmId *string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteVmDisk", ctx, disk, nodeName, vmId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteVmDisk indicates an expected call of DeleteVmDisk.
+func (mr *MockDiskServiceMockRecorder) DeleteVmDisk(ctx, disk, nodeName, vmId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteVmDisk", reflect.TypeOf((*MockDiskService)(nil).DeleteVmDisk), ctx, disk, nodeName, vmId)
+}
+
+// DeleteVmDisks mocks base method.
+func (m *MockDiskService) DeleteVmDisks(ctx context.Context, disks []types.VmDisk, nodeName, vmId *string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteVmDisks", ctx, disks, nodeName, vmId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteVmDisks indicates an expected call of DeleteVmDisks.
+func (mr *MockDiskServiceMockRecorder) DeleteVmDisks(ctx, disks, nodeName, vmId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteVmDisks", reflect.TypeOf((*MockDiskService)(nil).DeleteVmDisks), ctx, disks, nodeName, vmId)
+}
+
+// FindDiskIndex mocks base method.
+func (m *MockDiskService) FindDiskIndex(diskSlice []types.VmDisk, toBeFound types.VmDisk) int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindDiskIndex", diskSlice, toBeFound)
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// FindDiskIndex indicates an expected call of FindDiskIndex.
+func (mr *MockDiskServiceMockRecorder) FindDiskIndex(diskSlice, toBeFound any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindDiskIndex", reflect.TypeOf((*MockDiskService)(nil).FindDiskIndex), diskSlice, toBeFound)
+}
+
+// GetDiskFromState mocks base method.
+func (m *MockDiskService) GetDiskFromState(state types.VmModel, diskName string) types.VmDisk {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDiskFromState", state, diskName)
+	ret0, _ := ret[0].(types.VmDisk)
+	return ret0
+}
+
+// GetDiskFromState indicates an expected call of GetDiskFromState.
+func (mr *MockDiskServiceMockRecorder) GetDiskFromState(state, diskName any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDiskFromState", reflect.TypeOf((*MockDiskService)(nil).GetDiskFromState), state, diskName)
+}
+
+// GetDiskKeysFromJsonDict mocks base method.
+func (m *MockDiskService) GetDiskKeysFromJsonDict(dict map[string]any) []string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDiskKeysFromJsonDict", dict)
+	ret0, _ := ret[0].([]string)
+	return ret0
+}
+
+// GetDiskKeysFromJsonDict indicates an expected call of GetDiskKeysFromJsonDict.
+func (mr *MockDiskServiceMockRecorder) GetDiskKeysFromJsonDict(dict any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDiskKeysFromJsonDict", reflect.TypeOf((*MockDiskService)(nil).GetDiskKeysFromJsonDict), dict)
+}
+
+// MapPlannedDisksToExisting mocks base method.
+func (m *MockDiskService) MapPlannedDisksToExisting(plannedDisks, existingDisks []types.VmDisk) (map[int]int, []types.VmDisk) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MapPlannedDisksToExisting", plannedDisks, existingDisks)
+	ret0, _ := ret[0].(map[int]int)
+	ret1, _ := ret[1].([]types.VmDisk)
+	return ret0, ret1
+}
+
+// MapPlannedDisksToExisting indicates an expected call of MapPlannedDisksToExisting.
+func (mr *MockDiskServiceMockRecorder) MapPlannedDisksToExisting(plannedDisks, existingDisks any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MapPlannedDisksToExisting", reflect.TypeOf((*MockDiskService)(nil).MapPlannedDisksToExisting), plannedDisks, existingDisks)
+}
+
+// MoveDiskStorage mocks base method.
+func (m *MockDiskService) MoveDiskStorage(ctx context.Context, migrationMapping map[types.VmDisk]types.VmDisk, nodeName, vmId *string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MoveDiskStorage", ctx, migrationMapping, nodeName, vmId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MoveDiskStorage indicates an expected call of MoveDiskStorage.
+func (mr *MockDiskServiceMockRecorder) MoveDiskStorage(ctx, migrationMapping, nodeName, vmId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MoveDiskStorage", reflect.TypeOf((*MockDiskService)(nil).MoveDiskStorage), ctx, migrationMapping, nodeName, vmId)
+}
+
+// ResizeDisk mocks base method.
+func (m *MockDiskService) ResizeDisk(ctx context.Context, disk *types.VmDisk, nodeName, vmId *string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResizeDisk", ctx, disk, nodeName, vmId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResizeDisk indicates an expected call of ResizeDisk.
+func (mr *MockDiskServiceMockRecorder) ResizeDisk(ctx, disk, nodeName, vmId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResizeDisk", reflect.TypeOf((*MockDiskService)(nil).ResizeDisk), ctx, disk, nodeName, vmId)
+}
+
+// ResizeImportedDisks mocks base method.
+func (m *MockDiskService) ResizeImportedDisks(ctx context.Context, vmIf, nodeName *string, disks []types.VmDisk) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResizeImportedDisks", ctx, vmIf, nodeName, disks)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResizeImportedDisks indicates an expected call of ResizeImportedDisks.
+func (mr *MockDiskServiceMockRecorder) ResizeImportedDisks(ctx, vmIf, nodeName, disks any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResizeImportedDisks", reflect.TypeOf((*MockDiskService)(nil).ResizeImportedDisks), ctx, vmIf, nodeName, disks)
+}
+
+// ResizeVmDisks mocks base method.
+func (m *MockDiskService) ResizeVmDisks(ctx context.Context, disks []types.VmDisk, nodeName, vmId *string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResizeVmDisks", ctx, disks, nodeName, vmId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResizeVmDisks indicates an expected call of ResizeVmDisks.
+func (mr *MockDiskServiceMockRecorder) ResizeVmDisks(ctx, disks, nodeName, vmId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResizeVmDisks", reflect.TypeOf((*MockDiskService)(nil).ResizeVmDisks), ctx, disks, nodeName, vmId)
+}
+
+// UpdateDisksFromQemuResponse mocks base method.
+func (m *MockDiskService) UpdateDisksFromQemuResponse(otherFields map[string]any, vmModel, plan *types.VmModel) []types.VmDisk {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateDisksFromQemuResponse", otherFields, vmModel, plan)
+	ret0, _ := ret[0].([]types.VmDisk)
+	return ret0
+}
+
+// UpdateDisksFromQemuResponse indicates an expected call of UpdateDisksFromQemuResponse.
+func (mr *MockDiskServiceMockRecorder) UpdateDisksFromQemuResponse(otherFields, vmModel, plan any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateDisksFromQemuResponse", reflect.TypeOf((*MockDiskService)(nil).UpdateDisksFromQemuResponse), otherFields, vmModel, plan)
+}
+
+// UpdateDisksWithUserValues mocks base method.
+func (m *MockDiskService) UpdateDisksWithUserValues(disks []types.VmDisk, plan *types.VmModel) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "UpdateDisksWithUserValues", disks, plan)
+}
+
+// UpdateDisksWithUserValues indicates an expected call of UpdateDisksWithUserValues.
+func (mr *MockDiskServiceMockRecorder) UpdateDisksWithUserValues(disks, plan any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateDisksWithUserValues", reflect.TypeOf((*MockDiskService)(nil).UpdateDisksWithUserValues), disks, plan)
+}
+
+// UpdateVmDisks mocks base method.
+func (m *MockDiskService) UpdateVmDisks(ctx context.Context, toBeUpdated []types.VmDisk, nodeName, vmId *string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateVmDisks", ctx, toBeUpdated, nodeName, vmId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateVmDisks indicates an expected call of UpdateVmDisks.
+func (mr *MockDiskServiceMockRecorder) UpdateVmDisks(ctx, toBeUpdated, nodeName, vmId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateVmDisks", reflect.TypeOf((*MockDiskService)(nil).UpdateVmDisks), ctx, toBeUpdated, nodeName, vmId)
+}
+
+// findDiskIndexHelper mocks base method.
+func (m *MockDiskService) findDiskIndexHelper(diskSlice []types.VmDisk, toBeFound types.VmDisk, startIndex, endIndex int) int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "findDiskIndexHelper", diskSlice, toBeFound, startIndex, endIndex)
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// findDiskIndexHelper indicates an expected call of findDiskIndexHelper.
+func (mr *MockDiskServiceMockRecorder) findDiskIndexHelper(diskSlice, toBeFound, startIndex, endIndex any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "findDiskIndexHelper", reflect.TypeOf((*MockDiskService)(nil).findDiskIndexHelper), diskSlice, toBeFound, startIndex, endIndex)
+}
@@ -1,35 +1,392 @@
 package vm
 
-//func TestDiskServiceImpl_UpdateDisksFromQemuResponse(t *testing.T) {
-//	ctrl := gomock.NewController(t)
-//	defer ctrl.Finish()
-//	//realProxmoxUtils := services.NewProxmoxUtilService()
-//	mockProxmoxUtils := services.NewMockProxmoxUtilService(ctrl)
-//	mockProxmoxClient := proxmox_client.NewMockProxmoxClient(ctrl)
-//	diskService := DiskServiceImpl{
-//		proxmoxUtilsService: mockProxmoxUtils,
-//		proxmoxClient:       mockProxmoxClient,
-//	}
-//	otherFields := map[string]interface{}{
-//		"scsi0": "vm-os-storage:vm-9005-disk-0,iothread=1,size=50G",
-//		"test":  "",
-//		"scsi1": "cloudinit:myCloudinitStorage-9005-disk-0",
-//	}
-//	fieldMap := map[string]string{
-//		"iothread":  "1",
-//		"size":      "2048",
-//		"aio":       "idk",
-//		"replicate": "1",
-//		"ro":        "0",
-//		"ssd":       "0",
-//		"backup":    "",
-//		"discard":   "off",
-//	}
-//	mockProxmoxUtils.EXPECT().MapKeyValuePairsToMap(gomock.AssignableToTypeOf([]string{})).Times(1).Return(fieldMap)
-//
-//	vmModel := proxmoxTypes.VmModel{}
-//	diskService.UpdateDisksFromQemuResponse(otherFields, &vmModel, nil)
-//
-//	//disks := vmModel.Disks
-//
-//}
+import (
+	"context"
+	"net/url"
+	"terraform-provider-proxmox/proxmox_client"
+	"terraform-provider-proxmox/services"
+	proxmoxTypes "terraform-provider-proxmox/types"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func newTestDiskService() *DiskServiceImpl {
+	return &DiskServiceImpl{tfContext: context.Background(), proxmoxUtilsService: services.NewProxmoxUtilService()}
+}
+
+// testDisk returns a disk with the defaults of the resource schema, changes are applied to it before it is returned
+func testDisk(busType string, order int64, storage string, size string, changes ...func(disk *proxmoxTypes.VmDisk)) proxmoxTypes.VmDisk {
+	disk := proxmoxTypes.VmDisk{
+		Id:              types.Int64Value(order),
+		BusType:         types.StringValue(busType),
+		StorageLocation: types.StringValue(storage),
+		IoThread:        types.BoolValue(true),
+		Size:            types.StringValue(size),
+		Cache:           types.StringValue("default"),
+		AsyncIo:         types.StringValue("default"),
+		Replicate:       types.BoolValue(true),
+		ReadOnly:        types.BoolValue(false),
+		SsdEmulation:    types.BoolValue(false),
+		Backup:          types.BoolValue(true),
+		Discard:         types.BoolValue(false),
+		Order:           types.Int64Value(order),
+		ImportFrom:      types.StringValue(""),
+		Path:            types.StringValue(""),
+	}
+	for _, change := range changes {
+		change(&disk)
+	}
+	return disk
+}
+
+func diskNames(diskService *DiskServiceImpl, disks []proxmoxTypes.VmDisk) []string {
+	names := []string{}
+	for _, disk := range disks {
+		names = append(names, diskService.GetDiskName(disk))
+	}
+	return names
+}
+
+func TestCompareVmDisks(t *testing.T) {
+	testCases := []struct {
+		name            string
+		existing        []proxmoxTypes.VmDisk
+		planned         []proxmoxTypes.VmDisk
+		expectAdded     []string
+		expectRemoved   []string
+		expectUpdated   []string
+		expectResized   []string
+		expectMigrated  map[string]string
+		expectUpdatedId int64
+	}{
+		{
+			name:     "unchanged disks on every bus",
+			existing: []proxmoxTypes.VmDisk{testDisk("scsi", 0, "local-zfs", "32G"), testDisk("virtio", 0, "local-zfs", "8G"), testDisk("sata", 0, "local-zfs", "8G")},
+			planned:  []proxmoxTypes.VmDisk{testDisk("scsi", 0, "local-zfs", "32G"), testDisk("virtio", 0, "local-zfs", "8G"), testDisk("sata", 0, "local-zfs", "8G")},
+		},
+		{
+			name:          "disk added and removed",
+			existing:      []proxmoxTypes.VmDisk{testDisk("scsi", 0, "local-zfs", "32G"), testDisk("scsi", 1, "local-zfs", "8G")},
+			planned:       []proxmoxTypes.VmDisk{testDisk("scsi", 0, "local-zfs", "32G"), testDisk("virtio", 1, "local-zfs", "8G")},
+			expectAdded:   []string{"virtio1"},
+			expectRemoved: []string{"scsi1"},
+		},
+		{
+			name:          "grown disk is resized",
+			existing:      []proxmoxTypes.VmDisk{testDisk("scsi", 0, "local-zfs", "32G")},
+			planned:       []proxmoxTypes.VmDisk{testDisk("scsi", 0, "local-zfs", "64G")},
+			expectResized: []string{"scsi0"},
+		},
+		{
+			name:     "options of a reordered disk are updated with the volume from the state",
+			existing: []proxmoxTypes.VmDisk{testDisk("sata", 0, "local-zfs", "8G", func(disk *proxmoxTypes.VmDisk) { disk.Id = types.Int64Value(3) }), testDisk("scsi", 0, "local-zfs", "32G")},
+			planned: []proxmoxTypes.VmDisk{
+				testDisk("scsi", 0, "local-zfs", "32G"),
+				testDisk("sata", 0, "local-zfs", "8G", func(disk *proxmoxTypes.VmDisk) {
+					disk.Id = types.Int64Unknown()
+					disk.Cache = types.StringValue("writeback")
+				}),
+			},
+			expectUpdated:   []string{"sata0"},
+			expectUpdatedId: 3,
+		},
+		{
+			name:           "disk moved to another storage",
+			existing:       []proxmoxTypes.VmDisk{testDisk("virtio", 0, "local-zfs", "32G")},
+			planned:        []proxmoxTypes.VmDisk{testDisk("virtio", 0, "ceph", "32G")},
+			expectMigrated: map[string]string{"virtio0": "ceph"},
+		},
+		{
+			name:     "imported disk is resized once the import path changes",
+			existing: []proxmoxTypes.VmDisk{testDisk("scsi", 0, "local-zfs", "32G")},
+			planned: []proxmoxTypes.VmDisk{testDisk("scsi", 0, "local-zfs", "32G", func(disk *proxmoxTypes.VmDisk) {
+				disk.ImportFrom = types.StringValue("local")
+				disk.Path = types.StringValue("import/noble.qcow2")
+			})},
+			expectUpdated: []string{"scsi0"},
+			expectResized: []string{"scsi0"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			diskService := newTestDiskService()
+
+			changes := diskService.CompareVmDisks(&proxmoxTypes.VmModel{Disks: testCase.existing}, &proxmoxTypes.VmModel{Disks: testCase.planned})
+
+			assert.ElementsMatch(t, testCase.expectAdded, diskNames(diskService, changes.ToBeAdded), "added")
+			assert.ElementsMatch(t, testCase.expectRemoved, diskNames(diskService, changes.ToBeRemove), "removed")
+			assert.ElementsMatch(t, testCase.expectUpdated, diskNames(diskService, changes.ToBeUpdated), "updated")
+			assert.ElementsMatch(t, testCase.expectResized, diskNames(diskService, changes.ToBeResized), "resized")
+			migrated := map[string]string{}
+			for existingDisk, plannedDisk := range changes.ToBeMigrated {
+				migrated[diskService.GetDiskName(existingDisk)] = plannedDisk.StorageLocation.ValueString()
+			}
+			if testCase.expectMigrated == nil {
+				testCase.expectMigrated = map[string]string{}
+			}
+			assert.Equal(t, testCase.expectMigrated, migrated, "migrated")
+			if testCase.expectUpdatedId != 0 {
+				assert.Equal(t, testCase.expectUpdatedId, changes.ToBeUpdated[0].Id.ValueInt64())
+			}
+		})
+	}
+}
+
+func TestColdDiskChanges(t *testing.T) {
+	defaultHotplug := []string{"network", "disk", "usb"}
+	testCases := []struct {
+		name       string
+		existing   []proxmoxTypes.VmDisk
+		planned    []proxmoxTypes.VmDisk
+		hotplug    []string
+		expectCold []string
+	}{
+		{
+			name:     "grown and moved disks are changed live",
+			existing: []proxmoxTypes.VmDisk{testDisk("scsi", 0, "local-zfs", "32G"), testDisk("sata", 0, "local-zfs", "8G")},
+			planned:  []proxmoxTypes.VmDisk{testDisk("scsi", 0, "local-zfs", "64G"), testDisk("sata", 0, "ceph", "8G")},
+			hotplug:  []string{},
+		},
+		{
+			name:     "scsi and virtio disks are hot plugged",
+			existing: []proxmoxTypes.VmDisk{testDisk("scsi", 0, "local-zfs", "32G"), testDisk("scsi", 1, "local-zfs", "8G")},
+			planned:  []proxmoxTypes.VmDisk{testDisk("scsi", 0, "local-zfs", "32G"), testDisk("virtio", 0, "local-zfs", "8G")},
+			hotplug:  defaultHotplug,
+		},
+		{
+			name:       "disks are not hot plugged without disk hotplug",
+			existing:   []proxmoxTypes.VmDisk{testDisk("scsi", 0, "local-zfs", "32G"), testDisk("scsi", 1, "local-zfs", "8G")},
+			planned:    []proxmoxTypes.VmDisk{testDisk("scsi", 0, "local-zfs", "32G"), testDisk("scsi", 2, "local-zfs", "8G")},
+			hotplug:    []string{"network", "usb"},
+			expectCold: []string{"adding scsi2", "removing scsi1"},
+		},
+		{
+			name:       "sata disks are never hot plugged",
+			existing:   []proxmoxTypes.VmDisk{testDisk("scsi", 0, "local-zfs", "32G")},
+			planned:    []proxmoxTypes.VmDisk{testDisk("scsi", 0, "local-zfs", "32G"), testDisk("sata", 0, "local-zfs", "8G")},
+			hotplug:    defaultHotplug,
+			expectCold: []string{"adding sata0"},
+		},
+		{
+			name:     "backup and replication are changed live",
+			existing: []proxmoxTypes.VmDisk{testDisk("scsi", 0, "local-zfs", "32G")},
+			planned: []proxmoxTypes.VmDisk{testDisk("scsi", 0, "local-zfs", "32G", func(disk *proxmoxTypes.VmDisk) {
+				disk.Backup = types.BoolValue(false)
+				disk.Replicate = types.BoolValue(false)
+			})},
+			hotplug: defaultHotplug,
+		},
+		{
+			name:     "drive options need a restart",
+			existing: []proxmoxTypes.VmDisk{testDisk("scsi", 0, "local-zfs", "32G")},
+			planned: []proxmoxTypes.VmDisk{testDisk("scsi", 0, "local-zfs", "64G", func(disk *proxmoxTypes.VmDisk) {
+				disk.Cache = types.StringValue("writeback")
+			})},
+			hotplug:    defaultHotplug,
+			expectCold: []string{"changing the options of scsi0"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			diskService := newTestDiskService()
+			changes := diskService.CompareVmDisks(&proxmoxTypes.VmModel{Disks: testCase.existing}, &proxmoxTypes.VmModel{Disks: testCase.planned})
+
+			coldChanges := diskService.ColdDiskChanges(testCase.existing, changes, testCase.hotplug)
+
+			assert.ElementsMatch(t, testCase.expectCold, coldChanges)
+		})
+	}
+}
+
+func TestMapPlannedDisksToExisting(t *testing.T) {
+	testCases := []struct {
+		name           string
+		planned        []proxmoxTypes.VmDisk
+		existing       []proxmoxTypes.VmDisk
+		expectMappings map[int]int
+		expectRemoved  []string
+	}{
+		{
+			name:           "same disks in the same order",
+			planned:        []proxmoxTypes.VmDisk{testDisk("scsi", 0, "local-zfs", "32G"), testDisk("scsi", 1, "local-zfs", "8G")},
+			existing:       []proxmoxTypes.VmDisk{testDisk("scsi", 0, "local-zfs", "32G"), testDisk("scsi", 1, "local-zfs", "8G")},
+			expectMappings: map[int]int{0: 0, 1: 1},
+		},
+		{
+			name:           "mixed buses in a different order",
+			planned:        []proxmoxTypes.VmDisk{testDisk("virtio", 0, "local-zfs", "8G"), testDisk("scsi", 0, "local-zfs", "32G"), testDisk("sata", 1, "local-zfs", "8G")},
+			existing:       []proxmoxTypes.VmDisk{testDisk("sata", 1, "local-zfs", "8G"), testDisk("scsi", 0, "local-zfs", "32G"), testDisk("virtio", 0, "local-zfs", "8G")},
+			expectMappings: map[int]int{0: 2, 1: 1, 2: 0},
+		},
+		{
+			name:           "new and removed disks",
+			planned:        []proxmoxTypes.VmDisk{testDisk("scsi", 0, "local-zfs", "32G"), testDisk("virtio", 0, "local-zfs", "8G")},
+			existing:       []proxmoxTypes.VmDisk{testDisk("scsi", 0, "local-zfs", "32G"), testDisk("sata", 0, "local-zfs", "8G")},
+			expectMappings: map[int]int{0: 0, 1: -1},
+			expectRemoved:  []string{"sata0"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			diskService := newTestDiskService()
+
+			mappings, removed := diskService.MapPlannedDisksToExisting(testCase.planned, testCase.existing)
+
+			assert.Equal(t, testCase.expectMappings, mappings)
+			assert.ElementsMatch(t, testCase.expectRemoved, diskNames(diskService, removed))
+		})
+	}
+}
+
+func TestAttachVmDiskRequests(t *testing.T) {
+	testCases := []struct {
+		name         string
+		disks        []proxmoxTypes.VmDisk
+		otherFields  map[string]interface{}
+		cloudInit    *proxmoxTypes.VmCloudInit
+		createNew    bool
+		expectParams url.Values
+	}{
+		{
+			name:         "new scsi disk",
+			disks:        []proxmoxTypes.VmDisk{testDisk("scsi", 0, "local-zfs", "32G")},
+			createNew:    true,
+			expectParams: url.Values{"scsi0": {"local-zfs:32,size=32G,iothread=1"}},
+		},
+		{
+			name: "options of an existing virtio disk",
+			disks: []proxmoxTypes.VmDisk{testDisk("virtio", 1, "local-zfs", "8G", func(disk *proxmoxTypes.VmDisk) {
+				disk.Id = types.Int64Value(2)
+				disk.AsyncIo = types.StringValue("io_uring")
+				disk.Backup = types.BoolValue(false)
+				disk.Cache = types.StringValue("directsync")
+				disk.Discard = types.BoolValue(true)
+				disk.Replicate = types.BoolValue(false)
+				disk.ReadOnly = types.BoolValue(true)
+				disk.SsdEmulation = types.BoolValue(true)
+			})},
+			otherFields:  map[string]interface{}{"virtio1": "local-zfs:vm-140-disk-2,iothread=1,size=8G"},
+			expectParams: url.Values{"virtio1": {"local-zfs:vm-140-disk-2,size=8G,aio=io_uring,backup=0,cache=directsync,discard=on,iothread=1,replicate=0,ro=1"}},
+		},
+		{
+			name: "options of an existing imported disk",
+			disks: []proxmoxTypes.VmDisk{testDisk("scsi", 0, "local-zfs", "32G", func(disk *proxmoxTypes.VmDisk) {
+				disk.ImportFrom = types.StringValue("local")
+				disk.Path = types.StringValue("import/noble.qcow2")
+				disk.Discard = types.BoolValue(true)
+			})},
+			otherFields:  map[string]interface{}{"scsi0": "local-zfs:vm-140-disk-0,iothread=1,size=32G"},
+			expectParams: url.Values{"scsi0": {"local-zfs:vm-140-disk-0,size=32G,discard=on,iothread=1"}},
+		},
+		{
+			name: "options of an existing qcow2 disk",
+			disks: []proxmoxTypes.VmDisk{testDisk("scsi", 1, "local", "8G", func(disk *proxmoxTypes.VmDisk) {
+				disk.Cache = types.StringValue("writeback")
+			})},
+			otherFields:  map[string]interface{}{"scsi1": "local:140/vm-140-disk-1.qcow2,iothread=1,size=8G"},
+			expectParams: url.Values{"scsi1": {"local:140/vm-140-disk-1.qcow2,size=8G,cache=writeback,iothread=1"}},
+		},
+		{
+			name: "sata disk has no io thread",
+			disks: []proxmoxTypes.VmDisk{testDisk("sata", 0, "local-zfs", "8G", func(disk *proxmoxTypes.VmDisk) {
+				disk.SsdEmulation = types.BoolValue(true)
+			})},
+			createNew:    true,
+			expectParams: url.Values{"sata0": {"local-zfs:8,size=8G,ssd=1"}},
+		},
+		{
+			name: "imported disk with cloud init",
+			disks: []proxmoxTypes.VmDisk{testDisk("scsi", 0, "local-zfs", "32G", func(disk *proxmoxTypes.VmDisk) {
+				disk.ImportFrom = types.StringValue("local")
+				disk.Path = types.StringValue("import/noble.qcow2")
+			})},
+			cloudInit: &proxmoxTypes.VmCloudInit{Storage: types.StringValue("local-zfs"), Bus: types.StringValue("scsi"), Slot: types.Int64Value(1)},
+			createNew: true,
+			expectParams: url.Values{
+				"scsi0": {"local-zfs:0,import-from=local:import/noble.qcow2,iothread=1"},
+				"scsi1": {"local-zfs:cloudinit,media=cdrom"},
+			},
+		},
+		{
+			name:         "cloud-init drive on ide",
+			cloudInit:    &proxmoxTypes.VmCloudInit{Storage: types.StringValue("ceph"), Bus: types.StringValue("ide"), Slot: types.Int64Value(2)},
+			expectParams: url.Values{"ide2": {"ceph:cloudinit,media=cdrom"}},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			params := url.Values{}
+
+			newTestDiskService().AttachVmDiskRequests(testCase.disks, &params, testCase.otherFields, testCase.cloudInit, testCase.createNew)
+
+			assert.Equal(t, testCase.expectParams, params)
+		})
+	}
+}
+
+func TestUpdateDisksFromQemuResponse(t *testing.T) {
+	diskService := newTestDiskService()
+	otherFields := map[string]interface{}{
+		"scsi0":   "local-zfs:vm-140-disk-0,aio=io_uring,backup=0,cache=directsync,discard=on,iothread=1,replicate=0,ro=1,size=32G,ssd=1",
+		"virtio1": "local-zfs:vm-140-disk-1,iothread=1,size=8G",
+		"sata2":   "ceph:vm-140-disk-2,size=4G",
+		"scsi1":   "local-zfs:vm-140-cloudinit,media=cdrom",
+		"unused0": "local-zfs:vm-140-disk-3",
+	}
+	plan := proxmoxTypes.VmModel{Disks: []proxmoxTypes.VmDisk{testDisk("sata", 2, "ceph", "4G", func(disk *proxmoxTypes.VmDisk) {
+		disk.ImportFrom = types.StringValue("local")
+		disk.Path = types.StringValue("import/noble.qcow2")
+	})}}
+
+	disks := diskService.UpdateDisksFromQemuResponse(otherFields, &proxmoxTypes.VmModel{}, &plan)
+
+	assert.Equal(t, []string{"sata2", "scsi0", "virtio1"}, diskNames(diskService, disks))
+	assert.Equal(t, testDisk("sata", 2, "ceph", "4G", func(disk *proxmoxTypes.VmDisk) {
+		disk.IoThread = types.BoolValue(false)
+		disk.ImportFrom = types.StringValue("local")
+		disk.Path = types.StringValue("import/noble.qcow2")
+	}), disks[0])
+	assert.Equal(t, testDisk("scsi", 0, "local-zfs", "32G", func(disk *proxmoxTypes.VmDisk) {
+		disk.AsyncIo = types.StringValue("io_uring")
+		disk.Backup = types.BoolValue(false)
+		disk.Cache = types.StringValue("directsync")
+		disk.Discard = types.BoolValue(true)
+		disk.Replicate = types.BoolValue(false)
+		disk.ReadOnly = types.BoolValue(true)
+		disk.SsdEmulation = types.BoolValue(true)
+	}), disks[1])
+	assert.Equal(t, testDisk("virtio", 1, "local-zfs", "8G"), disks[2])
+}
+
+func TestMoveDiskStorage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockProxmoxClient := proxmox_client.NewMockProxmoxClient(ctrl)
+	mockTaskService := services.NewMockTaskService(ctrl)
+	diskService := DiskServiceImpl{
+		tfContext:           context.Background(),
+		proxmoxClient:       mockProxmoxClient,
+		taskService:         mockTaskService,
+		proxmoxUtilsService: services.NewProxmoxUtilService(),
+	}
+	nodeName, vmId, upid := "pve", "140", "UPID:pve:00001234:00005678:65000000:qmmove:140:root@pam:"
+	diskName, newStorage := "virtio0", "ceph"
+	released := false
+
+	mockProxmoxClient.EXPECT().AcquireTaskSlot(gomock.Any(), nodeName).Return(func() { released = true }, nil)
+	mockProxmoxClient.EXPECT().MoveVmDisk(gomock.Any(), &diskName, &nodeName, &vmId, &newStorage).Return(&upid, nil)
+	mockTaskService.EXPECT().WaitForTaskCompletion(gomock.Any(), &nodeName, &upid).Return(nil)
+
+	moveError := diskService.MoveDiskStorage(context.Background(), map[proxmoxTypes.VmDisk]proxmoxTypes.VmDisk{
+		testDisk("virtio", 0, "local-zfs", "32G"): testDisk("virtio", 0, newStorage, "32G"),
+	}, &nodeName, &vmId)
+
+	assert.NoError(t, moveError)
+	assert.True(t, released, "the task slot must be released once the move is done")
+}
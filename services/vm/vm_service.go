@@ -18,26 +18,52 @@ import (
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 )
 
+// ErrVmNotFound is wrapped by the errors returned when a vm does not exist on any node in the cluster
+var ErrVmNotFound = errors.New("vm not found")
+
+// maxVmIdAttempts is how often a vm without an id is created with the next free id before giving up
+const maxVmIdAttempts = 5
+
+// defaultHotplug is what proxmox hot plugs when the hotplug option of a vm is not set
+const defaultHotplug = "network,disk,usb"
+
+// unmanagedHotplug is what new vms get when the plan has no hotplug options, existing vms keep the options they have
+const unmanagedHotplug = "network,usb"
+
+// templateDiskKeyRegex matches every drive a template can hold a base image on
+var templateDiskKeyRegex = regexp.MustCompile(`^(ide|sata|scsi|virtio|efidisk|tpmstate)\d+$`)
+
 type VmService interface {
 	UpdateVmModelFromResponse(vmModel *proxmoxTypes.VmModel, plan *proxmoxTypes.VmModel, response *proxmoxTypes.QemuResponse) *proxmoxTypes.VmModel
 	MapNetworkInterfacesFromQemuResponse(otherFields map[string]interface{}) []proxmoxTypes.VmNetworkInterface
 	CreateVmRequest(vmModel *proxmoxTypes.VmModel, cloudInitEnabled bool, createNew bool) url.Values
-	ShutdownVm(nodeName *string, vmId *string) error
-	StartVm(nodeName *string, vmId *string) error
+	ShutdownVm(ctx context.Context, nodeName *string, vmId *string) error
+	StartVm(ctx context.Context, nodeName *string, vmId *string) error
+	RebootVm(ctx context.Context, nodeName *string, vmId *string) error
+	GetPendingChanges(ctx context.Context, nodeName *string, vmId *string) (map[string]string, error)
 	MapIpConfigsFromQemuResponse(otherFields map[string]interface{}) []proxmoxTypes.VmIpConfig
+	MapHotplugFromQemuResponse(otherFields map[string]interface{}) []string
+	MapCloudInitFromQemuResponse(otherFields map[string]interface{}, planned *proxmoxTypes.VmCloudInit) *proxmoxTypes.VmCloudInit
+	UpdateCloudInitDrive(ctx context.Context, otherFields map[string]interface{}, plan *proxmoxTypes.VmModel, nodeName *string, vmId *string) error
+	SetCloudInitPassword(ctx context.Context, nodeName *string, vmId *string, password string, otherFields map[string]interface{}) error
 	AttachVmNicRequests(vmModel *proxmoxTypes.VmModel, params *url.Values)
-	FindVmByNodeWithId(nodeName *string, vmId *string) (*proxmoxTypes.QemuResponse, error)
-	SearchVmById(vmId *string) (*proxmoxTypes.QemuResponse, *string, error)
-	GetVm(nodeName *string, vmId *string) (*proxmoxTypes.QemuResponse, *string, error)
-	UpdatePowerState(model *proxmoxTypes.VmModel) error
-	CreateVm(plan *proxmoxTypes.VmModel) error
-	MatchVmPowerState(plan *proxmoxTypes.VmModel, currentState *proxmoxTypes.VmModel) error
-	DeleteVm(nodeName *string, vmId *string) error
-	UpdateVm(plan *proxmoxTypes.VmModel, nodeName *string, vmId *string) error
-	MigrateVm(currentNode *string, newNode *string, vmId *string) error
+	FindVmByNodeWithId(ctx context.Context, nodeName *string, vmId *string) (*proxmoxTypes.QemuResponse, error)
+	SearchVmById(ctx context.Context, vmId *string) (*proxmoxTypes.QemuResponse, *string, error)
+	GetVm(ctx context.Context, nodeName *string, vmId *string) (*proxmoxTypes.QemuResponse, *string, error)
+	UpdatePowerState(ctx context.Context, model *proxmoxTypes.VmModel) error
+	CreateVm(ctx context.Context, plan *proxmoxTypes.VmModel) error
+	MatchVmPowerState(ctx context.Context, plan *proxmoxTypes.VmModel, currentState *proxmoxTypes.VmModel) error
+	DeleteVm(ctx context.Context, nodeName *string, vmId *string) error
+	UpdateVm(ctx context.Context, plan *proxmoxTypes.VmModel, otherFields map[string]interface{}, nodeName *string, vmId *string) error
+	MigrateVm(ctx context.Context, currentNode *string, newNode *string, vmId *string) error
+	CloneVm(ctx context.Context, clone *proxmoxTypes.VmClone, plan *proxmoxTypes.VmModel) error
+	FindCloneSource(ctx context.Context, clone *proxmoxTypes.VmClone) (*proxmoxTypes.ClusterVm, error)
+	ConvertVmToTemplate(ctx context.Context, nodeName *string, vmId *string, disk *string) error
+	UpdateVmTemplateFromResponse(template *proxmoxTypes.VmTemplate, response *proxmoxTypes.QemuResponse) error
 }
 
 type VmServiceImpl struct {
+	// tfContext is only used while mapping models, anything talking to proxmox takes the context of the running operation
 	tfContext     context.Context
 	proxmoxClient proxmox_client.ProxmoxClient
 	diskService   DiskService
@@ -61,7 +87,7 @@ func (vmService *VmServiceImpl) UpdateVmModelFromResponse(vmModel *proxmoxTypes.
 	memory, _ := strconv.ParseInt(response.Data.Memory, 10, 64)
 	tags := strings.Split(strings.Trim(response.Data.Tags, " "), ";")
 
-	if response.Data.Tags == " " {
+	if strings.Trim(response.Data.Tags, " ") == "" {
 		tags = []string{}
 	}
 
@@ -77,8 +103,10 @@ func (vmService *VmServiceImpl) UpdateVmModelFromResponse(vmModel *proxmoxTypes.
 	vmModel.OsType = types.StringValue(response.Data.OsType)
 	vmModel.ScsiHw = types.StringValue(response.Data.ScsiHw)
 	vmModel.Agent = types.BoolValue(response.Data.Agent == "1") //No clue why this is coming back a string as opposed to an int like the others
-	vmModel.BootOrder, _ = types.ListValueFrom(vmService.tfContext, types.StringType, strings.Split(strings.Replace(response.Data.Boot, "order=", "", 1), ","))
+	vmModel.BootOrder, _ = types.ListValueFrom(vmService.tfContext, types.StringType, strings.Split(strings.Replace(response.Data.Boot, "order=", "", 1), ";"))
 	vmModel.Numa = types.BoolValue(response.Data.Numa == 1)
+	vmModel.CloudInit = vmService.MapCloudInitFromQemuResponse(response.Data.OtherFields, plan.CloudInit)
+	vmModel.Hotplug, _ = types.SetValueFrom(vmService.tfContext, types.StringType, vmService.MapHotplugFromQemuResponse(response.Data.OtherFields))
 	vmModel.Cores = types.Int64Value(int64(response.Data.Cores))
 	vmModel.Acpi = types.BoolValue(response.Data.Acpi == 1)
 	cpuLimit, _ := strconv.ParseInt(response.Data.CpuLimit, 10, 64)
@@ -88,9 +116,20 @@ func (vmService *VmServiceImpl) UpdateVmModelFromResponse(vmModel *proxmoxTypes.
 	vmModel.CloudInitUpgrade = types.BoolValue(response.Data.CloudInitUpgrade == 1)
 	vmModel.Protection = types.BoolValue(response.Data.Protection != 0)
 	unescapedSshKeys, _ := url.PathUnescape(response.Data.SshKeys)
-	vmModel.SshKeys, _ = types.ListValueFrom(vmService.tfContext, types.StringType, strings.Split(unescapedSshKeys, "\\n"))
+	// the keys are sent and stored one per line
+	if strings.TrimSpace(unescapedSshKeys) == "" {
+		vmModel.SshKeys = types.ListNull(types.StringType)
+	} else {
+		vmModel.SshKeys, _ = types.ListValueFrom(vmService.tfContext, types.StringType, strings.Split(strings.TrimSpace(unescapedSshKeys), "\n"))
+	}
 	startupOrder, _ := strconv.ParseInt(strings.Replace(response.Data.HostStartupOrder, "order=", "", 1), 10, 64)
 	vmModel.DefaultUser = types.StringValue(response.Data.CiUser)
+	vmModel.SearchDomain = types.StringValue(response.Data.SearchDomain)
+	vmModel.CloudInitType = types.StringNull()
+	if response.Data.CiType != "" {
+		vmModel.CloudInitType = types.StringValue(response.Data.CiType)
+	}
+	vmModel.CloudInitCustom = mapCloudInitCustom(response.Data.CiCustom, plan.CloudInitCustom)
 	vmModel.HostStartupOrder = types.Int64Value(startupOrder)
 	if response.Data.Bios == "" {
 		vmModel.Bios = types.StringValue("seabios")
@@ -99,27 +138,60 @@ func (vmService *VmServiceImpl) UpdateVmModelFromResponse(vmModel *proxmoxTypes.
 	}
 	vmModel.Disks = vmService.diskService.UpdateDisksFromQemuResponse(response.Data.OtherFields, vmModel, plan)
 	vmModel.NetworkInterfaces = vmService.MapNetworkInterfacesFromQemuResponse(response.Data.OtherFields)
-	vmModel.IpConfigurations = vmService.MapIpConfigsFromQemuResponse(response.Data.OtherFields)
+	vmModel.IpConfigurations = orderIpConfigsLikePlan(vmService.MapIpConfigsFromQemuResponse(response.Data.OtherFields), plan.IpConfigurations)
 
 	return vmModel
 }
 
+// hotplugParameter joins the hotplug options of the plan, proxmox takes 0 to hot plug nothing
+func (vmService *VmServiceImpl) hotplugParameter(hotplug types.Set) string {
+	if hotplug.IsNull() || hotplug.IsUnknown() {
+		return unmanagedHotplug
+	}
+
+	var options []string
+	_ = hotplug.ElementsAs(vmService.tfContext, &options, false)
+	if len(options) == 0 {
+		return "0"
+	}
+	sort.Strings(options)
+	return strings.Join(options, ",")
+}
+
+// MapHotplugFromQemuResponse returns the device types the vm can hot plug, proxmox stores 0 and 1 for none and the defaults
+func (vmService *VmServiceImpl) MapHotplugFromQemuResponse(otherFields map[string]interface{}) []string {
+	hotplug, isSet := otherFields["hotplug"].(string)
+	if !isSet || hotplug == "1" {
+		return strings.Split(defaultHotplug, ",")
+	}
+	if hotplug == "0" || hotplug == "" {
+		return []string{}
+	}
+	return strings.Split(hotplug, ",")
+}
+
 func (vmService *VmServiceImpl) MapNetworkInterfacesFromQemuResponse(otherFields map[string]interface{}) []proxmoxTypes.VmNetworkInterface {
 	var vmNics []proxmoxTypes.VmNetworkInterface
 	var keySlice []string
 	for key, _ := range otherFields {
-		matched, _ := regexp.MatchString("net\\d+", key)
+		matched, _ := regexp.MatchString("^net\\d+$", key)
 		if matched {
 			keySlice = append(keySlice, key)
 		}
 	}
 
-	sort.Strings(keySlice)
+	// sorted by interface number, net10 comes after net9 and not after net1
+	sort.Slice(keySlice, func(i, j int) bool {
+		iNumber, _ := strconv.Atoi(strings.TrimPrefix(keySlice[i], "net"))
+		jNumber, _ := strconv.Atoi(strings.TrimPrefix(keySlice[j], "net"))
+		return iNumber < jNumber
+	})
 	var networkInterfaceTypes []string = strings.Split(proxmoxTypes.NetworkInterfaceTypes, " | ")
-	for order, key := range keySlice {
+	for _, key := range keySlice {
 		if !strings.Contains(key, "net") {
 			continue
 		}
+		order, _ := strconv.Atoi(strings.TrimPrefix(key, "net"))
 
 		nic := otherFields[key].(string)
 
@@ -204,14 +276,15 @@ func (vmService *VmServiceImpl) CreateVmRequest(vmModel *proxmoxTypes.VmModel, c
 	params.Add("boot", fmt.Sprintf("order=%s", bootOrder))
 	params.Add("ciupgrade", vmService.proxmoxUtils.MapBoolToProxmoxString(vmModel.CloudInitUpgrade.ValueBool()))
 	params.Add("cpu", vmModel.Cpu.ValueString())
-	params.Add("hotplug", "network,usb")
+	params.Add("hotplug", vmService.hotplugParameter(vmModel.Hotplug))
 	params.Add("cpulimit", vmModel.CpuLimit.String())
 	params.Add("description", vmModel.Description.ValueString())
-	for index, ipConfig := range vmModel.IpConfigurations {
-		params.Add(fmt.Sprintf("ipconfig%d", index), fmt.Sprintf("gw=%s,ip=%s", ipConfig.Gateway.ValueString(), ipConfig.IpAddress.ValueString()))
+	for _, ipConfig := range vmModel.IpConfigurations {
+		params.Add(fmt.Sprintf("ipconfig%d", ipConfig.Order.ValueInt64()), ipConfigParameter(ipConfig))
 	}
 	params.Add("kvm", vmService.proxmoxUtils.MapBoolToProxmoxString(vmModel.Kvm.ValueBool()))
 	params.Add("memory", vmModel.Memory.String())
+	params.Add("numa", vmService.proxmoxUtils.MapBoolToProxmoxString(vmModel.Numa.ValueBool()))
 	params.Add("nameserver", vmModel.Nameserver.ValueString())
 	params.Add("scsihw", vmModel.ScsiHw.ValueString())
 	params.Add("sockets", vmModel.Sockets.String())
@@ -227,26 +300,50 @@ func (vmService *VmServiceImpl) CreateVmRequest(vmModel *proxmoxTypes.VmModel, c
 	if vmModel.DefaultUser.ValueString() != "" {
 		params.Add("ciuser", vmModel.DefaultUser.ValueString())
 	}
+	// options that aren't set are left out of a new vm and removed from an existing one
+	var deletions []string
+	if vmModel.SearchDomain.ValueString() != "" {
+		params.Add("searchdomain", vmModel.SearchDomain.ValueString())
+	} else {
+		deletions = append(deletions, "searchdomain")
+	}
+	if vmModel.CloudInitType.ValueString() != "" {
+		params.Add("citype", vmModel.CloudInitType.ValueString())
+	} else {
+		deletions = append(deletions, "citype")
+	}
+	if cloudInitCustom := cloudInitCustomParameter(vmModel.CloudInitCustom); cloudInitCustom != "" {
+		params.Add("cicustom", cloudInitCustom)
+	} else {
+		deletions = append(deletions, "cicustom")
+	}
+	if !createNew && len(deletions) > 0 {
+		params.Add("delete", strings.Join(deletions, ","))
+	}
 
 	if createNew {
-		vmService.diskService.AttachVmDiskRequests(vmModel.Disks, &params, vmModel.VmId.ValueStringPointer(), cloudInitEnabled, createNew)
+		var cloudInit *proxmoxTypes.VmCloudInit
+		if cloudInitEnabled {
+			cloudInit = cloudInitDrive(vmModel)
+		}
+		vmService.diskService.AttachVmDiskRequests(vmModel.Disks, &params, nil, cloudInit, createNew)
 	}
 	vmService.AttachVmNicRequests(vmModel, &params)
 	return params
 }
 
-func (vmService *VmServiceImpl) ShutdownVm(nodeName *string, vmId *string) error {
-	shutdownUpid, shutdownVmError := vmService.proxmoxClient.ShutdownVm(nodeName, vmId)
+func (vmService *VmServiceImpl) ShutdownVm(ctx context.Context, nodeName *string, vmId *string) error {
+	shutdownUpid, shutdownVmError := vmService.proxmoxClient.ShutdownVm(ctx, nodeName, vmId)
 	if shutdownVmError != nil {
 		return shutdownVmError
 	}
-	waitForShutdownError := vmService.taskService.WaitForTaskCompletion(nodeName, shutdownUpid)
+	waitForShutdownError := vmService.taskService.WaitForTaskCompletion(ctx, nodeName, shutdownUpid)
 
 	if waitForShutdownError != nil {
 		return waitForShutdownError
 	}
 
-	vmStatus, getStatusError := vmService.proxmoxClient.GetVmStatus(nodeName, vmId)
+	vmStatus, getStatusError := vmService.proxmoxClient.GetVmStatus(ctx, nodeName, vmId)
 	if getStatusError != nil {
 		return getStatusError
 	}
@@ -257,18 +354,18 @@ func (vmService *VmServiceImpl) ShutdownVm(nodeName *string, vmId *string) error
 	return nil
 }
 
-func (vmService *VmServiceImpl) StartVm(nodeName *string, vmId *string) error {
-	shutdownUpid, startVmError := vmService.proxmoxClient.StartVm(nodeName, vmId)
+func (vmService *VmServiceImpl) StartVm(ctx context.Context, nodeName *string, vmId *string) error {
+	shutdownUpid, startVmError := vmService.proxmoxClient.StartVm(ctx, nodeName, vmId)
 	if startVmError != nil {
-		return errors.New(fmt.Sprintf("Failed to start VM: %s", startVmError.Error()))
+		return fmt.Errorf("Failed to start VM: %w", startVmError)
 	}
-	waitForStartupError := vmService.taskService.WaitForTaskCompletion(nodeName, shutdownUpid)
+	waitForStartupError := vmService.taskService.WaitForTaskCompletion(ctx, nodeName, shutdownUpid)
 
 	if waitForStartupError != nil {
 		return waitForStartupError
 	}
 
-	vmStatus, getStatusError := vmService.proxmoxClient.GetVmStatus(nodeName, vmId)
+	vmStatus, getStatusError := vmService.proxmoxClient.GetVmStatus(ctx, nodeName, vmId)
 	if getStatusError != nil {
 		return getStatusError
 	}
@@ -279,20 +376,82 @@ func (vmService *VmServiceImpl) StartVm(nodeName *string, vmId *string) error {
 	return nil
 }
 
+// RebootVm restarts a running vm so the changes proxmox queued as pending take effect
+func (vmService *VmServiceImpl) RebootVm(ctx context.Context, nodeName *string, vmId *string) error {
+	rebootUpid, rebootVmError := vmService.proxmoxClient.RebootVm(ctx, nodeName, vmId)
+	if rebootVmError != nil {
+		return fmt.Errorf("Failed to reboot VM: %w", rebootVmError)
+	}
+	waitForRebootError := vmService.taskService.WaitForTaskCompletion(ctx, nodeName, rebootUpid)
+
+	if waitForRebootError != nil {
+		return waitForRebootError
+	}
+
+	vmStatus, getStatusError := vmService.proxmoxClient.GetVmStatus(ctx, nodeName, vmId)
+	if getStatusError != nil {
+		return getStatusError
+	}
+
+	if vmStatus != "running" {
+		return errors.New("unexpected post reboot state")
+	}
+	return nil
+}
+
+// GetPendingChanges
+/**
+ * @description reads the options of the vm that proxmox only applies on the next restart. The config of the vm
+ * already shows these values, so this is the only way to tell that the running vm does not use them yet.
+ *
+ * @return the pending value of every changed option, options that are removed on the next restart map to an empty string
+ */
+func (vmService *VmServiceImpl) GetPendingChanges(ctx context.Context, nodeName *string, vmId *string) (map[string]string, error) {
+	pendingResponse, getPendingError := vmService.proxmoxClient.GetVmPending(ctx, nodeName, vmId)
+	if getPendingError != nil {
+		return nil, getPendingError
+	}
+
+	pendingChanges := map[string]string{}
+	for _, option := range pendingResponse.Data {
+		if option.Delete != 0 {
+			pendingChanges[option.Key] = ""
+			continue
+		}
+		if option.Pending != nil {
+			pendingChanges[option.Key] = formatPendingValue(option.Pending)
+		}
+	}
+	return pendingChanges, nil
+}
+
+// formatPendingValue prints numbers the way they are written to the config, json decodes them as floats
+func formatPendingValue(value interface{}) string {
+	if number, isNumber := value.(float64); isNumber {
+		return strconv.FormatFloat(number, 'f', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
+
 func (vmService *VmServiceImpl) MapIpConfigsFromQemuResponse(otherFields map[string]interface{}) []proxmoxTypes.VmIpConfig {
 	var vmIpConfigs []proxmoxTypes.VmIpConfig
 	var keySlice []string
 	for key, _ := range otherFields {
-		matched, _ := regexp.MatchString("ipconfig\\d+", key)
+		matched, _ := regexp.MatchString("^ipconfig\\d+$", key)
 		if matched {
 			keySlice = append(keySlice, key)
 		}
 	}
 
-	sort.Strings(keySlice)
-	fmt.Println(fmt.Sprintf("There are %d ip configurations to be loaded", len(keySlice)))
-	for order, key := range keySlice {
-		fmt.Println(fmt.Sprintf("Loading ipconfig %s", key))
+	// sorted by the number of the nic the ip config belongs to, ipconfig10 comes after ipconfig9
+	sort.Slice(keySlice, func(i, j int) bool {
+		iNumber, _ := strconv.Atoi(strings.TrimPrefix(keySlice[i], "ipconfig"))
+		jNumber, _ := strconv.Atoi(strings.TrimPrefix(keySlice[j], "ipconfig"))
+		return iNumber < jNumber
+	})
+	tflog.Debug(vmService.tfContext, fmt.Sprintf("There are %d ip configurations to be loaded", len(keySlice)))
+	for _, key := range keySlice {
+		order, _ := strconv.Atoi(strings.TrimPrefix(key, "ipconfig"))
 		nic := otherFields[key].(string)
 
 		nicParts := strings.Split(nic, ",")
@@ -300,9 +459,11 @@ func (vmService *VmServiceImpl) MapIpConfigsFromQemuResponse(otherFields map[str
 		mappedIpConfigFields := vmService.proxmoxUtils.MapKeyValuePairsToMap(nicParts)
 
 		newVmNic := proxmoxTypes.VmIpConfig{
-			IpAddress: types.StringValue(mappedIpConfigFields["ip"]),
-			Gateway:   types.StringValue(mappedIpConfigFields["gw"]),
-			Order:     types.Int64Value(int64(order)),
+			IpAddress:   optionalIpConfigValue(mappedIpConfigFields["ip"]),
+			Gateway:     optionalIpConfigValue(mappedIpConfigFields["gw"]),
+			Ipv6Address: optionalIpConfigValue(mappedIpConfigFields["ip6"]),
+			Ipv6Gateway: optionalIpConfigValue(mappedIpConfigFields["gw6"]),
+			Order:       types.Int64Value(int64(order)),
 		}
 
 		vmIpConfigs = append(vmIpConfigs, newVmNic)
@@ -310,6 +471,104 @@ func (vmService *VmServiceImpl) MapIpConfigsFromQemuResponse(otherFields map[str
 	return vmIpConfigs
 }
 
+// setConfigKeys returns the keys the current config has
+func setConfigKeys(otherFields map[string]interface{}, keys []string) []string {
+	var setKeys []string
+	for _, key := range keys {
+		if _, isSet := otherFields[key]; isSet {
+			setKeys = append(setKeys, key)
+		}
+	}
+	return setKeys
+}
+
+// staleIpConfigKeys returns the ip configs of the current config the plan has no ip config for
+func staleIpConfigKeys(otherFields map[string]interface{}, plan *proxmoxTypes.VmModel) []string {
+	var plannedOrders []int64
+	for _, ipConfig := range plan.IpConfigurations {
+		plannedOrders = append(plannedOrders, ipConfig.Order.ValueInt64())
+	}
+	return staleNumberedKeys(otherFields, "ipconfig", plannedOrders)
+}
+
+// staleNetworkInterfaceKeys returns the nics of the current config the plan has no network interface for, like the
+// nics a clone copies from its source
+func staleNetworkInterfaceKeys(otherFields map[string]interface{}, plan *proxmoxTypes.VmModel) []string {
+	var plannedOrders []int64
+	for _, nic := range plan.NetworkInterfaces {
+		plannedOrders = append(plannedOrders, nic.Order.ValueInt64())
+	}
+	return staleNumberedKeys(otherFields, "net", plannedOrders)
+}
+
+// staleNumberedKeys returns the sorted keys of the current config that are the prefix followed by a number which isn't
+// one of the planned orders
+func staleNumberedKeys(otherFields map[string]interface{}, prefix string, plannedOrders []int64) []string {
+	plannedKeys := map[string]bool{}
+	for _, order := range plannedOrders {
+		plannedKeys[fmt.Sprintf("%s%d", prefix, order)] = true
+	}
+
+	var staleKeys []string
+	for key := range otherFields {
+		index, hasPrefix := strings.CutPrefix(key, prefix)
+		_, parseError := strconv.Atoi(index)
+		if hasPrefix && parseError == nil && !plannedKeys[key] {
+			staleKeys = append(staleKeys, key)
+		}
+	}
+	sort.Strings(staleKeys)
+	return staleKeys
+}
+
+// orderIpConfigsLikePlan lists the ip configs in the order of the blocks of the plan, ip configs are bound to their nic
+// by order and moving a block around changes nothing. Ip configs the plan doesn't have follow in the order of their nic.
+func orderIpConfigsLikePlan(ipConfigs []proxmoxTypes.VmIpConfig, planned []proxmoxTypes.VmIpConfig) []proxmoxTypes.VmIpConfig {
+	var ordered []proxmoxTypes.VmIpConfig
+	used := make([]bool, len(ipConfigs))
+	for _, plannedIpConfig := range planned {
+		for index, ipConfig := range ipConfigs {
+			if !used[index] && ipConfig.Order.Equal(plannedIpConfig.Order) {
+				ordered = append(ordered, ipConfig)
+				used[index] = true
+				break
+			}
+		}
+	}
+	for index, ipConfig := range ipConfigs {
+		if !used[index] {
+			ordered = append(ordered, ipConfig)
+		}
+	}
+	return ordered
+}
+
+// ipConfigParameter formats the ip config the way proxmox stores it, unset addresses and gateways are left out
+func ipConfigParameter(ipConfig proxmoxTypes.VmIpConfig) string {
+	var parts []string
+	for _, part := range []struct {
+		key   string
+		value types.String
+	}{
+		{"gw", ipConfig.Gateway},
+		{"gw6", ipConfig.Ipv6Gateway},
+		{"ip", ipConfig.IpAddress},
+		{"ip6", ipConfig.Ipv6Address},
+	} {
+		if part.value.ValueString() != "" {
+			parts = append(parts, fmt.Sprintf("%s=%s", part.key, part.value.ValueString()))
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+func optionalIpConfigValue(value string) types.String {
+	if value == "" {
+		return types.StringNull()
+	}
+	return types.StringValue(value)
+}
+
 func (vmService *VmServiceImpl) AttachVmNicRequests(vmModel *proxmoxTypes.VmModel, params *url.Values) {
 	for _, nicConfig := range vmModel.NetworkInterfaces {
 		mtu := ""
@@ -320,7 +579,7 @@ func (vmService *VmServiceImpl) AttachVmNicRequests(vmModel *proxmoxTypes.VmMode
 	}
 }
 
-func (vmService *VmServiceImpl) FindVmByNodeWithId(nodeName *string, vmId *string) (*proxmoxTypes.QemuResponse, error) {
+func (vmService *VmServiceImpl) FindVmByNodeWithId(ctx context.Context, nodeName *string, vmId *string) (*proxmoxTypes.QemuResponse, error) {
 	if nodeName == nil {
 		return nil, errors.New("cannot retrieve vm by node without a valid node name, use searchVmById instead")
 	}
@@ -328,7 +587,7 @@ func (vmService *VmServiceImpl) FindVmByNodeWithId(nodeName *string, vmId *strin
 		return nil, errors.New("cannot retrieve vm by id without a valid vm id")
 	}
 
-	vmResponse, searchVmError := vmService.proxmoxClient.GetVmById(nodeName, vmId)
+	vmResponse, searchVmError := vmService.proxmoxClient.GetVmById(ctx, nodeName, vmId)
 
 	if searchVmError != nil {
 		return nil, searchVmError
@@ -337,12 +596,12 @@ func (vmService *VmServiceImpl) FindVmByNodeWithId(nodeName *string, vmId *strin
 	return vmResponse, nil
 }
 
-func (vmService *VmServiceImpl) SearchVmById(vmId *string) (*proxmoxTypes.QemuResponse, *string, error) {
+func (vmService *VmServiceImpl) SearchVmById(ctx context.Context, vmId *string) (*proxmoxTypes.QemuResponse, *string, error) {
 	if vmId == nil {
 		return nil, nil, errors.New("cannot search for vm by id without a valid vm id")
 	}
 
-	nodeList, listNodesError := vmService.proxmoxClient.ListNodes()
+	nodeList, listNodesError := vmService.proxmoxClient.ListNodes(ctx)
 
 	if listNodesError != nil {
 		return nil, nil, listNodesError
@@ -353,12 +612,12 @@ func (vmService *VmServiceImpl) SearchVmById(vmId *string) (*proxmoxTypes.QemuRe
 	var nodeName string
 	for _, node := range nodeList.Data {
 
-		tflog.Debug(vmService.tfContext, fmt.Sprintf("Node name is %s", node.Node))
+		tflog.Debug(ctx, fmt.Sprintf("Node name is %s", node.Node))
 
-		vmResponse, searchVmError = vmService.proxmoxClient.GetVmById(&node.Node, vmId)
+		vmResponse, searchVmError = vmService.proxmoxClient.GetVmById(ctx, &node.Node, vmId)
 
-		if searchVmError != nil && !strings.Contains(searchVmError.Error(), fmt.Sprintf("500 Configuration file 'nodes/%s/qemu-server/%s.conf' does not exist", node.Node, *vmId)) {
-			return nil, nil, errors.New(fmt.Sprintf("Failed to search for node that VM lives on: %s", searchVmError.Error()))
+		if searchVmError != nil && !proxmox_client.IsNotFound(searchVmError) {
+			return nil, nil, fmt.Errorf("Failed to search for node that VM lives on: %w", searchVmError)
 		}
 		if searchVmError == nil {
 			nodeName = node.Node
@@ -368,21 +627,25 @@ func (vmService *VmServiceImpl) SearchVmById(vmId *string) (*proxmoxTypes.QemuRe
 	}
 
 	if vmResponse == nil {
-		return nil, nil, errors.New(fmt.Sprintf("Could not find vm for id %s within the cluster", *vmId))
+		return nil, nil, fmt.Errorf("Could not find vm for id %s within the cluster: %w", *vmId, ErrVmNotFound)
 	}
 
 	return vmResponse, &nodeName, nil
 }
 
-func (vmService *VmServiceImpl) GetVm(nodeName *string, vmId *string) (*proxmoxTypes.QemuResponse, *string, error) {
+func (vmService *VmServiceImpl) GetVm(ctx context.Context, nodeName *string, vmId *string) (*proxmoxTypes.QemuResponse, *string, error) {
 	if nodeName == nil {
-		return vmService.SearchVmById(vmId)
+		return vmService.SearchVmById(ctx, vmId)
+	}
+	response, responseError := vmService.FindVmByNodeWithId(ctx, nodeName, vmId)
+	if proxmox_client.IsNotFound(responseError) {
+		tflog.Info(ctx, fmt.Sprintf("VM %s was not found on node %s, searching the rest of the cluster", *vmId, *nodeName))
+		return vmService.SearchVmById(ctx, vmId)
 	}
-	response, responseError := vmService.FindVmByNodeWithId(nodeName, vmId)
 	return response, nodeName, responseError
 }
 
-func (vmService *VmServiceImpl) UpdatePowerState(model *proxmoxTypes.VmModel) error {
+func (vmService *VmServiceImpl) UpdatePowerState(ctx context.Context, model *proxmoxTypes.VmModel) error {
 	if model == nil {
 		return errors.New("could not update provided model, no model provided")
 	}
@@ -393,7 +656,7 @@ func (vmService *VmServiceImpl) UpdatePowerState(model *proxmoxTypes.VmModel) er
 		return errors.New("could not get power state of the provided vm, vm id was null")
 	}
 
-	status, getStatusError := vmService.proxmoxClient.GetVmStatus(model.NodeName.ValueStringPointer(), model.VmId.ValueStringPointer())
+	status, getStatusError := vmService.proxmoxClient.GetVmStatus(ctx, model.NodeName.ValueStringPointer(), model.VmId.ValueStringPointer())
 
 	if getStatusError != nil {
 		return getStatusError
@@ -404,40 +667,74 @@ func (vmService *VmServiceImpl) UpdatePowerState(model *proxmoxTypes.VmModel) er
 	return nil
 }
 
-func (vmService *VmServiceImpl) CreateVm(plan *proxmoxTypes.VmModel) error {
-	//TODO: detect need for cloudinit disk
-	qemuVmCreationRequest := vmService.CreateVmRequest(plan, true, true)
+func (vmService *VmServiceImpl) CreateVm(ctx context.Context, plan *proxmoxTypes.VmModel) error {
+	return vmService.withFreeVmId(ctx, plan, func() error {
+		qemuVmCreationRequest := vmService.CreateVmRequest(plan, true, true)
+
+		releaseTaskSlot, acquireTaskSlotError := vmService.proxmoxClient.AcquireTaskSlot(ctx, plan.NodeName.ValueString())
+		if acquireTaskSlotError != nil {
+			return acquireTaskSlotError
+		}
+		defer releaseTaskSlot()
+
+		upid, vmCreationError := vmService.proxmoxClient.CreateVm(ctx, qemuVmCreationRequest, plan.NodeName.ValueString())
+
+		if vmCreationError != nil {
+			return fmt.Errorf("Failed to create proxmox vm, error response received: %w", vmCreationError)
+		}
 
-	upid, vmCreationError := vmService.proxmoxClient.CreateVm(qemuVmCreationRequest, plan.NodeName.ValueString())
+		taskCompletionError := vmService.taskService.WaitForTaskCompletion(ctx, plan.NodeName.ValueStringPointer(), upid)
 
-	if vmCreationError != nil {
-		return errors.New(fmt.Sprintf("Failed to create proxmox vm, error response received: %s", vmCreationError.Error()))
+		if taskCompletionError != nil {
+			return fmt.Errorf("Creation of requested VM failed: %w", taskCompletionError)
+		}
+		return nil
+	})
+}
+
+// withFreeVmId
+/**
+ * @description runs create as is when the plan has a vm id. Otherwise a free id is reserved and set on the plan
+ * first, when another client creates a vm with the same id before create gets to it the next free id is tried.
+ */
+func (vmService *VmServiceImpl) withFreeVmId(ctx context.Context, plan *proxmoxTypes.VmModel, create func() error) error {
+	if plan.VmId.ValueString() != "" {
+		return create()
 	}
 
-	taskCompletionError := vmService.taskService.WaitForTaskCompletion(plan.NodeName.ValueStringPointer(), upid)
+	for attempt := 1; ; attempt++ {
+		vmId, releaseVmId, reserveVmIdError := vmService.proxmoxClient.ReserveVmId(ctx)
+		if reserveVmIdError != nil {
+			return fmt.Errorf("Failed to find a free vm id: %w", reserveVmIdError)
+		}
+		plan.VmId = types.StringValue(*vmId)
+		tflog.Info(ctx, fmt.Sprintf("Using vm id %s", *vmId))
 
-	if taskCompletionError != nil {
-		return errors.New(fmt.Sprintf("Creation of requested VM failed: %s", taskCompletionError.Error()))
+		createError := create()
+		releaseVmId()
+		if createError == nil || !proxmox_client.IsVmIdConflict(createError) || attempt >= maxVmIdAttempts {
+			return createError
+		}
+		tflog.Warn(ctx, fmt.Sprintf("vm id %s was taken by another client on attempt %d of %d, trying the next free id", *vmId, attempt, maxVmIdAttempts))
 	}
-	return nil
 }
 
-func (vmService *VmServiceImpl) MatchVmPowerState(plan *proxmoxTypes.VmModel, currentState *proxmoxTypes.VmModel) error {
+func (vmService *VmServiceImpl) MatchVmPowerState(ctx context.Context, plan *proxmoxTypes.VmModel, currentState *proxmoxTypes.VmModel) error {
 	if plan.PowerState.ValueString() == "running" && currentState.PowerState.ValueString() != "running" {
-		startVmError := vmService.StartVm(plan.NodeName.ValueStringPointer(), plan.VmId.ValueStringPointer())
+		startVmError := vmService.StartVm(ctx, plan.NodeName.ValueStringPointer(), plan.VmId.ValueStringPointer())
 		if startVmError != nil {
 			return startVmError
 		}
-		updatePowerStateError := vmService.UpdatePowerState(currentState)
+		updatePowerStateError := vmService.UpdatePowerState(ctx, currentState)
 		if updatePowerStateError != nil {
 			return updatePowerStateError
 		}
 	} else if plan.PowerState.ValueString() == "stopped" && currentState.PowerState.ValueString() != "stopped" {
-		stopVmError := vmService.ShutdownVm(plan.NodeName.ValueStringPointer(), plan.VmId.ValueStringPointer())
+		stopVmError := vmService.ShutdownVm(ctx, plan.NodeName.ValueStringPointer(), plan.VmId.ValueStringPointer())
 		if stopVmError != nil {
 			return stopVmError
 		}
-		updatePowerStateError := vmService.UpdatePowerState(currentState)
+		updatePowerStateError := vmService.UpdatePowerState(ctx, currentState)
 		if updatePowerStateError != nil {
 			return updatePowerStateError
 		}
@@ -445,28 +742,28 @@ func (vmService *VmServiceImpl) MatchVmPowerState(plan *proxmoxTypes.VmModel, cu
 	return nil
 }
 
-func (vmService *VmServiceImpl) DeleteVm(nodeName *string, vmId *string) error {
+func (vmService *VmServiceImpl) DeleteVm(ctx context.Context, nodeName *string, vmId *string) error {
 
-	vmStatus, getStatusError := vmService.proxmoxClient.GetVmStatus(nodeName, vmId)
+	vmStatus, getStatusError := vmService.proxmoxClient.GetVmStatus(ctx, nodeName, vmId)
 
 	if getStatusError != nil {
 		return getStatusError
 	}
 
 	if vmStatus != "stopped" {
-		shutdownError := vmService.ShutdownVm(nodeName, vmId)
+		shutdownError := vmService.ShutdownVm(ctx, nodeName, vmId)
 		if shutdownError != nil {
 			return shutdownError
 		}
 	}
 
-	upid, vmDeletionError := vmService.proxmoxClient.DeleteVmById(nodeName, vmId)
+	upid, vmDeletionError := vmService.proxmoxClient.DeleteVmById(ctx, nodeName, vmId)
 
 	if vmDeletionError != nil {
 		return vmDeletionError
 	}
 
-	taskCompletionError := vmService.taskService.WaitForTaskCompletion(nodeName, upid)
+	taskCompletionError := vmService.taskService.WaitForTaskCompletion(ctx, nodeName, upid)
 
 	if taskCompletionError != nil {
 		return taskCompletionError
@@ -474,16 +771,30 @@ func (vmService *VmServiceImpl) DeleteVm(nodeName *string, vmId *string) error {
 	return nil
 }
 
-func (vmService *VmServiceImpl) UpdateVm(plan *proxmoxTypes.VmModel, nodeName *string, vmId *string) error {
+// UpdateVm
+/**
+ * @description applies the plan to the config of the vm. otherFields is the current config, ip configs and nics it
+ * has beyond those of the plan are removed. Options are only deleted when the current config has them, proxmox warns about
+ * every other one.
+ */
+func (vmService *VmServiceImpl) UpdateVm(ctx context.Context, plan *proxmoxTypes.VmModel, otherFields map[string]interface{}, nodeName *string, vmId *string) error {
 	qemuVmCreationRequest := vmService.CreateVmRequest(plan, false, false)
+	deletions := setConfigKeys(otherFields, strings.Split(qemuVmCreationRequest.Get("delete"), ","))
+	deletions = append(deletions, staleIpConfigKeys(otherFields, plan)...)
+	deletions = append(deletions, staleNetworkInterfaceKeys(otherFields, plan)...)
+	if len(deletions) > 0 {
+		qemuVmCreationRequest.Set("delete", strings.Join(deletions, ","))
+	} else {
+		qemuVmCreationRequest.Del("delete")
+	}
 
-	upid, updateVmError := vmService.proxmoxClient.UpdateVm(qemuVmCreationRequest, nodeName, vmId)
+	upid, updateVmError := vmService.proxmoxClient.UpdateVm(ctx, qemuVmCreationRequest, nodeName, vmId)
 
 	if updateVmError != nil {
 		return updateVmError
 	}
 
-	waitForTaskCompletionError := vmService.taskService.WaitForTaskCompletion(nodeName, upid)
+	waitForTaskCompletionError := vmService.taskService.WaitForTaskCompletion(ctx, nodeName, upid)
 
 	if waitForTaskCompletionError != nil {
 		return waitForTaskCompletionError
@@ -492,16 +803,188 @@ func (vmService *VmServiceImpl) UpdateVm(plan *proxmoxTypes.VmModel, nodeName *s
 	return nil
 }
 
-func (vmService *VmServiceImpl) MigrateVm(currentNode *string, newNode *string, vmId *string) error {
-	upid, migrateVmError := vmService.proxmoxClient.MigrateVm(currentNode, newNode, vmId)
+func (vmService *VmServiceImpl) MigrateVm(ctx context.Context, currentNode *string, newNode *string, vmId *string) error {
+	releaseTaskSlot, acquireTaskSlotError := vmService.proxmoxClient.AcquireTaskSlot(ctx, *currentNode)
+	if acquireTaskSlotError != nil {
+		return acquireTaskSlotError
+	}
+	defer releaseTaskSlot()
+
+	upid, migrateVmError := vmService.proxmoxClient.MigrateVm(ctx, currentNode, newNode, vmId)
 	if migrateVmError != nil {
 		return migrateVmError
 	}
 
-	waitForTaskError := vmService.taskService.WaitForTaskCompletion(currentNode, upid)
+	waitForTaskError := vmService.taskService.WaitForTaskCompletion(ctx, currentNode, upid)
 
 	if waitForTaskError != nil {
 		return waitForTaskError
 	}
 	return nil
 }
+
+// CloneVm
+/**
+ * @description clones the source of the clone block to the node of the plan and waits for the clone task. The clone
+ * gets the vm id of the plan, or the next free id when the plan has none.
+ * Only the name is taken from the plan, the remaining config of the plan is applied by the caller once the clone exists.
+ */
+func (vmService *VmServiceImpl) CloneVm(ctx context.Context, clone *proxmoxTypes.VmClone, plan *proxmoxTypes.VmModel) error {
+	source, findSourceError := vmService.FindCloneSource(ctx, clone)
+	if findSourceError != nil {
+		return findSourceError
+	}
+
+	fullClone := clone.FullClone.IsNull() || clone.FullClone.ValueBool()
+	if !fullClone && source.Template != 1 {
+		return fmt.Errorf("linked clones can only be created from templates, vm %d is not a template", source.VmId)
+	}
+
+	params := url.Values{}
+	params.Add("name", plan.Name.ValueString())
+	params.Add("full", vmService.proxmoxUtils.MapBoolToProxmoxString(fullClone))
+	if plan.NodeName.ValueString() != source.Node {
+		params.Add("target", plan.NodeName.ValueString())
+	}
+	if clone.TargetStorage.ValueString() != "" {
+		params.Add("storage", clone.TargetStorage.ValueString())
+	}
+	if clone.Pool.ValueString() != "" {
+		params.Add("pool", clone.Pool.ValueString())
+	}
+	if clone.SnapshotName.ValueString() != "" {
+		params.Add("snapname", clone.SnapshotName.ValueString())
+	}
+
+	releaseTaskSlot, acquireTaskSlotError := vmService.proxmoxClient.AcquireTaskSlot(ctx, source.Node)
+	if acquireTaskSlotError != nil {
+		return acquireTaskSlotError
+	}
+	defer releaseTaskSlot()
+
+	sourceVmId := strconv.Itoa(source.VmId)
+	return vmService.withFreeVmId(ctx, plan, func() error {
+		params.Set("newid", plan.VmId.ValueString())
+		tflog.Info(ctx, fmt.Sprintf("Cloning vm %s on node %s to vm %s", sourceVmId, source.Node, plan.VmId.ValueString()))
+		upid, cloneVmError := vmService.proxmoxClient.CloneVm(ctx, params, &source.Node, &sourceVmId)
+		if cloneVmError != nil {
+			return fmt.Errorf("Failed to clone vm %s: %w", sourceVmId, cloneVmError)
+		}
+
+		taskCompletionError := vmService.taskService.WaitForTaskCompletion(ctx, &source.Node, upid)
+		if taskCompletionError != nil {
+			return fmt.Errorf("Clone of vm %s failed: %w", sourceVmId, taskCompletionError)
+		}
+		return nil
+	})
+}
+
+// FindCloneSource finds the vm or template a clone block refers to by id or by name, optionally only on the source node
+func (vmService *VmServiceImpl) FindCloneSource(ctx context.Context, clone *proxmoxTypes.VmClone) (*proxmoxTypes.ClusterVm, error) {
+	vmList, listVmsError := vmService.proxmoxClient.ListClusterVms(ctx)
+	if listVmsError != nil {
+		return nil, fmt.Errorf("Failed to list the vms of the cluster: %w", listVmsError)
+	}
+
+	description := fmt.Sprintf("vm %s", clone.SourceVmId.ValueString())
+	if clone.SourceVmId.ValueString() == "" {
+		description = fmt.Sprintf("vm named %s", clone.SourceName.ValueString())
+	}
+	if clone.SourceNode.ValueString() != "" {
+		description = fmt.Sprintf("%s on node %s", description, clone.SourceNode.ValueString())
+	}
+
+	var matches []proxmoxTypes.ClusterVm
+	for _, vm := range vmList.Data {
+		if clone.SourceNode.ValueString() != "" && vm.Node != clone.SourceNode.ValueString() {
+			continue
+		}
+		if clone.SourceVmId.ValueString() != "" && strconv.Itoa(vm.VmId) != clone.SourceVmId.ValueString() {
+			continue
+		}
+		if clone.SourceVmId.ValueString() == "" && vm.Name != clone.SourceName.ValueString() {
+			continue
+		}
+		matches = append(matches, vm)
+	}
+
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("Could not find the clone source, %s: %w", description, ErrVmNotFound)
+	}
+	if len(matches) > 1 {
+		var ids []string
+		for _, match := range matches {
+			ids = append(ids, fmt.Sprintf("%d on %s", match.VmId, match.Node))
+		}
+		return nil, errors.New(fmt.Sprintf("The clone source is ambiguous, there is more than one %s: %s", description, strings.Join(ids, ", ")))
+	}
+	return &matches[0], nil
+}
+
+// ConvertVmToTemplate
+/**
+ * @description converts a stopped vm into a template and waits for the disks to be turned into base images. When disk
+ * is set only that disk is converted, proxmox marks the vm as a template either way.
+ */
+func (vmService *VmServiceImpl) ConvertVmToTemplate(ctx context.Context, nodeName *string, vmId *string, disk *string) error {
+	vmStatus, getStatusError := vmService.proxmoxClient.GetVmStatus(ctx, nodeName, vmId)
+	if getStatusError != nil {
+		return getStatusError
+	}
+	if vmStatus != "stopped" {
+		return errors.New(fmt.Sprintf("vm %s is %s, only stopped vms can be converted to a template", *vmId, vmStatus))
+	}
+
+	releaseTaskSlot, acquireTaskSlotError := vmService.proxmoxClient.AcquireTaskSlot(ctx, *nodeName)
+	if acquireTaskSlotError != nil {
+		return acquireTaskSlotError
+	}
+	defer releaseTaskSlot()
+
+	tflog.Info(ctx, fmt.Sprintf("Converting vm %s on node %s to a template", *vmId, *nodeName))
+	upid, convertError := vmService.proxmoxClient.ConvertVmToTemplate(ctx, nodeName, vmId, disk)
+	if convertError != nil {
+		return fmt.Errorf("Failed to convert vm %s to a template: %w", *vmId, convertError)
+	}
+
+	taskCompletionError := vmService.taskService.WaitForTaskCompletion(ctx, nodeName, upid)
+	if taskCompletionError != nil {
+		return fmt.Errorf("Conversion of vm %s to a template failed: %w", *vmId, taskCompletionError)
+	}
+	return nil
+}
+
+// UpdateVmTemplateFromResponse sets the name and the disks of the template from its config, cdroms and the cloudinit
+// drive are not part of the template disks
+func (vmService *VmServiceImpl) UpdateVmTemplateFromResponse(template *proxmoxTypes.VmTemplate, response *proxmoxTypes.QemuResponse) error {
+	var keys []string
+	for key, value := range response.Data.OtherFields {
+		drive, isString := value.(string)
+		if !isString || !templateDiskKeyRegex.MatchString(key) || strings.Contains(drive, "media=cdrom") || strings.Contains(drive, "cloudinit") {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	disks := []proxmoxTypes.VmTemplateDisk{}
+	for _, key := range keys {
+		driveParts := strings.Split(response.Data.OtherFields[key].(string), ",")
+		driveOptions := vmService.proxmoxUtils.MapKeyValuePairsToMap(driveParts[1:])
+		storageLocation, _, _ := strings.Cut(driveParts[0], ":")
+		disks = append(disks, proxmoxTypes.VmTemplateDisk{
+			Key:             types.StringValue(key),
+			Volume:          types.StringValue(driveParts[0]),
+			StorageLocation: types.StringValue(storageLocation),
+			Size:            types.StringValue(driveOptions["size"]),
+		})
+	}
+
+	diskList, diags := types.ListValueFrom(vmService.tfContext, proxmoxTypes.VmTemplateDiskType, disks)
+	if diags.HasError() {
+		return errors.New(fmt.Sprintf("Failed to map the disks of template %s", template.VmId.ValueString()))
+	}
+	template.Name = types.StringValue(response.Data.Name)
+	template.Disks = diskList
+	return nil
+}
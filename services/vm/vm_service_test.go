@@ -0,0 +1,492 @@
+package vm
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"terraform-provider-proxmox/proxmox_client"
+	"terraform-provider-proxmox/services"
+	proxmoxTypes "terraform-provider-proxmox/types"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func testVmModel(changes ...func(vmModel *proxmoxTypes.VmModel)) *proxmoxTypes.VmModel {
+	bootOrder, _ := types.ListValueFrom(context.Background(), types.StringType, []string{"scsi0", "net0"})
+	vmModel := proxmoxTypes.VmModel{
+		Acpi:             types.BoolValue(true),
+		Agent:            types.BoolValue(true),
+		Bios:             types.StringValue("ovmf"),
+		BootOrder:        bootOrder,
+		CloudInitUpgrade: types.BoolValue(false),
+		Cores:            types.Int64Value(4),
+		Cpu:              types.StringValue("host"),
+		CpuLimit:         types.Int64Value(0),
+		Description:      types.StringValue(""),
+		Disks:            []proxmoxTypes.VmDisk{testDisk("scsi", 0, "local-zfs", "32G")},
+		HostStartupOrder: types.Int64Value(2),
+		Kvm:              types.BoolValue(true),
+		Memory:           types.Int64Value(4096),
+		Name:             types.StringValue("web01"),
+		Nameserver:       types.StringValue("1.1.1.1"),
+		NetworkInterfaces: []proxmoxTypes.VmNetworkInterface{{
+			Type:       types.StringValue("virtio"),
+			MacAddress: types.StringValue("BC:24:11:2E:4A:10"),
+			Bridge:     types.StringValue("vmbr0"),
+			Firewall:   types.BoolValue(true),
+			Order:      types.Int64Value(0),
+			Mtu:        types.Int64Null(),
+		}},
+		OsType:     types.StringValue("l26"),
+		Protection: types.BoolValue(false),
+		ScsiHw:     types.StringValue("virtio-scsi-single"),
+		Sockets:    types.Int64Value(1),
+		SshKeys:    types.ListNull(types.StringType),
+		Tags:       types.ListNull(types.StringType),
+		VmId:       types.StringValue("140"),
+	}
+	for _, change := range changes {
+		change(&vmModel)
+	}
+	return &vmModel
+}
+
+func TestCreateVmRequest(t *testing.T) {
+	testCases := []struct {
+		name         string
+		vmModel      *proxmoxTypes.VmModel
+		createNew    bool
+		expectParams map[string]string
+		expectAbsent []string
+	}{
+		{
+			name:      "new vm",
+			vmModel:   testVmModel(),
+			createNew: true,
+			expectParams: map[string]string{
+				"vmid":    "140",
+				"boot":    "order=scsi0;net0",
+				"agent":   "1",
+				"bios":    "ovmf",
+				"memory":  "4096",
+				"startup": "order=2",
+				"hotplug": "network,usb",
+				"tags":    "",
+				"net0":    "virtio=BC:24:11:2E:4A:10,bridge=vmbr0,firewall=1",
+			},
+			expectAbsent: []string{"sshkeys", "ciuser", "searchdomain", "citype", "cicustom", "delete"},
+		},
+		{
+			name: "tags, keys and network options",
+			vmModel: testVmModel(func(vmModel *proxmoxTypes.VmModel) {
+				vmModel.Tags, _ = types.ListValueFrom(context.Background(), types.StringType, []string{"web", "prod"})
+				vmModel.SshKeys, _ = types.ListValueFrom(context.Background(), types.StringType, []string{"ssh-ed25519 AAAA+a=", "ssh-rsa BBBB"})
+				vmModel.DefaultUser = types.StringValue("ubuntu")
+				vmModel.NetworkInterfaces[0].Mtu = types.Int64Value(9000)
+			}),
+			expectParams: map[string]string{
+				"tags":    "web,prod",
+				"sshkeys": "ssh-ed25519%20AAAA%2Ba%3D%0Assh-rsa%20BBBB",
+				"ciuser":  "ubuntu",
+				"net0":    "virtio=BC:24:11:2E:4A:10,bridge=vmbr0,firewall=1,mtu=9000",
+			},
+		},
+		{
+			name: "hotplug options",
+			vmModel: testVmModel(func(vmModel *proxmoxTypes.VmModel) {
+				vmModel.Hotplug, _ = types.SetValueFrom(context.Background(), types.StringType, []string{"network", "memory", "disk"})
+				vmModel.Numa = types.BoolValue(true)
+			}),
+			expectParams: map[string]string{
+				"hotplug": "disk,memory,network",
+				"numa":    "1",
+			},
+		},
+		{
+			name: "cloud-init settings",
+			vmModel: testVmModel(func(vmModel *proxmoxTypes.VmModel) {
+				vmModel.SearchDomain = types.StringValue("example.com")
+				vmModel.CloudInitType = types.StringValue("configdrive2")
+				vmModel.CloudInitCustom = &proxmoxTypes.VmCloudInitCustom{User: types.StringValue("local:snippets/user.yaml"), Vendor: types.StringValue("local:snippets/vendor.yaml")}
+			}),
+			expectParams: map[string]string{
+				"searchdomain": "example.com",
+				"citype":       "configdrive2",
+				"cicustom":     "user=local:snippets/user.yaml,vendor=local:snippets/vendor.yaml",
+			},
+			expectAbsent: []string{"delete"},
+		},
+		{
+			name:         "unset cloud-init settings are removed",
+			vmModel:      testVmModel(),
+			expectParams: map[string]string{"delete": "searchdomain,citype,cicustom"},
+		},
+		{
+			name: "nothing hot plugged",
+			vmModel: testVmModel(func(vmModel *proxmoxTypes.VmModel) {
+				vmModel.Hotplug, _ = types.SetValueFrom(context.Background(), types.StringType, []string{})
+			}),
+			expectParams: map[string]string{
+				"hotplug": "0",
+				"numa":    "0",
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockDiskService := NewMockDiskService(ctrl)
+			vmService := VmServiceImpl{tfContext: context.Background(), diskService: mockDiskService, proxmoxUtils: services.NewProxmoxUtilService()}
+			if testCase.createNew {
+				cloudInit := &proxmoxTypes.VmCloudInit{Enabled: types.BoolValue(true), Storage: types.StringValue("local-zfs"), Bus: types.StringValue("scsi"), Slot: types.Int64Value(1)}
+				mockDiskService.EXPECT().AttachVmDiskRequests(testCase.vmModel.Disks, gomock.Any(), nil, cloudInit, true)
+			}
+
+			params := vmService.CreateVmRequest(testCase.vmModel, true, testCase.createNew)
+
+			for key, value := range testCase.expectParams {
+				assert.Equal(t, value, params.Get(key), key)
+			}
+			for _, key := range testCase.expectAbsent {
+				assert.False(t, params.Has(key), key)
+			}
+		})
+	}
+}
+
+func TestUpdateVmModelFromResponse(t *testing.T) {
+	testCases := []struct {
+		name            string
+		config          string
+		expectBootOrder []string
+		expectTags      []string
+		expectSshKeys   []string
+	}{
+		{
+			name:            "minimal config",
+			config:          `{"data":{"boot":"order=scsi0","memory":"2048","net0":"virtio=BC:24:11:2E:4A:10,bridge=vmbr0"}}`,
+			expectBootOrder: []string{"scsi0"},
+			expectTags:      []string{},
+		},
+		{
+			name:            "boot order, tags and keys",
+			config:          `{"data":{"boot":"order=scsi0;ide2;net0","memory":"2048","tags":"prod;web","sshkeys":"ssh-ed25519%20AAAA%2Ba%3D%0Assh-rsa%20BBBB%0A"}}`,
+			expectBootOrder: []string{"scsi0", "ide2", "net0"},
+			expectTags:      []string{"prod", "web"},
+			expectSshKeys:   []string{"ssh-ed25519 AAAA+a=", "ssh-rsa BBBB"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockDiskService := NewMockDiskService(ctrl)
+			vmService := VmServiceImpl{tfContext: context.Background(), diskService: mockDiskService, proxmoxUtils: services.NewProxmoxUtilService()}
+			var response proxmoxTypes.QemuResponse
+			assert.NoError(t, json.Unmarshal([]byte(testCase.config), &response))
+			var otherFields map[string]map[string]interface{}
+			assert.NoError(t, json.Unmarshal([]byte(testCase.config), &otherFields))
+			response.Data.OtherFields = otherFields["data"]
+			plan := testVmModel()
+			readDisks := []proxmoxTypes.VmDisk{testDisk("scsi", 0, "local-zfs", "32G")}
+			mockDiskService.EXPECT().UpdateDisksFromQemuResponse(response.Data.OtherFields, gomock.Any(), plan).Return(readDisks)
+
+			vmModel := vmService.UpdateVmModelFromResponse(&proxmoxTypes.VmModel{}, plan, &response)
+
+			var bootOrder, tags, sshKeys []string
+			_ = vmModel.BootOrder.ElementsAs(context.Background(), &bootOrder, false)
+			_ = vmModel.Tags.ElementsAs(context.Background(), &tags, false)
+			assert.Equal(t, testCase.expectBootOrder, bootOrder)
+			assert.Equal(t, testCase.expectTags, tags)
+			if testCase.expectSshKeys == nil {
+				assert.True(t, vmModel.SshKeys.IsNull(), "missing keys must read back as null")
+			} else {
+				_ = vmModel.SshKeys.ElementsAs(context.Background(), &sshKeys, false)
+				assert.Equal(t, testCase.expectSshKeys, sshKeys)
+			}
+			assert.Equal(t, int64(2048), vmModel.Memory.ValueInt64())
+			assert.Equal(t, readDisks, vmModel.Disks)
+			assert.Equal(t, "seabios", vmModel.Bios.ValueString())
+		})
+	}
+}
+
+func TestAttachVmNicRequestsAreReadBack(t *testing.T) {
+	vmService := VmServiceImpl{tfContext: context.Background(), proxmoxUtils: services.NewProxmoxUtilService()}
+	vmModel := testVmModel(func(vmModel *proxmoxTypes.VmModel) {
+		vmModel.NetworkInterfaces = append(vmModel.NetworkInterfaces, proxmoxTypes.VmNetworkInterface{
+			Type:       types.StringValue("e1000"),
+			MacAddress: types.StringValue("BC:24:11:2E:4A:11"),
+			Bridge:     types.StringValue("vmbr1"),
+			Firewall:   types.BoolValue(false),
+			Order:      types.Int64Value(1),
+			Mtu:        types.Int64Value(1400),
+		})
+	})
+	params := url.Values{}
+
+	vmService.AttachVmNicRequests(vmModel, &params)
+	otherFields := map[string]interface{}{}
+	for key := range params {
+		otherFields[key] = params.Get(key)
+	}
+
+	assert.Equal(t, vmModel.NetworkInterfaces, vmService.MapNetworkInterfacesFromQemuResponse(otherFields))
+}
+
+func TestIpConfigsAreReadBack(t *testing.T) {
+	vmService := VmServiceImpl{tfContext: context.Background(), proxmoxUtils: services.NewProxmoxUtilService()}
+	ipConfigs := []proxmoxTypes.VmIpConfig{
+		{IpAddress: types.StringValue("10.0.0.5/24"), Gateway: types.StringValue("10.0.0.1"), Ipv6Address: types.StringValue("2001:db8::5/64"), Ipv6Gateway: types.StringValue("2001:db8::1"), Order: types.Int64Value(1)},
+		{IpAddress: types.StringValue("dhcp"), Gateway: types.StringNull(), Ipv6Address: types.StringNull(), Ipv6Gateway: types.StringNull(), Order: types.Int64Value(0)},
+		{IpAddress: types.StringNull(), Gateway: types.StringNull(), Ipv6Address: types.StringValue("auto"), Ipv6Gateway: types.StringNull(), Order: types.Int64Value(10)},
+	}
+	stale := proxmoxTypes.VmIpConfig{IpAddress: types.StringValue("dhcp"), Gateway: types.StringNull(), Ipv6Address: types.StringNull(), Ipv6Gateway: types.StringNull(), Order: types.Int64Value(3)}
+	params := vmService.CreateVmRequest(testVmModel(func(vmModel *proxmoxTypes.VmModel) {
+		vmModel.IpConfigurations = ipConfigs
+	}), true, false)
+	otherFields := map[string]interface{}{"ipconfig3": "ip=dhcp"}
+	for key := range params {
+		otherFields[key] = params.Get(key)
+	}
+
+	assert.Equal(t, "gw=10.0.0.1,gw6=2001:db8::1,ip=10.0.0.5/24,ip6=2001:db8::5/64", params.Get("ipconfig1"))
+	assert.Equal(t, []proxmoxTypes.VmIpConfig{ipConfigs[1], ipConfigs[0], stale, ipConfigs[2]}, vmService.MapIpConfigsFromQemuResponse(otherFields))
+	assert.Equal(t, append(ipConfigs, stale), orderIpConfigsLikePlan(vmService.MapIpConfigsFromQemuResponse(otherFields), ipConfigs))
+	assert.Equal(t, []string{"ipconfig3"}, staleIpConfigKeys(otherFields, &proxmoxTypes.VmModel{IpConfigurations: ipConfigs}))
+}
+
+func TestUpdateVmOnlyDeletesSetOptions(t *testing.T) {
+	testCases := []struct {
+		name         string
+		otherFields  map[string]interface{}
+		expectDelete string
+	}{
+		{
+			name:        "nothing to delete",
+			otherFields: map[string]interface{}{"ipconfig0": "ip=dhcp"},
+		},
+		{
+			name:         "set options and stale ip configs",
+			otherFields:  map[string]interface{}{"citype": "nocloud", "ipconfig0": "ip=dhcp", "ipconfig2": "ip=dhcp"},
+			expectDelete: "citype,ipconfig2",
+		},
+		{
+			name:         "nics beyond those of the plan",
+			otherFields:  map[string]interface{}{"net0": "virtio=BC:24:11:00:00:00,bridge=vmbr0", "net1": "virtio=BC:24:11:00:00:01,bridge=vmbr1", "ipconfig0": "ip=dhcp"},
+			expectDelete: "net1",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockClient := proxmox_client.NewMockProxmoxClient(ctrl)
+			mockTaskService := services.NewMockTaskService(ctrl)
+			vmService := VmServiceImpl{tfContext: context.Background(), proxmoxClient: mockClient, proxmoxUtils: services.NewProxmoxUtilService(), taskService: mockTaskService}
+			plan := testVmModel(func(vmModel *proxmoxTypes.VmModel) {
+				vmModel.IpConfigurations = []proxmoxTypes.VmIpConfig{{IpAddress: types.StringValue("dhcp"), Order: types.Int64Value(0)}}
+			})
+			nodeName, vmId, upid := "pve", "140", "UPID:pve:qmconfig"
+
+			mockClient.EXPECT().UpdateVm(gomock.Any(), gomock.Any(), &nodeName, &vmId).DoAndReturn(func(_ context.Context, params url.Values, _ *string, _ *string) (*string, error) {
+				assert.Equal(t, testCase.expectDelete, params.Get("delete"))
+				assert.Equal(t, testCase.expectDelete != "", params.Has("delete"))
+				return &upid, nil
+			})
+			mockTaskService.EXPECT().WaitForTaskCompletion(gomock.Any(), &nodeName, &upid).Return(nil)
+
+			assert.NoError(t, vmService.UpdateVm(context.Background(), plan, testCase.otherFields, &nodeName, &vmId))
+		})
+	}
+}
+
+func TestRemovingAnUnsetCloudInitPasswordSendsNothing(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockClient := proxmox_client.NewMockProxmoxClient(ctrl)
+	vmService := VmServiceImpl{tfContext: context.Background(), proxmoxClient: mockClient, proxmoxUtils: services.NewProxmoxUtilService()}
+	nodeName, vmId := "pve", "140"
+
+	assert.NoError(t, vmService.SetCloudInitPassword(context.Background(), &nodeName, &vmId, "", map[string]interface{}{"ciuser": "ubuntu"}))
+}
+
+func TestFindCloneSource(t *testing.T) {
+	clusterVms := &proxmoxTypes.ClusterVmListResponse{Data: []proxmoxTypes.ClusterVm{
+		{Node: "pve1", VmId: 9000, Name: "ubuntu-template", Template: 1},
+		{Node: "pve2", VmId: 9001, Name: "ubuntu-template", Template: 1},
+		{Node: "pve1", VmId: 9002, Name: "debian-template", Template: 1},
+	}}
+	testCases := []struct {
+		name        string
+		clone       proxmoxTypes.VmClone
+		expectVmId  int
+		expectError string
+	}{
+		{
+			name:       "by id",
+			clone:      proxmoxTypes.VmClone{SourceVmId: types.StringValue("9001")},
+			expectVmId: 9001,
+		},
+		{
+			name:       "by unique name",
+			clone:      proxmoxTypes.VmClone{SourceName: types.StringValue("debian-template")},
+			expectVmId: 9002,
+		},
+		{
+			name:       "by name on a node",
+			clone:      proxmoxTypes.VmClone{SourceName: types.StringValue("ubuntu-template"), SourceNode: types.StringValue("pve2")},
+			expectVmId: 9001,
+		},
+		{
+			name:        "ambiguous name",
+			clone:       proxmoxTypes.VmClone{SourceName: types.StringValue("ubuntu-template")},
+			expectError: "ambiguous",
+		},
+		{
+			name:        "id on the wrong node",
+			clone:       proxmoxTypes.VmClone{SourceVmId: types.StringValue("9001"), SourceNode: types.StringValue("pve1")},
+			expectError: "Could not find the clone source",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockClient := proxmox_client.NewMockProxmoxClient(ctrl)
+			mockClient.EXPECT().ListClusterVms(gomock.Any()).Return(clusterVms, nil)
+			vmService := VmServiceImpl{tfContext: context.Background(), proxmoxClient: mockClient}
+
+			source, findError := vmService.FindCloneSource(context.Background(), &testCase.clone)
+
+			if testCase.expectError != "" {
+				assert.ErrorContains(t, findError, testCase.expectError)
+				return
+			}
+			assert.NoError(t, findError)
+			assert.Equal(t, testCase.expectVmId, source.VmId)
+		})
+	}
+}
+
+func TestUpdateVmTemplateFromResponse(t *testing.T) {
+	vmService := VmServiceImpl{tfContext: context.Background(), proxmoxUtils: services.NewProxmoxUtilService()}
+	var response proxmoxTypes.QemuResponse
+	response.Data.Name = "ubuntu-template"
+	response.Data.OtherFields = map[string]interface{}{
+		"virtio1":  "local:9000/base-9000-disk-1.qcow2,size=4G",
+		"scsi0":    "local-zfs:base-9000-disk-0,iothread=1,size=8G",
+		"efidisk0": "local-zfs:base-9000-disk-2,efitype=4m,size=1M",
+		"ide0":     "local:iso/ubuntu.iso,media=cdrom",
+		"ide2":     "local-zfs:vm-9000-cloudinit,media=cdrom",
+		"template": float64(1),
+	}
+	template := proxmoxTypes.VmTemplate{VmId: types.StringValue("9000")}
+
+	assert.NoError(t, vmService.UpdateVmTemplateFromResponse(&template, &response))
+
+	var disks []proxmoxTypes.VmTemplateDisk
+	assert.False(t, template.Disks.ElementsAs(context.Background(), &disks, false).HasError())
+	assert.Equal(t, "ubuntu-template", template.Name.ValueString())
+	assert.Equal(t, []proxmoxTypes.VmTemplateDisk{
+		{Key: types.StringValue("efidisk0"), Volume: types.StringValue("local-zfs:base-9000-disk-2"), StorageLocation: types.StringValue("local-zfs"), Size: types.StringValue("1M")},
+		{Key: types.StringValue("scsi0"), Volume: types.StringValue("local-zfs:base-9000-disk-0"), StorageLocation: types.StringValue("local-zfs"), Size: types.StringValue("8G")},
+		{Key: types.StringValue("virtio1"), Volume: types.StringValue("local:9000/base-9000-disk-1.qcow2"), StorageLocation: types.StringValue("local"), Size: types.StringValue("4G")},
+	}, disks)
+}
+
+func TestCreateVmRetriesWithTheNextFreeVmId(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockClient := proxmox_client.NewMockProxmoxClient(ctrl)
+	mockDiskService := NewMockDiskService(ctrl)
+	mockTaskService := services.NewMockTaskService(ctrl)
+	vmService := VmServiceImpl{tfContext: context.Background(), proxmoxClient: mockClient, diskService: mockDiskService, proxmoxUtils: services.NewProxmoxUtilService(), taskService: mockTaskService}
+	plan := testVmModel(func(vmModel *proxmoxTypes.VmModel) {
+		vmModel.VmId = types.StringUnknown()
+	})
+	takenId, freeId, upid := "1001", "1002", "UPID:pve:qmcreate"
+	conflict := &proxmox_client.ApiError{StatusCode: 500, Message: "unable to create VM 1001 - VM 1001 already exists on node 'pve'"}
+	released := 0
+
+	mockDiskService.EXPECT().AttachVmDiskRequests(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), true).Times(2)
+	mockClient.EXPECT().AcquireTaskSlot(gomock.Any(), "").Return(func() {}, nil).Times(2)
+	gomock.InOrder(
+		mockClient.EXPECT().ReserveVmId(gomock.Any()).Return(&takenId, func() { released++ }, nil),
+		mockClient.EXPECT().CreateVm(gomock.Any(), gomock.Any(), "").DoAndReturn(func(_ context.Context, params url.Values, _ string) (*string, error) {
+			assert.Equal(t, takenId, params.Get("vmid"))
+			return nil, conflict
+		}),
+		mockClient.EXPECT().ReserveVmId(gomock.Any()).Return(&freeId, func() { released++ }, nil),
+		mockClient.EXPECT().CreateVm(gomock.Any(), gomock.Any(), "").DoAndReturn(func(_ context.Context, params url.Values, _ string) (*string, error) {
+			assert.Equal(t, freeId, params.Get("vmid"))
+			return &upid, nil
+		}),
+		mockTaskService.EXPECT().WaitForTaskCompletion(gomock.Any(), gomock.Any(), &upid).Return(nil),
+	)
+
+	assert.NoError(t, vmService.CreateVm(context.Background(), plan))
+	assert.Equal(t, freeId, plan.VmId.ValueString())
+	assert.Equal(t, 2, released)
+}
+
+func TestCloneVmRetriesWhenTheTaskLosesTheVmId(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockClient := proxmox_client.NewMockProxmoxClient(ctrl)
+	mockTaskService := services.NewMockTaskService(ctrl)
+	vmService := VmServiceImpl{tfContext: context.Background(), proxmoxClient: mockClient, proxmoxUtils: services.NewProxmoxUtilService(), taskService: mockTaskService}
+	plan := testVmModel(func(vmModel *proxmoxTypes.VmModel) {
+		vmModel.VmId = types.StringUnknown()
+		vmModel.NodeName = types.StringValue("pve")
+	})
+	clone := proxmoxTypes.VmClone{SourceVmId: types.StringValue("9000")}
+	takenId, freeId, takenUpid, freeUpid := "1001", "1002", "UPID:pve:qmclone:1001", "UPID:pve:qmclone:1002"
+	// the id was free when the clone was started, the task itself lost the race
+	conflict := &services.TaskFailedError{NodeName: "pve", Upid: takenUpid, TaskType: "qmclone", ExitStatus: "unable to create VM 1001: config file already exists"}
+	released := 0
+
+	mockClient.EXPECT().ListClusterVms(gomock.Any()).Return(&proxmoxTypes.ClusterVmListResponse{Data: []proxmoxTypes.ClusterVm{
+		{Node: "pve", VmId: 9000, Name: "ubuntu-template", Template: 1},
+	}}, nil)
+	mockClient.EXPECT().AcquireTaskSlot(gomock.Any(), "pve").Return(func() {}, nil)
+	gomock.InOrder(
+		mockClient.EXPECT().ReserveVmId(gomock.Any()).Return(&takenId, func() { released++ }, nil),
+		mockClient.EXPECT().CloneVm(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, params url.Values, _ *string, _ *string) (*string, error) {
+			assert.Equal(t, takenId, params.Get("newid"))
+			return &takenUpid, nil
+		}),
+		mockTaskService.EXPECT().WaitForTaskCompletion(gomock.Any(), gomock.Any(), &takenUpid).Return(conflict),
+		mockClient.EXPECT().ReserveVmId(gomock.Any()).Return(&freeId, func() { released++ }, nil),
+		mockClient.EXPECT().CloneVm(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, params url.Values, _ *string, _ *string) (*string, error) {
+			assert.Equal(t, freeId, params.Get("newid"))
+			return &freeUpid, nil
+		}),
+		mockTaskService.EXPECT().WaitForTaskCompletion(gomock.Any(), gomock.Any(), &freeUpid).Return(nil),
+	)
+
+	assert.NoError(t, vmService.CloneVm(context.Background(), &clone, plan))
+	assert.Equal(t, freeId, plan.VmId.ValueString())
+	assert.Equal(t, 2, released)
+}
+
+func TestGetPendingChanges(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockClient := proxmox_client.NewMockProxmoxClient(ctrl)
+	vmService := VmServiceImpl{tfContext: context.Background(), proxmoxClient: mockClient, proxmoxUtils: services.NewProxmoxUtilService()}
+	var pendingResponse proxmoxTypes.VmPendingResponse
+	assert.NoError(t, json.Unmarshal([]byte(`{"data":[
+		{"key":"cores","value":2},
+		{"key":"memory","value":"2048","pending":"1048576"},
+		{"key":"sockets","value":1,"pending":2},
+		{"key":"agent","value":"1","delete":1}
+	]}`), &pendingResponse))
+	nodeName, vmId := "pve", "140"
+	mockClient.EXPECT().GetVmPending(gomock.Any(), &nodeName, &vmId).Return(&pendingResponse, nil)
+
+	pendingChanges, getPendingError := vmService.GetPendingChanges(context.Background(), &nodeName, &vmId)
+
+	assert.NoError(t, getPendingError)
+	assert.Equal(t, map[string]string{"memory": "1048576", "sockets": "2", "agent": ""}, pendingChanges)
+}
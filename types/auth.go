@@ -0,0 +1,10 @@
+package types
+
+type TicketResponse struct {
+	Data struct {
+		Username            string `json:"username"`
+		Ticket              string `json:"ticket"`
+		CSRFPreventionToken string `json:"CSRFPreventionToken"`
+		NeedTFA             int    `json:"NeedTFA"`
+	} `json:"data"`
+}
@@ -0,0 +1,15 @@
+package types
+
+type ClusterStatusResponse struct {
+	Data []ClusterStatusItem `json:"data"`
+}
+
+type ClusterStatusItem struct {
+	Type   string `json:"type"`
+	Id     string `json:"id"`
+	Name   string `json:"name"`
+	Ip     string `json:"ip"`
+	Online int    `json:"online"`
+	Local  int    `json:"local"`
+	NodeId int    `json:"nodeid"`
+}
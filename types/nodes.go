@@ -57,3 +57,15 @@ type NodeStorageResponseItem struct {
 	Active       int     `json:"active"`
 	UsedFraction float64 `json:"used_fraction,omitempty"`
 }
+
+// StorageConfigResponse is the cluster wide definition of a storage, path is only set for file based storages
+type StorageConfigResponse struct {
+	Data StorageConfig `json:"data"`
+}
+
+type StorageConfig struct {
+	Storage string `json:"storage"`
+	Type    string `json:"type"`
+	Path    string `json:"path"`
+	Content string `json:"content"`
+}
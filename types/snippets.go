@@ -0,0 +1,18 @@
+package types
+
+import "github.com/hashicorp/terraform-plugin-framework/types"
+
+// CloudInitSnippetTypes are the kinds of cloud-init data a snippet can hold, matching the keys of cicustom
+var CloudInitSnippetTypes = []string{"user", "network", "vendor", "meta"}
+
+// CloudInitSnippet is a cloud-init file on a storage with the snippets content type, Volid takes the form
+// storage:snippets/file
+type CloudInitSnippet struct {
+	NodeName types.String `tfsdk:"node_name"`
+	Storage  types.String `tfsdk:"storage"`
+	FileName types.String `tfsdk:"file_name"`
+	Type     types.String `tfsdk:"type"`
+	Content  types.String `tfsdk:"content"`
+	Sha256   types.String `tfsdk:"sha256"`
+	Volid    types.String `tfsdk:"volid"`
+}
@@ -19,3 +19,13 @@ type TaskStatus struct {
 type TaskCreationResponse struct {
 	Upid string `json:"data"`
 }
+
+type TaskLogResponse struct {
+	Data  []TaskLogLine `json:"data"`
+	Total int           `json:"total"`
+}
+
+type TaskLogLine struct {
+	LineNumber int    `json:"n"`
+	Text       string `json:"t"`
+}
@@ -1,14 +1,26 @@
 package types
 
-import "github.com/hashicorp/terraform-plugin-framework/types"
+import (
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
 
 const NetworkInterfaceTypes = "e1000 | e1000-82540em | e1000-82544gc | e1000-82545em | e1000e | i82551 | i82557b | i82559er | ne2k_isa | ne2k_pci | pcnet | rtl8139 | virtio | vmxnet3"
 
+// CloudInitTypes are the formats proxmox can generate the cloud-init data in
+var CloudInitTypes = []string{"configdrive2", "nocloud", "opennebula"}
+
+// CloudInitBusSlots is the highest slot of every bus the cloud-init drive can be attached to
+var CloudInitBusSlots = map[string]int64{"ide": 3, "sata": 5, "scsi": 30}
+
 type VmModel struct {
 	Acpi                 types.Bool           `tfsdk:"acpi"`
 	Agent                types.Bool           `tfsdk:"qemu_agent_enabled"`
 	Bios                 types.String         `tfsdk:"bios"`
 	BootOrder            types.List           `tfsdk:"boot_order"`
+	CloudInit            *VmCloudInit         `tfsdk:"cloud_init"`
+	CloudInitCustom      *VmCloudInitCustom   `tfsdk:"cloud_init_custom"`
+	CloudInitType        types.String         `tfsdk:"cloud_init_type"`
 	CloudInitUpgrade     types.Bool           `tfsdk:"perform_cloud_init_upgrade"`
 	Cores                types.Int64          `tfsdk:"cores"`
 	Cpu                  types.String         `tfsdk:"cpu_type"`
@@ -16,6 +28,7 @@ type VmModel struct {
 	Description          types.String         `tfsdk:"description"`
 	Disks                []VmDisk             `tfsdk:"disk"`
 	HostStartupOrder     types.Int64          `tfsdk:"host_startup_order"`
+	Hotplug              types.Set            `tfsdk:"hotplug"`
 	IpConfigurations     []VmIpConfig         `tfsdk:"ip_config"`
 	Kvm                  types.Bool           `tfsdk:"kvm"`
 	Memory               types.Int64          `tfsdk:"memory"`
@@ -28,6 +41,7 @@ type VmModel struct {
 	OsType               types.String         `tfsdk:"os_type"`
 	Protection           types.Bool           `tfsdk:"protection"`
 	ScsiHw               types.String         `tfsdk:"scsi_hw"`
+	SearchDomain         types.String         `tfsdk:"search_domain"`
 	Sockets              types.Int64          `tfsdk:"sockets"`
 	SshKeys              types.List           `tfsdk:"ssh_keys"`
 	Tags                 types.List           `tfsdk:"tags"`
@@ -66,6 +80,10 @@ type QemuResponse struct { //some of the optional fields in the spec will not ap
 		Protection       int                    `json:"protection"`
 		SshKeys          string                 `json:"sshKeys"`
 		CiUser           string                 `json:"ciuser"`
+		CiType           string                 `json:"citype"`
+		CiCustom         string                 `json:"cicustom"`
+		SearchDomain     string                 `json:"searchdomain"`
+		Template         int                    `json:"template"`
 		OtherFields      map[string]interface{} `json:"-"` //skip this key
 	} `json:"data"`
 }
@@ -80,6 +98,37 @@ type VmStatus struct {
 	Data VmStatusData `json:"data"`
 }
 
+// VmCloudInit places the cloud-init drive of a vm, a vm without the block gets a scsi drive on cloud_init_storage_name
+type VmCloudInit struct {
+	Enabled types.Bool   `tfsdk:"enabled"`
+	Storage types.String `tfsdk:"storage"`
+	Bus     types.String `tfsdk:"bus"`
+	Slot    types.Int64  `tfsdk:"slot"`
+}
+
+// VmCloudInitCustom references snippets that replace the generated cloud-init data, each takes the form
+// storage:snippets/file
+type VmCloudInitCustom struct {
+	User    types.String `tfsdk:"user"`
+	Network types.String `tfsdk:"network"`
+	Vendor  types.String `tfsdk:"vendor"`
+	Meta    types.String `tfsdk:"meta"`
+}
+
+// VmPendingResponse lists every option of a vm config, options changed while the vm runs carry the value that applies
+// once it is restarted
+type VmPendingResponse struct {
+	Data []VmPendingValue `json:"data"`
+}
+
+type VmPendingValue struct {
+	Key     string      `json:"key"`
+	Value   interface{} `json:"value"`
+	Pending interface{} `json:"pending"`
+	// Delete is 1 when the option is removed on the next restart and 2 when it is removed even though it can't be
+	Delete int `json:"delete"`
+}
+
 type VmDisk struct {
 	Id              types.Int64  `tfsdk:"id"`
 	BusType         types.String `tfsdk:"bus_type"`
@@ -107,12 +156,66 @@ type VmNetworkInterface struct {
 	Mtu        types.Int64  `tfsdk:"mtu"`
 }
 
+// VmIpConfig is the cloud-init network config of a nic, addresses are either cidrs or dhcp, ipv6 also takes auto for
+// slaac. Gateways only apply to static addresses.
 type VmIpConfig struct {
-	IpAddress types.String `tfsdk:"ip_address"`
-	Gateway   types.String `tfsdk:"gateway"`
-	Order     types.Int64  `tfsdk:"order"`
+	IpAddress   types.String `tfsdk:"ip_address"`
+	Gateway     types.String `tfsdk:"gateway"`
+	Ipv6Address types.String `tfsdk:"ipv6_address"`
+	Ipv6Gateway types.String `tfsdk:"ipv6_gateway"`
+	Order       types.Int64  `tfsdk:"order"`
+}
+
+// VmClone is the clone block of the vm resource, the vm is cloned from its source before the rest of the config is applied
+type VmClone struct {
+	SourceVmId    types.String `tfsdk:"source_vm_id"`
+	SourceName    types.String `tfsdk:"source_name"`
+	SourceNode    types.String `tfsdk:"source_node"`
+	FullClone     types.Bool   `tfsdk:"full_clone"`
+	TargetStorage types.String `tfsdk:"target_storage"`
+	Pool          types.String `tfsdk:"pool"`
+	SnapshotName  types.String `tfsdk:"snapshot_name"`
 }
 
+// ClusterVmListResponse is the response of /cluster/resources filtered to vms
+type ClusterVmListResponse struct {
+	Data []ClusterVm `json:"data"`
+}
+
+type ClusterVm struct {
+	Id       string `json:"id"`
+	Type     string `json:"type"`
+	Node     string `json:"node"`
+	VmId     int    `json:"vmid"`
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Template int    `json:"template"`
+}
+
+// VmTemplate is the model of the template resource, Disks is a list of VmTemplateDisk read from the template
+type VmTemplate struct {
+	VmId     types.String `tfsdk:"vm_id"`
+	NodeName types.String `tfsdk:"node_name"`
+	Disk     types.String `tfsdk:"disk"`
+	Name     types.String `tfsdk:"name"`
+	Disks    types.List   `tfsdk:"disks"`
+}
+
+type VmTemplateDisk struct {
+	Key             types.String `tfsdk:"key"`
+	Volume          types.String `tfsdk:"volume"`
+	StorageLocation types.String `tfsdk:"storage_location"`
+	Size            types.String `tfsdk:"size"`
+}
+
+// VmTemplateDiskType is the element type of VmTemplate.Disks
+var VmTemplateDiskType = types.ObjectType{AttrTypes: map[string]attr.Type{
+	"key":              types.StringType,
+	"volume":           types.StringType,
+	"storage_location": types.StringType,
+	"size":             types.StringType,
+}}
+
 type DiskChanges struct {
 	ToBeAdded    []VmDisk
 	ToBeUpdated  []VmDisk